@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"PR-reviewer/internal/handlers"
+)
+
+// opsHandlers are the endpoints served by packages other than handlers.
+// Chaos is nil unless failure injection is enabled.
+type opsHandlers struct {
+	Metrics http.Handler
+	Ready   http.Handler
+	Version http.Handler
+	Status  http.Handler
+	Dump    http.Handler
+	Cluster http.Handler
+	Chaos   http.Handler
+}
+
+// registerRoutes mounts every endpoint on r. Every route must be documented
+// in openapi.yml, which routes_test.go checks.
+func registerRoutes(r chi.Router, h *handlers.Handler, ops opsHandlers) {
+	r.Post("/team/add", h.AddTeam)
+	r.Get("/team/get", h.GetTeam)
+	r.Post("/team/update", h.UpdateTeam)
+	r.Post("/team/addMember", h.AddTeamMember)
+	r.Post("/team/removeMember", h.RemoveTeamMember)
+	r.Post("/team/addMembership", h.AddMembership)
+	r.Post("/team/removeMembership", h.RemoveMembership)
+	r.Post("/users/setIsActive", h.SetIsActive)
+	r.Post("/users/moveTeam", h.MoveUserTeam)
+	r.Post("/users/setSkills", h.SetUserSkills)
+	r.Post("/users/setVacation", h.SetVacation)
+	r.Post("/users/setMentor", h.SetMentor)
+	r.Post("/users/setTimezone", h.SetTimezone)
+	r.Post("/users/setNotifications", h.SetNotifications)
+	r.Post("/users/setWeight", h.SetUserWeight)
+	r.Post("/users/forget", h.ForgetUser)
+	r.Post("/pullRequest/create", h.CreatePR)
+	r.Post("/pullRequest/merge", h.MergePR)
+	r.Post("/pullRequest/close", h.ClosePR)
+	r.Post("/pullRequest/reopen", h.ReopenPR)
+	r.Post("/pullRequest/queueMerge", h.QueueMerge)
+	r.Post("/pullRequest/setLabels", h.SetLabels)
+	r.Get("/pullRequest/get", h.GetPR)
+	r.Get("/pullRequest/history", h.GetAssignmentHistory)
+	r.Get("/pullRequest/rounds", h.GetReviewRounds)
+	r.Get("/pullRequest/list", h.ListPRs)
+	r.Get("/pullRequest/search", h.SearchPRs)
+	r.Get("/pullRequest/archive/get", h.GetArchivedPR)
+	r.Get("/pullRequest/archive/list", h.ListArchivedPRs)
+	r.Get("/pullRequest/overdue", h.GetOverdueReviews)
+	r.Post("/pullRequest/reassign", h.Reassign)
+	r.Post("/pullRequest/addReviewer", h.AddReviewer)
+	r.Post("/pullRequest/removeReviewer", h.RemoveReviewer)
+	r.Post("/pullRequest/fillReviewers", h.FillReviewers)
+	r.Post("/pullRequest/reserve", h.ReserveReviewers)
+	r.Post("/pullRequest/approve", h.ApprovePR)
+	r.Post("/pullRequest/requestChanges", h.RequestChanges)
+	r.Post("/pullRequest/review", h.SubmitReview)
+	r.Post("/pullRequest/ack", h.AcknowledgeReview)
+	r.Get("/assignment/suggest", h.SuggestReviewers)
+	r.Get("/users/get", h.GetUser)
+	r.Get("/users/getReview", h.GetUserReviews)
+	r.Get("/users/getAuthored", h.GetUserAuthored)
+	r.Get("/users/activity", h.GetUserActivity)
+	r.Get("/stats", h.GetStats)
+	r.Get("/stats/org", h.GetOrgSummary)
+	r.Get("/stats/user", h.GetUserStats)
+	r.Get("/stats/security", h.GetSecurityCoverage)
+	r.Get("/stats/load", h.GetReviewLoad)
+	r.Get("/stats/team", h.GetTeamStats)
+	r.Get("/alerts", h.GetAlerts)
+	r.Post("/team/deactivate", h.DeactivateTeam)
+	r.Get("/team/deactivate/preview", h.PreviewDeactivation)
+	r.Post("/team/delete", h.DeleteTeam)
+	r.Post("/team/token", h.IssueTeamToken)
+	r.Post("/users/token", h.IssueUserToken)
+	r.Get("/me/reviews", h.MyReviews)
+	r.Get("/me/stats", h.MyStats)
+	r.Get("/team/settings", h.GetTeamSettings)
+	r.Post("/team/settings", h.UpdateTeamSettings)
+	r.Get("/repository/settings", h.GetRepositorySettings)
+	r.Post("/repository/settings", h.UpdateRepositorySettings)
+	r.Get("/team/rotation", h.GetRotation)
+	r.Post("/team/rotation", h.RegenerateRotation)
+	r.Method(http.MethodGet, "/metrics", ops.Metrics)
+	r.Method(http.MethodGet, "/readyz", ops.Ready)
+	r.Method(http.MethodGet, "/version", ops.Version)
+	r.Method(http.MethodGet, "/status", ops.Status)
+	r.Method(http.MethodPost, "/admin/dump", ops.Dump)
+	r.Method(http.MethodGet, "/admin/cluster", ops.Cluster)
+	r.Post("/admin/consistency/check", h.CheckConsistency)
+	r.Post("/admin/reassignAll", h.ReassignAll)
+	r.Get("/admin/export/assignments", h.ExportAssignments)
+	if ops.Chaos != nil {
+		r.Method(http.MethodGet, "/admin/chaos", ops.Chaos)
+		r.Method(http.MethodPost, "/admin/chaos", ops.Chaos)
+		r.Method(http.MethodDelete, "/admin/chaos", ops.Chaos)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+
+	"PR-reviewer/internal/handlers"
+	"PR-reviewer/internal/logger"
+)
+
+// TestRoutesDocumented keeps the request validator honest: it only checks
+// routes found in the spec, so an undocumented route is never validated.
+func TestRoutesDocumented(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromFile("../../openapi.yml")
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+
+	stub := http.NotFoundHandler()
+	r := chi.NewRouter()
+	registerRoutes(r, handlers.NewHandler(nil, logger.NewStdLogger(io.Discard, "error")), opsHandlers{
+		Metrics: stub, Ready: stub, Version: stub, Status: stub, Dump: stub, Cluster: stub, Chaos: stub,
+	})
+
+	err = chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		item := doc.Paths.Find(route)
+		if item == nil || item.GetOperation(method) == nil {
+			t.Errorf("%s %s is not documented in openapi.yml", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+}
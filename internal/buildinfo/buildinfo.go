@@ -0,0 +1,56 @@
+// Package buildinfo reports which build is running. The variables are set at
+// link time:
+//
+//	go build -ldflags "-X PR-reviewer/internal/buildinfo.Version=v1.4.0 \
+//	  -X PR-reviewer/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X PR-reviewer/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"PR-reviewer/internal/service"
+)
+
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+var started = time.Now()
+
+type Source interface {
+	Diagnostics() service.Diagnostics
+}
+
+type Info struct {
+	Version       string  `json:"version"`
+	Commit        string  `json:"commit"`
+	BuildTime     string  `json:"build_time"`
+	UptimeSec     float64 `json:"uptime_seconds"`
+	Workers       int     `json:"workers"`
+	QueueCapacity int     `json:"queue_capacity"`
+}
+
+func Get(src Source) Info {
+	d := src.Diagnostics()
+	return Info{
+		Version:       Version,
+		Commit:        Commit,
+		BuildTime:     BuildTime,
+		UptimeSec:     time.Since(started).Round(time.Second).Seconds(),
+		Workers:       len(d.Workers),
+		QueueCapacity: d.QueueCapacity,
+	}
+}
+
+// Handler serves GET /version.
+func Handler(src Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Get(src))
+	})
+}
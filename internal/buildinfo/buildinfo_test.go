@@ -0,0 +1,33 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"PR-reviewer/internal/service"
+)
+
+type fakeSource struct{ d service.Diagnostics }
+
+func (f fakeSource) Diagnostics() service.Diagnostics { return f.d }
+
+func TestHandler(t *testing.T) {
+	Version, Commit = "v1.2.3", "abc123"
+	src := fakeSource{d: service.Diagnostics{QueueCapacity: 200, Workers: make([]service.WorkerState, 3)}}
+
+	rr := httptest.NewRecorder()
+	Handler(src).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	var got Info
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid response %q: %v", rr.Body.String(), err)
+	}
+	if got.Version != "v1.2.3" || got.Commit != "abc123" || got.BuildTime != "unknown" || got.Workers != 3 || got.QueueCapacity != 200 {
+		t.Fatalf("unexpected info %+v", got)
+	}
+	if got.UptimeSec < 0 {
+		t.Fatalf("negative uptime %v", got.UptimeSec)
+	}
+}
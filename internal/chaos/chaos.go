@@ -0,0 +1,220 @@
+// Package chaos injects latency and errors into repo calls and jobs, so the
+// service's handling of slow and failing dependencies can be exercised end
+// to end. It is for test environments only and stays off unless the server
+// is started with CHAOS_ENABLED=true.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/service"
+)
+
+// ErrInjected is the error a fault returns in place of the real result.
+var ErrInjected = errors.New("chaos: injected failure")
+
+var injected = metrics.NewCounter("chaos_injected_total", "Faults injected, by target and kind: latency or error.", "target", "kind")
+
+// Fault slows down or fails the calls of Target: "repo:<Method>" or
+// "job:<type>", or "repo:*" and "job:*" for every repo call or job. Each
+// call first waits Latency, then fails with probability ErrorRate. A zero
+// Until keeps the fault until it is cleared.
+type Fault struct {
+	Target    string
+	Latency   time.Duration
+	ErrorRate float64
+	Until     time.Time
+}
+
+type faultJSON struct {
+	Target    string     `json:"target"`
+	Latency   string     `json:"latency,omitempty"`
+	ErrorRate float64    `json:"error_rate,omitempty"`
+	Duration  string     `json:"duration,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+}
+
+// Injector holds the active faults. It is safe for concurrent use.
+type Injector struct {
+	mu     sync.Mutex
+	faults map[string]Fault
+	now    func() time.Time
+	roll   func() float64
+}
+
+func New() *Injector {
+	return &Injector{faults: map[string]Fault{}, now: time.Now, roll: rand.Float64}
+}
+
+// Inject applies the fault set for target, or for its kind's wildcard, to
+// one call. It returns ErrInjected when the call should fail and ctx's
+// error when ctx ends during the added latency.
+func (i *Injector) Inject(ctx context.Context, target string) error {
+	f, ok := i.match(target)
+	if !ok {
+		return nil
+	}
+	if f.Latency > 0 {
+		injected.Inc(f.Target, "latency")
+		t := time.NewTimer(f.Latency)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+	if f.ErrorRate > 0 && i.roll() < f.ErrorRate {
+		injected.Inc(f.Target, "error")
+		return fmt.Errorf("%w in %s", ErrInjected, target)
+	}
+	return nil
+}
+
+func (i *Injector) match(target string) (Fault, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(i.faults) == 0 {
+		return Fault{}, false
+	}
+	kind, _, _ := strings.Cut(target, ":")
+	for _, key := range []string{target, kind + ":*"} {
+		f, ok := i.faults[key]
+		if !ok {
+			continue
+		}
+		if !f.Until.IsZero() && !i.now().Before(f.Until) {
+			delete(i.faults, key)
+			continue
+		}
+		return f, true
+	}
+	return Fault{}, false
+}
+
+// Set adds f, replacing any fault on the same target.
+func (i *Injector) Set(f Fault) error {
+	kind, name, ok := strings.Cut(f.Target, ":")
+	if !ok || (kind != "repo" && kind != "job") || name == "" {
+		return fmt.Errorf("target must be repo:<Method>, job:<type>, repo:* or job:*")
+	}
+	if f.Latency < 0 || f.Latency > time.Minute {
+		return fmt.Errorf("latency must be between 0 and 1m")
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		return fmt.Errorf("error_rate must be between 0 and 1")
+	}
+	if f.Latency == 0 && f.ErrorRate == 0 {
+		return fmt.Errorf("fault needs latency or error_rate")
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults[f.Target] = f
+	return nil
+}
+
+// Clear removes the fault on target, or every fault when target is empty.
+func (i *Injector) Clear(target string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if target == "" {
+		clear(i.faults)
+		return
+	}
+	delete(i.faults, target)
+}
+
+// Faults returns the active faults sorted by target.
+func (i *Injector) Faults() []Fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := i.now()
+	res := make([]Fault, 0, len(i.faults))
+	for key, f := range i.faults {
+		if !f.Until.IsZero() && !now.Before(f.Until) {
+			delete(i.faults, key)
+			continue
+		}
+		res = append(res, f)
+	}
+	sort.Slice(res, func(a, b int) bool { return res[a].Target < res[b].Target })
+	return res
+}
+
+// Handler serves /admin/chaos: GET lists the active faults, POST sets one
+// and DELETE clears the one named by ?target, or all of them.
+func (i *Injector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := service.ScopeFromContext(r.Context()); ok {
+			http.Error(w, "token scope does not allow this operation", http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var body faultJSON
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&body); err != nil {
+				http.Error(w, "invalid body", http.StatusBadRequest)
+				return
+			}
+			f, err := body.fault(i.now())
+			if err == nil {
+				err = i.Set(f)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case http.MethodDelete:
+			i.Clear(r.URL.Query().Get("target"))
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		faults := i.Faults()
+		out := make([]faultJSON, 0, len(faults))
+		for _, f := range faults {
+			fj := faultJSON{Target: f.Target, ErrorRate: f.ErrorRate}
+			if f.Latency > 0 {
+				fj.Latency = f.Latency.String()
+			}
+			if !f.Until.IsZero() {
+				until := f.Until.UTC()
+				fj.Until = &until
+			}
+			out = append(out, fj)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"faults": out})
+	})
+}
+
+func (fj faultJSON) fault(now time.Time) (Fault, error) {
+	f := Fault{Target: strings.TrimSpace(fj.Target), ErrorRate: fj.ErrorRate}
+	var err error
+	if fj.Latency != "" {
+		if f.Latency, err = time.ParseDuration(fj.Latency); err != nil {
+			return f, fmt.Errorf("invalid latency: %w", err)
+		}
+	}
+	if fj.Duration != "" {
+		d, err := time.ParseDuration(fj.Duration)
+		if err != nil || d <= 0 {
+			return f, fmt.Errorf("duration must be a positive duration")
+		}
+		f.Until = now.Add(d)
+	}
+	return f, nil
+}
@@ -0,0 +1,102 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"PR-reviewer/internal/service"
+)
+
+func TestInject(t *testing.T) {
+	i := New()
+	if err := i.Set(Fault{Target: "repo:*", ErrorRate: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := i.Set(Fault{Target: "repo:GetPR", Latency: 20 * time.Millisecond}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	if err := i.Inject(context.Background(), "repo:GetPR"); err != nil {
+		t.Fatalf("expected only latency for GetPR, got %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("expected GetPR to be delayed")
+	}
+	if err := i.Inject(context.Background(), "repo:GetUser"); !errors.Is(err, ErrInjected) {
+		t.Fatalf("expected the wildcard to fail GetUser, got %v", err)
+	}
+	if err := i.Inject(context.Background(), "job:create_pr"); err != nil {
+		t.Fatalf("expected jobs to be left alone, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := i.Inject(ctx, "repo:GetPR"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the latency to end with ctx, got %v", err)
+	}
+}
+
+func TestInject_Expires(t *testing.T) {
+	i := New()
+	now := time.Now()
+	i.now = func() time.Time { return now }
+	if err := i.Set(Fault{Target: "job:create_pr", ErrorRate: 1, Until: now.Add(time.Minute)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := i.Inject(context.Background(), "job:create_pr"); !errors.Is(err, ErrInjected) {
+		t.Fatalf("expected an injected failure, got %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := i.Inject(context.Background(), "job:create_pr"); err != nil {
+		t.Fatalf("expected the fault to have expired, got %v", err)
+	}
+	if len(i.Faults()) != 0 {
+		t.Fatalf("expected no faults left, got %+v", i.Faults())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		scoped     bool
+		wantStatus int
+		wantBody   string
+	}{
+		{"задать сбой", http.MethodPost, "", `{"target":"repo:GetPR","latency":"100ms","error_rate":0.5,"duration":"5m"}`, false, http.StatusOK, `"target":"repo:GetPR"`},
+		{"неизвестная цель", http.MethodPost, "", `{"target":"db:GetPR","error_rate":1}`, false, http.StatusBadRequest, "target must be"},
+		{"пустой сбой", http.MethodPost, "", `{"target":"job:*"}`, false, http.StatusBadRequest, "latency or error_rate"},
+		{"снять сбой", http.MethodDelete, "?target=repo:GetUser", "", false, http.StatusOK, `{"faults":[]}`},
+		{"командный токен", http.MethodGet, "", "", true, http.StatusForbidden, "token scope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := New()
+			if err := i.Set(Fault{Target: "repo:GetUser", ErrorRate: 1}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req := httptest.NewRequest(tt.method, "/admin/chaos"+tt.target, strings.NewReader(tt.body))
+			if tt.scoped {
+				req = req.WithContext(service.WithScope(req.Context(), service.Scope{TeamName: "alpha"}))
+			}
+			rr := httptest.NewRecorder()
+			i.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %q, got %s", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
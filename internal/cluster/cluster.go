@@ -0,0 +1,174 @@
+// Package cluster elects one replica to run the background tasks that must
+// happen once per deployment, such as reminders and escalations, and keeps
+// a registry of the running replicas.
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"PR-reviewer/internal/logger"
+	"PR-reviewer/internal/service"
+)
+
+// lockKey identifies the Postgres advisory lock held by the leader.
+const lockKey = 0x5052_5276 // "PRRv"
+
+// Status is a replica's view of the election and of the fleet.
+type Status struct {
+	Instance string `json:"instance"`
+	IsLeader bool   `json:"is_leader"`
+	// Leader is the last instance to take the lock and RenewedAt when it
+	// last confirmed it still holds it; a RenewedAt far behind the renewal
+	// interval means the leader died and nobody has taken over yet.
+	Leader      string     `json:"leader,omitempty"`
+	LeaderSince *time.Time `json:"leader_since,omitempty"`
+	RenewedAt   *time.Time `json:"renewed_at,omitempty"`
+	Instances   []Instance `json:"instances"`
+	// MixedVersions is set when live instances run different commits.
+	MixedVersions bool `json:"mixed_versions"`
+}
+
+// Elector campaigns for leadership with a session-level advisory lock held
+// on a dedicated connection. The lock goes away with the session, so a
+// crashed leader is replaced within one interval of its connection closing.
+type Elector struct {
+	db       *sql.DB
+	instance string
+	interval time.Duration
+	log      logger.Logger
+	started  time.Time
+
+	leader atomic.Bool
+	// conn holds the lock while leading; only Run touches it.
+	conn *sql.Conn
+}
+
+func NewElector(db *sql.DB, instance string, interval time.Duration, log logger.Logger) *Elector {
+	return &Elector{db: db, instance: instance, interval: interval, log: log.WithWorker("leader-election"), started: time.Now().UTC()}
+}
+
+// IsLeader reports whether this replica currently holds the lock.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run heartbeats and campaigns right away, and then heartbeats and renews
+// or retries every interval until ctx is done, when it gives the lock up
+// and leaves the registry.
+func (e *Elector) Run(ctx context.Context, src Source) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	defer func() {
+		e.stepDown()
+		leaveCtx, cancel := context.WithTimeout(context.Background(), e.interval)
+		defer cancel()
+		e.deregister(leaveCtx)
+	}()
+	for {
+		e.tick(ctx, src)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) tick(ctx context.Context, src Source) {
+	tickCtx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+
+	if err := e.heartbeat(tickCtx, src); err != nil {
+		e.log.Warn("failed to heartbeat", "instance", e.instance, "error", err)
+	}
+
+	if e.conn != nil {
+		_, err := e.conn.ExecContext(tickCtx, `UPDATE cluster_leader SET renewed_at=NOW() WHERE id=1 AND instance=$1`, e.instance)
+		if err == nil {
+			return
+		}
+		e.log.Warn("lost scheduler leadership", "instance", e.instance, "error", err)
+		e.stepDown()
+	}
+
+	conn, err := e.db.Conn(tickCtx)
+	if err != nil {
+		e.log.Warn("failed to get election connection", "error", err)
+		return
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(tickCtx, `SELECT pg_try_advisory_lock($1)`, lockKey).Scan(&acquired); err != nil || !acquired {
+		if err != nil {
+			e.log.Warn("failed to campaign for leadership", "error", err)
+		}
+		_ = conn.Close()
+		return
+	}
+	e.conn = conn
+	e.leader.Store(true)
+	if _, err := conn.ExecContext(tickCtx, `
+		INSERT INTO cluster_leader(id, instance, acquired_at, renewed_at) VALUES (1, $1, NOW(), NOW())
+		ON CONFLICT (id) DO UPDATE SET instance=EXCLUDED.instance, acquired_at=NOW(), renewed_at=NOW()`, e.instance); err != nil {
+		e.log.Warn("failed to record leader", "instance", e.instance, "error", err)
+	}
+	e.log.Success("became scheduler leader", "instance", e.instance)
+}
+
+// stepDown drops the lock connection instead of returning it to the pool,
+// where it would keep the lock for whoever used it next.
+func (e *Elector) stepDown() {
+	e.leader.Store(false)
+	if e.conn == nil {
+		return
+	}
+	_ = e.conn.Raw(func(any) error { return driver.ErrBadConn })
+	e.conn = nil
+}
+
+// Status reads the current leader and the registered instances from the
+// database.
+func (e *Elector) Status(ctx context.Context) (Status, error) {
+	st := Status{Instance: e.instance, IsLeader: e.IsLeader()}
+	instances, err := e.Instances(ctx)
+	if err != nil {
+		return st, err
+	}
+	st.Instances, st.MixedVersions = instances, mixedVersions(instances)
+
+	var since, renewed time.Time
+	err = e.db.QueryRowContext(ctx, `SELECT instance, acquired_at, renewed_at FROM cluster_leader WHERE id=1`).Scan(&st.Leader, &since, &renewed)
+	if err == sql.ErrNoRows {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("get leader: %w", err)
+	}
+	since, renewed = since.UTC(), renewed.UTC()
+	st.LeaderSince, st.RenewedAt = &since, &renewed
+	return st, nil
+}
+
+// Handler serves GET /admin/cluster.
+func (e *Elector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := service.ScopeFromContext(r.Context()); ok {
+			http.Error(w, "token scope does not allow this operation", http.StatusForbidden)
+			return
+		}
+		st, err := e.Status(r.Context())
+		if err != nil {
+			e.log.Error("failed to get cluster status", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(st)
+	})
+}
@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"PR-reviewer/internal/buildinfo"
+	"PR-reviewer/internal/service"
+)
+
+// staleHeartbeats is how many renewal intervals an instance may miss before
+// it is reported stale; forgetAfter drops it from the registry altogether.
+const (
+	staleHeartbeats = 3
+	forgetAfter     = 24 * time.Hour
+)
+
+// Source is the replica's job queue and workers.
+type Source interface {
+	Diagnostics() service.Diagnostics
+}
+
+// Instance is a replica as of its last heartbeat.
+type Instance struct {
+	Instance    string    `json:"instance"`
+	Version     string    `json:"version"`
+	Commit      string    `json:"commit"`
+	StartedAt   time.Time `json:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	Workers     int       `json:"workers"`
+	BusyWorkers int       `json:"busy_workers"`
+	QueueDepth  int       `json:"queue_depth"`
+	// LongestJobSec is how long the oldest running job had been running; a
+	// worker stuck on a job shows up as a large value.
+	LongestJobSec float64 `json:"longest_job_seconds"`
+	// Stale is set once the instance has missed several heartbeats: it is
+	// gone or hung.
+	Stale bool `json:"stale"`
+}
+
+// heartbeat records this replica's build and worker stats, and forgets
+// replicas not heard from in a day.
+func (e *Elector) heartbeat(ctx context.Context, src Source) error {
+	d := src.Diagnostics()
+	busy, longest := workerLoad(d, time.Now())
+	if _, err := e.db.ExecContext(ctx, `
+		INSERT INTO instances(instance, version, commit, started_at, heartbeat_at, workers, busy_workers, queue_depth, longest_job_seconds)
+		VALUES ($1, $2, $3, $4, NOW(), $5, $6, $7, $8)
+		ON CONFLICT (instance) DO UPDATE SET version=EXCLUDED.version, commit=EXCLUDED.commit, started_at=EXCLUDED.started_at,
+			heartbeat_at=NOW(), workers=EXCLUDED.workers, busy_workers=EXCLUDED.busy_workers,
+			queue_depth=EXCLUDED.queue_depth, longest_job_seconds=EXCLUDED.longest_job_seconds`,
+		e.instance, buildinfo.Version, buildinfo.Commit, e.started, len(d.Workers), busy, d.QueueDepth, longest.Seconds()); err != nil {
+		return fmt.Errorf("record heartbeat: %w", err)
+	}
+	if _, err := e.db.ExecContext(ctx, `DELETE FROM instances WHERE heartbeat_at < $1`, time.Now().UTC().Add(-forgetAfter)); err != nil {
+		return fmt.Errorf("forget instances: %w", err)
+	}
+	return nil
+}
+
+// deregister removes this replica on a clean shutdown.
+func (e *Elector) deregister(ctx context.Context) {
+	if _, err := e.db.ExecContext(ctx, `DELETE FROM instances WHERE instance=$1`, e.instance); err != nil {
+		e.log.Warn("failed to deregister instance", "instance", e.instance, "error", err)
+	}
+}
+
+// Instances lists the replicas that heartbeated in the last day.
+func (e *Elector) Instances(ctx context.Context) ([]Instance, error) {
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT instance, version, commit, started_at, heartbeat_at, workers, busy_workers, queue_depth, longest_job_seconds
+		FROM instances ORDER BY instance`)
+	if err != nil {
+		return nil, fmt.Errorf("query instances: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Instance{}
+	for rows.Next() {
+		var in Instance
+		if err := rows.Scan(&in.Instance, &in.Version, &in.Commit, &in.StartedAt, &in.HeartbeatAt, &in.Workers, &in.BusyWorkers, &in.QueueDepth, &in.LongestJobSec); err != nil {
+			return nil, fmt.Errorf("scan instance: %w", err)
+		}
+		in.StartedAt, in.HeartbeatAt = in.StartedAt.UTC(), in.HeartbeatAt.UTC()
+		out = append(out, in)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	markStale(out, time.Now(), e.interval)
+	return out, nil
+}
+
+// workerLoad counts the busy workers and how long the oldest running job
+// has been going.
+func workerLoad(d service.Diagnostics, now time.Time) (busy int, longest time.Duration) {
+	for _, w := range d.Workers {
+		if w.JobType == "" {
+			continue
+		}
+		busy++
+		if age := now.Sub(w.BusySince); age > longest {
+			longest = age
+		}
+	}
+	return busy, longest
+}
+
+func markStale(instances []Instance, now time.Time, interval time.Duration) {
+	for i := range instances {
+		instances[i].Stale = now.Sub(instances[i].HeartbeatAt) > staleHeartbeats*interval
+	}
+}
+
+// mixedVersions reports whether the live instances run different commits,
+// as in the middle of a rollout or after one got stuck.
+func mixedVersions(instances []Instance) bool {
+	commit := ""
+	for _, in := range instances {
+		if in.Stale {
+			continue
+		}
+		if commit != "" && in.Commit != commit {
+			return true
+		}
+		commit = in.Commit
+	}
+	return false
+}
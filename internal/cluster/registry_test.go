@@ -0,0 +1,43 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"PR-reviewer/internal/service"
+)
+
+func TestWorkerLoad(t *testing.T) {
+	now := time.Now()
+	d := service.Diagnostics{Workers: []service.WorkerState{
+		{ID: 0},
+		{ID: 1, JobType: "create_pr", BusySince: now.Add(-2 * time.Second)},
+		{ID: 2, JobType: "merge_pr", BusySince: now.Add(-time.Minute)},
+	}}
+
+	busy, longest := workerLoad(d, now)
+	if busy != 2 || longest != time.Minute {
+		t.Fatalf("expected 2 busy workers and 1m, got %d and %v", busy, longest)
+	}
+}
+
+func TestMixedVersions(t *testing.T) {
+	now := time.Now()
+	instances := []Instance{
+		{Instance: "a", Commit: "abc", HeartbeatAt: now},
+		{Instance: "b", Commit: "old", HeartbeatAt: now.Add(-time.Hour)},
+	}
+	markStale(instances, now, 10*time.Second)
+	if instances[0].Stale || !instances[1].Stale {
+		t.Fatalf("expected only b to be stale, got %+v", instances)
+	}
+	if mixedVersions(instances) {
+		t.Fatal("a stale instance should not count as a mixed rollout")
+	}
+
+	instances[1].HeartbeatAt = now
+	markStale(instances, now, 10*time.Second)
+	if !mixedVersions(instances) {
+		t.Fatal("expected mixed versions")
+	}
+}
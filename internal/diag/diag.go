@@ -0,0 +1,158 @@
+// Package diag builds diagnostic dumps for postmortems of stuck deployments:
+// goroutine stacks, job queue and worker state, and DB pool stats.
+package diag
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/logger"
+	"PR-reviewer/internal/service"
+)
+
+type Source interface {
+	Diagnostics() service.Diagnostics
+}
+
+type Snapshot struct {
+	At         time.Time
+	Goroutines int
+	Service    service.Diagnostics
+	DB         sql.DBStats
+	Stacks     []byte
+}
+
+// Collect takes a snapshot. db may be nil.
+func Collect(src Source, db *sql.DB) Snapshot {
+	snap := Snapshot{
+		At:         time.Now().UTC(),
+		Goroutines: runtime.NumGoroutine(),
+		Service:    src.Diagnostics(),
+		Stacks:     stacks(),
+	}
+	if db != nil {
+		snap.DB = db.Stats()
+	}
+	return snap
+}
+
+func stacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// Write renders the snapshot as plain text.
+func Write(w io.Writer, snap Snapshot) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "=== diagnostic dump %s ===\n", snap.At.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "goroutines: %d\n\n", snap.Goroutines)
+
+	fmt.Fprintf(&sb, "queue: %d/%d\n", snap.Service.QueueDepth, snap.Service.QueueCapacity)
+	types := make([]string, 0, len(snap.Service.QueuedByType))
+	for t := range snap.Service.QueuedByType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(&sb, "  %s: %d\n", t, snap.Service.QueuedByType[t])
+	}
+
+	sb.WriteString("\nworkers:\n")
+	for _, ws := range snap.Service.Workers {
+		if ws.JobType == "" {
+			fmt.Fprintf(&sb, "  worker-%d: idle\n", ws.ID)
+			continue
+		}
+		fmt.Fprintf(&sb, "  worker-%d: %s for %s\n", ws.ID, ws.JobType, snap.At.Sub(ws.BusySince).Round(time.Millisecond))
+	}
+
+	sb.WriteString("\nschedulers:\n")
+	for _, st := range snap.Service.Schedulers {
+		if st.LastRun.IsZero() {
+			fmt.Fprintf(&sb, "  %s (every %s): not run yet\n", st.Name, st.Interval)
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s (every %s): last run %s ago\n", st.Name, st.Interval, snap.At.Sub(st.LastRun).Round(time.Second))
+	}
+
+	db := snap.DB
+	fmt.Fprintf(&sb, "\ndb pool: open=%d in_use=%d idle=%d max_open=%d wait_count=%d wait_duration=%s\n",
+		db.OpenConnections, db.InUse, db.Idle, db.MaxOpenConnections, db.WaitCount, db.WaitDuration)
+
+	sb.WriteString("\n=== goroutine stacks ===\n")
+	sb.Write(snap.Stacks)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Dumper writes snapshots to files in dir, or to out when dir is empty.
+type Dumper struct {
+	src Source
+	db  *sql.DB
+	dir string
+	out io.Writer
+	log logger.Logger
+}
+
+func NewDumper(src Source, db *sql.DB, dir string, out io.Writer, l logger.Logger) *Dumper {
+	return &Dumper{src: src, db: db, dir: dir, out: out, log: l}
+}
+
+// Dump writes one snapshot and returns the file it went to, or "" when it
+// was written to out.
+func (d *Dumper) Dump() (string, error) {
+	return d.write(Collect(d.src, d.db))
+}
+
+func (d *Dumper) write(snap Snapshot) (string, error) {
+	if d.dir == "" {
+		return "", Write(d.out, snap)
+	}
+
+	path := filepath.Join(d.dir, "dump-"+snap.At.Format("20060102T150405.000Z")+".txt")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("create dump file: %w", err)
+	}
+	defer f.Close()
+	if err := Write(f, snap); err != nil {
+		return "", fmt.Errorf("write dump file: %w", err)
+	}
+	return path, nil
+}
+
+// Handler serves POST /admin/dump: the snapshot goes back in the response
+// and is also written like a SIGQUIT dump. Team-scoped tokens are rejected.
+func (d *Dumper) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := service.ScopeFromContext(r.Context()); ok {
+			http.Error(w, "token scope does not allow this operation", http.StatusForbidden)
+			return
+		}
+
+		snap := Collect(d.src, d.db)
+		if path, err := d.write(snap); err != nil {
+			d.log.Error("failed to write diagnostic dump", "error", err)
+		} else {
+			d.log.Info("diagnostic dump written", "path", path, "trigger", "http")
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = Write(w, snap)
+	})
+}
@@ -0,0 +1,38 @@
+package diag
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"PR-reviewer/internal/service"
+)
+
+type fakeSource struct{ d service.Diagnostics }
+
+func (f fakeSource) Diagnostics() service.Diagnostics { return f.d }
+
+func TestWrite(t *testing.T) {
+	now := time.Now()
+	src := fakeSource{d: service.Diagnostics{
+		QueueDepth:    2,
+		QueueCapacity: 200,
+		QueuedByType:  map[string]int{"create_pr": 2},
+		Workers: []service.WorkerState{
+			{ID: 1},
+			{ID: 2, JobType: "reassign_pr", BusySince: now.Add(-3 * time.Second)},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, Collect(src, nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"queue: 2/200", "create_pr: 2", "worker-1: idle", "worker-2: reassign_pr for", "goroutine stacks", "TestWrite"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump does not contain %q:\n%s", want, out)
+		}
+	}
+}
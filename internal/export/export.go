@@ -0,0 +1,106 @@
+// Package export turns assignment history into anonymized ndjson for
+// offline analysis of reviewer selection, and stores the dumps.
+package export
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+// Record is one reviewer assignment with its outcome. IDs are keyed hashes:
+// stable within one salt so rows can be joined, but not reversible.
+type Record struct {
+	PR         string    `json:"pr"`
+	Team       string    `json:"team,omitempty"`
+	Author     string    `json:"author"`
+	Reviewer   string    `json:"reviewer"`
+	AssignedAt time.Time `json:"assigned_at"`
+	Status     string    `json:"status"`
+	// ApproveSec is the time from assignment to this reviewer's approval.
+	ApproveSec *float64 `json:"approve_seconds"`
+	// MergeSec is the time from PR creation to merge.
+	MergeSec *float64 `json:"merge_seconds"`
+	// Removed is set when the reviewer was later reassigned away or
+	// unassigned.
+	Removed bool `json:"removed"`
+}
+
+type Anonymizer struct {
+	salt []byte
+}
+
+func NewAnonymizer(salt string) Anonymizer {
+	return Anonymizer{salt: []byte(salt)}
+}
+
+func (a Anonymizer) hash(id string) string {
+	if id == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func (a Anonymizer) Records(in []models.AssignmentRecord) []Record {
+	out := make([]Record, 0, len(in))
+	for _, r := range in {
+		rec := Record{
+			PR:         a.hash(r.PullRequestID),
+			Team:       a.hash(r.TeamName),
+			Author:     a.hash(r.AuthorID),
+			Reviewer:   a.hash(r.ReviewerID),
+			AssignedAt: r.AssignedAt.UTC(),
+			Status:     string(r.Status),
+			Removed:    r.Removed,
+		}
+		if r.ApprovedAt != nil {
+			sec := r.ApprovedAt.Sub(r.AssignedAt).Seconds()
+			rec.ApproveSec = &sec
+		}
+		if r.MergedAt != nil {
+			sec := r.MergedAt.Sub(r.PRCreatedAt).Seconds()
+			rec.MergeSec = &sec
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// Write renders records as ndjson, one per line.
+func Write(w io.Writer, recs []Record) error {
+	enc := json.NewEncoder(w)
+	for _, r := range recs {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sink stores a finished dump under name.
+type Sink interface {
+	Put(ctx context.Context, name string, body []byte) error
+}
+
+// DirSink writes dumps as files in a local directory.
+type DirSink struct {
+	Dir string
+}
+
+func (s DirSink) Put(_ context.Context, name string, body []byte) error {
+	if err := os.WriteFile(filepath.Join(s.Dir, name), body, 0o644); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	return nil
+}
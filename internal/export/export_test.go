@@ -0,0 +1,61 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+func TestRecords(t *testing.T) {
+	created := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	assigned := created.Add(time.Minute)
+	approved := assigned.Add(time.Hour)
+	merged := created.Add(2 * time.Hour)
+	in := []models.AssignmentRecord{
+		{PullRequestID: "pr1", TeamName: "alpha", AuthorID: "u1", ReviewerID: "u2", PRCreatedAt: created,
+			AssignedAt: assigned, ApprovedAt: &approved, MergedAt: &merged, Status: models.StatusMerged},
+		{PullRequestID: "pr2", AuthorID: "u2", ReviewerID: "u1", PRCreatedAt: created, AssignedAt: assigned,
+			Status: models.StatusOpen, Removed: true},
+	}
+
+	recs := NewAnonymizer("salt").Records(in)
+	if recs[0].Author != recs[1].Reviewer || recs[0].Author == "" || recs[0].Author == "u1" {
+		t.Fatalf("expected stable opaque IDs, got %+v", recs)
+	}
+	if *recs[0].ApproveSec != 3600 || *recs[0].MergeSec != 7200 || recs[1].ApproveSec != nil {
+		t.Fatalf("unexpected outcomes %+v", recs)
+	}
+	if other := NewAnonymizer("other").Records(in); other[0].Author == recs[0].Author {
+		t.Fatal("hashes must depend on the salt")
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, recs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || strings.Contains(buf.String(), `"u1"`) {
+		t.Fatalf("unexpected ndjson:\n%s", buf.String())
+	}
+	var back Record
+	if err := json.Unmarshal([]byte(lines[1]), &back); err != nil || !back.Removed {
+		t.Fatalf("line does not round-trip: %v %+v", err, back)
+	}
+}
+
+func TestDirSink(t *testing.T) {
+	dir := t.TempDir()
+	if err := (DirSink{Dir: dir}).Put(context.Background(), "a.ndjson", []byte("{}\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "a.ndjson")); err != nil || string(b) != "{}\n" {
+		t.Fatalf("unexpected file %q, err=%v", b, err)
+	}
+}
@@ -4,21 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"time"
 
+	"PR-reviewer/internal/export"
 	"PR-reviewer/internal/logger"
 	"PR-reviewer/internal/models"
 	"PR-reviewer/internal/service"
 )
 
 type Handler struct {
-	svc service.Service
-	log logger.Logger
+	svc   service.Service
+	log   logger.Logger
+	guard *authGuard
 }
 
 func NewHandler(s service.Service, l logger.Logger) *Handler {
-	return &Handler{svc: s, log: l}
+	return &Handler{svc: s, log: l, guard: newAuthGuard()}
 }
 
 func writeJSON(w http.ResponseWriter, code int, v interface{}) {
@@ -27,6 +31,19 @@ func writeJSON(w http.ResponseWriter, code int, v interface{}) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// writePR is the single serializer for PR responses: every endpoint that
+// returns one PR wraps it as models.PRResult.
+func writePR(w http.ResponseWriter, code int, data interface{}) {
+	switch v := data.(type) {
+	case models.PRResult:
+		writeJSON(w, code, v)
+	case models.PullRequest:
+		writeJSON(w, code, models.PRResult{PR: v})
+	default:
+		writeError(w, http.StatusInternalServerError, "ERROR", "unexpected PR result")
+	}
+}
+
 func writeError(w http.ResponseWriter, code int, errCode, msg string) {
 	writeJSON(w, code, map[string]interface{}{
 		"error": map[string]string{
@@ -60,6 +77,11 @@ func (h *Handler) AddTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, scoped := service.ScopeFromContext(ctx); scoped {
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		return
+	}
+
 	if err := h.svc.AddTeam(ctx, team); err != nil {
 		h.log.Error("failed to add team", "team", team.TeamName, "error", err)
 		writeError(w, http.StatusInternalServerError, "ERROR", err.Error())
@@ -69,13 +91,58 @@ func (h *Handler) AddTeam(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, map[string]interface{}{"team": team})
 }
 
-func (h *Handler) SetIsActive(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateTeam(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	h.log.Info("received request SetIsActive")
+	h.log.Info("received request UpdateTeam")
+
+	var upd models.TeamUpdate
+	if err := decodeBody(r, &upd); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateTeamUpdate(upd); err != nil {
+		h.log.Warn("validation failed", "team", upd.TeamName, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "update_team", map[string]interface{}{
+		"update": upd,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrTeamExists):
+			writeError(w, http.StatusConflict, "TEAM_EXISTS", "new_team_name already exists")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"team": res.Data})
+}
+
+func (h *Handler) AddTeamMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request AddTeamMember")
 
 	var payload struct {
-		UserID   string `json:"user_id"`
-		IsActive bool   `json:"is_active"`
+		TeamName string            `json:"team_name"`
+		Member   models.TeamMember `json:"member"`
 	}
 	if err := decodeBody(r, &payload); err != nil {
 		h.log.Warn("invalid request body", "error", err)
@@ -83,21 +150,16 @@ func (h *Handler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := validateSetActivePayload(payload); err != nil {
-		h.log.Warn("validation failed", "user_id", payload.UserID, "error", err)
+	if err := validateAddMemberPayload(payload); err != nil {
+		h.log.Warn("validation failed", "team", payload.TeamName, "error", err)
 		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
 		return
 	}
 
-	job := service.Job{
-		Type: "set_user_active",
-		Payload: map[string]interface{}{
-			"uid":    payload.UserID,
-			"active": payload.IsActive,
-		},
-		RespCh: make(chan service.JobResult, 1),
-		Ctx:    ctx,
-	}
+	job := service.NewJob(ctx, "add_team_member", map[string]interface{}{
+		"team_name": payload.TeamName,
+		"member":    payload.Member,
+	})
 	h.svc.EnqueueJob(job)
 
 	res, err := waitJob(ctx, job.RespCh)
@@ -107,25 +169,27 @@ func (h *Handler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if res.Error != nil {
-		if errors.Is(res.Error, service.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
-			return
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
 		}
-		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"team": res.Data})
 }
 
-func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	h.log.Info("received request CreatePR")
+	h.log.Info("received request RemoveTeamMember")
 
 	var payload struct {
-		PullRequestID   string `json:"pull_request_id"`
-		PullRequestName string `json:"pull_request_name"`
-		AuthorID        string `json:"author_id"`
+		TeamName string `json:"team_name"`
+		UserID   string `json:"user_id"`
 	}
 	if err := decodeBody(r, &payload); err != nil {
 		h.log.Warn("invalid request body", "error", err)
@@ -133,26 +197,62 @@ func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := validateCreatePRPayload(payload); err != nil {
-		h.log.Warn("validation failed", "payload", payload, "error", err)
+	if err := validateRemoveMemberPayload(payload); err != nil {
+		h.log.Warn("validation failed", "team", payload.TeamName, "error", err)
 		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
 		return
 	}
 
-	pr := models.PullRequest{
-		PullRequestID:   payload.PullRequestID,
-		PullRequestName: payload.PullRequestName,
-		AuthorID:        payload.AuthorID,
+	job := service.NewJob(ctx, "remove_team_member", map[string]interface{}{
+		"team_name": payload.TeamName,
+		"user_id":   payload.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
 	}
 
-	job := service.Job{
-		Type: "create_pr",
-		Payload: map[string]interface{}{
-			"pr": pr,
-		},
-		RespCh: make(chan service.JobResult, 1),
-		Ctx:    ctx,
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team or member not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"handoff": res.Data})
+}
+
+func (h *Handler) AddMembership(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request AddMembership")
+
+	var payload models.Membership
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateMembership(payload); err != nil {
+		h.log.Warn("validation failed", "team", payload.TeamName, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+	if payload.Role == "" {
+		payload.Role = models.RoleMember
 	}
+
+	job := service.NewJob(ctx, "add_membership", map[string]interface{}{
+		"membership": payload,
+	})
 	h.svc.EnqueueJob(job)
 
 	res, err := waitJob(ctx, job.RespCh)
@@ -164,24 +264,27 @@ func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	if res.Error != nil {
 		switch {
 		case errors.Is(res.Error, service.ErrNotFound):
-			writeError(w, http.StatusNotFound, "NOT_FOUND", "author/team not found")
-		case errors.Is(res.Error, service.ErrPRExists):
-			writeError(w, http.StatusConflict, "PR_EXISTS", "PR id already exists")
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team or user not found")
+		case errors.Is(res.Error, service.ErrHomeTeam):
+			writeError(w, http.StatusConflict, "HOME_TEAM", "team is the user's home team")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
 		default:
 			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
 		}
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]interface{}{"pr": res.Data})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"membership": res.Data})
 }
 
-func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) RemoveMembership(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	h.log.Info("received request MergePR")
+	h.log.Info("received request RemoveMembership")
 
 	var payload struct {
-		PullRequestID string `json:"pull_request_id"`
+		TeamName string `json:"team_name"`
+		UserID   string `json:"user_id"`
 	}
 	if err := decodeBody(r, &payload); err != nil {
 		h.log.Warn("invalid request body", "error", err)
@@ -189,20 +292,16 @@ func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := validateMergePRPayload(payload); err != nil {
-		h.log.Warn("validation failed", "pull_request_id", payload.PullRequestID, "error", err)
+	if err := validateRemoveMemberPayload(payload); err != nil {
+		h.log.Warn("validation failed", "team", payload.TeamName, "error", err)
 		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
 		return
 	}
 
-	job := service.Job{
-		Type: "merge_pr",
-		Payload: map[string]interface{}{
-			"pr_id": payload.PullRequestID,
-		},
-		RespCh: make(chan service.JobResult, 1),
-		Ctx:    ctx,
-	}
+	job := service.NewJob(ctx, "remove_membership", map[string]interface{}{
+		"team_name": payload.TeamName,
+		"user_id":   payload.UserID,
+	})
 	h.svc.EnqueueJob(job)
 
 	res, err := waitJob(ctx, job.RespCh)
@@ -212,24 +311,28 @@ func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if res.Error != nil {
-		if errors.Is(res.Error, service.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
-			return
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "membership not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
 		}
-		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{"pr": res.Data})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"team_name": payload.TeamName, "user_id": payload.UserID})
 }
 
-func (h *Handler) Reassign(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) MoveUserTeam(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	h.log.Info("received request Reassign")
+	h.log.Info("received request MoveUserTeam")
 
 	var payload struct {
-		PullRequestID string `json:"pull_request_id"`
-		OldUserID     string `json:"old_user_id"`
+		UserID          string `json:"user_id"`
+		TeamName        string `json:"team_name"`
+		ReassignReviews bool   `json:"reassign_reviews"`
 	}
 	if err := decodeBody(r, &payload); err != nil {
 		h.log.Warn("invalid request body", "error", err)
@@ -237,21 +340,17 @@ func (h *Handler) Reassign(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := validateReassignPayload(payload); err != nil {
-		h.log.Warn("validation failed", "payload", payload, "error", err)
+	if err := validateMoveTeamPayload(payload); err != nil {
+		h.log.Warn("validation failed", "user", payload.UserID, "error", err)
 		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
 		return
 	}
 
-	job := service.Job{
-		Type: "reassign_pr",
-		Payload: map[string]interface{}{
-			"pr_id":    payload.PullRequestID,
-			"old_user": payload.OldUserID,
-		},
-		RespCh: make(chan service.JobResult, 1),
-		Ctx:    ctx,
-	}
+	job := service.NewJob(ctx, "move_user_team", map[string]interface{}{
+		"user_id":          payload.UserID,
+		"team_name":        payload.TeamName,
+		"reassign_reviews": payload.ReassignReviews,
+	})
 	h.svc.EnqueueJob(job)
 
 	res, err := waitJob(ctx, job.RespCh)
@@ -263,46 +362,45 @@ func (h *Handler) Reassign(w http.ResponseWriter, r *http.Request) {
 	if res.Error != nil {
 		switch {
 		case errors.Is(res.Error, service.ErrNotFound):
-			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr or user not found")
-		case errors.Is(res.Error, service.ErrPRMerged):
-			writeError(w, http.StatusConflict, "PR_MERGED", "cannot reassign on merged PR")
-		case errors.Is(res.Error, service.ErrNotAssigned):
-			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
-		case errors.Is(res.Error, service.ErrNoCandidate):
-			writeError(w, http.StatusConflict, "NO_CANDIDATE", "no active replacement candidate in team")
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user or team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
 		default:
 			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
 		}
 		return
 	}
 
-	data := res.Data.(map[string]interface{})
-	writeJSON(w, http.StatusOK, data)
-}
-
-type getTeamRequest struct {
-	TeamName string
+	writeJSON(w, http.StatusOK, res.Data)
 }
 
-func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) SetUserSkills(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	req := getTeamRequest{
-		TeamName: r.URL.Query().Get("team_name"),
+	h.log.Info("received request SetUserSkills")
+
+	var payload struct {
+		UserID string   `json:"user_id"`
+		Skills []string `json:"skills"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
 	}
 
-	if err := validateGetTeamRequest(req); err != nil {
+	if err := validateSetSkillsPayload(payload); err != nil {
+		h.log.Warn("validation failed", "user", payload.UserID, "error", err)
 		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
 		return
 	}
-
-	job := service.Job{
-		Type: "get_team",
-		Payload: map[string]interface{}{
-			"team": req.TeamName,
-		},
-		RespCh: make(chan service.JobResult, 1),
-		Ctx:    ctx,
+	if payload.Skills == nil {
+		payload.Skills = []string{}
 	}
+
+	job := service.NewJob(ctx, "set_user_skills", map[string]interface{}{
+		"uid":    payload.UserID,
+		"skills": payload.Skills,
+	})
 	h.svc.EnqueueJob(job)
 
 	res, err := waitJob(ctx, job.RespCh)
@@ -312,41 +410,55 @@ func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if res.Error != nil {
-		if errors.Is(res.Error, service.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
-			return
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
 		}
-		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, res.Data)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
 }
 
-type getUserReviewsRequest struct {
-	UserID string
+type setVacationPayload struct {
+	UserID string     `json:"user_id"`
+	From   *time.Time `json:"from"`
+	Until  *time.Time `json:"until"`
 }
 
-func (h *Handler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) SetVacation(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	h.log.Info("received request GetUserReviews")
-	req := getUserReviewsRequest{
-		UserID: r.URL.Query().Get("user_id"),
+	h.log.Info("received request SetVacation")
+
+	var payload setVacationPayload
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
 	}
 
-	if err := validateGetUserReviewsRequest(req); err != nil {
+	if err := validateSetVacationPayload(payload); err != nil {
+		h.log.Warn("validation failed", "user", payload.UserID, "error", err)
 		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
 		return
 	}
-
-	job := service.Job{
-		Type: "get_reviews",
-		Payload: map[string]interface{}{
-			"uid": req.UserID,
-		},
-		RespCh: make(chan service.JobResult, 1),
-		Ctx:    ctx,
+	var from, until time.Time
+	if payload.From != nil {
+		from = *payload.From
+	}
+	if payload.Until != nil {
+		until = *payload.Until
 	}
+
+	job := service.NewJob(ctx, "set_vacation", map[string]interface{}{
+		"uid":   payload.UserID,
+		"from":  from,
+		"until": until,
+	})
 	h.svc.EnqueueJob(job)
 
 	res, err := waitJob(ctx, job.RespCh)
@@ -356,53 +468,95 @@ func (h *Handler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if res.Error != nil {
-		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidVacation):
+			writeError(w, http.StatusBadRequest, "INVALID", "until must be after from and in the future")
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": req.UserID, "pull_requests": res.Data})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
 }
 
-func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) SetMentor(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	h.log.Info("received request GetStats")
-	stats, err := h.svc.GetStats(ctx)
+	h.log.Info("received request SetMentor")
+
+	var payload struct {
+		UserID   string `json:"user_id"`
+		MentorID string `json:"mentor_id"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateSetMentorPayload(payload); err != nil {
+		h.log.Warn("validation failed", "user", payload.UserID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "set_mentor", map[string]interface{}{
+		"uid":    payload.UserID,
+		"mentor": payload.MentorID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
 	if err != nil {
-		h.log.Error("failed to get stats", "error", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"error": map[string]string{
-				"code":    "ERROR",
-				"message": err.Error(),
-			},
-		})
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
 		return
 	}
-	writeJSON(w, http.StatusOK, stats)
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidMentor):
+			writeError(w, http.StatusBadRequest, "INVALID", "users cannot mentor themselves or each other")
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
 }
 
-func (h *Handler) DeactivateTeam(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) SetTimezone(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	h.log.Info("received request deactivate team")
+	h.log.Info("received request SetTimezone")
 
-	type req struct {
-		Team string `json:"team_name"`
+	var payload struct {
+		UserID   string `json:"user_id"`
+		Timezone string `json:"timezone"`
 	}
-	var body req
-	if err := decodeJSON(r.Body, &body); err != nil {
-		h.log.Error("invalid request body", "error", err)
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
 		return
 	}
 
-	respCh := make(chan service.JobResult, 1)
-	job := service.Job{
-		Type: "deactivate_team",
-		Payload: map[string]interface{}{
-			"team_name": body.Team,
-		},
-		RespCh: respCh,
-		Ctx:    ctx,
+	if err := validateSetTimezonePayload(payload); err != nil {
+		h.log.Warn("validation failed", "user", payload.UserID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
 	}
+
+	job := service.NewJob(ctx, "set_timezone", map[string]interface{}{
+		"uid":      payload.UserID,
+		"timezone": payload.Timezone,
+	})
 	h.svc.EnqueueJob(job)
 
 	res, err := waitJob(ctx, job.RespCh)
@@ -412,14 +566,2591 @@ func (h *Handler) DeactivateTeam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if res.Error != nil {
-		h.log.Error("failed to deactivate team", "team_name", body.Team, "error", res.Error)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": res.Error.Error()})
-		return
-	}
-
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidTimezone):
+			writeError(w, http.StatusBadRequest, "INVALID", "unknown timezone")
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
+}
+
+func (h *Handler) SetNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request SetNotifications")
+
+	var payload struct {
+		UserID string `json:"user_id"`
+		Merged *bool  `json:"merged"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateSetNotificationsPayload(payload); err != nil {
+		h.log.Warn("validation failed", "user", payload.UserID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "set_notifications", map[string]interface{}{
+		"uid":    payload.UserID,
+		"merged": *payload.Merged,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
+}
+
+func (h *Handler) SetUserWeight(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request SetUserWeight")
+
+	var payload struct {
+		UserID string `json:"user_id"`
+		Weight int    `json:"weight"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateSetWeightPayload(payload); err != nil {
+		h.log.Warn("validation failed", "user", payload.UserID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "set_user_weight", map[string]interface{}{
+		"uid":    payload.UserID,
+		"weight": payload.Weight,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidWeight):
+			writeError(w, http.StatusBadRequest, "INVALID", "weight must be between 1 and 10")
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
+}
+
+func (h *Handler) SetIsActive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request SetIsActive")
+
+	var payload struct {
+		UserID   string `json:"user_id"`
+		IsActive bool   `json:"is_active"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateSetActivePayload(payload); err != nil {
+		h.log.Warn("validation failed", "user_id", payload.UserID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "set_user_active", map[string]interface{}{
+		"uid":    payload.UserID,
+		"active": payload.IsActive,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		if errors.Is(res.Error, service.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
+}
+
+// ForgetUser erases a user's personal data on their request, keeping their
+// ID and history for stats.
+func (h *Handler) ForgetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ForgetUser")
+
+	var payload struct {
+		UserID string `json:"user_id"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if payload.UserID == "" {
+		h.log.Warn("validation failed", "error", errMissingUserID)
+		writeError(w, http.StatusBadRequest, "INVALID", errMissingUserID.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "forget_user", map[string]interface{}{
+		"uid": payload.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
+}
+
+func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request CreatePR")
+
+	var payload struct {
+		PullRequestID   string   `json:"pull_request_id"`
+		PullRequestName string   `json:"pull_request_name"`
+		AuthorID        string   `json:"author_id"`
+		Labels          []string `json:"labels"`
+		Paths           []string `json:"paths"`
+		Priority        string   `json:"priority"`
+		Size            string   `json:"size"`
+		LinesChanged    int      `json:"lines_changed"`
+		Repository      string   `json:"repository"`
+		CoAuthors       []string `json:"co_authors"`
+		TargetBranch    string   `json:"target_branch"`
+		Description     string   `json:"description"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateCreatePRPayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	pr := models.PullRequest{
+		PullRequestID:   payload.PullRequestID,
+		PullRequestName: payload.PullRequestName,
+		AuthorID:        payload.AuthorID,
+		Labels:          payload.Labels,
+		Paths:           payload.Paths,
+		Priority:        models.PRPriority(payload.Priority),
+		Size:            models.PRSize(payload.Size),
+		Repository:      payload.Repository,
+		CoAuthors:       payload.CoAuthors,
+		TargetBranch:    payload.TargetBranch,
+		Description:     payload.Description,
+	}
+	if pr.Size == "" && payload.LinesChanged > 0 {
+		pr.Size = models.SizeForLines(payload.LinesChanged)
+	}
+
+	job := service.NewJob(ctx, "create_pr", map[string]interface{}{
+		"pr": pr,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "author/team not found")
+		case errors.Is(res.Error, service.ErrPRExists):
+			writeError(w, http.StatusConflict, "PR_EXISTS", "PR id already exists")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusCreated, res.Data)
+}
+
+// mergePRRequest merges a PR; Override skips the team's approval quorum and
+// merge window.
+type mergePRRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	Repository    string `json:"repository"`
+	Override      bool   `json:"override"`
+	MergedBy      string `json:"merged_by"`
+}
+
+func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request MergePR")
+
+	var payload mergePRRequest
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateMergePRRequest(payload); err != nil {
+		h.log.Warn("validation failed", "pull_request_id", payload.PullRequestID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "merge_pr", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"override":   payload.Override,
+		"merged_by":  payload.MergedBy,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		if errors.Is(res.Error, service.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+			return
+		}
+		if errors.Is(res.Error, service.ErrUnknownMerger) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "merged_by user not found")
+			return
+		}
+		if errors.Is(res.Error, service.ErrPRClosed) {
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot merge closed PR")
+			return
+		}
+		if errors.Is(res.Error, service.ErrBadTransition) {
+			writeError(w, http.StatusConflict, "BAD_TRANSITION", res.Error.Error())
+			return
+		}
+		if errors.Is(res.Error, service.ErrNotEnoughApprovals) {
+			writeError(w, http.StatusConflict, "NOT_ENOUGH_APPROVALS", res.Error.Error())
+			return
+		}
+		if errors.Is(res.Error, service.ErrMergeWindowClosed) {
+			writeError(w, http.StatusConflict, "MERGE_WINDOW_CLOSED", res.Error.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+// SetLabels replaces a PR's labels.
+func (h *Handler) SetLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request SetLabels")
+
+	var payload struct {
+		PullRequestID string   `json:"pull_request_id"`
+		Repository    string   `json:"repository"`
+		Labels        []string `json:"labels"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateSetLabelsPayload(payload); err != nil {
+		h.log.Warn("validation failed", "pull_request_id", payload.PullRequestID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+	if payload.Labels == nil {
+		payload.Labels = []string{}
+	}
+
+	job := service.NewJob(ctx, "set_labels", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"labels":     payload.Labels,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot label merged PR")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot label closed PR")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+// QueueMerge queues a PR to be merged when its team's merge window opens.
+func (h *Handler) QueueMerge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request QueueMerge")
+
+	var payload struct {
+		PullRequestID string `json:"pull_request_id"`
+		Repository    string `json:"repository"`
+		MergedBy      string `json:"merged_by"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if payload.PullRequestID == "" {
+		h.log.Warn("validation failed", "error", errMissingPullRequestID)
+		writeError(w, http.StatusBadRequest, "INVALID", errMissingPullRequestID.Error())
+		return
+	}
+	if err := validateRepository(payload.Repository); err != nil {
+		h.log.Warn("validation failed", "pull_request_id", payload.PullRequestID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "queue_merge", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"merged_by":  payload.MergedBy,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "pr already merged")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot merge closed PR")
+		case errors.Is(res.Error, service.ErrBadTransition):
+			writeError(w, http.StatusConflict, "BAD_TRANSITION", res.Error.Error())
+		case errors.Is(res.Error, service.ErrNotEnoughApprovals):
+			writeError(w, http.StatusConflict, "NOT_ENOUGH_APPROVALS", res.Error.Error())
+		case errors.Is(res.Error, service.ErrUnknownMerger):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "merged_by user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"queued_merge": res.Data})
+}
+
+func (h *Handler) ClosePR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ClosePR")
+
+	var payload struct {
+		PullRequestID string `json:"pull_request_id"`
+		Repository    string `json:"repository"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateMergePRPayload(payload); err != nil {
+		h.log.Warn("validation failed", "pull_request_id", payload.PullRequestID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "close_pr", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot close merged PR")
+		case errors.Is(res.Error, service.ErrBadTransition):
+			writeError(w, http.StatusConflict, "BAD_TRANSITION", res.Error.Error())
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+func (h *Handler) ReopenPR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ReopenPR")
+
+	var payload struct {
+		PullRequestID string `json:"pull_request_id"`
+		Repository    string `json:"repository"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateMergePRPayload(payload); err != nil {
+		h.log.Warn("validation failed", "pull_request_id", payload.PullRequestID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "reopen_pr", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot reopen merged PR")
+		case errors.Is(res.Error, service.ErrBadTransition):
+			writeError(w, http.StatusConflict, "BAD_TRANSITION", res.Error.Error())
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+func (h *Handler) Reassign(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request Reassign")
+
+	var payload struct {
+		PullRequestID string `json:"pull_request_id"`
+		Repository    string `json:"repository"`
+		OldUserID     string `json:"old_user_id"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateReassignPayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "reassign_pr", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"old_user":   payload.OldUserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr or user not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot reassign on merged PR")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot reassign on closed PR")
+		case errors.Is(res.Error, service.ErrNotAssigned):
+			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		case errors.Is(res.Error, service.ErrNoCandidate):
+			writeError(w, http.StatusConflict, "NO_CANDIDATE", "no active replacement candidate in team")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+// addReviewerRequest adds a reviewer to a PR; Role "secondary" makes them the
+// PR's optional secondary reviewer.
+type addReviewerRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	Repository    string `json:"repository"`
+	UserID        string `json:"user_id"`
+	Role          string `json:"role"`
+}
+
+func (h *Handler) AddReviewer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request AddReviewer")
+
+	var payload addReviewerRequest
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateAddReviewerPayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	jobType := "assign_reviewer"
+	if payload.Role == models.ReviewerSecondary {
+		jobType = "assign_secondary_reviewer"
+	}
+	job := service.NewJob(ctx, jobType, map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"uid":        payload.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr or user not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot assign on merged PR")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot assign on closed PR")
+		case errors.Is(res.Error, service.ErrAuthorReviewer):
+			writeError(w, http.StatusConflict, "AUTHOR_REVIEWER", "author cannot review own PR")
+		case errors.Is(res.Error, service.ErrAlreadyAssigned):
+			writeError(w, http.StatusConflict, "ALREADY_ASSIGNED", "user is already a reviewer")
+		case errors.Is(res.Error, service.ErrReviewersFull):
+			writeError(w, http.StatusConflict, "REVIEWERS_FULL", "PR already has the maximum number of reviewers")
+		case errors.Is(res.Error, service.ErrSecondaryTaken):
+			writeError(w, http.StatusConflict, "SECONDARY_TAKEN", "PR already has a secondary reviewer")
+		case errors.Is(res.Error, service.ErrUserInactive):
+			writeError(w, http.StatusConflict, "USER_INACTIVE", "user is not active")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+func (h *Handler) FillReviewers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request FillReviewers")
+
+	var payload struct {
+		PullRequestID string `json:"pull_request_id"`
+		Repository    string `json:"repository"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateMergePRPayload(payload); err != nil {
+		h.log.Warn("validation failed", "pull_request_id", payload.PullRequestID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "fill_reviewers", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot add reviewers to merged PR")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot add reviewers to closed PR")
+		case errors.Is(res.Error, service.ErrNoCandidate):
+			writeError(w, http.StatusConflict, "NO_CANDIDATE", "no active candidate in team")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+func (h *Handler) RemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request RemoveReviewer")
+
+	var payload struct {
+		PullRequestID string `json:"pull_request_id"`
+		Repository    string `json:"repository"`
+		UserID        string `json:"user_id"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateApprovePayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "remove_reviewer", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"uid":        payload.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot remove reviewer on merged PR")
+		case errors.Is(res.Error, service.ErrNotAssigned):
+			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+func (h *Handler) ApprovePR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ApprovePR")
+
+	var payload struct {
+		PullRequestID string `json:"pull_request_id"`
+		Repository    string `json:"repository"`
+		UserID        string `json:"user_id"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateApprovePayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "approve_pr", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"uid":        payload.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot approve merged PR")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot approve closed PR")
+		case errors.Is(res.Error, service.ErrNotAssigned):
+			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+func (h *Handler) AcknowledgeReview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request AcknowledgeReview")
+
+	var payload struct {
+		PullRequestID string `json:"pull_request_id"`
+		Repository    string `json:"repository"`
+		UserID        string `json:"user_id"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateApprovePayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "ack_review", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"uid":        payload.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot acknowledge review on merged PR")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot acknowledge review on closed PR")
+		case errors.Is(res.Error, service.ErrNotAssigned):
+			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+type getTeamRequest struct {
+	TeamName string
+}
+
+func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req := getTeamRequest{
+		TeamName: r.URL.Query().Get("team_name"),
+	}
+
+	if err := validateGetTeamRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_team", map[string]interface{}{
+		"team": req.TeamName,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		if errors.Is(res.Error, service.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+type getPRRequest struct {
+	PullRequestID string
+	Repository    string
+}
+
+func (h *Handler) GetPR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetPR")
+	req := getPRRequest{
+		PullRequestID: r.URL.Query().Get("pull_request_id"),
+		Repository:    r.URL.Query().Get("repository"),
+	}
+
+	if err := validateGetPRRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_pr", map[string]interface{}{
+		"pr_id":      req.PullRequestID,
+		"repository": req.Repository,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+// GetAssignmentHistory answers why reviewers were assigned to or taken off
+// a PR.
+func (h *Handler) GetAssignmentHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetAssignmentHistory")
+	req := getPRRequest{
+		PullRequestID: r.URL.Query().Get("pull_request_id"),
+		Repository:    r.URL.Query().Get("repository"),
+	}
+
+	if err := validateGetPRRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_assignment_history", map[string]interface{}{
+		"pr_id":      req.PullRequestID,
+		"repository": req.Repository,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"pull_request_id": req.PullRequestID, "history": res.Data})
+}
+
+// requestChangesPayload starts a PR's next review round. Hours 0 takes the
+// team's review SLA as the round's deadline.
+type requestChangesPayload struct {
+	PullRequestID string `json:"pull_request_id"`
+	Repository    string `json:"repository"`
+	UserID        string `json:"user_id"`
+	Hours         int    `json:"hours"`
+	FreshReviewer bool   `json:"fresh_reviewer"`
+}
+
+func (h *Handler) RequestChanges(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request RequestChanges")
+
+	var payload requestChangesPayload
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+	if err := validateRequestChangesPayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "request_changes", map[string]interface{}{
+		"pr_id":          payload.PullRequestID,
+		"repository":     payload.Repository,
+		"uid":            payload.UserID,
+		"hours":          payload.Hours,
+		"fresh_reviewer": payload.FreshReviewer,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidRound):
+			writeError(w, http.StatusBadRequest, "INVALID", errInvalidRoundHours.Error())
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot request changes on merged PR")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot request changes on closed PR")
+		case errors.Is(res.Error, service.ErrNotAssigned):
+			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		case errors.Is(res.Error, service.ErrNoCandidate):
+			writeError(w, http.StatusConflict, "NO_CANDIDATE", "no active candidate in team for a fresh reviewer")
+		case errors.Is(res.Error, service.ErrSecondaryTaken):
+			writeError(w, http.StatusConflict, "SECONDARY_TAKEN", "PR already has a secondary reviewer")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+// submitReviewPayload is a reviewer's decision on a PR. CHANGES_REQUESTED
+// starts the next review round like RequestChanges with hours 0.
+type submitReviewPayload struct {
+	PullRequestID string              `json:"pull_request_id"`
+	Repository    string              `json:"repository"`
+	UserID        string              `json:"user_id"`
+	Decision      models.ReviewStatus `json:"decision"`
+	Comment       string              `json:"comment"`
+}
+
+func (h *Handler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request SubmitReview")
+
+	var payload submitReviewPayload
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+	if err := validateSubmitReviewPayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "submit_review", map[string]interface{}{
+		"pr_id":      payload.PullRequestID,
+		"repository": payload.Repository,
+		"uid":        payload.UserID,
+		"decision":   payload.Decision,
+		"comment":    payload.Comment,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidReview):
+			writeError(w, http.StatusBadRequest, "INVALID", errInvalidDecision.Error())
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrPRMerged):
+			writeError(w, http.StatusConflict, "PR_MERGED", "cannot review merged PR")
+		case errors.Is(res.Error, service.ErrPRClosed):
+			writeError(w, http.StatusConflict, "PR_CLOSED", "cannot review closed PR")
+		case errors.Is(res.Error, service.ErrNotAssigned):
+			writeError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writePR(w, http.StatusOK, res.Data)
+}
+
+func (h *Handler) GetReviewRounds(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetReviewRounds")
+	req := getPRRequest{
+		PullRequestID: r.URL.Query().Get("pull_request_id"),
+		Repository:    r.URL.Query().Get("repository"),
+	}
+
+	if err := validateGetPRRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_review_rounds", map[string]interface{}{
+		"pr_id":      req.PullRequestID,
+		"repository": req.Repository,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "pr not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"pull_request_id": req.PullRequestID, "rounds": res.Data})
+}
+
+type suggestRequest struct {
+	AuthorID string
+	Count    int
+}
+
+func (h *Handler) SuggestReviewers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request SuggestReviewers")
+
+	req, err := parseSuggestRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "suggest_reviewers", map[string]interface{}{
+		"author_id": req.AuthorID,
+		"count":     req.Count,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "author not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"author_id": req.AuthorID, "suggestions": res.Data})
+}
+
+type listPRsRequest struct {
+	Filter models.PRFilter
+	Page   models.Page
+}
+
+func (h *Handler) ListPRs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ListPRs")
+
+	req, err := parseListPRsRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "list_prs", map[string]interface{}{
+		"filter": req.Filter,
+		"page":   req.Page,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"pull_requests": res.Data, "limit": req.Page.Limit, "offset": req.Page.Offset})
+}
+
+// GetArchivedPR returns a PR the archiver moved out of the live tables,
+// with its assignment history.
+func (h *Handler) GetArchivedPR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetArchivedPR")
+	req := getPRRequest{
+		PullRequestID: r.URL.Query().Get("pull_request_id"),
+		Repository:    r.URL.Query().Get("repository"),
+	}
+
+	if err := validateGetPRRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_archived_pr", map[string]interface{}{
+		"pr_id":      req.PullRequestID,
+		"repository": req.Repository,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "archived pr not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+// ListArchivedPRs lists archived PRs with the filters of ListPRs.
+func (h *Handler) ListArchivedPRs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ListArchivedPRs")
+
+	req, err := parseListPRsRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "list_archived_prs", map[string]interface{}{
+		"filter": req.Filter,
+		"page":   req.Page,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"pull_requests": res.Data, "limit": req.Page.Limit, "offset": req.Page.Offset})
+}
+
+type searchPRsRequest struct {
+	Query  string
+	Filter models.PRFilter
+	Page   models.Page
+}
+
+func (h *Handler) SearchPRs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request SearchPRs")
+
+	req, err := parseSearchPRsRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "search_prs", map[string]interface{}{
+		"q":      req.Query,
+		"filter": req.Filter,
+		"page":   req.Page,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"pull_requests": res.Data, "limit": req.Page.Limit, "offset": req.Page.Offset})
+}
+
+type getUserActivityRequest struct {
+	UserID string
+	Page   models.Page
+}
+
+func (h *Handler) GetUserActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetUserActivity")
+
+	req, err := parseGetUserActivityRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_user_activity", map[string]interface{}{
+		"uid":  req.UserID,
+		"page": req.Page,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": req.UserID, "events": res.Data, "limit": req.Page.Limit, "offset": req.Page.Offset})
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetUser")
+	req := getUserReviewsRequest{
+		UserID: r.URL.Query().Get("user_id"),
+	}
+
+	if err := validateGetUserReviewsRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_user", map[string]interface{}{
+		"uid": req.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": res.Data})
+}
+
+type getUserReviewsRequest struct {
+	UserID string
+}
+
+func (h *Handler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetUserReviews")
+	req := getUserReviewsRequest{
+		UserID: r.URL.Query().Get("user_id"),
+	}
+
+	if err := validateGetUserReviewsRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_reviews", map[string]interface{}{
+		"uid": req.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": req.UserID, "pull_requests": res.Data})
+}
+
+func (h *Handler) GetUserAuthored(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetUserAuthored")
+	req := getUserReviewsRequest{
+		UserID: r.URL.Query().Get("user_id"),
+	}
+
+	if err := validateGetUserReviewsRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_authored", map[string]interface{}{
+		"uid": req.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": req.UserID, "pull_requests": res.Data})
+}
+
+type getStatsRequest struct {
+	Query  models.StatsQuery
+	Budget time.Duration
+}
+
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetStats")
+
+	if r.URL.RawQuery != "" {
+		h.queryStats(w, r)
+		return
+	}
+
+	stats, err := h.svc.GetStats(ctx)
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		h.log.Error("failed to get stats", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": map[string]string{
+				"code":    "ERROR",
+				"message": err.Error(),
+			},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// queryStats serves /stats when scope hints or a time budget are given. The
+// response wraps the stats map so it can carry the truncated flag.
+func (h *Handler) queryStats(w http.ResponseWriter, r *http.Request) {
+	req, err := parseGetStatsRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	report, err := h.svc.QueryStats(r.Context(), req.Query, req.Budget)
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		h.log.Error("failed to query stats", "error", err)
+		writeError(w, http.StatusInternalServerError, "ERROR", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *Handler) GetOrgSummary(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("received request GetOrgSummary")
+
+	sum, err := h.svc.GetOrgSummary(r.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		h.log.Error("failed to get org summary", "error", err)
+		writeError(w, http.StatusInternalServerError, "ERROR", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, sum)
+}
+
+func (h *Handler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.svc.ActiveAlerts(r.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		h.log.Error("failed to list alerts", "error", err)
+		writeError(w, http.StatusInternalServerError, "ERROR", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"alerts": alerts})
+}
+
+func (h *Handler) ReassignAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ReassignAll")
+
+	var payload struct {
+		FromUserID string `json:"from_user_id"`
+		ToUserID   string `json:"to_user_id"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+	if payload.FromUserID == "" {
+		writeError(w, http.StatusBadRequest, "INVALID", errMissingFromUserID.Error())
+		return
+	}
+	if payload.FromUserID == payload.ToUserID {
+		writeError(w, http.StatusBadRequest, "INVALID", errSameUser.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "reassign_all", map[string]interface{}{
+		"from": payload.FromUserID,
+		"to":   payload.ToUserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrUserInactive):
+			writeError(w, http.StatusConflict, "USER_INACTIVE", "to_user_id is inactive")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+type getUserStatsRequest struct {
+	UserID string
+	Weeks  int
+}
+
+func (h *Handler) GetUserStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetUserStats")
+
+	req, err := parseGetUserStatsRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_user_stats", map[string]interface{}{
+		"uid":   req.UserID,
+		"weeks": req.Weeks,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+type getSecurityCoverageRequest struct {
+	TeamName string
+	Weeks    int
+}
+
+func (h *Handler) GetSecurityCoverage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetSecurityCoverage")
+
+	req, err := parseGetSecurityCoverageRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_security_coverage", map[string]interface{}{
+		"team_name": req.TeamName,
+		"weeks":     req.Weeks,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+// GetReviewLoad reports a team's open review load by PR size.
+func (h *Handler) GetReviewLoad(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetReviewLoad")
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		writeError(w, http.StatusBadRequest, "INVALID", errMissingTeamName.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_review_load", map[string]interface{}{
+		"team_name": teamName,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+// GetTeamStats reports a team's PR counters.
+func (h *Handler) GetTeamStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetTeamStats")
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		writeError(w, http.StatusBadRequest, "INVALID", errMissingTeamName.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_team_stats", map[string]interface{}{
+		"team_name": teamName,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+// GetOverdueReviews lists the reviews past their team's SLA, optionally for
+// one team_name.
+func (h *Handler) GetOverdueReviews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetOverdueReviews")
+
+	job := service.NewJob(ctx, "list_overdue_reviews", map[string]interface{}{
+		"team_name": r.URL.Query().Get("team_name"),
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"overdue": res.Data})
+}
+
+type reserveReviewersPayload struct {
+	AuthorID  string     `json:"author_id"`
+	Reviewers []string   `json:"reviewers"`
+	Until     *time.Time `json:"until"`
+}
+
+// ReserveReviewers holds reviewers for a large PR the author is about to
+// open, or cancels the hold when no reviewers are given.
+func (h *Handler) ReserveReviewers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ReserveReviewers")
+
+	var payload reserveReviewersPayload
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+	if err := validateReserveReviewersPayload(payload); err != nil {
+		h.log.Warn("validation failed", "author", payload.AuthorID, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+	reviewers := payload.Reviewers
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+	var until time.Time
+	if payload.Until != nil {
+		until = *payload.Until
+	}
+
+	job := service.NewJob(ctx, "reserve_reviewers", map[string]interface{}{
+		"uid":       payload.AuthorID,
+		"reviewers": reviewers,
+		"until":     until,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidReservation):
+			writeError(w, http.StatusBadRequest, "INVALID", "at most 2 reviewers, until in the future and within 14 days")
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrAuthorReviewer):
+			writeError(w, http.StatusConflict, "AUTHOR_REVIEWER", "author cannot review own PR")
+		case errors.Is(res.Error, service.ErrUserInactive):
+			writeError(w, http.StatusConflict, "USER_INACTIVE", "reviewer is not active")
+		case errors.Is(res.Error, service.ErrReviewerHeld):
+			writeError(w, http.StatusConflict, "RESERVED", "reviewer is reserved by another author")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"reservation": res.Data})
+}
+
+type exportAssignmentsRequest struct {
+	From time.Time
+	To   time.Time
+}
+
+// ExportAssignments streams the anonymized assignment export as ndjson.
+func (h *Handler) ExportAssignments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request ExportAssignments")
+
+	req, err := parseExportAssignmentsRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "export_assignments", map[string]interface{}{
+		"from": req.From,
+		"to":   req.To,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrExportDisabled):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "assignment export is disabled")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	recs, _ := res.Data.([]export.Record)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if err := export.Write(w, recs); err != nil {
+		h.log.Warn("failed to write assignment export", "error", err)
+	}
+}
+
+func (h *Handler) CheckConsistency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request CheckConsistency")
+
+	var payload struct {
+		Repair bool `json:"repair"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	job := service.NewJob(ctx, "check_consistency", map[string]interface{}{"repair": payload.Repair})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+// PreviewDeactivation reports how many active PRs deactivating a team would
+// leave without reviewers, before anyone is deactivated.
+func (h *Handler) PreviewDeactivation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request PreviewDeactivation")
+	req := getTeamRequest{
+		TeamName: r.URL.Query().Get("team_name"),
+	}
+
+	if err := validateGetTeamRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "preview_deactivation", map[string]interface{}{
+		"team_name": req.TeamName,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"preview": res.Data})
+}
+
+func (h *Handler) DeactivateTeam(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request deactivate team")
+
+	type req struct {
+		Team string `json:"team_name"`
+	}
+	var body req
+	if err := decodeJSON(r.Body, &body); err != nil {
+		h.log.Error("invalid request body", "error", err)
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+
+	job := service.NewJob(ctx, "deactivate_team", map[string]interface{}{
+		"team_name": body.Team,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		h.log.Error("failed to deactivate team", "team_name", body.Team, "error", res.Error)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": res.Error.Error()})
+		return
+	}
+
 	writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
 }
 
+func (h *Handler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request DeleteTeam")
+
+	var payload struct {
+		TeamName string `json:"team_name"`
+		Force    bool   `json:"force"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateDeleteTeamPayload(payload); err != nil {
+		h.log.Warn("validation failed", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "delete_team", map[string]interface{}{
+		"team_name": payload.TeamName,
+		"force":     payload.Force,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrTeamInUse):
+			prs, _ := res.Data.([]string)
+			writeError(w, http.StatusConflict, "TEAM_IN_USE", fmt.Sprintf("team members review %d open PRs, pass force=true to remove them", len(prs)))
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"team_name": payload.TeamName, "affected_prs": res.Data})
+}
+
+func (h *Handler) IssueTeamToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request IssueTeamToken")
+
+	var payload struct {
+		TeamName string `json:"team_name"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateTeamNamePayload(payload); err != nil {
+		h.log.Warn("validation failed", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "issue_team_token", map[string]interface{}{
+		"team_name": payload.TeamName,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, res.Data)
+}
+
+func (h *Handler) IssueUserToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request IssueUserToken")
+
+	var payload struct {
+		UserID string `json:"user_id"`
+	}
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if payload.UserID == "" {
+		h.log.Warn("validation failed", "error", errMissingUserID)
+		writeError(w, http.StatusBadRequest, "INVALID", errMissingUserID.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "issue_user_token", map[string]interface{}{
+		"uid": payload.UserID,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, res.Data)
+}
+
+// MyReviews lists the PRs reviewed by the user the request's token is bound
+// to.
+func (h *Handler) MyReviews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request MyReviews")
+
+	job := service.NewJob(ctx, "get_my_reviews", map[string]interface{}{})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		writeMeError(w, res.Error)
+		return
+	}
+
+	scope, _ := service.ScopeFromContext(ctx)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": scope.UserID, "pull_requests": res.Data})
+}
+
+// MyStats returns the review stats of the user the request's token is bound
+// to.
+func (h *Handler) MyStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request MyStats")
+
+	weeks, err := parseStatsWeeks(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_my_stats", map[string]interface{}{
+		"weeks": weeks,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		writeMeError(w, res.Error)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res.Data)
+}
+
+func writeMeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnauthorized):
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "user token required")
+	case errors.Is(err, service.ErrNotFound):
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+	default:
+		writeError(w, http.StatusInternalServerError, "ERROR", err.Error())
+	}
+}
+
+func (h *Handler) GetTeamSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetTeamSettings")
+	req := getTeamRequest{
+		TeamName: r.URL.Query().Get("team_name"),
+	}
+
+	if err := validateGetTeamRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_team_settings", map[string]interface{}{
+		"team_name": req.TeamName,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"settings": res.Data})
+}
+
+func (h *Handler) UpdateTeamSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request UpdateTeamSettings")
+
+	var settings models.TeamSettings
+	if err := decodeBody(r, &settings); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateTeamSettings(settings); err != nil {
+		h.log.Warn("validation failed", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "update_team_settings", map[string]interface{}{
+		"settings": settings,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidSettings):
+			writeError(w, http.StatusBadRequest, "INVALID", res.Error.Error())
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"settings": res.Data})
+}
+
+func (h *Handler) GetRepositorySettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetRepositorySettings")
+
+	repository := r.URL.Query().Get("repository")
+	if err := validateRepositorySettings(models.RepositorySettings{Repository: repository}); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_repository_settings", map[string]interface{}{
+		"repository": repository,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		if errors.Is(res.Error, service.ErrForbidden) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"settings": res.Data})
+}
+
+func (h *Handler) UpdateRepositorySettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request UpdateRepositorySettings")
+
+	var settings models.RepositorySettings
+	if err := decodeBody(r, &settings); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+
+	if err := validateRepositorySettings(settings); err != nil {
+		h.log.Warn("validation failed", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "update_repository_settings", map[string]interface{}{
+		"settings": settings,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidSettings):
+			writeError(w, http.StatusBadRequest, "INVALID", res.Error.Error())
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"settings": res.Data})
+}
+
+func (h *Handler) GetRotation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request GetRotation")
+	req := getTeamRequest{
+		TeamName: r.URL.Query().Get("team_name"),
+	}
+
+	if err := validateGetTeamRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "get_rotation", map[string]interface{}{
+		"team_name": req.TeamName,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"rotation": res.Data})
+}
+
+// regenerateRotationPayload asks for Weeks weeks of new reviewer pairs;
+// 0 means the default of four.
+type regenerateRotationPayload struct {
+	TeamName string `json:"team_name"`
+	Weeks    int    `json:"weeks"`
+}
+
+func (h *Handler) RegenerateRotation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.log.Info("received request RegenerateRotation")
+
+	var payload regenerateRotationPayload
+	if err := decodeBody(r, &payload); err != nil {
+		h.log.Warn("invalid request body", "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", "invalid body")
+		return
+	}
+	if err := validateRegenerateRotationPayload(payload); err != nil {
+		h.log.Warn("validation failed", "payload", payload, "error", err)
+		writeError(w, http.StatusBadRequest, "INVALID", err.Error())
+		return
+	}
+
+	job := service.NewJob(ctx, "regenerate_rotation", map[string]interface{}{
+		"team_name": payload.TeamName,
+		"weeks":     payload.Weeks,
+	})
+	h.svc.EnqueueJob(job)
+
+	res, err := waitJob(ctx, job.RespCh)
+	if err != nil {
+		writeError(w, http.StatusGatewayTimeout, "CANCELED", "request canceled")
+		return
+	}
+
+	if res.Error != nil {
+		switch {
+		case errors.Is(res.Error, service.ErrInvalidRotation):
+			writeError(w, http.StatusBadRequest, "INVALID", errInvalidRotationWeeks.Error())
+		case errors.Is(res.Error, service.ErrNotFound):
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(res.Error, service.ErrNoCandidate):
+			writeError(w, http.StatusConflict, "NO_CANDIDATE", "a rotation needs at least two active members")
+		case errors.Is(res.Error, service.ErrForbidden):
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "token scope does not allow this operation")
+		default:
+			writeError(w, http.StatusInternalServerError, "ERROR", res.Error.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"rotation": res.Data})
+}
+
 func waitJob(ctx context.Context, ch <-chan service.JobResult) (service.JobResult, error) {
 	select {
 	case res := <-ch:
@@ -3,12 +3,14 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"PR-reviewer/internal/export"
 	"PR-reviewer/internal/logger"
 	"PR-reviewer/internal/mocks"
 	"PR-reviewer/internal/models"
@@ -55,76 +57,2258 @@ func TestAddTeam(t *testing.T) {
 	}
 }
 
+func TestUpdateTeam(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Переименование и удаление участника",
+			inputJSON:      `{"team_name":"alpha","new_team_name":"beta","remove":["u2"]}`,
+			result:         &service.JobResult{Data: models.Team{TeamName: "beta"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"team_name":"beta"`,
+		},
+		{
+			name:           "Имя занято",
+			inputJSON:      `{"team_name":"alpha","new_team_name":"beta"}`,
+			result:         &service.JobResult{Error: service.ErrTeamExists},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "TEAM_EXISTS",
+		},
+		{
+			name:           "Пользователь и в upsert, и в remove",
+			inputJSON:      `{"team_name":"alpha","upsert":[{"user_id":"u2","username":"Bob","is_active":true}],"remove":["u2"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "duplicates",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/team/update", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.UpdateTeam(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAddTeamMember(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Успешное добавление",
+			inputJSON:      `{"team_name":"alpha","member":{"user_id":"u4","username":"Dave","is_active":true}}`,
+			result:         &service.JobResult{Data: models.Team{TeamName: "alpha", Members: []models.TeamMember{{UserID: "u4"}}}},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"user_id":"u4"`,
+		},
+		{
+			name:           "Команда не найдена",
+			inputJSON:      `{"team_name":"ghost","member":{"user_id":"u4","username":"Dave","is_active":true}}`,
+			result:         &service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "NOT_FOUND",
+		},
+		{
+			name:           "Нет user_id",
+			inputJSON:      `{"team_name":"alpha","member":{"username":"Dave"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "user_id required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/team/addMember", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.AddTeamMember(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRemoveTeamMember(t *testing.T) {
+	t.Run("Успешное исключение", func(t *testing.T) {
+		svcMock := mocks.NewServiceMock(t)
+		svcMock.EnqueueJobMock.Set(func(job service.Job) {
+			job.RespCh <- service.JobResult{Data: models.MemberHandoff{
+				TeamName:   "alpha",
+				UserID:     "u2",
+				Reassigned: []models.ReviewHandoff{{PullRequestID: "pr1", NewReviewerID: "u3"}},
+				Unassigned: []string{},
+			}}
+		})
+
+		handler := newTestHandler(t, svcMock)
+		req := httptest.NewRequest(http.MethodPost, "/team/removeMember", strings.NewReader(`{"team_name":"alpha","user_id":"u2"}`))
+		rr := httptest.NewRecorder()
+		handler.RemoveTeamMember(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), `"new_reviewer_id":"u3"`) {
+			t.Errorf("unexpected body: %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Нет team_name", func(t *testing.T) {
+		handler := newTestHandler(t, mocks.NewServiceMock(t))
+		req := httptest.NewRequest(http.MethodPost, "/team/removeMember", strings.NewReader(`{"user_id":"u2"}`))
+		rr := httptest.NewRecorder()
+		handler.RemoveTeamMember(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+func TestMoveUserTeam(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Успешный перевод",
+			inputJSON: `{"user_id":"u2","team_name":"gamma","reassign_reviews":true}`,
+			result: &service.JobResult{Data: models.UserMove{
+				User:       models.User{UserID: "u2", TeamName: "gamma"},
+				FromTeam:   "alpha",
+				Reassigned: []models.ReviewHandoff{{PullRequestID: "pr1", NewReviewerID: "u3"}},
+				Kept:       []string{},
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"from_team":"alpha"`,
+		},
+		{
+			name:           "Команда не найдена",
+			inputJSON:      `{"user_id":"u2","team_name":"ghost"}`,
+			result:         &service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "NOT_FOUND",
+		},
+		{
+			name:           "Нет team_name",
+			inputJSON:      `{"user_id":"u2"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/users/moveTeam", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.MoveUserTeam(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetUserSkills(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Навыки сохранены",
+			inputJSON: `{"user_id":"u2","skills":["db","search"]}`,
+			result: &service.JobResult{Data: models.UserProfile{
+				User:   models.User{UserID: "u2", TeamName: "alpha"},
+				Skills: []string{"db", "search"},
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"skills":["db","search"]`,
+		},
+		{
+			name:           "Пользователь не найден",
+			inputJSON:      `{"user_id":"ghost","skills":[]}`,
+			result:         &service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "NOT_FOUND",
+		},
+		{
+			name:           "Слишком длинный навык",
+			inputJSON:      `{"user_id":"u2","skills":["` + strings.Repeat("x", 33) + `"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/users/setSkills", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.SetUserSkills(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetVacation(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Отпуск запланирован",
+			inputJSON: `{"user_id":"u2","from":"2026-07-01T00:00:00Z","until":"2026-07-15T00:00:00Z"}`,
+			result: &service.JobResult{Data: models.UserProfile{
+				User:     models.User{UserID: "u2", TeamName: "alpha"},
+				Vacation: &models.Vacation{From: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC)},
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"until":"2026-07-15T00:00:00Z"`,
+		},
+		{
+			name:           "Окончание раньше начала",
+			inputJSON:      `{"user_id":"u2","until":"2020-01-01T00:00:00Z"}`,
+			result:         &service.JobResult{Error: service.ErrInvalidVacation},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+		{
+			name:           "Начало без окончания",
+			inputJSON:      `{"user_id":"u2","from":"2026-07-01T00:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "until required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/users/setVacation", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.SetVacation(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetMentor(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Наставник назначен",
+			inputJSON:      `{"user_id":"u3","mentor_id":"u1"}`,
+			result:         &service.JobResult{Data: models.UserProfile{User: models.User{UserID: "u3", TeamName: "alpha"}, Mentor: "u1"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"mentor":"u1"`,
+		},
+		{
+			name:           "Наставник самому себе",
+			inputJSON:      `{"user_id":"u3","mentor_id":"u3"}`,
+			result:         &service.JobResult{Error: service.ErrInvalidMentor},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+		{
+			name:           "Наставник не найден",
+			inputJSON:      `{"user_id":"u3","mentor_id":"uX"}`,
+			result:         &service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "NOT_FOUND",
+		},
+		{
+			name:           "Без пользователя",
+			inputJSON:      `{"mentor_id":"u1"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/users/setMentor", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.SetMentor(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetTimezone(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Пояс сохранён",
+			inputJSON:      `{"user_id":"u2","timezone":"Asia/Tokyo"}`,
+			result:         &service.JobResult{Data: models.UserProfile{User: models.User{UserID: "u2", TeamName: "alpha"}, Timezone: "Asia/Tokyo"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"timezone":"Asia/Tokyo"`,
+		},
+		{
+			name:           "Неизвестный пояс",
+			inputJSON:      `{"user_id":"u2","timezone":"Mars/Olympus"}`,
+			result:         &service.JobResult{Error: service.ErrInvalidTimezone},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "unknown timezone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			svcMock.EnqueueJobMock.Set(func(job service.Job) {
+				job.RespCh <- *tt.result
+			})
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/users/setTimezone", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.SetTimezone(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetNotifications(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Уведомления о merge отключены",
+			inputJSON:      `{"user_id":"u2","merged":false}`,
+			result:         &service.JobResult{Data: models.UserProfile{User: models.User{UserID: "u2"}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"notifications":{"merged":false}`,
+		},
+		{
+			name:           "Не указан merged",
+			inputJSON:      `{"user_id":"u2"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "merged required",
+		},
+		{
+			name:           "Пользователь не найден",
+			inputJSON:      `{"user_id":"u9","merged":true}`,
+			result:         &service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "user not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/users/setNotifications", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.SetNotifications(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetUserWeight(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Вес сохранён",
+			inputJSON:      `{"user_id":"u2","weight":5}`,
+			result:         service.JobResult{Data: models.UserProfile{User: models.User{UserID: "u2", TeamName: "alpha"}, Weight: 5}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"weight":5`,
+		},
+		{
+			name:           "Вес вне диапазона",
+			inputJSON:      `{"user_id":"u2","weight":11}`,
+			result:         service.JobResult{Error: service.ErrInvalidWeight},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "between 1 and 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			svcMock.EnqueueJobMock.Set(func(job service.Job) {
+				job.RespCh <- tt.result
+			})
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/users/setWeight", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.SetUserWeight(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestForgetUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Данные пользователя стёрты",
+			inputJSON:      `{"user_id":"u1"}`,
+			result:         &service.JobResult{Data: models.User{UserID: "u1", Username: models.ForgottenUsername, TeamName: "backend"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"username":"forgotten user"`,
+		},
+		{
+			name:           "Без user_id",
+			inputJSON:      `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "user_id required",
+		},
+		{
+			name:           "Пользователь не найден",
+			inputJSON:      `{"user_id":"u9"}`,
+			result:         &service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "user not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/users/forget", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.ForgetUser(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestSetIsActive(t *testing.T) {
 	tests := []struct {
 		name           string
-		inputJSON      string
-		mockJobResult  service.JobResult
+		inputJSON      string
+		mockJobResult  service.JobResult
+		expectedStatus int
+		expectedBody   string
+		contextTimeout time.Duration
+	}{
+		{
+			name:      "Success",
+			inputJSON: `{"user_id": "u1", "is_active": false}`,
+			mockJobResult: service.JobResult{
+				Data:  models.User{UserID: "u1", IsActive: false},
+				Error: nil,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"user_id":"u1"`,
+		},
+		{
+			name:           "Validation error",
+			inputJSON:      `{"is_active": true}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `user_id required`,
+		},
+		{
+			name:      "Service error",
+			inputJSON: `{"user_id": "not-found", "is_active": true}`,
+			mockJobResult: service.JobResult{
+				Error: service.ErrNotFound,
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `user not found`,
+		},
+		{
+			name:           "Timeout",
+			inputJSON:      `{"user_id": "u1", "is_active": true}`,
+			mockJobResult:  service.JobResult{},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   `request canceled`,
+			contextTimeout: 5 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+
+			if tt.name != "Validation error" {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					if tt.name != "Timeout" {
+						job.RespCh <- tt.mockJobResult
+					}
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/user/active", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+
+			if tt.contextTimeout > 0 {
+				ctx, cancel := context.WithTimeout(req.Context(), tt.contextTimeout)
+				defer cancel()
+				req = req.WithContext(ctx)
+			}
+
+			handler.SetIsActive(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreatePR(t *testing.T) {
+	inputJSON := `{"pull_request_id":"pr-1","pull_request_name":"My PR","author_id":"u1"}`
+	mockResult := service.JobResult{
+		Data: models.PullRequest{
+			PullRequestID:   "pr-1",
+			PullRequestName: "My PR",
+			AuthorID:        "u1",
+			Status:          "OPEN",
+		},
+	}
+
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		job.RespCh <- mockResult
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(inputJSON))
+	rr := httptest.NewRecorder()
+	handler.CreatePR(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"pr":{"pull_request_id":"pr-1"`) {
+		t.Errorf("body does not contain PR id")
+	}
+	if !strings.Contains(rr.Body.String(), `"pull_request_name":"My PR"`) {
+		t.Errorf("body does not contain PR name")
+	}
+	if !strings.Contains(rr.Body.String(), `"author_id":"u1"`) {
+		t.Errorf("body does not contain author_id")
+	}
+}
+
+func TestCreatePR_TooManyLabels(t *testing.T) {
+	inputJSON := `{"pull_request_id":"pr-1","pull_request_name":"My PR","author_id":"u1","labels":[` + strings.Repeat(`"x",`, 10) + `"x"]}`
+
+	handler := newTestHandler(t, mocks.NewServiceMock(t))
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(inputJSON))
+	rr := httptest.NewRecorder()
+	handler.CreatePR(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreatePR_InvalidPriority(t *testing.T) {
+	inputJSON := `{"pull_request_id":"pr-1","pull_request_name":"My PR","author_id":"u1","priority":"ASAP"}`
+
+	handler := newTestHandler(t, mocks.NewServiceMock(t))
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(inputJSON))
+	rr := httptest.NewRecorder()
+	handler.CreatePR(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreatePR_IDWithHash(t *testing.T) {
+	inputJSON := `{"pull_request_id":"org/web#42","pull_request_name":"My PR","author_id":"u1"}`
+
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		job.RespCh <- service.JobResult{Data: job.Payload["pr"].(models.PullRequest)}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(inputJSON))
+	rr := httptest.NewRecorder()
+	handler.CreatePR(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"pull_request_id":"org/web#42"`) {
+		t.Errorf("expected ID kept as sent, got %s", rr.Body.String())
+	}
+}
+
+func TestCreatePR_TargetBranchAndDescription(t *testing.T) {
+	tests := []struct {
+		name       string
+		inputJSON  string
+		wantStatus int
+	}{
+		{"ветка и описание", `{"pull_request_id":"pr-1","pull_request_name":"My PR","author_id":"u1","target_branch":"main","description":"Fixes login"}`, http.StatusCreated},
+		{"ветка с пробелом", `{"pull_request_id":"pr-1","pull_request_name":"My PR","author_id":"u1","target_branch":"release 1"}`, http.StatusBadRequest},
+		{"слишком длинная ветка", `{"pull_request_id":"pr-1","pull_request_name":"My PR","author_id":"u1","target_branch":"` + strings.Repeat("b", 256) + `"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.wantStatus == http.StatusCreated {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					pr := job.Payload["pr"].(models.PullRequest)
+					if pr.TargetBranch != "main" || pr.Description != "Fixes login" {
+						t.Errorf("unexpected target_branch %q, description %q", pr.TargetBranch, pr.Description)
+					}
+					job.RespCh <- service.JobResult{Data: pr}
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.CreatePR(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreatePR_SizeFromLines(t *testing.T) {
+	inputJSON := `{"pull_request_id":"pr-1","pull_request_name":"My PR","author_id":"u1","lines_changed":400}`
+
+	var got models.PullRequest
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		got = job.Payload["pr"].(models.PullRequest)
+		job.RespCh <- service.JobResult{Data: got}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(inputJSON))
+	rr := httptest.NewRecorder()
+	handler.CreatePR(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got.Size != models.SizeL {
+		t.Errorf("expected size L for 400 changed lines, got %q", got.Size)
+	}
+}
+
+func TestMergePR(t *testing.T) {
+	inputJSON := `{"pull_request_id":"pr-1"}`
+	mockResult := service.JobResult{Data: models.PullRequest{PullRequestID: "pr-1"}}
+
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		job.RespCh <- mockResult
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", strings.NewReader(inputJSON))
+	rr := httptest.NewRecorder()
+	handler.MergePR(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"pr":{"pull_request_id":"pr-1"`) {
+		t.Errorf("body does not contain PR id")
+	}
+}
+
+func TestMergePR_NotEnoughApprovals(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Payload["override"] != false {
+			t.Errorf("unexpected override %v", job.Payload["override"])
+		}
+		job.RespCh <- service.JobResult{Error: fmt.Errorf("%w: 1 of 2", service.ErrNotEnoughApprovals)}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", strings.NewReader(`{"pull_request_id":"pr-1"}`))
+	rr := httptest.NewRecorder()
+	handler.MergePR(rr, req)
+
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "NOT_ENOUGH_APPROVALS") {
+		t.Errorf("expected 409 NOT_ENOUGH_APPROVALS, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestMergePR_WindowClosed(t *testing.T) {
+	opensAt := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		job.RespCh <- service.JobResult{Error: &service.MergeWindowError{TeamName: "backend", OpensAt: opensAt}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", strings.NewReader(`{"pull_request_id":"pr-1"}`))
+	rr := httptest.NewRecorder()
+	handler.MergePR(rr, req)
+
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "MERGE_WINDOW_CLOSED") || !strings.Contains(rr.Body.String(), "2026-10-19T09:00:00Z") {
+		t.Errorf("expected 409 MERGE_WINDOW_CLOSED with opening time, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReserveReviewers(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Ревьюверы зарезервированы",
+			inputJSON: `{"author_id":"u1","reviewers":["u2","u3"],"until":"2026-10-20T18:00:00Z"}`,
+			result: &service.JobResult{Data: models.ReviewReservation{
+				AuthorID: "u1", Reviewers: []string{"u2", "u3"}, ExpiresAt: time.Date(2026, 10, 20, 18, 0, 0, 0, time.UTC),
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"reviewers":["u2","u3"]`,
+		},
+		{
+			name:           "Ревьювер занят другим автором",
+			inputJSON:      `{"author_id":"u1","reviewers":["u2"],"until":"2026-10-20T18:00:00Z"}`,
+			result:         &service.JobResult{Error: service.ErrReviewerHeld},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "RESERVED",
+		},
+		{
+			name:           "Ревьюверы без срока",
+			inputJSON:      `{"author_id":"u1","reviewers":["u2"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "until required",
+		},
+		{
+			name:           "Повторяющийся ревьювер",
+			inputJSON:      `{"author_id":"u1","reviewers":["u2","u2"],"until":"2026-10-20T18:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "duplicates",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/reserve", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.ReserveReviewers(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetAssignmentHistory(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "get_assignment_history" || job.Payload["pr_id"] != "pr1" {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Data: []models.AssignmentEvent{{PullRequestID: "pr1", UserID: "u2", Action: models.EventAssigned, Reason: "sla", TriggeredBy: "scheduler:review_sla"}}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/pullRequest/history?pull_request_id=pr1", nil)
+	rr := httptest.NewRecorder()
+	handler.GetAssignmentHistory(rr, req)
+
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"reason":"sla","triggered_by":"scheduler:review_sla"`) {
+		t.Errorf("unexpected response %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.GetAssignmentHistory(rr, httptest.NewRequest(http.MethodGet, "/pullRequest/history", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without pull_request_id, got %d", rr.Code)
+	}
+}
+
+func TestRequestChanges(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Начат новый раунд",
+			inputJSON: `{"pull_request_id":"pr1","user_id":"u2","fresh_reviewer":true}`,
+			result: &service.JobResult{Data: models.PRResult{
+				PR:    models.PullRequest{PullRequestID: "pr1", Status: models.StatusOpen},
+				Round: &models.ReviewRound{PullRequestID: "pr1", Round: 2, RequestedBy: "u2", FreshReviewer: "u4"},
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"round":2,"requested_by":"u2","fresh_reviewer":"u4"`,
+		},
+		{
+			name:           "Не ревьювер PR",
+			inputJSON:      `{"pull_request_id":"pr1","user_id":"u9"}`,
+			result:         &service.JobResult{Error: service.ErrNotAssigned},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "NOT_ASSIGNED",
+		},
+		{
+			name:           "Слишком долгий раунд",
+			inputJSON:      `{"pull_request_id":"pr1","user_id":"u2","hours":1000}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "hours must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/requestChanges", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.RequestChanges(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSubmitReview(t *testing.T) {
+	decided := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Одобрено с комментарием",
+			inputJSON: `{"pull_request_id":"pr1","user_id":"u2","decision":"APPROVED","comment":"lgtm"}`,
+			result: &service.JobResult{Data: models.PullRequest{PullRequestID: "pr1", Status: models.StatusOpen, Assigned: []models.PRReviewer{
+				{UserID: "u2", Status: models.ReviewApproved, Comment: "lgtm", DecidedAt: &decided},
+			}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"comment":"lgtm","decided_at":"2026-10-12T09:00:00Z"`,
+		},
+		{
+			name:      "Запрошены изменения",
+			inputJSON: `{"pull_request_id":"pr1","user_id":"u2","decision":"CHANGES_REQUESTED"}`,
+			result: &service.JobResult{Data: models.PRResult{
+				PR:    models.PullRequest{PullRequestID: "pr1", Status: models.StatusOpen},
+				Round: &models.ReviewRound{PullRequestID: "pr1", Round: 2, RequestedBy: "u2"},
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"round":2`,
+		},
+		{
+			name:           "Неизвестное решение",
+			inputJSON:      `{"pull_request_id":"pr1","user_id":"u2","decision":"PENDING"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "decision must be",
+		},
+		{
+			name:           "Слишком длинный комментарий",
+			inputJSON:      `{"pull_request_id":"pr1","user_id":"u2","decision":"APPROVED","comment":"` + strings.Repeat("ы", 4001) + `"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "comment: at most",
+		},
+		{
+			name:           "Не ревьювер PR",
+			inputJSON:      `{"pull_request_id":"pr1","user_id":"u9","decision":"APPROVED"}`,
+			result:         &service.JobResult{Error: service.ErrNotAssigned},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "NOT_ASSIGNED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/review", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.SubmitReview(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRegenerateRotation(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Ротация сгенерирована",
+			inputJSON: `{"team_name":"backend","weeks":2}`,
+			result: &service.JobResult{Data: models.ReviewRotation{TeamName: "backend", Weeks: []models.RotationWeek{
+				{WeekStart: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Reviewers: []string{"u2", "u5"}},
+			}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"reviewers":["u2","u5"]`,
+		},
+		{
+			name:           "Мало участников",
+			inputJSON:      `{"team_name":"backend"}`,
+			result:         &service.JobResult{Error: service.ErrNoCandidate},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "NO_CANDIDATE",
+		},
+		{
+			name:           "Слишком много недель",
+			inputJSON:      `{"team_name":"backend","weeks":52}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "weeks must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/team/rotation", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.RegenerateRotation(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetOverdueReviews(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "list_overdue_reviews" || job.Payload["team_name"] != "alpha" {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Data: []models.OverdueReview{{PullRequestID: "pr1", TeamName: "alpha", UserID: "u2"}}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/pullRequest/overdue?team_name=alpha", nil)
+	rr := httptest.NewRecorder()
+	handler.GetOverdueReviews(rr, req)
+
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"overdue":[{"pull_request_id":"pr1"`) {
+		t.Errorf("unexpected response %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReassign(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputJSON      string
+		mockJobResult  service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Успешное переназначение",
+			inputJSON: `{"pull_request_id": "pr1", "old_user_id": "u1"}`,
+			mockJobResult: service.JobResult{
+				Data: models.PRResult{
+					PR:         models.PullRequest{PullRequestID: "pr1"},
+					ReplacedBy: "u2",
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"replaced_by":"u2"`,
+		},
+		{
+			name:      "PR смержен",
+			inputJSON: `{"pull_request_id": "pr1", "old_user_id": "u1"}`,
+			mockJobResult: service.JobResult{
+				Error: service.ErrPRMerged,
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `cannot reassign on merged PR`,
+		},
+		{
+			name:      "Нет кандидата",
+			inputJSON: `{"pull_request_id": "pr1", "old_user_id": "u1"}`,
+			mockJobResult: service.JobResult{
+				Error: service.ErrNoCandidate,
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `no active replacement candidate in team`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			svcMock.EnqueueJobMock.Set(func(job service.Job) {
+				job.RespCh <- tt.mockJobResult
+			})
+
+			handler := newTestHandler(t, svcMock)
+
+			req := httptest.NewRequest(http.MethodPost, "/pr/reassign", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+
+			handler.Reassign(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetTeam(t *testing.T) {
+	tests := []struct {
+		name           string
+		targetURL      string
+		mockJobResult  service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Успешное получение команды",
+			targetURL: "/team?team_name=alpha",
+			mockJobResult: service.JobResult{
+				Data: models.Team{TeamName: "alpha", Members: []models.TeamMember{}},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"team_name":"alpha"`,
+		},
+		{
+			name:           "Ошибка валидации",
+			targetURL:      "/team?team_name=",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `team_name required`,
+		},
+		{
+			name:      "Команда не найдена",
+			targetURL: "/team?team_name=beta",
+			mockJobResult: service.JobResult{
+				Error: service.ErrNotFound,
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `team not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.mockJobResult.Data != nil || tt.mockJobResult.Error != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- tt.mockJobResult
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodGet, tt.targetURL, nil)
+			rr := httptest.NewRecorder()
+
+			handler.GetTeam(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestPreviewDeactivation(t *testing.T) {
+	tests := []struct {
+		name           string
+		targetURL      string
+		mockJobResult  service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Успешный предпросмотр",
+			targetURL: "/team/deactivate/preview?team_name=alpha",
+			mockJobResult: service.JobResult{
+				Data: models.DeactivationPreview{TeamName: "alpha", PRs: 3, Reviews: 4, Reassignable: 2,
+					Stranded: []models.StrandedPR{{PullRequestID: "pr-1", TeamName: "alpha", Missing: 2}}},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"stranded":[{"pull_request_id":"pr-1","team_name":"alpha","missing":2}]`,
+		},
+		{
+			name:           "Ошибка валидации",
+			targetURL:      "/team/deactivate/preview",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `team_name required`,
+		},
+		{
+			name:      "Команда не найдена",
+			targetURL: "/team/deactivate/preview?team_name=beta",
+			mockJobResult: service.JobResult{
+				Error: service.ErrNotFound,
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `team not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.mockJobResult.Data != nil || tt.mockJobResult.Error != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- tt.mockJobResult
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodGet, tt.targetURL, nil)
+			rr := httptest.NewRecorder()
+
+			handler.PreviewDeactivation(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetUserReviews(t *testing.T) {
+	url := "/reviews?user_id=u1"
+	mockResult := service.JobResult{Data: []models.PullRequestShort{{PullRequestID: "pr1"}}}
+
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		job.RespCh <- mockResult
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	rr := httptest.NewRecorder()
+	handler.GetUserReviews(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+}
+
+func TestAddMembership(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *mocks.ServiceMock)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Роль по умолчанию",
+			body: `{"team_name":"beta","user_id":"u1"}`,
+			mockSetup: func(m *mocks.ServiceMock) {
+				m.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- service.JobResult{Data: job.Payload["membership"]}
+				})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"role":"member"`,
+		},
+		{
+			name:           "Неизвестная роль",
+			body:           `{"team_name":"beta","user_id":"u1","role":"lead"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "role must be",
+		},
+		{
+			name: "Домашняя команда",
+			body: `{"team_name":"alpha","user_id":"u1"}`,
+			mockSetup: func(m *mocks.ServiceMock) {
+				m.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- service.JobResult{Error: service.ErrHomeTeam}
+				})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "HOME_TEAM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.mockSetup != nil {
+				tt.mockSetup(svcMock)
+			}
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/team/addMembership", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			handler.AddMembership(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAddReviewer(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *mocks.ServiceMock)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Успешное назначение",
+			body: `{"pull_request_id":"pr1","user_id":"u2"}`,
+			mockSetup: func(m *mocks.ServiceMock) {
+				m.EnqueueJobMock.Set(func(job service.Job) {
+					if job.Type != "assign_reviewer" || job.Payload["uid"] != "u2" {
+						t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+					}
+					job.RespCh <- service.JobResult{Data: models.PullRequest{
+						PullRequestID: "pr1",
+						Assigned:      []models.PRReviewer{{UserID: "u2", IsActive: true}},
+					}}
+				})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"user_id":"u2"`,
+		},
+		{
+			name:           "Нет user_id",
+			body:           `{"pull_request_id":"pr1"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+		{
+			name: "Автор PR",
+			body: `{"pull_request_id":"pr1","user_id":"u1"}`,
+			mockSetup: func(m *mocks.ServiceMock) {
+				m.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- service.JobResult{Error: service.ErrAuthorReviewer}
+				})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "AUTHOR_REVIEWER",
+		},
+		{
+			name: "Второстепенный ревьювер",
+			body: `{"pull_request_id":"pr1","user_id":"u3","role":"secondary"}`,
+			mockSetup: func(m *mocks.ServiceMock) {
+				m.EnqueueJobMock.Set(func(job service.Job) {
+					if job.Type != "assign_secondary_reviewer" {
+						t.Errorf("unexpected job %s", job.Type)
+					}
+					job.RespCh <- service.JobResult{Error: service.ErrSecondaryTaken}
+				})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "SECONDARY_TAKEN",
+		},
+		{
+			name:           "Неизвестная роль",
+			body:           `{"pull_request_id":"pr1","user_id":"u3","role":"optional"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.mockSetup != nil {
+				tt.mockSetup(svcMock)
+			}
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/addReviewer", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			handler.AddReviewer(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestFillReviewers(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "fill_reviewers" || job.Payload["pr_id"] != "pr1" {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Error: service.ErrNoCandidate}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/fillReviewers", strings.NewReader(`{"pull_request_id":"pr1"}`))
+	rr := httptest.NewRecorder()
+	handler.FillReviewers(rr, req)
+
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "NO_CANDIDATE") {
+		t.Errorf("expected 409 NO_CANDIDATE, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRemoveReviewer(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "remove_reviewer" || job.Payload["uid"] != "u2" {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Error: service.ErrPRMerged}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/removeReviewer", strings.NewReader(`{"pull_request_id":"pr1","user_id":"u2"}`))
+	rr := httptest.NewRecorder()
+	handler.RemoveReviewer(rr, req)
+
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "PR_MERGED") {
+		t.Errorf("expected 409 PR_MERGED, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "get_user" || job.Payload["uid"] != "u1" {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Data: models.UserProfile{
+			User:        models.User{UserID: "u1", Username: "Alice", TeamName: "alpha", IsActive: true},
+			OpenReviews: 2,
+		}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/users/get?user_id=u1", nil)
+	rr := httptest.NewRecorder()
+	handler.GetUser(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"team_name":"alpha"`) || !strings.Contains(rr.Body.String(), `"open_reviews":2`) {
+		t.Errorf("unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestGetUserAuthored(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "get_authored" || job.Payload["uid"] != "u1" {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Data: []models.PullRequestShort{{PullRequestID: "pr1", AuthorID: "u1", Status: "MERGED"}}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/users/getAuthored?user_id=u1", nil)
+	rr := httptest.NewRecorder()
+	handler.GetUserAuthored(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"status":"MERGED"`) {
+		t.Errorf("unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestGetUserActivity(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		page := job.Payload["page"].(models.Page)
+		if job.Type != "get_user_activity" || page.Limit != 5 || page.Offset != 10 {
+			t.Errorf("unexpected job %s %+v", job.Type, page)
+		}
+		job.RespCh <- service.JobResult{Data: []models.UserEvent{{PullRequestID: "pr-1", Kind: models.EventApproved}}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/users/activity?user_id=u1&limit=5&offset=10", nil)
+	rr := httptest.NewRecorder()
+	handler.GetUserActivity(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"kind":"approved"`) {
+		t.Errorf("body does not contain event: %s", rr.Body.String())
+	}
+}
+
+func TestGetUserStats(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		mockJobResult  service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Успешный запрос",
+			query: "?user_id=u1&weeks=4",
+			mockJobResult: service.JobResult{Data: models.UserStats{
+				User:      models.User{UserID: "u1"},
+				Completed: []models.ReviewBucket{{Count: 3}},
+				Declines:  1,
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"declines":1`,
+		},
+		{
+			name:           "Пользователь не найден",
+			query:          "?user_id=ghost",
+			mockJobResult:  service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `NOT_FOUND`,
+		},
+		{
+			name:           "Без user_id",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `user_id required`,
+		},
+		{
+			name:           "Слишком длинный период",
+			query:          "?user_id=u1&weeks=53",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `weeks must be`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.mockJobResult.Data != nil || tt.mockJobResult.Error != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- tt.mockJobResult
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodGet, "/stats/user"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.GetUserStats(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetSecurityCoverage(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "get_security_coverage" || job.Payload["team_name"] != "alpha" || job.Payload["weeks"] != 4 {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Data: models.SecurityCoverage{TeamName: "alpha", Required: 2, Covered: 1, Coverage: 0.5, Uncovered: []string{"pr-9"}}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/stats/security?team_name=alpha&weeks=4", nil)
+	rr := httptest.NewRecorder()
+	handler.GetSecurityCoverage(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"coverage":0.5`) || !strings.Contains(rr.Body.String(), `"uncovered":["pr-9"]`) {
+		t.Errorf("unexpected body: %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/stats/security?weeks=4", nil)
+	rr = httptest.NewRecorder()
+	handler.GetSecurityCoverage(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without team_name, got %d", rr.Code)
+	}
+}
+
+func TestGetTeamStats(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "get_team_stats" || job.Payload["team_name"] != "alpha" {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Data: models.TeamStats{TeamName: "alpha", CreatedPRs: 12, MergedPRs: 9, Reassignments: 3}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/stats/team?team_name=alpha", nil)
+	rr := httptest.NewRecorder()
+	handler.GetTeamStats(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"merged_prs":9`) || !strings.Contains(rr.Body.String(), `"reassignments":3`) {
+		t.Errorf("unexpected body: %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/stats/team", nil)
+	rr = httptest.NewRecorder()
+	handler.GetTeamStats(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without team_name, got %d", rr.Code)
+	}
+}
+
+func TestGetOrgSummary(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.GetOrgSummaryMock.Set(func(ctx context.Context) (models.OrgSummary, error) {
+		return models.OrgSummary{OpenPRs: 7, BusiestTeams: []models.TeamLoad{{TeamName: "alpha", OpenPRs: 5}}}, nil
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/stats/org", nil)
+	rr := httptest.NewRecorder()
+	handler.GetOrgSummary(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"open_prs":7`) || !strings.Contains(rr.Body.String(), `"team_name":"alpha"`) {
+		t.Errorf("unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestGetAlerts(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.ActiveAlertsMock.Set(func(ctx context.Context) ([]models.Alert, error) {
+		return []models.Alert{{Rule: "queue_depth", Value: 150, Threshold: 100}}, nil
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/alerts", nil)
+	rr := httptest.NewRecorder()
+	handler.GetAlerts(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"rule":"queue_depth"`) {
+		t.Errorf("unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestCheckConsistency(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Payload["repair"] != true {
+			t.Errorf("expected repair flag, got %v", job.Payload["repair"])
+		}
+		job.RespCh <- service.JobResult{Data: models.ConsistencyReport{
+			Violations: []models.ConsistencyViolation{{Kind: models.ViolationAuthorIsReviewer, PullRequestID: "pr1", UserID: "u1"}},
+			Repaired:   true,
+		}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/admin/consistency/check", strings.NewReader(`{"repair":true}`))
+	rr := httptest.NewRecorder()
+	handler.CheckConsistency(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"kind":"author_is_reviewer"`) {
+		t.Errorf("unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	t.Run("Успешное получение статистики", func(t *testing.T) {
+		svcMock := mocks.NewServiceMock(t)
+		svcMock.GetStatsMock.Set(func(ctx context.Context) (map[string]int, error) {
+			return map[string]int{"u1": 10, "u2": 5}, nil
+		})
+
+		handler := newTestHandler(t, svcMock)
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		rr := httptest.NewRecorder()
+
+		handler.GetStats(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), `"u1":10`) {
+			t.Errorf("body does not contain expected data: %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Ошибка сервиса", func(t *testing.T) {
+		svcMock := mocks.NewServiceMock(t)
+		svcMock.GetStatsMock.Set(func(ctx context.Context) (map[string]int, error) {
+			return nil, errors.New("stats db error")
+		})
+
+		handler := newTestHandler(t, svcMock)
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		rr := httptest.NewRecorder()
+
+		handler.GetStats(rr, req)
+
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), `stats db error`) {
+			t.Errorf("body does not contain expected error: %s", rr.Body.String())
+		}
+	})
+}
+
+func TestGetStats_Query(t *testing.T) {
+	t.Run("Частичный результат", func(t *testing.T) {
+		svcMock := mocks.NewServiceMock(t)
+		svcMock.QueryStatsMock.Set(func(ctx context.Context, q models.StatsQuery, budget time.Duration) (models.StatsReport, error) {
+			if q.TeamName != "alpha" || q.From.IsZero() || budget != 50*time.Millisecond {
+				t.Errorf("unexpected query %+v budget %v", q, budget)
+			}
+			return models.StatsReport{Stats: map[string]int{"u1": 1}, Truncated: true}, nil
+		})
+
+		handler := newTestHandler(t, svcMock)
+		req := httptest.NewRequest(http.MethodGet, "/stats?team_name=alpha&from=2025-01-01T00:00:00Z&budget_ms=50", nil)
+		rr := httptest.NewRecorder()
+		handler.GetStats(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), `"truncated":true`) {
+			t.Errorf("body does not contain truncated flag: %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Некорректный период", func(t *testing.T) {
+		svcMock := mocks.NewServiceMock(t)
+		handler := newTestHandler(t, svcMock)
+		req := httptest.NewRequest(http.MethodGet, "/stats?from=yesterday", nil)
+		rr := httptest.NewRecorder()
+		handler.GetStats(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+func TestGetPR(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "PR найден",
+			query:          "?pull_request_id=pr-1",
+			result:         &service.JobResult{Data: models.PullRequest{PullRequestID: "pr-1", Status: "OPEN"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"pull_request_id":"pr-1"`,
+		},
+		{
+			name:           "PR не найден",
+			query:          "?pull_request_id=pr-2",
+			result:         &service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "NOT_FOUND",
+		},
+		{
+			name:           "Нет pull_request_id",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "pull_request_id required",
+		},
+		{
+			name:           "PR в репозитории",
+			query:          "?pull_request_id=42&repository=org/api",
+			result:         &service.JobResult{Data: models.PullRequest{PullRequestID: "42", Repository: "org/api", Status: "OPEN"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"repository":"org/api"`,
+		},
+		{
+			name:           "Недопустимый repository",
+			query:          "?pull_request_id=42&repository=org%20api",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					want, _ := tt.result.Data.(models.PullRequest)
+					if repository, _ := job.Payload["repository"].(string); repository != want.Repository {
+						t.Errorf("unexpected repository %q in job", repository)
+					}
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodGet, "/pullRequest/get"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.GetPR(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSuggestReviewers(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		if job.Type != "suggest_reviewers" || job.Payload["count"] != 3 {
+			t.Errorf("unexpected job %s %v", job.Type, job.Payload)
+		}
+		job.RespCh <- service.JobResult{Data: []models.ReviewerSuggestion{{UserID: "u2", Score: 0.9}}}
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodGet, "/assignment/suggest?author_id=u1&count=3", nil)
+	rr := httptest.NewRecorder()
+	handler.SuggestReviewers(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"score":0.9`) {
+		t.Errorf("body does not contain suggestion: %s", rr.Body.String())
+	}
+}
+
+func TestUpdateRepositorySettings(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Успешное обновление",
+			inputJSON:      `{"repository":"org/api","default_reviewers":["owner"]}`,
+			result:         &service.JobResult{Data: models.RepositorySettings{Repository: "org/api", DefaultReviewers: []string{"owner"}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"default_reviewers":["owner"]`,
+		},
+		{
+			name:           "Неизвестный ревьювер",
+			inputJSON:      `{"repository":"org/api","default_reviewers":["ghost"]}`,
+			result:         &service.JobResult{Error: service.ErrInvalidSettings},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+		{
+			name:           "Командный токен",
+			inputJSON:      `{"repository":"org/api","default_reviewers":["owner"]}`,
+			result:         &service.JobResult{Error: service.ErrForbidden},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "FORBIDDEN",
+		},
+		{
+			name:           "Нет repository",
+			inputJSON:      `{"default_reviewers":["owner"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "repository required",
+		},
+		{
+			name:           "Недопустимый repository",
+			inputJSON:      `{"repository":"org/api 1","default_reviewers":["owner"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "without spaces",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/repository/settings", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.UpdateRepositorySettings(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateTeamSettings(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Успешное обновление",
+			inputJSON:      `{"team_name":"alpha","scoring":{"weights":{"load":1}}}`,
+			result:         &service.JobResult{Data: models.TeamSettings{TeamName: "alpha"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"team_name":"alpha"`,
+		},
+		{
+			name:           "Неизвестный оценщик",
+			inputJSON:      `{"team_name":"alpha","scoring":{"weights":{"karma":1}}}`,
+			result:         &service.JobResult{Error: service.ErrInvalidSettings},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+		{
+			name:           "Нет team_name",
+			inputJSON:      `{"scoring":{"weights":{"load":1}}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "team_name required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/team/settings", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.UpdateTeamSettings(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetArchivedPR(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Архивный PR",
+			query:          "?pull_request_id=pr-1",
+			result:         &service.JobResult{Data: models.ArchivedPR{PR: models.PullRequest{PullRequestID: "pr-1", Status: models.StatusMerged}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"archived_at"`,
+		},
+		{
+			name:           "Нет в архиве",
+			query:          "?pull_request_id=pr-2",
+			result:         &service.JobResult{Error: service.ErrNotFound},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "NOT_FOUND",
+		},
+		{
+			name:           "Без pull_request_id",
+			query:          "",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					if job.Type != "get_archived_pr" {
+						t.Errorf("unexpected job %q", job.Type)
+					}
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodGet, "/pullRequest/archive/get"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.GetArchivedPR(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain %q, got %s", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestListPRs(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
 		expectedStatus int
 		expectedBody   string
-		contextTimeout time.Duration
 	}{
 		{
-			name:      "Success",
-			inputJSON: `{"user_id": "u1", "is_active": false}`,
-			mockJobResult: service.JobResult{
-				Data:  models.User{UserID: "u1", IsActive: false},
-				Error: nil,
-			},
+			name:           "Фильтры и пагинация",
+			query:          "?status=OPEN&team_name=alpha&label=db&repository=org/app&limit=10&offset=20",
 			expectedStatus: http.StatusOK,
-			expectedBody:   `"user_id":"u1"`,
+			expectedBody:   `"offset":20`,
 		},
 		{
-			name:           "Validation error",
-			inputJSON:      `{"is_active": true}`,
+			name:           "Неизвестный статус",
+			query:          "?status=DRAFT",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `user_id required`,
+			expectedBody:   "INVALID",
 		},
 		{
-			name:      "Service error",
-			inputJSON: `{"user_id": "not-found", "is_active": true}`,
-			mockJobResult: service.JobResult{
-				Error: service.ErrNotFound,
-			},
-			expectedStatus: http.StatusNotFound,
-			expectedBody:   `user not found`,
+			name:           "Некорректный limit",
+			query:          "?limit=-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
 		},
 		{
-			name:           "Timeout",
-			inputJSON:      `{"user_id": "u1", "is_active": true}`,
-			mockJobResult:  service.JobResult{},
-			expectedStatus: http.StatusGatewayTimeout,
-			expectedBody:   `request canceled`,
-			contextTimeout: 5 * time.Millisecond,
+			name:           "Слишком длинная метка",
+			query:          "?label=" + strings.Repeat("a", 33),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+		{
+			name:           "Репозиторий с пробелом",
+			query:          "?repository=org%20app",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svcMock := mocks.NewServiceMock(t)
-
-			if tt.name != "Validation error" {
+			if tt.expectedStatus == http.StatusOK {
 				svcMock.EnqueueJobMock.Set(func(job service.Job) {
-					if tt.name != "Timeout" {
-						job.RespCh <- tt.mockJobResult
+					filter := job.Payload["filter"].(models.PRFilter)
+					page := job.Payload["page"].(models.Page)
+					if filter.Status != "OPEN" || filter.TeamName != "alpha" || filter.Label != "db" || filter.Repository != "org/app" || page.Limit != 10 {
+						t.Errorf("unexpected filter %+v page %+v", filter, page)
 					}
+					job.RespCh <- service.JobResult{Data: []models.PullRequestShort{{PullRequestID: "pr-1"}}}
 				})
 			}
 
 			handler := newTestHandler(t, svcMock)
-			req := httptest.NewRequest(http.MethodPost, "/user/active", strings.NewReader(tt.inputJSON))
+			req := httptest.NewRequest(http.MethodGet, "/pullRequest/list"+tt.query, nil)
 			rr := httptest.NewRecorder()
+			handler.ListPRs(rr, req)
 
-			if tt.contextTimeout > 0 {
-				ctx, cancel := context.WithTimeout(req.Context(), tt.contextTimeout)
-				defer cancel()
-				req = req.WithContext(ctx)
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
 			}
+		})
+	}
+}
 
-			handler.SetIsActive(rr, req)
+func TestSearchPRs(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Поиск с пагинацией",
+			query:          "?q=%20Login%20&label=db&repository=org/app&limit=5",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"limit":5`,
+		},
+		{
+			name:           "Пустой запрос",
+			query:          "?q=%20",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+		{
+			name:           "Слишком длинный запрос",
+			query:          "?q=" + strings.Repeat("a", 101),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "INVALID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.expectedStatus == http.StatusOK {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					if q := job.Payload["q"].(string); q != "Login" {
+						t.Errorf("expected trimmed query, got %q", q)
+					}
+					if f := job.Payload["filter"].(models.PRFilter); f.Label != "db" || f.Repository != "org/app" {
+						t.Errorf("expected label db in org/app, got %+v", f)
+					}
+					job.RespCh <- service.JobResult{Data: []models.PullRequestShort{{PullRequestID: "pr-1"}}}
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodGet, "/pullRequest/search"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.SearchPRs(rr, req)
 
 			if rr.Code != tt.expectedStatus {
-				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
 			}
 			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
 				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
@@ -133,64 +2317,346 @@ func TestSetIsActive(t *testing.T) {
 	}
 }
 
-func TestCreatePR(t *testing.T) {
-	inputJSON := `{"pull_request_id":"pr-1","pull_request_name":"My PR","author_id":"u1"}`
-	mockResult := service.JobResult{
-		Data: models.PullRequest{
-			PullRequestID:   "pr-1",
-			PullRequestName: "My PR",
-			AuthorID:        "u1",
-			Status:          "OPEN",
+func TestDeactivateTeam(t *testing.T) {
+	inputJSON := `{"team_name":"alpha"}`
+	mockResult := service.JobResult{Data: nil}
+
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.EnqueueJobMock.Set(func(job service.Job) {
+		job.RespCh <- mockResult
+	})
+
+	handler := newTestHandler(t, svcMock)
+	req := httptest.NewRequest(http.MethodPost, "/team/deactivate", strings.NewReader(inputJSON))
+	rr := httptest.NewRecorder()
+	handler.DeactivateTeam(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"status":"success"`) {
+		t.Errorf("body does not contain status")
+	}
+}
+
+func TestDeleteTeam(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputJSON      string
+		result         service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Есть открытые ревью",
+			inputJSON:      `{"team_name":"alpha"}`,
+			result:         service.JobResult{Data: []string{"pr-1", "pr-2"}, Error: service.ErrTeamInUse},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "review 2 open PRs",
 		},
+		{
+			name:           "Принудительное удаление",
+			inputJSON:      `{"team_name":"alpha","force":true}`,
+			result:         service.JobResult{Data: []string{"pr-1"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"affected_prs":["pr-1"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			svcMock.EnqueueJobMock.Set(func(job service.Job) {
+				job.RespCh <- tt.result
+			})
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/team/delete", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+			handler.DeleteTeam(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
 	}
+}
 
+func TestIssueTeamToken(t *testing.T) {
 	svcMock := mocks.NewServiceMock(t)
 	svcMock.EnqueueJobMock.Set(func(job service.Job) {
-		job.RespCh <- mockResult
+		if job.Payload["team_name"] != "alpha" {
+			t.Errorf("expected team_name alpha, got %v", job.Payload["team_name"])
+		}
+		job.RespCh <- service.JobResult{Data: map[string]string{"team_name": "alpha", "token": "secret"}}
 	})
 
 	handler := newTestHandler(t, svcMock)
-	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(inputJSON))
+	req := httptest.NewRequest(http.MethodPost, "/team/token", strings.NewReader(`{"team_name":"alpha"}`))
 	rr := httptest.NewRecorder()
-	handler.CreatePR(rr, req)
+	handler.IssueTeamToken(rr, req)
 
 	if rr.Code != http.StatusCreated {
 		t.Errorf("expected 201, got %d", rr.Code)
 	}
-	if !strings.Contains(rr.Body.String(), `"pr":{"pull_request_id":"pr-1"`) {
-		t.Errorf("body does not contain PR id")
+	if !strings.Contains(rr.Body.String(), `"token":"secret"`) {
+		t.Errorf("body does not contain token: %s", rr.Body.String())
+	}
+}
+
+func TestMyStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		result         *service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Своя статистика",
+			query:          "?weeks=4",
+			result:         &service.JobResult{Data: models.UserStats{User: models.User{UserID: "u2"}, OpenReviews: 3}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"open_reviews":3`,
+		},
+		{
+			name:           "Без пользовательского токена",
+			result:         &service.JobResult{Error: service.ErrUnauthorized},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "user token required",
+		},
+		{
+			name:           "Неверное число недель",
+			query:          "?weeks=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "weeks must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.result != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- *tt.result
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodGet, "/me/stats"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.MyStats(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestTeamScope(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.ResolveTokenScopeMock.Set(func(ctx context.Context, token string) (service.Scope, error) {
+		if token == "good" {
+			return service.Scope{TeamName: "alpha"}, nil
+		}
+		return service.Scope{}, service.ErrUnauthorized
+	})
+
+	handler := newTestHandler(t, svcMock)
+
+	var gotScope service.Scope
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotScope, _ = service.ScopeFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	mw := handler.TeamScope(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Authorization", "Bearer good")
+	rr := httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || gotScope.TeamName != "alpha" {
+		t.Errorf("expected alpha scope, got %d %v", rr.Code, gotScope)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Authorization", "Bearer bad")
+	rr = httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestTeamScopeLockout(t *testing.T) {
+	svcMock := mocks.NewServiceMock(t)
+	svcMock.ResolveTokenScopeMock.Set(func(ctx context.Context, token string) (service.Scope, error) {
+		return service.Scope{}, service.ErrUnauthorized
+	})
+
+	handler := newTestHandler(t, svcMock)
+	mw := handler.TeamScope(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < maxAuthFailures; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		req.Header.Set("Authorization", "Bearer bad")
+		rr := httptest.NewRecorder()
+		mw.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, rr.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Authorization", "Bearer bad")
+	rr := httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after lockout, got %d", rr.Code)
+	}
+
+	handler.guard.now = func() time.Time { return time.Now().Add(authLockout + time.Second) }
+	rr = httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected lockout to expire, got %d", rr.Code)
+	}
+}
+
+func TestAuthGuard_SweepsExpiredClients(t *testing.T) {
+	g := newAuthGuard()
+	start := time.Now()
+	g.now = func() time.Time { return start }
+	for i := 0; i < 100; i++ {
+		g.fail(fmt.Sprintf("10.0.0.%d", i))
+	}
+
+	g.now = func() time.Time { return start.Add(authFailureWindow + time.Second) }
+	g.fail("10.0.1.1")
+	if len(g.clients) != 1 {
+		t.Fatalf("expected expired clients swept, %d left", len(g.clients))
+	}
+}
+
+func TestAuthGuard_TrustedProxies(t *testing.T) {
+	handler := newTestHandler(t, mocks.NewServiceMock(t))
+	if err := handler.TrustProxies([]string{"10.0.0.0/8", "192.168.1.1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := handler.TrustProxies([]string{"proxy"}); err == nil {
+		t.Fatalf("expected an invalid proxy rejected")
+	}
+
+	tests := []struct {
+		remote, forwarded, want string
+	}{
+		{"10.1.2.3:5000", "203.0.113.7", "203.0.113.7"},
+		{"10.1.2.3:5000", "198.51.100.1, 203.0.113.7, 192.168.1.1", "203.0.113.7"},
+		{"10.1.2.3:5000", "", "10.1.2.3"},
+		{"203.0.113.9:5000", "198.51.100.1", "203.0.113.9"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		req.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if got := handler.guard.clientKey(req); got != tt.want {
+			t.Errorf("%s via %q: expected %s, got %s", tt.remote, tt.forwarded, tt.want, got)
+		}
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mw := SecurityHeaders(SecurityConfig{HSTSMaxAge: time.Hour})(next)
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rr := httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+
+	if rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("expected nosniff header")
+	}
+	if rr.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("expected frame options header")
+	}
+	if rr.Header().Get("Strict-Transport-Security") != "max-age=3600; includeSubDomains" {
+		t.Errorf("unexpected HSTS header: %q", rr.Header().Get("Strict-Transport-Security"))
 	}
-	if !strings.Contains(rr.Body.String(), `"pull_request_name":"My PR"`) {
-		t.Errorf("body does not contain PR name")
+
+	mw = SecurityHeaders(SecurityConfig{RedirectHTTPS: true})(next)
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/team/get?team_name=a", nil)
+	rr = httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusPermanentRedirect {
+		t.Fatalf("expected 308, got %d", rr.Code)
 	}
-	if !strings.Contains(rr.Body.String(), `"author_id":"u1"`) {
-		t.Errorf("body does not contain author_id")
+	if loc := rr.Header().Get("Location"); loc != "https://example.com/team/get?team_name=a" {
+		t.Errorf("unexpected redirect location %q", loc)
+	}
+
+	for _, path := range []string{"/metrics", "/readyz"} {
+		rr = httptest.NewRecorder()
+		mw.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "http://10.0.0.5:8080"+path, nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected %s served over plain HTTP, got %d", path, rr.Code)
+		}
 	}
 }
 
-func TestMergePR(t *testing.T) {
-	inputJSON := `{"pull_request_id":"pr-1"}`
-	mockResult := service.JobResult{Data: models.PullRequest{PullRequestID: "pr-1"}}
+func TestRequestValidator(t *testing.T) {
+	v, err := NewRequestValidator("../../openapi.yml", &dummyLogger{})
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
 
-	svcMock := mocks.NewServiceMock(t)
-	svcMock.EnqueueJobMock.Set(func(job service.Job) {
-		job.RespCh <- mockResult
-	})
+	called := false
+	mw := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
 
-	handler := newTestHandler(t, svcMock)
-	req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", strings.NewReader(inputJSON))
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(`{"pull_request_id":"pr-1","author_id":"u1"}`))
+	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
-	handler.MergePR(rr, req)
+	mw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest || called {
+		t.Fatalf("expected 400 for body missing required field, got %d", rr.Code)
+	}
 
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+	req = httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(`{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !called {
+		t.Fatalf("expected valid body to pass, got %d: %s", rr.Code, rr.Body.String())
 	}
-	if !strings.Contains(rr.Body.String(), `"pr":{"pull_request_id":"pr-1"`) {
-		t.Errorf("body does not contain PR id")
+
+	called = false
+	req = httptest.NewRequest(http.MethodPost, "/team/token", strings.NewReader(`{"team":"backend"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest || called {
+		t.Fatalf("expected 400 for team token body without team_name, got %d", rr.Code)
 	}
 }
 
-func TestReassign(t *testing.T) {
+func TestApprovePR(t *testing.T) {
 	testCases := []struct {
 		name           string
 		inputJSON      string
@@ -199,50 +2665,48 @@ func TestReassign(t *testing.T) {
 		expectedBody   string
 	}{
 		{
-			name:      "Успешное переназначение",
-			inputJSON: `{"pull_request_id": "pr1", "old_user_id": "u1"}`,
+			name:      "Успешное одобрение",
+			inputJSON: `{"pull_request_id": "pr1", "user_id": "u2"}`,
 			mockJobResult: service.JobResult{
-				Data: map[string]interface{}{
-					"pr":       models.PullRequest{PullRequestID: "pr1"},
-					"new_user": "u2",
+				Data: models.PullRequest{
+					PullRequestID: "pr1",
+					Approvals:     []models.PRApproval{{UserID: "u2"}},
 				},
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `"new_user":"u2"`,
+			expectedBody:   `"approvals":[{"user_id":"u2"`,
 		},
 		{
-			name:      "PR смержен",
-			inputJSON: `{"pull_request_id": "pr1", "old_user_id": "u1"}`,
+			name:      "Не назначен",
+			inputJSON: `{"pull_request_id": "pr1", "user_id": "u9"}`,
 			mockJobResult: service.JobResult{
-				Error: service.ErrPRMerged,
+				Error: service.ErrNotAssigned,
 			},
 			expectedStatus: http.StatusConflict,
-			expectedBody:   `cannot reassign on merged PR`,
+			expectedBody:   `NOT_ASSIGNED`,
 		},
 		{
-			name:      "Нет кандидата",
-			inputJSON: `{"pull_request_id": "pr1", "old_user_id": "u1"}`,
-			mockJobResult: service.JobResult{
-				Error: service.ErrNoCandidate,
-			},
-			expectedStatus: http.StatusConflict,
-			expectedBody:   `no active replacement candidate in team`,
+			name:           "Ошибка валидации",
+			inputJSON:      `{"pull_request_id": "pr1"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `missing fields`,
 		},
 	}
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
 			svcMock := mocks.NewServiceMock(t)
-			svcMock.EnqueueJobMock.Set(func(job service.Job) {
-				job.RespCh <- tt.mockJobResult
-			})
+			if tt.mockJobResult.Data != nil || tt.mockJobResult.Error != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- tt.mockJobResult
+				})
+			}
 
 			handler := newTestHandler(t, svcMock)
-
-			req := httptest.NewRequest(http.MethodPost, "/pr/reassign", strings.NewReader(tt.inputJSON))
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/approve", strings.NewReader(tt.inputJSON))
 			rr := httptest.NewRecorder()
 
-			handler.Reassign(rr, req)
+			handler.ApprovePR(rr, req)
 
 			if rr.Code != tt.expectedStatus {
 				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
@@ -254,41 +2718,113 @@ func TestReassign(t *testing.T) {
 	}
 }
 
-func TestGetTeam(t *testing.T) {
-	tests := []struct {
+func TestAcknowledgeReview(t *testing.T) {
+	testCases := []struct {
 		name           string
-		targetURL      string
+		inputJSON      string
 		mockJobResult  service.JobResult
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
-			name:      "Успешное получение команды",
-			targetURL: "/team?team_name=alpha",
+			name:      "Успешное подтверждение",
+			inputJSON: `{"pull_request_id": "pr1", "user_id": "u2"}`,
 			mockJobResult: service.JobResult{
-				Data: models.Team{TeamName: "alpha", Members: []models.TeamMember{}},
+				Data: models.PullRequest{PullRequestID: "pr1"},
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `"team_name":"alpha"`,
+			expectedBody:   `"pull_request_id":"pr1"`,
+		},
+		{
+			name:           "Не назначен",
+			inputJSON:      `{"pull_request_id": "pr1", "user_id": "u9"}`,
+			mockJobResult:  service.JobResult{Error: service.ErrNotAssigned},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `NOT_ASSIGNED`,
+		},
+		{
+			name:           "PR закрыт",
+			inputJSON:      `{"pull_request_id": "pr1", "user_id": "u2"}`,
+			mockJobResult:  service.JobResult{Error: service.ErrPRClosed},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `PR_CLOSED`,
 		},
 		{
 			name:           "Ошибка валидации",
-			targetURL:      "/team?team_name=",
+			inputJSON:      `{"user_id": "u2"}`,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `team_name required`,
+			expectedBody:   `missing fields`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.mockJobResult.Data != nil || tt.mockJobResult.Error != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					if job.Type != "ack_review" {
+						t.Errorf("unexpected job type %q", job.Type)
+					}
+					job.RespCh <- tt.mockJobResult
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/ack", strings.NewReader(tt.inputJSON))
+			rr := httptest.NewRecorder()
+
+			handler.AcknowledgeReview(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestReassignAll(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputJSON      string
+		mockJobResult  service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Успешное переназначение",
+			inputJSON: `{"from_user_id": "u2", "to_user_id": "u5"}`,
+			mockJobResult: service.JobResult{Data: models.BulkReassignReport{
+				FromUserID: "u2", ToUserID: "u5",
+				Results: []models.BulkReassignResult{{PullRequestID: "pr1", NewReviewerID: "u5"}},
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"new_reviewer_id":"u5"`,
 		},
 		{
-			name:      "Команда не найдена",
-			targetURL: "/team?team_name=beta",
-			mockJobResult: service.JobResult{
-				Error: service.ErrNotFound,
-			},
+			name:           "Пользователь не найден",
+			inputJSON:      `{"from_user_id": "u9"}`,
+			mockJobResult:  service.JobResult{Error: service.ErrNotFound},
 			expectedStatus: http.StatusNotFound,
-			expectedBody:   `team not found`,
+			expectedBody:   `NOT_FOUND`,
+		},
+		{
+			name:           "Без from_user_id",
+			inputJSON:      `{"to_user_id": "u5"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `from_user_id required`,
+		},
+		{
+			name:           "Тот же пользователь",
+			inputJSON:      `{"from_user_id": "u2", "to_user_id": "u2"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `INVALID`,
 		},
 	}
 
-	for _, tt := range tests {
+	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
 			svcMock := mocks.NewServiceMock(t)
 			if tt.mockJobResult.Data != nil || tt.mockJobResult.Error != nil {
@@ -298,10 +2834,10 @@ func TestGetTeam(t *testing.T) {
 			}
 
 			handler := newTestHandler(t, svcMock)
-			req := httptest.NewRequest(http.MethodGet, tt.targetURL, nil)
+			req := httptest.NewRequest(http.MethodPost, "/admin/reassignAll", strings.NewReader(tt.inputJSON))
 			rr := httptest.NewRecorder()
 
-			handler.GetTeam(rr, req)
+			handler.ReassignAll(rr, req)
 
 			if rr.Code != tt.expectedStatus {
 				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
@@ -313,85 +2849,178 @@ func TestGetTeam(t *testing.T) {
 	}
 }
 
-func TestGetUserReviews(t *testing.T) {
-	url := "/reviews?user_id=u1"
-	mockResult := service.JobResult{Data: []models.PullRequestShort{{PullRequestID: "pr1"}}}
-
+func TestClosePR(t *testing.T) {
 	svcMock := mocks.NewServiceMock(t)
 	svcMock.EnqueueJobMock.Set(func(job service.Job) {
-		job.RespCh <- mockResult
+		if job.Type != "close_pr" {
+			t.Errorf("expected close_pr job, got %s", job.Type)
+		}
+		job.RespCh <- service.JobResult{Data: models.PullRequest{PullRequestID: "pr-1", Status: "CLOSED"}}
 	})
 
 	handler := newTestHandler(t, svcMock)
-	req := httptest.NewRequest(http.MethodGet, url, nil)
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/close", strings.NewReader(`{"pull_request_id":"pr-1"}`))
 	rr := httptest.NewRecorder()
-	handler.GetUserReviews(rr, req)
+	handler.ClosePR(rr, req)
 
 	if rr.Code != http.StatusOK {
 		t.Errorf("expected 200, got %d", rr.Code)
 	}
+	if !strings.Contains(rr.Body.String(), `"status":"CLOSED"`) {
+		t.Errorf("body does not contain CLOSED status: %s", rr.Body.String())
+	}
 }
 
-func TestGetStats(t *testing.T) {
-	t.Run("Успешное получение статистики", func(t *testing.T) {
-		svcMock := mocks.NewServiceMock(t)
-		svcMock.GetStatsMock.Set(func(ctx context.Context) (map[string]int, error) {
-			return map[string]int{"u1": 10, "u2": 5}, nil
-		})
-
-		handler := newTestHandler(t, svcMock)
-		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
-		rr := httptest.NewRecorder()
+func TestReopenPR(t *testing.T) {
+	tests := []struct {
+		name           string
+		result         service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "reopened",
+			result:         service.JobResult{Data: models.PullRequest{PullRequestID: "pr-1", Status: "OPEN"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"status":"OPEN"`,
+		},
+		{
+			name:           "merged",
+			result:         service.JobResult{Error: service.ErrPRMerged},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "PR_MERGED",
+		},
+	}
 
-		handler.GetStats(rr, req)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			svcMock.EnqueueJobMock.Set(func(job service.Job) {
+				if job.Type != "reopen_pr" {
+					t.Errorf("expected reopen_pr job, got %s", job.Type)
+				}
+				job.RespCh <- tt.result
+			})
 
-		if rr.Code != http.StatusOK {
-			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), `"u1":10`) {
-			t.Errorf("body does not contain expected data: %s", rr.Body.String())
-		}
-	})
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodPost, "/pullRequest/reopen", strings.NewReader(`{"pull_request_id":"pr-1"}`))
+			rr := httptest.NewRecorder()
+			handler.ReopenPR(rr, req)
 
-	t.Run("Ошибка сервиса", func(t *testing.T) {
-		svcMock := mocks.NewServiceMock(t)
-		svcMock.GetStatsMock.Set(func(ctx context.Context) (map[string]int, error) {
-			return nil, errors.New("stats db error")
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
 		})
+	}
+}
 
-		handler := newTestHandler(t, svcMock)
-		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
-		rr := httptest.NewRecorder()
+func TestReadOnly(t *testing.T) {
+	h := ReadOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
 
-		handler.GetStats(rr, req)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/team/get", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected GET to pass, got %d", rr.Code)
+	}
 
-		if rr.Code != http.StatusInternalServerError {
-			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), `stats db error`) {
-			t.Errorf("body does not contain expected error: %s", rr.Body.String())
-		}
-	})
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/team/add", nil))
+	if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), "READ_ONLY") {
+		t.Errorf("expected POST to be rejected, got %d %s", rr.Code, rr.Body.String())
+	}
 }
 
-func TestDeactivateTeam(t *testing.T) {
-	inputJSON := `{"team_name":"alpha"}`
-	mockResult := service.JobResult{Data: nil}
+func TestCallerIdentity(t *testing.T) {
+	var caller string
+	h := CallerIdentity(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ = service.CallerFromContext(r.Context())
+	}))
 
-	svcMock := mocks.NewServiceMock(t)
-	svcMock.EnqueueJobMock.Set(func(job service.Job) {
-		job.RespCh <- mockResult
-	})
+	req := httptest.NewRequest(http.MethodGet, "/pullRequest/get", nil)
+	req.Header.Set("X-User-ID", "u1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
 
-	handler := newTestHandler(t, svcMock)
-	req := httptest.NewRequest(http.MethodPost, "/team/deactivate", strings.NewReader(inputJSON))
+	if caller != "u1" {
+		t.Errorf("expected caller u1, got %q", caller)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var got string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = service.RequestIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/pullRequest/get", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
 	rr := httptest.NewRecorder()
-	handler.DeactivateTeam(rr, req)
+	h.ServeHTTP(rr, req)
+	if got != "abc-123" || rr.Header().Get("X-Request-ID") != "abc-123" {
+		t.Errorf("expected caller request ID to be kept, got ctx %q header %q", got, rr.Header().Get("X-Request-ID"))
+	}
 
-	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/pullRequest/get", nil))
+	if got == "" || rr.Header().Get("X-Request-ID") != got {
+		t.Errorf("expected generated request ID, got ctx %q header %q", got, rr.Header().Get("X-Request-ID"))
 	}
-	if !strings.Contains(rr.Body.String(), `"status":"success"`) {
-		t.Errorf("body does not contain status")
+}
+
+func TestExportAssignments(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		mockJobResult  service.JobResult
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Успешная выгрузка",
+			query:          "?from=2025-11-01T00:00:00Z&to=2025-11-02T00:00:00Z",
+			mockJobResult:  service.JobResult{Data: []export.Record{{PR: "a1", Reviewer: "b2", Status: "OPEN"}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"reviewer":"b2"`,
+		},
+		{
+			name:           "Выгрузка выключена",
+			mockJobResult:  service.JobResult{Error: service.ErrExportDisabled},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `NOT_FOUND`,
+		},
+		{
+			name:           "Некорректный период",
+			query:          "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `INVALID`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			svcMock := mocks.NewServiceMock(t)
+			if tt.mockJobResult.Data != nil || tt.mockJobResult.Error != nil {
+				svcMock.EnqueueJobMock.Set(func(job service.Job) {
+					job.RespCh <- tt.mockJobResult
+				})
+			}
+
+			handler := newTestHandler(t, svcMock)
+			req := httptest.NewRequest(http.MethodGet, "/admin/export/assignments"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.ExportAssignments(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d. body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain '%s', got '%s'", tt.expectedBody, rr.Body.String())
+			}
+		})
 	}
 }
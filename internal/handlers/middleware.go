@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/service"
+)
+
+const (
+	maxAuthFailures   = 5
+	authFailureWindow = 5 * time.Minute
+	authLockout       = 15 * time.Minute
+)
+
+var (
+	authFailuresTotal = metrics.NewCounter("auth_failures_total", "Failed token validations by reason.", "reason")
+	authLockoutsTotal = metrics.NewCounter("auth_lockouts_total", "Clients temporarily locked out after repeated auth failures.")
+	authRejectedTotal = metrics.NewCounter("auth_rejected_locked_total", "Requests rejected because the client is locked out.")
+)
+
+type authAttempts struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// expired reports whether the attempts no longer count for anything at now.
+func (a *authAttempts) expired(now time.Time) bool {
+	return now.Sub(a.windowStart) > authFailureWindow && !now.Before(a.lockedUntil)
+}
+
+// authGuard tracks failed token validations per client and locks out
+// clients that exceed maxAuthFailures within authFailureWindow. Entries are
+// swept once they expire, so clients that never succeed don't pile up.
+type authGuard struct {
+	mu        sync.Mutex
+	clients   map[string]*authAttempts
+	lastSweep time.Time
+	// trusted are the proxies whose X-Forwarded-For is believed.
+	trusted []*net.IPNet
+	now     func() time.Time
+}
+
+func newAuthGuard() *authGuard {
+	return &authGuard{
+		clients: make(map[string]*authAttempts),
+		now:     time.Now,
+	}
+}
+
+func (g *authGuard) locked(client string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	a, ok := g.clients[client]
+	if ok && a.expired(now) {
+		delete(g.clients, client)
+		return false
+	}
+	return ok && now.Before(a.lockedUntil)
+}
+
+// sweep drops expired entries, at most once per authFailureWindow. The
+// caller holds g.mu.
+func (g *authGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < authFailureWindow {
+		return
+	}
+	g.lastSweep = now
+	for client, a := range g.clients {
+		if a.expired(now) {
+			delete(g.clients, client)
+		}
+	}
+}
+
+// fail records a failure and reports whether it triggered a lockout.
+func (g *authGuard) fail(client string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.sweep(now)
+	a, ok := g.clients[client]
+	if !ok || now.Sub(a.windowStart) > authFailureWindow {
+		a = &authAttempts{windowStart: now}
+		g.clients[client] = a
+	}
+	a.failures++
+	if a.failures >= maxAuthFailures {
+		a.failures = 0
+		a.windowStart = now
+		a.lockedUntil = now.Add(authLockout)
+		return true
+	}
+	return false
+}
+
+func (g *authGuard) succeed(client string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.clients, client)
+}
+
+// clientKey identifies the client by its address. Behind a trusted proxy
+// that is the last address in X-Forwarded-For not itself a trusted proxy,
+// so one bad client doesn't lock out everyone behind the same proxy.
+func (g *authGuard) clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !g.isTrusted(host) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !g.isTrusted(hop) {
+			return hop
+		}
+		host = hop
+	}
+	return host
+}
+
+func (g *authGuard) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range g.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrustProxies makes the auth lockout key clients by X-Forwarded-For when
+// the request comes from one of the proxies, given as CIDRs or single IPs.
+func (h *Handler) TrustProxies(proxies []string) error {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return fmt.Errorf("invalid proxy address %q", p)
+			}
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 128
+			}
+			p = fmt.Sprintf("%s/%d", p, bits)
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return fmt.Errorf("invalid proxy address %q", p)
+		}
+		nets = append(nets, n)
+	}
+	h.guard.trusted = nets
+	return nil
+}
+
+// TeamScope resolves a bearer team token into a service scope. Requests
+// without a token keep unrestricted access; clients that keep presenting
+// bad tokens are locked out for authLockout.
+func (h *Handler) TeamScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := h.guard.clientKey(r)
+		if h.guard.locked(client) {
+			authRejectedTotal.Inc()
+			writeError(w, http.StatusTooManyRequests, "AUTH_LOCKED", "too many failed auth attempts, try later")
+			return
+		}
+
+		token, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok {
+			h.authFailed(client, "invalid_header")
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid authorization header")
+			return
+		}
+
+		scope, err := h.svc.ResolveTokenScope(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			if errors.Is(err, service.ErrUnauthorized) {
+				h.authFailed(client, "invalid_token")
+				writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid token")
+				return
+			}
+			h.log.Error("failed to resolve token", "error", err)
+			writeError(w, http.StatusInternalServerError, "ERROR", err.Error())
+			return
+		}
+
+		h.guard.succeed(client)
+		ctx := service.WithScope(r.Context(), scope)
+		if scope.UserID != "" {
+			ctx = service.WithCaller(ctx, scope.UserID)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// CallerIdentity passes the acting user from the X-User-ID header to the
+// service so responses can be filtered for that user (e.g. blind review).
+// It is a hint from trusted frontends and bots, not an authentication step,
+// and a user-bound token's own user wins over it. Admin requests, made
+// without a token, may skip blind review with X-Blind-Review-Bypass: true.
+func CallerIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, scoped := service.ScopeFromContext(r.Context())
+		if scoped && scope.UserID != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !scoped && r.Header.Get("X-Blind-Review-Bypass") == "true" {
+			r = r.WithContext(service.WithBlindReviewBypass(r.Context()))
+		}
+		if userID := strings.TrimSpace(r.Header.Get("X-User-ID")); userID != "" {
+			r = r.WithContext(service.WithCaller(r.Context(), userID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+const maxRequestIDLen = 64
+
+// RequestID takes the caller's X-Request-ID or generates one, echoes it in
+// the response and passes it down so repo failures can be traced back to
+// the request.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+		if id == "" || len(id) > maxRequestIDLen {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(service.WithRequestID(r.Context(), id)))
+	})
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// ReadOnly rejects every request that could write. It is installed when the
+// service starts against a drifted schema in read-only mode.
+func ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+		default:
+			writeError(w, http.StatusServiceUnavailable, "READ_ONLY", "service is in read-only mode due to database schema drift")
+		}
+	})
+}
+
+func (h *Handler) authFailed(client, reason string) {
+	authFailuresTotal.Inc(reason)
+	audit := h.log.WithWorker("audit")
+	audit.Warn("auth failure", "client", client, "reason", reason)
+
+	if h.guard.fail(client) {
+		authLockoutsTotal.Inc()
+		audit.Error("auth lockout: repeated failures, possible brute force", "client", client, "lockout", authLockout)
+	}
+}
+
+type SecurityConfig struct {
+	// HSTSMaxAge is sent in Strict-Transport-Security on HTTPS responses;
+	// zero disables the header.
+	HSTSMaxAge    time.Duration
+	RedirectHTTPS bool
+}
+
+// redirectExempt lists the paths scraped over plain HTTP from inside the
+// cluster, which the HTTPS redirect would break.
+var redirectExempt = map[string]bool{
+	"/metrics": true,
+	"/readyz":  true,
+	"/healthz": true,
+}
+
+// SecurityHeaders sets standard hardening headers and, when enabled,
+// redirects plain HTTP requests to HTTPS, except for probes and metrics.
+func SecurityHeaders(cfg SecurityConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secure := isHTTPS(r)
+			if cfg.RedirectHTTPS && !secure && !redirectExempt[r.URL.Path] {
+				http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+				return
+			}
+
+			hdr := w.Header()
+			hdr.Set("X-Content-Type-Options", "nosniff")
+			hdr.Set("X-Frame-Options", "DENY")
+			hdr.Set("Referrer-Policy", "no-referrer")
+			if secure && cfg.HSTSMaxAge > 0 {
+				hdr.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))+"; includeSubDomains")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
@@ -5,8 +5,13 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/service"
 )
 
 var (
@@ -16,6 +21,55 @@ var (
 	errMissingFieldsPR      = errors.New("missing fields")
 	errInvalidBody          = errors.New("invalid body")
 	errDuplicates           = errors.New("duplicates user_id's")
+	errInvalidPeriod        = errors.New("from and to must be RFC3339 timestamps with from before to")
+	errInvalidBudget        = errors.New("budget_ms must be a positive integer")
+	errInvalidStatus        = errors.New("status must be one of OPEN, MERGED, CLOSED")
+	errInvalidPage          = errors.New("limit must be 1..500 and offset non-negative")
+	errInvalidCount         = errors.New("count must be a positive integer")
+	errInvalidRole          = errors.New("role must be one of member, observer")
+	errInvalidQuery         = errors.New("q must be 1..100 characters")
+	errMissingFromUserID    = errors.New("from_user_id required")
+	errSameUser             = errors.New("to_user_id must differ from from_user_id")
+	errInvalidWeeks         = errors.New("weeks must be 1..52")
+	errInvalidLabels        = errors.New("labels: at most 10, each 1..32 characters")
+	errInvalidSkills        = errors.New("skills: at most 10, each 1..32 characters")
+	errInvalidPaths         = errors.New("paths: at most 3000, each 1..1024 characters")
+	errMissingUntil         = errors.New("until required with from")
+	errInvalidReviewerRole  = errors.New("role must be one of required, secondary")
+	errMissingAuthorID      = errors.New("author_id required")
+	errMissingReserveUntil  = errors.New("until required with reviewers")
+	errInvalidRoundHours    = errors.New("hours must be 0..720")
+	errInvalidRotationWeeks = errors.New("weeks must be 0..26")
+	errMissingMerged        = errors.New("merged required")
+	errInvalidPriority      = errors.New("priority must be one of LOW, NORMAL, URGENT")
+	errInvalidSize          = errors.New("size must be one of XS, S, M, L, XL")
+	errInvalidLinesChanged  = errors.New("lines_changed must not be negative")
+	errInvalidRepository    = errors.New("repository: at most 200 characters, without spaces")
+	errMissingRepository    = errors.New("repository required")
+	errInvalidCoAuthors     = errors.New("co_authors: at most 250 non-empty user ids")
+	errInvalidTargetBranch  = errors.New("target_branch: at most 255 characters, without spaces")
+	errInvalidDescription   = errors.New("description: at most 65536 characters")
+	errInvalidDecision      = errors.New("decision must be one of APPROVED, CHANGES_REQUESTED")
+	errInvalidComment       = errors.New("comment: at most 4000 characters")
+)
+
+const (
+	maxSearchQueryLen = 100
+	maxLabels         = 10
+	maxLabelLen       = 32
+	// maxPaths matches the most files a GitHub PR diff lists.
+	maxPaths   = 3000
+	maxPathLen = 1024
+	// maxCoAuthors matches the most commits a GitHub PR lists.
+	maxCoAuthors = 250
+	// maxRoundHours matches the longest team review SLA.
+	maxRoundHours    = 30 * 24
+	maxRotationWeeks = 26
+	maxRepositoryLen = 200
+	// maxBranchLen and maxDescriptionLen match GitHub's limits.
+	maxBranchLen      = 255
+	maxDescriptionLen = 65536
+	maxCommentLen     = 4000
 )
 
 func decodeBody(r *http.Request, dst interface{}) error {
@@ -43,6 +97,106 @@ func validateTeam(team models.Team) error {
 	return nil
 }
 
+func validateTeamSettings(settings models.TeamSettings) error {
+	if settings.TeamName == "" {
+		return errMissingTeamName
+	}
+	return nil
+}
+
+func validateRepositorySettings(settings models.RepositorySettings) error {
+	if settings.Repository == "" {
+		return errMissingRepository
+	}
+	return validateRepository(settings.Repository)
+}
+
+func validateTeamUpdate(upd models.TeamUpdate) error {
+	if upd.TeamName == "" {
+		return errMissingTeamName
+	}
+	userIDs := make(map[string]bool)
+	for _, member := range upd.Upsert {
+		if member.UserID == "" {
+			return errMissingUserID
+		}
+		if userIDs[member.UserID] {
+			return errDuplicates
+		}
+		userIDs[member.UserID] = true
+	}
+	for _, uid := range upd.Remove {
+		if userIDs[uid] {
+			return errDuplicates
+		}
+		userIDs[uid] = true
+	}
+	return nil
+}
+
+func validateDeleteTeamPayload(payload struct {
+	TeamName string `json:"team_name"`
+	Force    bool   `json:"force"`
+}) error {
+	if payload.TeamName == "" {
+		return errMissingTeamName
+	}
+	return nil
+}
+
+func validateAddMemberPayload(payload struct {
+	TeamName string            `json:"team_name"`
+	Member   models.TeamMember `json:"member"`
+}) error {
+	if payload.TeamName == "" {
+		return errMissingTeamName
+	}
+	if payload.Member.UserID == "" {
+		return errMissingUserID
+	}
+	return nil
+}
+
+func validateRemoveMemberPayload(payload struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+}) error {
+	if payload.TeamName == "" {
+		return errMissingTeamName
+	}
+	if payload.UserID == "" {
+		return errMissingUserID
+	}
+	return nil
+}
+
+func validateMembership(m models.Membership) error {
+	if m.TeamName == "" {
+		return errMissingTeamName
+	}
+	if m.UserID == "" {
+		return errMissingUserID
+	}
+	if m.Role != "" && !m.Role.Valid() {
+		return errInvalidRole
+	}
+	return nil
+}
+
+func validateMoveTeamPayload(payload struct {
+	UserID          string `json:"user_id"`
+	TeamName        string `json:"team_name"`
+	ReassignReviews bool   `json:"reassign_reviews"`
+}) error {
+	if payload.UserID == "" {
+		return errMissingUserID
+	}
+	if payload.TeamName == "" {
+		return errMissingTeamName
+	}
+	return nil
+}
+
 func validateSetActivePayload(payload struct {
 	UserID   string `json:"user_id"`
 	IsActive bool   `json:"is_active"`
@@ -54,35 +208,273 @@ func validateSetActivePayload(payload struct {
 }
 
 func validateCreatePRPayload(payload struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	Labels          []string `json:"labels"`
+	Paths           []string `json:"paths"`
+	Priority        string   `json:"priority"`
+	Size            string   `json:"size"`
+	LinesChanged    int      `json:"lines_changed"`
+	Repository      string   `json:"repository"`
+	CoAuthors       []string `json:"co_authors"`
+	TargetBranch    string   `json:"target_branch"`
+	Description     string   `json:"description"`
 }) error {
 	if payload.PullRequestID == "" || payload.PullRequestName == "" || payload.AuthorID == "" {
 		return errMissingFieldsPR
 	}
+	if payload.Priority != "" && !models.PRPriority(payload.Priority).Valid() {
+		return errInvalidPriority
+	}
+	if payload.Size != "" && !models.PRSize(payload.Size).Valid() {
+		return errInvalidSize
+	}
+	if payload.LinesChanged < 0 {
+		return errInvalidLinesChanged
+	}
+	if err := validateRepository(payload.Repository); err != nil {
+		return err
+	}
+	if len(payload.TargetBranch) > maxBranchLen || strings.ContainsAny(payload.TargetBranch, " \t\n") {
+		return errInvalidTargetBranch
+	}
+	if utf8.RuneCountInString(payload.Description) > maxDescriptionLen {
+		return errInvalidDescription
+	}
+	if len(payload.CoAuthors) > maxCoAuthors {
+		return errInvalidCoAuthors
+	}
+	for _, id := range payload.CoAuthors {
+		if id == "" {
+			return errInvalidCoAuthors
+		}
+	}
+	if len(payload.Paths) > maxPaths {
+		return errInvalidPaths
+	}
+	for _, p := range payload.Paths {
+		if p == "" || len(p) > maxPathLen {
+			return errInvalidPaths
+		}
+	}
+	return validateLabels(payload.Labels, errInvalidLabels)
+}
+
+func validateSetLabelsPayload(payload struct {
+	PullRequestID string   `json:"pull_request_id"`
+	Repository    string   `json:"repository"`
+	Labels        []string `json:"labels"`
+}) error {
+	if payload.PullRequestID == "" {
+		return errMissingPullRequestID
+	}
+	if err := validateRepository(payload.Repository); err != nil {
+		return err
+	}
+	return validateLabels(payload.Labels, errInvalidLabels)
+}
+
+func validateSetSkillsPayload(payload struct {
+	UserID string   `json:"user_id"`
+	Skills []string `json:"skills"`
+}) error {
+	if payload.UserID == "" {
+		return errMissingUserID
+	}
+	return validateLabels(payload.Skills, errInvalidSkills)
+}
+
+func validateSetVacationPayload(payload setVacationPayload) error {
+	if payload.UserID == "" {
+		return errMissingUserID
+	}
+	if payload.From != nil && payload.Until == nil {
+		return errMissingUntil
+	}
+	return nil
+}
+
+func validateReserveReviewersPayload(payload reserveReviewersPayload) error {
+	if payload.AuthorID == "" {
+		return errMissingAuthorID
+	}
+	if len(payload.Reviewers) > 0 && payload.Until == nil {
+		return errMissingReserveUntil
+	}
+	seen := make(map[string]bool, len(payload.Reviewers))
+	for _, id := range payload.Reviewers {
+		if id == "" {
+			return errMissingUserID
+		}
+		if seen[id] {
+			return errDuplicates
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func validateSetMentorPayload(payload struct {
+	UserID   string `json:"user_id"`
+	MentorID string `json:"mentor_id"`
+}) error {
+	if payload.UserID == "" {
+		return errMissingUserID
+	}
+	return nil
+}
+
+func validateSetTimezonePayload(payload struct {
+	UserID   string `json:"user_id"`
+	Timezone string `json:"timezone"`
+}) error {
+	if payload.UserID == "" {
+		return errMissingUserID
+	}
 	return nil
 }
 
+func validateSetNotificationsPayload(payload struct {
+	UserID string `json:"user_id"`
+	Merged *bool  `json:"merged"`
+}) error {
+	if payload.UserID == "" {
+		return errMissingUserID
+	}
+	if payload.Merged == nil {
+		return errMissingMerged
+	}
+	return nil
+}
+
+func validateSetWeightPayload(payload struct {
+	UserID string `json:"user_id"`
+	Weight int    `json:"weight"`
+}) error {
+	if payload.UserID == "" {
+		return errMissingUserID
+	}
+	return nil
+}
+
+// validateLabels checks PR labels and user skills, which share one format.
+func validateLabels(labels []string, errInvalid error) error {
+	if len(labels) > maxLabels {
+		return errInvalid
+	}
+	for _, l := range labels {
+		if n := utf8.RuneCountInString(strings.TrimSpace(l)); n == 0 || n > maxLabelLen {
+			return errInvalid
+		}
+	}
+	return nil
+}
+
+// validateRepository checks a repository name.
+func validateRepository(repository string) error {
+	if len(repository) > maxRepositoryLen || strings.ContainsAny(repository, " \t\n") {
+		return errInvalidRepository
+	}
+	return nil
+}
+
+// validateLabelFilter checks the optional label query parameter of PR lists.
+func validateLabelFilter(label string) error {
+	if label == "" {
+		return nil
+	}
+	return validateLabels([]string{label}, errInvalidLabels)
+}
+
 func validateMergePRPayload(payload struct {
 	PullRequestID string `json:"pull_request_id"`
+	Repository    string `json:"repository"`
 }) error {
 	if payload.PullRequestID == "" {
 		return errMissingPullRequestID
 	}
-	return nil
+	return validateRepository(payload.Repository)
+}
+
+func validateMergePRRequest(req mergePRRequest) error {
+	if req.PullRequestID == "" {
+		return errMissingPullRequestID
+	}
+	return validateRepository(req.Repository)
 }
 
 func validateReassignPayload(payload struct {
 	PullRequestID string `json:"pull_request_id"`
+	Repository    string `json:"repository"`
 	OldUserID     string `json:"old_user_id"`
 }) error {
 	if payload.PullRequestID == "" || payload.OldUserID == "" {
 		return errMissingFieldsPR
 	}
+	return validateRepository(payload.Repository)
+}
+
+func validateApprovePayload(payload struct {
+	PullRequestID string `json:"pull_request_id"`
+	Repository    string `json:"repository"`
+	UserID        string `json:"user_id"`
+}) error {
+	if payload.PullRequestID == "" || payload.UserID == "" {
+		return errMissingFieldsPR
+	}
+	return validateRepository(payload.Repository)
+}
+
+func validateRequestChangesPayload(payload requestChangesPayload) error {
+	if payload.PullRequestID == "" || payload.UserID == "" {
+		return errMissingFieldsPR
+	}
+	if payload.Hours < 0 || payload.Hours > maxRoundHours {
+		return errInvalidRoundHours
+	}
+	return validateRepository(payload.Repository)
+}
+
+func validateSubmitReviewPayload(payload submitReviewPayload) error {
+	if payload.PullRequestID == "" || payload.UserID == "" {
+		return errMissingFieldsPR
+	}
+	switch payload.Decision {
+	case models.ReviewApproved, models.ReviewChangesRequested:
+	default:
+		return errInvalidDecision
+	}
+	if utf8.RuneCountInString(payload.Comment) > maxCommentLen {
+		return errInvalidComment
+	}
+	return validateRepository(payload.Repository)
+}
+
+func validateRegenerateRotationPayload(payload regenerateRotationPayload) error {
+	if payload.TeamName == "" {
+		return errMissingTeamName
+	}
+	if payload.Weeks < 0 || payload.Weeks > maxRotationWeeks {
+		return errInvalidRotationWeeks
+	}
 	return nil
 }
 
+func validateAddReviewerPayload(payload addReviewerRequest) error {
+	if payload.PullRequestID == "" || payload.UserID == "" {
+		return errMissingFieldsPR
+	}
+	if err := validateRepository(payload.Repository); err != nil {
+		return err
+	}
+	switch payload.Role {
+	case "", models.ReviewerRequired, models.ReviewerSecondary:
+		return nil
+	}
+	return errInvalidReviewerRole
+}
+
 func validateGetTeamRequest(req getTeamRequest) error {
 	if req.TeamName == "" {
 		return errMissingTeamName
@@ -90,9 +482,210 @@ func validateGetTeamRequest(req getTeamRequest) error {
 	return nil
 }
 
+func validateGetPRRequest(req getPRRequest) error {
+	if req.PullRequestID == "" {
+		return errMissingPullRequestID
+	}
+	return validateRepository(req.Repository)
+}
+
 func validateGetUserReviewsRequest(req getUserReviewsRequest) error {
 	if req.UserID == "" {
 		return errMissingUserID
 	}
 	return nil
 }
+
+func validateTeamNamePayload(payload struct {
+	TeamName string `json:"team_name"`
+}) error {
+	if payload.TeamName == "" {
+		return errMissingTeamName
+	}
+	return nil
+}
+
+func parseGetStatsRequest(r *http.Request) (getStatsRequest, error) {
+	var req getStatsRequest
+	q := r.URL.Query()
+	req.Query.TeamName = q.Get("team_name")
+	req.Query.Repository = q.Get("repository")
+	if err := validateRepository(req.Query.Repository); err != nil {
+		return req, err
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if req.Query.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return req, errInvalidPeriod
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if req.Query.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return req, errInvalidPeriod
+		}
+	}
+	if !req.Query.From.IsZero() && !req.Query.To.IsZero() && !req.Query.From.Before(req.Query.To) {
+		return req, errInvalidPeriod
+	}
+
+	if v := q.Get("budget_ms"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms <= 0 {
+			return req, errInvalidBudget
+		}
+		req.Budget = time.Duration(ms) * time.Millisecond
+	}
+	return req, nil
+}
+
+func parseListPRsRequest(r *http.Request) (listPRsRequest, error) {
+	q := r.URL.Query()
+	req := listPRsRequest{
+		Filter: models.PRFilter{
+			Status:     models.PRStatus(q.Get("status")),
+			TeamName:   q.Get("team_name"),
+			AuthorID:   q.Get("author_id"),
+			Label:      q.Get("label"),
+			Repository: q.Get("repository"),
+		},
+	}
+	if req.Filter.Status != "" && !req.Filter.Status.Valid() {
+		return req, errInvalidStatus
+	}
+	if err := validateLabelFilter(req.Filter.Label); err != nil {
+		return req, err
+	}
+	if err := validateRepository(req.Filter.Repository); err != nil {
+		return req, err
+	}
+
+	page, err := parsePage(r)
+	req.Page = page
+	return req, err
+}
+
+func parseExportAssignmentsRequest(r *http.Request) (exportAssignmentsRequest, error) {
+	var req exportAssignmentsRequest
+	q := r.URL.Query()
+	var err error
+	if v := q.Get("from"); v != "" {
+		if req.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return req, errInvalidPeriod
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if req.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return req, errInvalidPeriod
+		}
+	}
+	if !req.From.IsZero() && !req.To.IsZero() && !req.From.Before(req.To) {
+		return req, errInvalidPeriod
+	}
+	return req, nil
+}
+
+func parseSearchPRsRequest(r *http.Request) (searchPRsRequest, error) {
+	q := r.URL.Query()
+	req := searchPRsRequest{
+		Query: strings.TrimSpace(q.Get("q")),
+		Filter: models.PRFilter{
+			Label:      q.Get("label"),
+			Repository: q.Get("repository"),
+		},
+	}
+	if req.Query == "" || utf8.RuneCountInString(req.Query) > maxSearchQueryLen {
+		return req, errInvalidQuery
+	}
+	if err := validateLabelFilter(req.Filter.Label); err != nil {
+		return req, err
+	}
+	if err := validateRepository(req.Filter.Repository); err != nil {
+		return req, err
+	}
+	page, err := parsePage(r)
+	req.Page = page
+	return req, err
+}
+
+func parseGetUserActivityRequest(r *http.Request) (getUserActivityRequest, error) {
+	req := getUserActivityRequest{UserID: r.URL.Query().Get("user_id")}
+	if req.UserID == "" {
+		return req, errMissingUserID
+	}
+	page, err := parsePage(r)
+	req.Page = page
+	return req, err
+}
+
+func parseGetUserStatsRequest(r *http.Request) (getUserStatsRequest, error) {
+	req := getUserStatsRequest{UserID: r.URL.Query().Get("user_id")}
+	if req.UserID == "" {
+		return req, errMissingUserID
+	}
+	weeks, err := parseStatsWeeks(r)
+	req.Weeks = weeks
+	return req, err
+}
+
+// parseStatsWeeks reads the optional weeks query parameter.
+func parseStatsWeeks(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("weeks")
+	if v == "" {
+		return service.DefaultStatsWeeks, nil
+	}
+	weeks, err := strconv.Atoi(v)
+	if err != nil || weeks < 1 || weeks > service.MaxStatsWeeks {
+		return 0, errInvalidWeeks
+	}
+	return weeks, nil
+}
+
+func parseGetSecurityCoverageRequest(r *http.Request) (getSecurityCoverageRequest, error) {
+	req := getSecurityCoverageRequest{TeamName: r.URL.Query().Get("team_name"), Weeks: service.DefaultStatsWeeks}
+	if req.TeamName == "" {
+		return req, errMissingTeamName
+	}
+	if v := r.URL.Query().Get("weeks"); v != "" {
+		weeks, err := strconv.Atoi(v)
+		if err != nil || weeks < 1 || weeks > service.MaxStatsWeeks {
+			return req, errInvalidWeeks
+		}
+		req.Weeks = weeks
+	}
+	return req, nil
+}
+
+func parsePage(r *http.Request) (models.Page, error) {
+	q := r.URL.Query()
+	page := models.Page{Limit: service.DefaultPageLimit}
+
+	var err error
+	if v := q.Get("limit"); v != "" {
+		if page.Limit, err = strconv.Atoi(v); err != nil || page.Limit < 1 || page.Limit > service.MaxPageLimit {
+			return page, errInvalidPage
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if page.Offset, err = strconv.Atoi(v); err != nil || page.Offset < 0 {
+			return page, errInvalidPage
+		}
+	}
+	return page, nil
+}
+
+func parseSuggestRequest(r *http.Request) (suggestRequest, error) {
+	q := r.URL.Query()
+	req := suggestRequest{AuthorID: q.Get("author_id")}
+	if req.AuthorID == "" {
+		return req, errMissingUserID
+	}
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return req, errInvalidCount
+		}
+		req.Count = n
+	}
+	return req, nil
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type TeamMember struct {
 	UserID   string `json:"user_id"`
@@ -13,6 +16,15 @@ type Team struct {
 	Members  []TeamMember `json:"members"`
 }
 
+// TeamUpdate renames a team (when NewTeamName is set), detaches the Remove
+// users and upserts the Upsert members.
+type TeamUpdate struct {
+	TeamName    string       `json:"team_name"`
+	NewTeamName string       `json:"new_team_name,omitempty"`
+	Upsert      []TeamMember `json:"upsert"`
+	Remove      []string     `json:"remove"`
+}
+
 type User struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
@@ -20,25 +32,788 @@ type User struct {
 	IsActive bool   `json:"is_active"`
 }
 
+// ForgottenUsername replaces the name of a user whose personal data was
+// erased on request.
+const ForgottenUsername = "forgotten user"
+
+// TeamRole is a user's role in a team other than their home team.
+type TeamRole string
+
+const (
+	// RoleMember is picked as a reviewer for the team's PRs.
+	RoleMember TeamRole = "member"
+	// RoleObserver belongs to the team but is never assigned.
+	RoleObserver TeamRole = "observer"
+)
+
+func (r TeamRole) Valid() bool {
+	return r == RoleMember || r == RoleObserver
+}
+
+// Membership places a user in an extra team. The home team (User.TeamName)
+// is always a member role and is not listed as a Membership.
+type Membership struct {
+	TeamName string   `json:"team_name"`
+	UserID   string   `json:"user_id"`
+	Role     TeamRole `json:"role"`
+}
+
+// UserProfile is a user together with their open work: reviews assigned to
+// them and PRs they authored that are still open. Open reviews count PRs of
+// every team the user reviews for.
+type UserProfile struct {
+	User
+	Memberships  []Membership `json:"memberships"`
+	OpenReviews  int          `json:"open_reviews"`
+	OpenAuthored int          `json:"open_authored"`
+	Skills       []string     `json:"skills"`
+	Vacation     *Vacation    `json:"vacation,omitempty"`
+	// Mentor is paired with the user on every PR the user is picked for.
+	Mentor string `json:"mentor,omitempty"`
+	// Timezone is an IANA name such as "Europe/Berlin", used to match
+	// working hours when assigning reviewers.
+	Timezone string `json:"timezone,omitempty"`
+	// JoinedAt is when the user joined their current team; empty for users
+	// who joined before it was tracked.
+	JoinedAt *time.Time `json:"joined_at,omitempty"`
+	// Weight is the user's seniority, 1 by default, used by teams with
+	// weighted selection.
+	Weight        int               `json:"weight"`
+	Notifications NotificationPrefs `json:"notifications"`
+}
+
+// NotificationPrefs are the notifications a user wants.
+type NotificationPrefs struct {
+	// Merged notifies the user when a PR they wrote or review is merged.
+	Merged bool `json:"merged"`
+}
+
+// Vacation is a user's out-of-office period. While it lasts the user is
+// inactive and gets no new reviews.
+type Vacation struct {
+	From  time.Time `json:"from"`
+	Until time.Time `json:"until"`
+}
+
+// UserStats summarizes one reviewer's work since a week boundary.
+type UserStats struct {
+	User
+	OpenReviews int `json:"open_reviews"`
+	// Completed counts approvals per week, oldest first, empty weeks
+	// included.
+	Completed []ReviewBucket `json:"completed"`
+	// AvgTurnaroundSec is the mean time from assignment, or from the start
+	// of the review round if later, to approval over the period, 0 without
+	// approvals.
+	AvgTurnaroundSec float64 `json:"avg_turnaround_seconds"`
+	// Declines counts reviews taken away from the user over the period:
+	// unassigned or reassigned to someone else.
+	Declines int `json:"declines"`
+	// Merged counts the PRs the user merged over the period.
+	Merged int       `json:"merged"`
+	Since  time.Time `json:"since"`
+}
+
+type ReviewBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// PRStatus is the lifecycle state of a pull request. The database enforces
+// the same set with a CHECK constraint; the service decides which moves
+// between them are allowed.
+type PRStatus string
+
+const (
+	StatusOpen PRStatus = "OPEN"
+	// StatusInReview means a reviewer has started on the PR.
+	StatusInReview PRStatus = "IN_REVIEW"
+	// StatusApproved means the PR has the approvals its team requires.
+	StatusApproved PRStatus = "APPROVED"
+	StatusMerged   PRStatus = "MERGED"
+	StatusClosed   PRStatus = "CLOSED"
+)
+
+func (s PRStatus) Valid() bool {
+	switch s {
+	case StatusOpen, StatusInReview, StatusApproved, StatusMerged, StatusClosed:
+		return true
+	}
+	return false
+}
+
+// ActiveStatuses are the statuses of PRs still under way, neither merged
+// nor closed. Queries for open work filter on exactly these.
+var ActiveStatuses = []PRStatus{StatusOpen, StatusInReview, StatusApproved}
+
+// Active reports whether s is one of ActiveStatuses.
+func (s PRStatus) Active() bool {
+	return slices.Contains(ActiveStatuses, s)
+}
+
+// PRPriority is how urgently a PR needs review.
+type PRPriority string
+
+const (
+	PriorityLow    PRPriority = "LOW"
+	PriorityNormal PRPriority = "NORMAL"
+	// PriorityUrgent PRs may be assigned past reviewers' open review caps
+	// and come first in their review lists.
+	PriorityUrgent PRPriority = "URGENT"
+)
+
+func (p PRPriority) Valid() bool {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+// Rank orders priorities most urgent first.
+func (p PRPriority) Rank() int {
+	switch p {
+	case PriorityUrgent:
+		return 0
+	case PriorityLow:
+		return 2
+	}
+	return 1
+}
+
+// PRSize is a t-shirt estimate of the effort a PR takes to review.
+type PRSize string
+
+const (
+	SizeXS PRSize = "XS"
+	SizeS  PRSize = "S"
+	SizeM  PRSize = "M"
+	SizeL  PRSize = "L"
+	SizeXL PRSize = "XL"
+)
+
+// PRSizes lists the sizes smallest first.
+var PRSizes = []PRSize{SizeXS, SizeS, SizeM, SizeL, SizeXL}
+
+func (s PRSize) Valid() bool {
+	switch s {
+	case SizeXS, SizeS, SizeM, SizeL, SizeXL:
+		return true
+	}
+	return false
+}
+
+// Effort is how much the PR adds to a reviewer's load. A PR of unknown size
+// weighs as much as an M one, so teams that don't size PRs are balanced by
+// count as before.
+func (s PRSize) Effort() int {
+	switch s {
+	case SizeXS:
+		return 1
+	case SizeS:
+		return 2
+	case SizeL:
+		return 5
+	case SizeXL:
+		return 8
+	}
+	return 3
+}
+
+// SizeForLines estimates a PR's size from the number of lines it changes.
+func SizeForLines(lines int) PRSize {
+	switch {
+	case lines < 10:
+		return SizeXS
+	case lines < 50:
+		return SizeS
+	case lines < 250:
+		return SizeM
+	case lines < 1000:
+		return SizeL
+	}
+	return SizeXL
+}
+
 type PullRequest struct {
-	PullRequestID     string       `json:"pull_request_id"`
-	PullRequestName   string       `json:"pull_request_name"`
-	AuthorID          string       `json:"author_id"`
-	Status            string       `json:"status"`
+	PullRequestID string `json:"pull_request_id"`
+	// ExternalID is the ID the PR was created with, unique within its
+	// repository. PullRequestID is the service-wide key, which only differs
+	// when another repository's PR took the ID first; responses show
+	// ExternalID in its place.
+	ExternalID      string     `json:"-"`
+	PullRequestName string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	TeamName        string     `json:"team_name,omitempty"`
+	Status          PRStatus   `json:"status"`
+	Priority        PRPriority `json:"priority,omitempty"`
+	Size            PRSize     `json:"size,omitempty"`
+	// Repository is the git repository the PR belongs to, if the service
+	// covers several. Endpoints addressing the PR by ID take it alongside.
+	Repository   string `json:"repository,omitempty"`
+	TargetBranch string `json:"target_branch,omitempty"`
+	Description  string `json:"description,omitempty"`
+	// Labels route the PR to reviewers whose skills match.
+	Labels []string `json:"labels,omitempty"`
+	// Paths are the files the PR changes. They are only used to route it on
+	// creation and are not stored.
+	Paths []string `json:"paths,omitempty"`
+	// CoAuthors are the users who authored commits of the PR besides its
+	// author, as the SCM integration reports them. They are only picked as
+	// reviewers when nobody independent is left, and are not stored.
+	CoAuthors []string `json:"co_authors,omitempty"`
+	// SecurityReview is set when the team's security rule matched the PR.
+	// SecurityReviewer is the security reviewer forced onto it, if any.
+	SecurityReview    bool         `json:"security_review,omitempty"`
+	SecurityReviewer  string       `json:"-"`
 	Assigned          []PRReviewer `json:"assigned_reviewers"`
 	NeedMoreReviewers bool         `json:"need_more_reviewers"`
+	Approvals         []PRApproval `json:"approvals"`
 	CreatedAt         time.Time    `json:"createdAt,omitempty"`
 	MergedAt          *time.Time   `json:"mergedAt,omitempty"`
+	ClosedAt          *time.Time   `json:"closedAt,omitempty"`
+	MergedBy          string       `json:"merged_by,omitempty"`
 }
 
+type PRApproval struct {
+	UserID     string    `json:"user_id"`
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+// ReviewStatus is one reviewer's progress on a PR.
+type ReviewStatus string
+
+const (
+	ReviewPending          ReviewStatus = "PENDING"
+	ReviewApproved         ReviewStatus = "APPROVED"
+	ReviewChangesRequested ReviewStatus = "CHANGES_REQUESTED"
+)
+
 type PRReviewer struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID     string       `json:"user_id"`
+	Username   string       `json:"username"`
+	IsActive   bool         `json:"is_active"`
+	Status     ReviewStatus `json:"status,omitempty"`
+	AssignedAt *time.Time   `json:"assigned_at,omitempty"`
+	ApprovedAt *time.Time   `json:"approved_at,omitempty"`
+	// AcknowledgedAt is set once the reviewer confirms they have seen the
+	// current assignment.
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	// MentorOf names the reviewer's mentee on the same PR. A mentor shares
+	// the mentee's slot.
+	MentorOf string `json:"mentor_of,omitempty"`
+	// Role is ReviewerRequired or ReviewerSecondary. A secondary reviewer is
+	// optional and takes no slot.
+	Role string `json:"role"`
+	// Comment is what the reviewer wrote with their last decision, made at
+	// DecidedAt.
+	Comment   string     `json:"comment,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+}
+
+const (
+	ReviewerRequired  = "required"
+	ReviewerSecondary = "secondary"
+)
+
+// AssignmentRecord is one reviewer assignment joined with its outcome, the
+// raw input of the assignment export.
+type AssignmentRecord struct {
+	PullRequestID string
+	TeamName      string
+	AuthorID      string
+	ReviewerID    string
+	PRCreatedAt   time.Time
+	AssignedAt    time.Time
+	ApprovedAt    *time.Time
+	MergedAt      *time.Time
+	Status        PRStatus
+	Removed       bool
+}
+
+// PendingReview is an assignment on an open PR the reviewer has not
+// approved yet.
+type PendingReview struct {
+	PullRequestID   string
+	PullRequestName string
+	TeamName        string
+	UserID          string
+	AssignedAt      time.Time
+	AcknowledgedAt  *time.Time
+	// RoundStartedAt and RoundDueAt describe the PR's current review round
+	// after changes were requested; nil during the first round.
+	RoundStartedAt *time.Time
+	RoundDueAt     *time.Time
+}
+
+// PRResult is the response of every PR mutation. ReplacedBy is set only by
+// reassign, Round only by a change request.
+type PRResult struct {
+	PR         PullRequest  `json:"pr"`
+	ReplacedBy string       `json:"replaced_by,omitempty"`
+	Round      *ReviewRound `json:"round,omitempty"`
+}
+
+// ReviewRound is a round of review that began when RequestedBy asked for
+// changes. The first round, from the PR's creation, is not recorded. DueAt
+// is the round's own deadline for the pending reviewers, and FreshReviewer
+// the secondary reviewer brought in for it.
+type ReviewRound struct {
+	PullRequestID string     `json:"pull_request_id"`
+	Round         int        `json:"round"`
+	RequestedBy   string     `json:"requested_by"`
+	FreshReviewer string     `json:"fresh_reviewer,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	DueAt         *time.Time `json:"due_at,omitempty"`
+}
+
+// StatsQuery narrows reviewer statistics to one team and/or a PR creation
+// period. Zero values mean "no restriction".
+type StatsQuery struct {
+	TeamName string
+	From     time.Time
+	To       time.Time
+	// Repository counts only reviews of that repository's PRs.
+	Repository string
+}
+
+// CandidateSignals is the raw per-user input to reviewer ranking.
+type CandidateSignals struct {
+	UserID        string
+	Username      string
+	IsActive      bool
+	OpenReviews   int
+	AuthorReviews int
+	LastReviewAt  *time.Time
+}
+
+// RepositorySettings holds per-repository tuning.
+type RepositorySettings struct {
+	Repository string `json:"repository"`
+	// DefaultReviewers are assigned to every new PR of the repository,
+	// before the team's own defaults and the random picks.
+	DefaultReviewers []string `json:"default_reviewers"`
+}
+
+// TeamSettings holds per-team tuning. Nil sections fall back to defaults.
+type TeamSettings struct {
+	TeamName string         `json:"team_name"`
+	Scoring  *ScoringConfig `json:"scoring,omitempty"`
+	// BlindReview hides reviewers from the author, and the author from
+	// everyone else, until the PR is merged.
+	BlindReview bool `json:"blind_review"`
+	// DefaultReviewers are assigned to every new PR of the team before the
+	// random picks, which fill the remaining slots. They need not be members.
+	DefaultReviewers []string `json:"default_reviewers,omitempty"`
+	// FallbackTeams are drawn on, as one pool, when the team itself cannot
+	// fill a new PR's reviewer slots. Used only if cross-team fallback is on.
+	FallbackTeams []string `json:"fallback_teams,omitempty"`
+	// Escalation is walked hop by hop when one of the team's PRs breaches
+	// the SLA or loses a reviewer nobody could replace.
+	Escalation []EscalationHop `json:"escalation,omitempty"`
+	// SecurityReview forces a security reviewer onto matching PRs.
+	SecurityReview *SecurityReviewRule `json:"security_review,omitempty"`
+	// WeightedSelection skews random reviewer picks by user weight towards
+	// FavorSeniors or FavorJuniors; empty keeps them even.
+	WeightedSelection string `json:"weighted_selection,omitempty"`
+	// RequiredApprovals is how many assigned reviewers must approve a PR of
+	// the team before it can be merged; zero leaves merges ungated.
+	RequiredApprovals int `json:"required_approvals,omitempty"`
+	// ReviewSLA bounds how long an assigned reviewer may leave a PR of the
+	// team without approving it.
+	ReviewSLA *ReviewSLA `json:"review_sla,omitempty"`
+	// ReviewRotation gives the team's new PRs to the reviewer pair on duty
+	// this week, as set by its ReviewRotation schedule.
+	ReviewRotation bool `json:"review_rotation,omitempty"`
+	// MergeWindow keeps the team's PRs from being merged during its weekly
+	// freezes.
+	MergeWindow *MergeWindow `json:"merge_window,omitempty"`
+}
+
+// MergeWindow is when a team merges: any time outside its Freezes, which
+// are read in Timezone (UTC if empty).
+type MergeWindow struct {
+	Timezone string        `json:"timezone,omitempty"`
+	Freezes  []MergeFreeze `json:"freezes"`
+}
+
+// MergeFreeze is a weekly span without merges from From until Until, both a
+// weekday and a time such as "Fri 16:00". A freeze may run over the week's
+// end, e.g. from "Fri 16:00" until "Mon 09:00".
+type MergeFreeze struct {
+	From  string `json:"from"`
+	Until string `json:"until"`
+}
+
+// QueuedMerge is a merge waiting for its team's merge window to open.
+type QueuedMerge struct {
+	PullRequestID string    `json:"pull_request_id"`
+	MergeAt       time.Time `json:"merge_at"`
+	// MergedBy is recorded as the PR's merged_by once the queue merges it.
+	MergedBy string `json:"merged_by,omitempty"`
+}
+
+// TableStats is the size of one of the service's tables and its indexes.
+// Counts and the bloat estimate come from planner statistics and are only as
+// fresh as the last (auto)vacuum or analyze.
+type TableStats struct {
+	Table      string
+	Rows       int64
+	DeadRows   int64
+	TotalBytes int64
+	Indexes    []IndexStats
+}
+
+// IndexStats is one btree index's size and the part of it estimated to be
+// bloat, its size above what its rows should need.
+type IndexStats struct {
+	Name       string
+	Bytes      int64
+	BloatBytes int64
+}
+
+// ReviewRotation is a team's schedule of reviewer pairs, one per week from
+// the current one on.
+type ReviewRotation struct {
+	TeamName string         `json:"team_name"`
+	Weeks    []RotationWeek `json:"weeks"`
 }
+
+// RotationWeek is the pair on duty in the week starting WeekStart, a
+// Monday in UTC.
+type RotationWeek struct {
+	WeekStart time.Time `json:"week_start"`
+	Reviewers []string  `json:"reviewers"`
+}
+
+// ReviewSLA is the time a reviewer has, and what happens once it is up:
+// SLANotify (the default) sends a notification, SLAReassign hands the
+// review to another member of the team.
+type ReviewSLA struct {
+	Hours  int    `json:"hours"`
+	Action string `json:"action,omitempty"`
+}
+
+const (
+	SLANotify   = "notify"
+	SLAReassign = "reassign"
+)
+
+// OverdueReview is an assignment past its team's review SLA.
+type OverdueReview struct {
+	PullRequestID   string    `json:"pull_request_id"`
+	PullRequestName string    `json:"pull_request_name"`
+	TeamName        string    `json:"team_name"`
+	UserID          string    `json:"user_id"`
+	AssignedAt      time.Time `json:"assigned_at"`
+	DueAt           time.Time `json:"due_at"`
+}
+
+// ReviewReservation holds reviewers for an author's upcoming PR until
+// ExpiresAt, keeping automatic assignments of other PRs off them.
+type ReviewReservation struct {
+	AuthorID  string    `json:"author_id"`
+	Reviewers []string  `json:"reviewers"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+const (
+	FavorSeniors = "seniors"
+	FavorJuniors = "juniors"
+)
+
+// SecurityReviewRule matches PRs carrying one of Labels or changing a file
+// under one of Paths, and requires an active member of Team among their
+// reviewers.
+type SecurityReviewRule struct {
+	Team   string   `json:"team"`
+	Labels []string `json:"labels,omitempty"`
+	// Paths are prefixes such as "internal/auth/".
+	Paths []string `json:"paths,omitempty"`
+}
+
+// SecurityCoverage reports how the team's PRs that needed a security review
+// since Since were served.
+type SecurityCoverage struct {
+	TeamName string `json:"team_name"`
+	Required int    `json:"required"`
+	// Covered PRs got a security reviewer on creation; Approved ones were
+	// also approved by that reviewer.
+	Covered  int     `json:"covered"`
+	Approved int     `json:"approved"`
+	Coverage float64 `json:"coverage"`
+	// Uncovered lists the open PRs still waiting for a security reviewer,
+	// regardless of Since.
+	Uncovered []string  `json:"uncovered"`
+	Since     time.Time `json:"since"`
+}
+
+// TeamStats are a team's running PR counters: PRs created and merged and
+// reviewers reassigned, archived PRs included.
+type TeamStats struct {
+	TeamName      string `json:"team_name"`
+	CreatedPRs    int    `json:"created_prs"`
+	MergedPRs     int    `json:"merged_prs"`
+	Reassignments int    `json:"reassignments"`
+}
+
+// ReviewLoad is the open review load of a team's members.
+type ReviewLoad struct {
+	TeamName string `json:"team_name"`
+	// BySize counts the team's open reviews by PR size, "" for unsized.
+	BySize    map[string]int `json:"by_size"`
+	Effort    int            `json:"effort"`
+	Reviewers []ReviewerLoad `json:"reviewers"`
+}
+
+// ReviewerLoad is one reviewer's open reviews. Effort sums their sizes'
+// efforts and is what load-balanced assignment compares.
+type ReviewerLoad struct {
+	UserID string         `json:"user_id"`
+	Open   int            `json:"open"`
+	Effort int            `json:"effort"`
+	BySize map[string]int `json:"by_size"`
+}
+
+// EscalationHop is one step of a team's escalation chain.
+type EscalationHop struct {
+	// To is "reviewers" for the PR's current reviewers, or a user ID such
+	// as a team or department lead.
+	To string `json:"to"`
+	// DelayMinutes counts from the start of the escalation, not from the
+	// previous hop.
+	DelayMinutes int `json:"delay_minutes"`
+	// Title replaces the default notification title.
+	Title string `json:"title,omitempty"`
+}
+
+// Escalation reasons.
+const (
+	EscalationSLA            = "sla"
+	EscalationReassignFailed = "reassign_failed"
+)
+
+// EscalationTarget addresses the PR's reviewers in an escalation hop.
+const EscalationTarget = "reviewers"
+
+// Escalation is an open PR being escalated. NextHop indexes the team's
+// chain; hops before it were already sent.
+type Escalation struct {
+	PullRequestID   string
+	PullRequestName string
+	TeamName        string
+	Reason          string
+	StartedAt       time.Time
+	NextHop         int
+	Reviewers       []string
+}
+
+// ScoringConfig describes the candidate ranking pipeline: filters drop
+// candidates by name, weights combine the named scorers.
+type ScoringConfig struct {
+	Filters []string           `json:"filters"`
+	Weights map[string]float64 `json:"weights"`
+}
+
+type ReviewerSuggestion struct {
+	UserID       string  `json:"user_id"`
+	Username     string  `json:"username"`
+	Score        float64 `json:"score"`
+	Load         float64 `json:"load"`
+	Expertise    float64 `json:"expertise"`
+	Availability float64 `json:"availability"`
+}
+
+type PRFilter struct {
+	Status   PRStatus
+	TeamName string
+	AuthorID string
+	// Label keeps only PRs carrying it.
+	Label string
+	// Repository keeps only the PRs of that repository.
+	Repository string
+}
+
+type Page struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// MemberHandoff reports where a removed member's open reviews went.
+// Unassigned lists PRs where no teammate was free to take over.
+type MemberHandoff struct {
+	TeamName   string          `json:"team_name"`
+	UserID     string          `json:"user_id"`
+	Reassigned []ReviewHandoff `json:"reassigned"`
+	Unassigned []string        `json:"unassigned"`
+}
+
+type ReviewHandoff struct {
+	PullRequestID string `json:"pull_request_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+// DeactivationPreview is what deactivating a team would do to active PRs.
+// Reviews counts the reviewers that would need replacing across PRs, and
+// Reassignable those a candidate outside the team could take over; the
+// PRs left short are listed in Stranded. Candidates is how many members of
+// each affected PR's team would remain to draw on.
+type DeactivationPreview struct {
+	TeamName     string         `json:"team_name"`
+	Members      int            `json:"members"`
+	PRs          int            `json:"prs"`
+	Reviews      int            `json:"reviews"`
+	Reassignable int            `json:"reassignable"`
+	Enough       bool           `json:"enough"`
+	Candidates   map[string]int `json:"candidates"`
+	Stranded     []StrandedPR   `json:"stranded"`
+}
+
+// StrandedPR is a PR that would lose Missing reviewers nobody could replace.
+type StrandedPR struct {
+	PullRequestID string `json:"pull_request_id"`
+	TeamName      string `json:"team_name"`
+	Missing       int    `json:"missing"`
+}
+
+// BulkReassignReport lists what happened to each open review the user held.
+// A result with Error set kept the original reviewer.
+type BulkReassignReport struct {
+	FromUserID string               `json:"from_user_id"`
+	ToUserID   string               `json:"to_user_id,omitempty"`
+	Results    []BulkReassignResult `json:"results"`
+}
+
+type BulkReassignResult struct {
+	PullRequestID string `json:"pull_request_id"`
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// UserMove reports a team change. Kept lists open reviews the user still
+// holds, either because no handoff was requested or nobody was free.
+type UserMove struct {
+	User       User            `json:"user"`
+	FromTeam   string          `json:"from_team"`
+	Reassigned []ReviewHandoff `json:"reassigned"`
+	Kept       []string        `json:"kept"`
+}
+
+// Consistency violation kinds.
+const (
+	ViolationAuthorIsReviewer  = "author_is_reviewer"
+	ViolationTooManyReviewers  = "too_many_reviewers"
+	ViolationNeedMoreMismatch  = "need_more_reviewers_mismatch"
+	ViolationOrphanedReviewer  = "orphaned_reviewer"
+	ViolationReviewerStatDrift = "reviewer_stats_mismatch"
+)
+
+type ConsistencyViolation struct {
+	Kind          string `json:"kind"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	UserID        string `json:"user_id,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+}
+
+type ConsistencyReport struct {
+	Violations []ConsistencyViolation `json:"violations"`
+	Repaired   bool                   `json:"repaired"`
+}
+
+// OrgSummary aggregates PR activity across every team.
+type OrgSummary struct {
+	OpenPRs             int     `json:"open_prs"`
+	MergedPRs           int     `json:"merged_prs"`
+	MedianTurnaroundSec float64 `json:"median_turnaround_seconds"`
+	// AvgReviewRounds is how many review rounds merged PRs took on average:
+	// one plus their change requests.
+	AvgReviewRounds float64 `json:"avg_review_rounds"`
+	// MedianAckLatencySec is the median time from assignment to the
+	// reviewer's acknowledgment.
+	MedianAckLatencySec float64    `json:"median_ack_latency_seconds"`
+	PRsWithoutReviewers int        `json:"prs_without_reviewers"`
+	BusiestTeams        []TeamLoad `json:"busiest_teams"`
+	GeneratedAt         time.Time  `json:"generated_at"`
+}
+
+type TeamLoad struct {
+	TeamName string `json:"team_name"`
+	OpenPRs  int    `json:"open_prs"`
+}
+
+// AlertSignals are the PR counts the alert rules are evaluated against.
+type AlertSignals struct {
+	OpenPRs          int
+	WithoutReviewers int
+	OverSLA          int
+}
+
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Since     time.Time `json:"since"`
+}
+
+type StatsReport struct {
+	Stats     map[string]int `json:"stats"`
+	Truncated bool           `json:"truncated"`
+}
+
+// Activity event kinds recorded in pr_events.
+const (
+	EventAssigned       = "assigned"
+	EventUnassigned     = "unassigned"
+	EventReassignedAway = "reassigned_away"
+	EventApproved       = "approved"
+	EventAcknowledged   = "acknowledged"
+	EventMerged         = "merged"
+	EventEscalated      = "escalated"
+	// EventChangesRequested starts a new review round.
+	EventChangesRequested = "changes_requested"
+)
+
+// AssignmentEvent is a reviewer assigned to or taken off a PR: Action is
+// EventAssigned, EventUnassigned or EventReassignedAway. Reason is how the
+// reviewer was picked or why they left, TriggeredBy who asked: "admin",
+// "team:<name>" for a team token or "scheduler:<task>".
+type AssignmentEvent struct {
+	PullRequestID string    `json:"pull_request_id"`
+	UserID        string    `json:"user_id"`
+	Action        string    `json:"action"`
+	Reason        string    `json:"reason"`
+	TriggeredBy   string    `json:"triggered_by"`
+	At            time.Time `json:"at"`
+}
+
+type UserEvent struct {
+	PullRequestID   string    `json:"pull_request_id"`
+	PullRequestName string    `json:"pull_request_name"`
+	Kind            string    `json:"kind"`
+	At              time.Time `json:"at"`
+}
+
+// ArchivedPR is a merged or closed PR moved out of the hot tables, as it
+// stood when archived, together with its assignment history.
+type ArchivedPR struct {
+	PR         PullRequest       `json:"pr"`
+	History    []AssignmentEvent `json:"history"`
+	ArchivedAt time.Time         `json:"archived_at"`
+}
+
+type ArchivedPRShort struct {
+	PullRequestShort
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
 type PullRequestShort struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
-	Status          string `json:"status"`
+	PullRequestID   string     `json:"pull_request_id"`
+	ExternalID      string     `json:"-"`
+	PullRequestName string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	TeamName        string     `json:"team_name,omitempty"`
+	Repository      string     `json:"repository,omitempty"`
+	TargetBranch    string     `json:"target_branch,omitempty"`
+	Status          PRStatus   `json:"status"`
+	Priority        PRPriority `json:"priority,omitempty"`
 }
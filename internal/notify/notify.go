@@ -0,0 +1,92 @@
+// Package notify delivers service notifications to external channels.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Message is a single notification. Kind identifies the event (for example
+// "alert.firing") so receivers can route without parsing Text.
+type Message struct {
+	Kind   string            `json:"kind"`
+	Title  string            `json:"title"`
+	Text   string            `json:"text"`
+	Fields map[string]string `json:"fields,omitempty"`
+	At     time.Time         `json:"at"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Pinger is implemented by channels that can check they are reachable
+// without delivering a message.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Nop drops every message. It is the default when no channel is configured.
+type Nop struct{}
+
+func (Nop) Notify(context.Context, Message) error { return nil }
+
+// Webhook posts each message as JSON to a fixed URL.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (w *Webhook) Notify(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("notification webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Ping opens and closes a TCP connection to the webhook host. Nothing is
+// posted, so receivers never see a test message.
+func (w *Webhook) Ping(ctx context.Context) error {
+	u, err := url.Parse(w.url)
+	if err != nil {
+		return fmt.Errorf("parse webhook url: %w", err)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	d := net.Dialer{Timeout: w.client.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return fmt.Errorf("dial webhook host: %w", err)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhook_PostsMessage(t *testing.T) {
+	var got Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(srv.URL, time.Second)
+	if err := wh.Notify(context.Background(), Message{Kind: "alert.firing", Title: "t"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Kind != "alert.firing" {
+		t.Fatalf("unexpected message %+v", got)
+	}
+}
+
+func TestWebhook_Non2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook(srv.URL, time.Second).Notify(context.Background(), Message{}); err == nil {
+		t.Fatal("expected error for 502")
+	}
+}
+
+func TestWebhook_PingDoesNotPost(t *testing.T) {
+	posted := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted = true
+	}))
+	wh := NewWebhook(srv.URL, time.Second)
+	if err := wh.Ping(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if posted {
+		t.Fatal("ping must not deliver a message")
+	}
+
+	srv.Close()
+	if err := wh.Ping(context.Background()); err == nil {
+		t.Fatal("expected error once the host is gone")
+	}
+}
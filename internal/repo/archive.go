@@ -0,0 +1,191 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"PR-reviewer/internal/models"
+)
+
+// GetArchivablePRs returns up to limit PRs merged or closed before before,
+// oldest first.
+func (r *PostgresRepo) GetArchivablePRs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pull_request_id FROM pull_requests
+		WHERE (status = 'MERGED' AND merged_at < $1) OR (status = 'CLOSED' AND closed_at < $1)
+		ORDER BY COALESCE(merged_at, closed_at), pull_request_id
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query archivable prs: %w", err)
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan archivable pr: %w", err)
+		}
+		res = append(res, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+// ArchivePR stores a in archived_pull_requests and deletes the PR from the
+// hot tables, which cascades to its reviewers, approvals and events. The
+// PR is left alone when its status no longer matches the snapshot, so a
+// PR reopened since is not archived from stale data.
+func (r *PostgresRepo) ArchivePR(ctx context.Context, a models.ArchivedPR) error {
+	pr := a.PR
+	prJSON, err := json.Marshal(pr)
+	if err != nil {
+		return fmt.Errorf("marshal pr: %w", err)
+	}
+	history := a.History
+	if history == nil {
+		history = []models.AssignmentEvent{}
+	}
+	historyJSON, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("marshal history: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var status models.PRStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM pull_requests WHERE pull_request_id=$1 FOR UPDATE`, pr.PullRequestID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("not found")
+		}
+		return fmt.Errorf("lock pr: %w", err)
+	}
+	if status != pr.Status {
+		return fmt.Errorf("pr %s is %s now, not %s", pr.PullRequestID, status, pr.Status)
+	}
+
+	// reviewer_stats counts every pr_reviewers row, so the reviewers that
+	// go with the PR leave the counts too.
+	reviewers, err := tx.QueryContext(ctx, `SELECT user_id FROM pr_reviewers WHERE pull_request_id=$1`, pr.PullRequestID)
+	if err != nil {
+		return fmt.Errorf("query reviewers: %w", err)
+	}
+	var userIDs []string
+	for reviewers.Next() {
+		var uid string
+		if err := reviewers.Scan(&uid); err != nil {
+			reviewers.Close()
+			return fmt.Errorf("scan reviewer: %w", err)
+		}
+		userIDs = append(userIDs, uid)
+	}
+	reviewers.Close()
+	if err := reviewers.Err(); err != nil {
+		return fmt.Errorf("rows err: %w", err)
+	}
+	for _, uid := range userIDs {
+		if err := adjustReviewerStats(ctx, tx, uid, -1); err != nil {
+			return err
+		}
+	}
+
+	finishedAt := pr.MergedAt
+	if finishedAt == nil {
+		finishedAt = pr.ClosedAt
+	}
+	// An archived snapshot is never replaced: a PR whose ID is archived
+	// already stays in the hot tables rather than overwrite it.
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO archived_pull_requests(pull_request_id, external_id, pull_request_name, author_id, team_name, repository,
+			target_branch, status, priority, labels, created_at, finished_at, archived_at, pr, history)
+		VALUES ($1,COALESCE(NULLIF($2,''),$1),$3,$4,$5,$6,$7,$8,$9,COALESCE($10,'{}'),$11,$12,$13,$14,$15)
+		ON CONFLICT DO NOTHING
+	`, pr.PullRequestID, pr.ExternalID, pr.PullRequestName, pr.AuthorID, pr.TeamName, pr.Repository, pr.TargetBranch, pr.Status,
+		pr.Priority, pq.Array(pr.Labels), pr.CreatedAt, finishedAt, a.ArchivedAt, prJSON, historyJSON)
+	if err != nil {
+		return fmt.Errorf("insert archived pr: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("conflict: pr %s is archived already", pr.PullRequestID)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pull_requests WHERE pull_request_id=$1`, pr.PullRequestID); err != nil {
+		return fmt.Errorf("delete pr: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetArchivedPR(ctx context.Context, prID string) (models.ArchivedPR, error) {
+	var a models.ArchivedPR
+	var prJSON, historyJSON []byte
+	var externalID string
+	err := r.db.QueryRowContext(ctx, `SELECT pr, history, archived_at, external_id FROM archived_pull_requests WHERE pull_request_id=$1`, prID).
+		Scan(&prJSON, &historyJSON, &a.ArchivedAt, &externalID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, fmt.Errorf("not found")
+		}
+		return a, fmt.Errorf("get archived pr: %w", err)
+	}
+	if err := json.Unmarshal(prJSON, &a.PR); err != nil {
+		return a, fmt.Errorf("decode archived pr: %w", err)
+	}
+	a.PR.ExternalID = externalID
+	if err := json.Unmarshal(historyJSON, &a.History); err != nil {
+		return a, fmt.Errorf("decode archived history: %w", err)
+	}
+	return a, nil
+}
+
+// ListArchivedPRs lists archived PRs matching filter, most recently
+// archived first.
+func (r *PostgresRepo) ListArchivedPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.ArchivedPRShort, error) {
+	if filter.Status != "" {
+		if err := checkStatus(filter.Status); err != nil {
+			return nil, err
+		}
+	}
+	query, args := newSelect(`SELECT pull_request_id, external_id, pull_request_name, author_id, team_name, repository, target_branch,
+		status, priority, archived_at FROM archived_pull_requests`).
+		WhereIf(filter.Status != "", `status = ?`, filter.Status).
+		WhereIf(filter.TeamName != "", `team_name = ?`, filter.TeamName).
+		WhereIf(filter.AuthorID != "", `author_id = ?`, filter.AuthorID).
+		WhereIf(filter.Label != "", `labels @> ARRAY[?]::text[]`, filter.Label).
+		WhereIf(filter.Repository != "", `repository = ?`, filter.Repository).
+		OrderBy(`archived_at DESC, pull_request_id`).
+		Page(page).
+		Build()
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query archived prs: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.ArchivedPRShort{}
+	for rows.Next() {
+		var p models.ArchivedPRShort
+		if err := rows.Scan(&p.PullRequestID, &p.ExternalID, &p.PullRequestName, &p.AuthorID, &p.TeamName, &p.Repository, &p.TargetBranch, &p.Status, &p.Priority, &p.ArchivedAt); err != nil {
+			return nil, fmt.Errorf("scan archived pr: %w", err)
+		}
+		res = append(res, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
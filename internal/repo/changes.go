@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"PR-reviewer/internal/logger"
+)
+
+// ChangesChannel is the channel the triggers in migrations.sql notify on.
+// The payload is the name of the changed table.
+const ChangesChannel = "pr_changes"
+
+// pingInterval keeps an idle listener connection checked, so a silently
+// dropped one is noticed and reconnected.
+const pingInterval = 90 * time.Second
+
+// ChangeListener receives the change notifications of every replica on a
+// dedicated connection.
+type ChangeListener struct {
+	l   *pq.Listener
+	log logger.Logger
+}
+
+// NewChangeListener connects to dsn and listens on ChangesChannel.
+func NewChangeListener(dsn string, log logger.Logger) (*ChangeListener, error) {
+	listenLog := log.WithWorker("change-listener")
+	l := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			listenLog.Warn("change listener connection event", "event", int(ev), "error", err)
+		}
+	})
+	if err := l.Listen(ChangesChannel); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("listen %s: %w", ChangesChannel, err)
+	}
+	return &ChangeListener{l: l, log: listenLog}, nil
+}
+
+// Run calls onChange with the table of each notification until ctx is done,
+// then closes the listener. Notifications sent while the connection was down
+// are lost, so after a reconnect onChange gets "" for "anything may have
+// changed".
+func (c *ChangeListener) Run(ctx context.Context, onChange func(table string)) {
+	defer c.l.Close()
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-c.l.Notify:
+			if n == nil {
+				c.log.Info("change listener reconnected")
+				onChange("")
+				continue
+			}
+			onChange(n.Extra)
+		case <-ticker.C:
+			if err := c.l.Ping(); err != nil {
+				c.log.Warn("change listener ping failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"PR-reviewer/internal/models"
+)
+
+// Each check selects the offending rows. Reviewer-row checks return the
+// (pull_request_id, user_id) pairs to delete on repair; they exclude rows an
+// earlier check already reports, so one bad row yields one violation.
+const (
+	orphanedReviewersQuery = `
+		SELECT rv.pull_request_id, rv.user_id
+		FROM pr_reviewers rv
+		WHERE NOT EXISTS (SELECT 1 FROM pull_requests pr WHERE pr.pull_request_id = rv.pull_request_id)
+		   OR NOT EXISTS (SELECT 1 FROM users u WHERE u.user_id = rv.user_id)
+		ORDER BY 1, 2`
+
+	authorIsReviewerQuery = `
+		SELECT rv.pull_request_id, rv.user_id
+		FROM pr_reviewers rv
+		JOIN pull_requests pr ON pr.pull_request_id = rv.pull_request_id
+		WHERE rv.user_id = pr.author_id
+		ORDER BY 1, 2`
+
+	tooManyReviewersQuery = `
+		SELECT pull_request_id, user_id FROM (
+			SELECT rv.pull_request_id, rv.user_id,
+				row_number() OVER (PARTITION BY rv.pull_request_id ORDER BY rv.user_id) AS rn
+			FROM pr_reviewers rv
+			JOIN pull_requests pr ON pr.pull_request_id = rv.pull_request_id
+			JOIN users u ON u.user_id = rv.user_id
+			WHERE rv.user_id <> pr.author_id AND rv.role = 'required' AND NOT ` + pairedMentor + `
+		) ranked
+		WHERE rn > $1
+		ORDER BY 1, 2`
+
+	// validReviewers counts the required reviewer slots that survive the
+	// checks above.
+	validReviewers = `
+		SELECT LEAST(COUNT(*), $1) FROM pr_reviewers rv
+		JOIN users u ON u.user_id = rv.user_id
+		WHERE rv.pull_request_id = pr.pull_request_id AND rv.user_id <> pr.author_id
+			AND rv.role = 'required' AND NOT ` + pairedMentor
+
+	statsMismatchQuery = `
+		SELECT u.user_id, COALESCE(s.assigned_count, 0), COUNT(rv.pull_request_id)
+		FROM users u
+		LEFT JOIN reviewer_stats s ON s.user_id = u.user_id
+		LEFT JOIN pr_reviewers rv ON rv.user_id = u.user_id
+		GROUP BY u.user_id, s.assigned_count
+		HAVING COALESCE(s.assigned_count, 0) <> COUNT(rv.pull_request_id)
+		ORDER BY 1`
+)
+
+var needMoreMismatchQuery = `
+	SELECT pr.pull_request_id, pr.need_more_reviewers
+	FROM pull_requests pr
+	WHERE ` + activeStatus("pr.status") + `
+	  AND pr.need_more_reviewers <> ((` + validReviewers + `) < $1)
+	ORDER BY 1`
+
+func (r *PostgresRepo) CheckConsistency(ctx context.Context, wantReviewers int, repair bool) (models.ConsistencyReport, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ConsistencyReport{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	report := models.ConsistencyReport{Violations: []models.ConsistencyViolation{}, Repaired: repair}
+
+	// live marks checks whose rows reference an existing PR and user, so the
+	// repair keeps reviewer_stats and pr_events in step like other removals.
+	rowChecks := []struct {
+		kind  string
+		query string
+		args  []any
+		live  bool
+	}{
+		{models.ViolationOrphanedReviewer, orphanedReviewersQuery, nil, false},
+		{models.ViolationAuthorIsReviewer, authorIsReviewerQuery, nil, true},
+		{models.ViolationTooManyReviewers, tooManyReviewersQuery, []any{wantReviewers}, true},
+	}
+	for _, c := range rowChecks {
+		pairs, err := queryPairs(ctx, tx, c.query, c.args...)
+		if err != nil {
+			return models.ConsistencyReport{}, fmt.Errorf("check %s: %w", c.kind, err)
+		}
+		for _, p := range pairs {
+			report.Violations = append(report.Violations, models.ConsistencyViolation{Kind: c.kind, PullRequestID: p[0], UserID: p[1]})
+			if !repair {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2`, p[0], p[1]); err != nil {
+				return models.ConsistencyReport{}, fmt.Errorf("repair %s: %w", c.kind, err)
+			}
+			if c.live {
+				if err := adjustReviewerStats(ctx, tx, p[1], -1); err != nil {
+					return models.ConsistencyReport{}, err
+				}
+				if err := recordEvent(ctx, tx, p[0], p[1], models.EventUnassigned); err != nil {
+					return models.ConsistencyReport{}, err
+				}
+			}
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, needMoreMismatchQuery, wantReviewers)
+	if err != nil {
+		return models.ConsistencyReport{}, fmt.Errorf("check need_more_reviewers: %w", err)
+	}
+	var mismatched []string
+	for rows.Next() {
+		var prID string
+		var stored bool
+		if err := rows.Scan(&prID, &stored); err != nil {
+			rows.Close()
+			return models.ConsistencyReport{}, fmt.Errorf("scan need_more_reviewers row: %w", err)
+		}
+		mismatched = append(mismatched, prID)
+		report.Violations = append(report.Violations, models.ConsistencyViolation{
+			Kind:          models.ViolationNeedMoreMismatch,
+			PullRequestID: prID,
+			Detail:        "stored " + strconv.FormatBool(stored),
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.ConsistencyReport{}, fmt.Errorf("rows err: %w", err)
+	}
+	if repair {
+		for _, prID := range mismatched {
+			if _, err := tx.ExecContext(ctx, `UPDATE pull_requests SET need_more_reviewers = NOT need_more_reviewers WHERE pull_request_id=$1`, prID); err != nil {
+				return models.ConsistencyReport{}, fmt.Errorf("repair need_more_reviewers: %w", err)
+			}
+		}
+	}
+
+	rows, err = tx.QueryContext(ctx, statsMismatchQuery)
+	if err != nil {
+		return models.ConsistencyReport{}, fmt.Errorf("check reviewer stats: %w", err)
+	}
+	var drifted bool
+	for rows.Next() {
+		var userID string
+		var stored, actual int
+		if err := rows.Scan(&userID, &stored, &actual); err != nil {
+			rows.Close()
+			return models.ConsistencyReport{}, fmt.Errorf("scan reviewer stats row: %w", err)
+		}
+		drifted = true
+		report.Violations = append(report.Violations, models.ConsistencyViolation{
+			Kind:   models.ViolationReviewerStatDrift,
+			UserID: userID,
+			Detail: fmt.Sprintf("stored %d, actual %d", stored, actual),
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.ConsistencyReport{}, fmt.Errorf("rows err: %w", err)
+	}
+	if repair && drifted {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO reviewer_stats(user_id, assigned_count)
+			SELECT u.user_id, COUNT(rv.pull_request_id)
+			FROM users u LEFT JOIN pr_reviewers rv ON rv.user_id = u.user_id
+			GROUP BY u.user_id
+			ON CONFLICT (user_id) DO UPDATE SET assigned_count = EXCLUDED.assigned_count
+		`); err != nil {
+			return models.ConsistencyReport{}, fmt.Errorf("repair reviewer stats: %w", err)
+		}
+	}
+
+	if !repair {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return models.ConsistencyReport{}, fmt.Errorf("commit: %w", err)
+	}
+	return report, nil
+}
+
+func queryPairs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([][2]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out [][2]string
+	for rows.Next() {
+		var p [2]string
+		if err := rows.Scan(&p[0], &p[1]); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"PR-reviewer/internal/models"
+)
+
+// ForgetUser anonymizes the user in one transaction: their name and
+// personal settings are replaced, their skills, tokens, mentorships,
+// reservations and review comments dropped, and their name scrubbed from
+// archived PRs. The user row, its ID and every assignment, approval and
+// event stay, so stats and PR history still add up.
+func (r *PostgresRepo) ForgetUser(ctx context.Context, userID string) (models.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.User{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var u models.User
+	err = tx.QueryRowContext(ctx, `
+		UPDATE users SET username = $2, is_active = FALSE, timezone = '', notify_merged = FALSE,
+			vacation_from = NULL, vacation_until = NULL, vacation_active = FALSE
+		WHERE user_id = $1
+		RETURNING user_id, username, COALESCE(team_name, ''), is_active
+	`, userID, models.ForgottenUsername).Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.User{}, fmt.Errorf("not found")
+		}
+		return models.User{}, fmt.Errorf("anonymize user: %w", err)
+	}
+
+	for _, q := range []string{
+		`DELETE FROM user_skills WHERE user_id = $1`,
+		`DELETE FROM user_tokens WHERE user_id = $1`,
+		`DELETE FROM mentorships WHERE mentee_id = $1 OR mentor_id = $1`,
+		`DELETE FROM review_reservations WHERE user_id = $1 OR author_id = $1`,
+		`UPDATE pr_reviewers SET decision_comment = '' WHERE user_id = $1 AND decision_comment <> ''`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return models.User{}, fmt.Errorf("forget user data: %w", err)
+		}
+	}
+
+	// Archived PRs keep a snapshot of each reviewer's name and comment.
+	_, err = tx.ExecContext(ctx, `
+		UPDATE archived_pull_requests a SET pr = jsonb_set(a.pr, '{assigned_reviewers}', (
+			SELECT jsonb_agg(CASE WHEN e.r->>'user_id' = $1
+				THEN (e.r || jsonb_build_object('username', $2::text)) - 'comment'
+				ELSE e.r END ORDER BY e.i)
+			FROM jsonb_array_elements(a.pr->'assigned_reviewers') WITH ORDINALITY AS e(r, i)
+		))
+		WHERE a.pr->'assigned_reviewers' @> jsonb_build_array(jsonb_build_object('user_id', $1::text))
+	`, userID, models.ForgottenUsername)
+	if err != nil {
+		return models.User{}, fmt.Errorf("scrub archived prs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.User{}, fmt.Errorf("commit: %w", err)
+	}
+	return u, nil
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"PR-reviewer/internal/models"
+)
+
+// AssignmentCause says why reviewers are about to be assigned or taken off
+// and who asked. Writes pick it up from the context and record it in
+// assignment_events next to the change.
+type AssignmentCause struct {
+	Reason      string
+	TriggeredBy string
+	// Reasons overrides Reason per user, for operations that pick reviewers
+	// in several ways at once.
+	Reasons map[string]string
+}
+
+type causeKey struct{}
+
+// WithAssignmentCause attaches c to ctx.
+func WithAssignmentCause(ctx context.Context, c AssignmentCause) context.Context {
+	return context.WithValue(ctx, causeKey{}, c)
+}
+
+// AssignmentCauseFromContext returns the cause attached to ctx, if any.
+func AssignmentCauseFromContext(ctx context.Context) (AssignmentCause, bool) {
+	c, ok := ctx.Value(causeKey{}).(AssignmentCause)
+	return c, ok
+}
+
+// recordAssignment appends to the assignment history. Changes made without
+// a cause are still recorded, as "unknown" by "system".
+func recordAssignment(ctx context.Context, tx *sql.Tx, prID, userID, action string) error {
+	c, _ := AssignmentCauseFromContext(ctx)
+	reason, by := c.Reason, c.TriggeredBy
+	if r, ok := c.Reasons[userID]; ok {
+		reason = r
+	}
+	if reason == "" {
+		reason = "unknown"
+	}
+	if by == "" {
+		by = "system"
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO assignment_events(pull_request_id, user_id, action, reason, triggered_by)
+		VALUES ($1, $2, $3, $4, $5)`, prID, userID, action, reason, by); err != nil {
+		return fmt.Errorf("insert assignment event: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetAssignmentHistory(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pull_request_id, user_id, action, reason, triggered_by, created_at
+		FROM assignment_events
+		WHERE pull_request_id = $1
+		ORDER BY created_at, id`, prID)
+	if err != nil {
+		return nil, fmt.Errorf("query assignment history: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.AssignmentEvent{}
+	for rows.Next() {
+		var e models.AssignmentEvent
+		if err := rows.Scan(&e.PullRequestID, &e.UserID, &e.Action, &e.Reason, &e.TriggeredBy, &e.At); err != nil {
+			return nil, fmt.Errorf("scan assignment event: %w", err)
+		}
+		e.At = e.At.UTC()
+		res = append(res, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
@@ -11,19 +11,216 @@ import (
 type Repo interface {
 	InsertTeam(ctx context.Context, team models.Team) error
 	GetTeam(ctx context.Context, teamName string) (models.Team, error)
+	UpdateTeam(ctx context.Context, upd models.TeamUpdate) (models.Team, error)
+	// DeleteTeam returns the open PRs reviewed by team members, home members
+	// anywhere and extra members on the team's own PRs. Without force it
+	// deletes nothing when that list is non-empty.
+	DeleteTeam(ctx context.Context, teamName string, force bool, wantReviewers int) ([]string, error)
 	UpdateUserActive(ctx context.Context, userID string, isActive bool) (models.User, error)
+	// ForgetUser anonymizes the user's personal data and deactivates them,
+	// keeping their ID, assignments and events for stats.
+	ForgetUser(ctx context.Context, userID string) (models.User, error)
+	// UpdateUserTeam moves the user and applies the review handoffs in one
+	// transaction.
+	UpdateUserTeam(ctx context.Context, userID, teamName string, handoffs []models.ReviewHandoff) (models.User, error)
 
+	// CreatePR fails with "conflict" when the PR's repository already has a
+	// PR, live or archived, with its ID. The PR is stored under its ID, or
+	// under one qualified by its repository if another repository's PR holds
+	// the ID; FindPR returns the key.
 	CreatePR(ctx context.Context, pr models.PullRequest) error
+	// FindPR returns the key of the PR created as id in repository, live or
+	// archived, or "not found".
+	FindPR(ctx context.Context, repository, id string) (string, error)
 	GetPR(ctx context.Context, prID string) (models.PullRequest, error)
-	MergePR(ctx context.Context, prID string, t time.Time) (models.PullRequest, error)
+	// MergePR marks the active PR merged at t by mergedBy, who may be empty
+	// when unknown, failing with a conflict when it is merged or closed.
+	MergePR(ctx context.Context, prID, mergedBy string, t time.Time) (models.PullRequest, error)
+	// ClosePR closes the active PR at t, failing with a conflict when it is
+	// merged or closed.
+	ClosePR(ctx context.Context, prID string, t time.Time) (models.PullRequest, error)
+	// ReopenPR moves a closed PR back to OPEN, failing with a conflict when
+	// it is no longer closed.
+	ReopenPR(ctx context.Context, prID string) (models.PullRequest, error)
+	// SetPRStatus moves the PR from status from to status to, failing with
+	// "conflict" when it's no longer in from.
+	SetPRStatus(ctx context.Context, prID string, from, to models.PRStatus) error
+	// SetPRLabels replaces the PR's labels.
+	SetPRLabels(ctx context.Context, prID string, labels []string) error
 	ReplaceReviewer(ctx context.Context, prID, oldUID, newUID string) (models.PullRequest, error)
-	AddReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error)
+	// AddReviewer assigns userID and recomputes need_more_reviewers against
+	// wantReviewers.
+	AddReviewer(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error)
+	// AddSecondaryReviewer adds an optional reviewer, who takes no slot. It
+	// fails with "conflict" when the PR already has one or the user reviews
+	// it already.
+	AddSecondaryReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error)
+	// RemoveReviewer unassigns userID without a replacement and recomputes
+	// need_more_reviewers against wantReviewers.
+	RemoveReviewer(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error)
 	CleanupInactiveReviewers(ctx context.Context, prID string) error
+	ApprovePR(ctx context.Context, prID, userID string, t time.Time) (models.PullRequest, error)
+	// RecordReviewDecision stores the reviewer's latest decision and its
+	// comment. It fails with "not assigned" for anyone not on the PR.
+	RecordReviewDecision(ctx context.Context, prID, userID string, decision models.ReviewStatus, comment string, t time.Time) error
 
 	GetActiveTeamMembersExcept(ctx context.Context, teamName, exceptUser string) ([]string, error)
+	// GetActiveMembersOfTeams returns the active members of any of the
+	// teams, each once.
+	GetActiveMembersOfTeams(ctx context.Context, teamNames []string, exceptUser string) ([]string, error)
 	GetUserTeam(ctx context.Context, userID string) (string, error)
+	GetCandidateSignals(ctx context.Context, teamName, authorID string) ([]models.CandidateSignals, error)
+	// GetOpenReviewLoad returns the summed effort of the open PRs each
+	// member of the team is assigned to review, by PR size. Members with
+	// none are omitted.
+	GetOpenReviewLoad(ctx context.Context, teamName string) (map[string]int, error)
+	// GetReviewLoadBySize returns each member of the team with open reviews
+	// and how many of them are of each size, busiest first.
+	GetReviewLoadBySize(ctx context.Context, teamName string) ([]models.ReviewerLoad, error)
+	// GetUserStats returns the user's review stats from the start of the
+	// week containing since.
+	GetUserStats(ctx context.Context, userID string, since time.Time) (models.UserStats, error)
+	// SetUserSkills replaces the user's skills.
+	SetUserSkills(ctx context.Context, userID string, skills []string) error
+	// GetSkillMatches returns, for every user with any of the skills, how
+	// many of them they have.
+	GetSkillMatches(ctx context.Context, skills []string) (map[string]int, error)
+	// SetMentor pairs the mentee with a mentor, replacing any previous one;
+	// an empty mentorID removes the pairing.
+	SetMentor(ctx context.Context, menteeID, mentorID string) error
+	// GetMentors returns the mentor of each of the users that has one.
+	GetMentors(ctx context.Context, userIDs []string) (map[string]string, error)
+	// SetTimezone stores the user's IANA timezone; empty clears it.
+	SetTimezone(ctx context.Context, userID, tz string) error
+	// GetTimezones returns the timezone of each of the users that has one.
+	GetTimezones(ctx context.Context, userIDs []string) (map[string]string, error)
+	// SetNotificationPrefs stores which notifications the user wants.
+	SetNotificationPrefs(ctx context.Context, userID string, prefs models.NotificationPrefs) error
+	// GetMergeSubscribers returns those of userIDs who want to hear about
+	// merges.
+	GetMergeSubscribers(ctx context.Context, userIDs []string) ([]string, error)
+	// GetRampUpLoads returns the open review count of every user who joined
+	// their team after joinedAfter and, unless maxAssigned is 0, has been
+	// assigned fewer than maxAssigned reviews since.
+	GetRampUpLoads(ctx context.Context, joinedAfter time.Time, maxAssigned int) (map[string]int, error)
+	// GetRecentReviewers returns who reviewed the author's PRs created since
+	// since, those on the most PRs first, then the most recent.
+	GetRecentReviewers(ctx context.Context, authorID string, since time.Time) ([]string, error)
+	SetUserWeight(ctx context.Context, userID string, weight int) error
+	// GetUserWeights returns the weight of each of the users.
+	GetUserWeights(ctx context.Context, userIDs []string) (map[string]int, error)
+	// SetVacation replaces the user's vacation; nil cancels it.
+	SetVacation(ctx context.Context, userID string, v *models.Vacation) error
+	// StartVacations deactivates the active users whose vacation has begun
+	// and returns them.
+	StartVacations(ctx context.Context, now time.Time) ([]string, error)
+	// EndVacations reactivates the users deactivated by a vacation that is
+	// over, cancelled or moved to the future, and returns them.
+	EndVacations(ctx context.Context, now time.Time) ([]string, error)
+	// GetSecurityCoverage counts the team's PRs created since that needed a
+	// security review and lists its open ones still lacking a reviewer.
+	GetSecurityCoverage(ctx context.Context, teamName string, since time.Time) (models.SecurityCoverage, error)
+
+	// StartEscalation opens an escalation of the PR for reason. It is a
+	// no-op if one was already started, so each reason escalates once.
+	StartEscalation(ctx context.Context, prID, reason string) error
+	// StartSLAEscalations opens an "sla" escalation for every open PR
+	// created before cutoff and returns how many were new.
+	StartSLAEscalations(ctx context.Context, cutoff time.Time) (int, error)
+	// GetActiveEscalations lists escalations of open PRs. A reassign_failed
+	// escalation stays active only while the PR needs more reviewers.
+	GetActiveEscalations(ctx context.Context) ([]models.Escalation, error)
+	// AdvanceEscalation marks hop as sent and records an "escalated" event
+	// for each recipient. It fails with "conflict" if the hop was already
+	// sent.
+	AdvanceEscalation(ctx context.Context, prID, reason string, hop int, recipients []string) error
 	GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error)
+	GetPRsByAuthor(ctx context.Context, userID string) ([]models.PullRequestShort, error)
+	GetUserEvents(ctx context.Context, userID string, page models.Page) ([]models.UserEvent, error)
+	AcknowledgeReview(ctx context.Context, prID, userID string) (models.PullRequest, error)
+	GetPendingReviews(ctx context.Context) ([]models.PendingReview, error)
+	// GetStaleReviews returns the unapproved reviews on open PRs whose
+	// reviewer has been inactive since before inactiveBefore.
+	GetStaleReviews(ctx context.Context, inactiveBefore time.Time) ([]models.PendingReview, error)
+	// GetUnderstaffedPRs returns the open PRs of teamName that need more
+	// reviewers and that userID neither authored nor reviews, oldest first.
+	GetUnderstaffedPRs(ctx context.Context, teamName, userID string) ([]string, error)
+	// GetAssignmentHistory returns every reviewer assignment and removal on
+	// the PR, oldest first.
+	GetAssignmentHistory(ctx context.Context, prID string) ([]models.AssignmentEvent, error)
+	// StartReviewRound records that requestedBy asked for changes on the PR,
+	// starting its next review round.
+	StartReviewRound(ctx context.Context, prID, requestedBy, freshReviewer string, dueAt *time.Time) (models.ReviewRound, error)
+	// GetReviewRounds returns the PR's recorded review rounds, oldest first.
+	GetReviewRounds(ctx context.Context, prID string) ([]models.ReviewRound, error)
+	// SaveRotation replaces the team's rotation from the first of weeks on.
+	SaveRotation(ctx context.Context, teamName string, weeks []models.RotationWeek) error
+	// GetRotation returns the team's rotation weeks starting from from on.
+	GetRotation(ctx context.Context, teamName string, from time.Time) ([]models.RotationWeek, error)
+	// QueueMerge queues the PR to be merged at mergeAt by mergedBy, or moves
+	// its queued merge there.
+	QueueMerge(ctx context.Context, prID, mergedBy string, mergeAt time.Time) error
+	// GetDueMerges returns the queued merges due at now, earliest first.
+	GetDueMerges(ctx context.Context, now time.Time) ([]models.QueuedMerge, error)
+	// DequeueMerge drops the PR's queued merge, if any.
+	DequeueMerge(ctx context.Context, prID string) error
+	// ReserveReviewers replaces authorID's reservation with userIDs until
+	// until; no userIDs just drops it. It fails with "conflict" when one of
+	// them is held for another author.
+	ReserveReviewers(ctx context.Context, authorID string, userIDs []string, until time.Time) error
+	// GetReservations maps each reviewer reserved at now to the author
+	// holding them.
+	GetReservations(ctx context.Context, now time.Time) (map[string]string, error)
+	GetAssignmentRecords(ctx context.Context, from, to time.Time) ([]models.AssignmentRecord, error)
+	ListPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error)
+	SearchPRs(ctx context.Context, query string, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error)
 	GetUser(ctx context.Context, userID string) (models.User, error)
+	GetUserProfile(ctx context.Context, userID string) (models.UserProfile, error)
+	AddMembership(ctx context.Context, m models.Membership) error
+	RemoveMembership(ctx context.Context, teamName, userID string) error
 	GetReviewerStats(ctx context.Context) (map[string]int, error)
+	// GetTeamStats reads the team's counters, zero for a team that has no
+	// PRs yet, or fails with "not found" for an unknown team.
+	GetTeamStats(ctx context.Context, teamName string) (models.TeamStats, error)
+	// QueryReviewerStats returns the rows read so far alongside any error, so
+	// a caller with a time budget can still report partial results.
+	QueryReviewerStats(ctx context.Context, q models.StatsQuery) (map[string]int, error)
+	// GetOrgSummary returns the org-wide summary; limit caps BusiestTeams.
+	GetOrgSummary(ctx context.Context, limit int) (models.OrgSummary, error)
+	// GetAlertSignals counts open PRs, those without reviewers and those
+	// created before slaCutoff.
+	GetAlertSignals(ctx context.Context, slaCutoff time.Time) (models.AlertSignals, error)
+	// CheckConsistency scans for broken invariants and, with repair, fixes
+	// them in the same transaction.
+	CheckConsistency(ctx context.Context, wantReviewers int, repair bool) (models.ConsistencyReport, error)
+	// SetTeamActive sets the active flag of the team's home members. On
+	// deactivation the team's extra members are demoted to observers.
 	SetTeamActive(ctx context.Context, teamName string, isActive bool) error
+	// GetTeamMemberships lists the team's extra members with their roles.
+	GetTeamMemberships(ctx context.Context, teamName string) ([]models.Membership, error)
+
+	GetTeamSettings(ctx context.Context, teamName string) (models.TeamSettings, error)
+	SaveTeamSettings(ctx context.Context, settings models.TeamSettings) error
+	// GetRepositorySettings fails with "not found" for a repository that
+	// was never configured.
+	GetRepositorySettings(ctx context.Context, repository string) (models.RepositorySettings, error)
+	SaveRepositorySettings(ctx context.Context, settings models.RepositorySettings) error
+
+	CreateTeamToken(ctx context.Context, teamName, tokenHash string) error
+	GetTeamByToken(ctx context.Context, tokenHash string) (string, error)
+	// CreateUserToken stores the hash of a token bound to one user.
+	CreateUserToken(ctx context.Context, userID, tokenHash string) error
+	// GetUserByToken returns the user a token is bound to.
+	GetUserByToken(ctx context.Context, tokenHash string) (string, error)
+	// GetTableStats returns the sizes of the service's tables, by name.
+	GetTableStats(ctx context.Context) ([]models.TableStats, error)
+	// GetArchivablePRs returns up to limit PRs merged or closed before
+	// before, oldest first.
+	GetArchivablePRs(ctx context.Context, before time.Time, limit int) ([]string, error)
+	// ArchivePR moves a merged or closed PR out of the hot tables into the
+	// archive, unless its status has changed since the snapshot was taken.
+	// It fails with "conflict" rather than replace an archived PR.
+	ArchivePR(ctx context.Context, a models.ArchivedPR) error
+	GetArchivedPR(ctx context.Context, prID string) (models.ArchivedPR, error)
+	ListArchivedPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.ArchivedPRShort, error)
 }
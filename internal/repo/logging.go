@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/logger"
+	"PR-reviewer/internal/metrics"
+)
+
+// maxLoggedArgLen caps each parameter printed for a slow call; teams and
+// PRs would otherwise dump every member into the log line.
+const maxLoggedArgLen = 64
+
+var slowCalls = metrics.NewCounter("repo_slow_calls_total", "Repo calls slower than the configured threshold, by operation.", "op")
+
+type (
+	requestIDKey struct{}
+	jobTypeKey   struct{}
+)
+
+// WithRequestID attaches the HTTP request ID to ctx so repo failures can be
+// tied back to the request that caused them.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithJobType attaches the service job type running the repo call.
+func WithJobType(ctx context.Context, jobType string) context.Context {
+	return context.WithValue(ctx, jobTypeKey{}, jobType)
+}
+
+func JobTypeFromContext(ctx context.Context) string {
+	t, _ := ctx.Value(jobTypeKey{}).(string)
+	return t
+}
+
+// OpError carries the operation and request metadata of a failed repo call.
+// Its message is the wrapped error's, so callers matching on "not found" and
+// clients reading error messages see no difference.
+type OpError struct {
+	Op        string
+	RequestID string
+	JobType   string
+	Err       error
+}
+
+func (e *OpError) Error() string { return e.Err.Error() }
+
+func (e *OpError) Unwrap() error { return e.Err }
+
+// expectedError reports outcomes the service turns into 4xx responses;
+// they are not worth an error log line.
+func expectedError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "not found") ||
+		strings.Contains(msg, "not assigned") ||
+		strings.Contains(msg, "team exists")
+}
+
+func logOpError(log logger.Logger, err *OpError) {
+	if log == nil || expectedError(err) {
+		return
+	}
+	kvs := []any{"op", err.Op}
+	if err.RequestID != "" {
+		kvs = append(kvs, "request_id", err.RequestID)
+	}
+	if err.JobType != "" {
+		kvs = append(kvs, "job", err.JobType)
+	}
+	log.Error("repo call failed: "+err.Error(), kvs...)
+}
+
+func logSlowCall(ctx context.Context, log logger.Logger, op string, args []any, elapsed time.Duration) {
+	slowCalls.Inc(op)
+	if log == nil {
+		return
+	}
+	kvs := []any{"op", op, "duration", elapsed.Round(time.Millisecond), "args", formatArgs(args)}
+	if id := RequestIDFromContext(ctx); id != "" {
+		kvs = append(kvs, "request_id", id)
+	}
+	if t := JobTypeFromContext(ctx); t != "" {
+		kvs = append(kvs, "job", t)
+	}
+	log.Warn("slow repo call", kvs...)
+}
+
+func formatArgs(args []any) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		v := fmt.Sprintf("%+v", a)
+		if r := []rune(v); len(r) > maxLoggedArgLen {
+			v = string(r[:maxLoggedArgLen]) + "..."
+		}
+		parts = append(parts, v)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
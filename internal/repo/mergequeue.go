@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+func (r *PostgresRepo) QueueMerge(ctx context.Context, prID, mergedBy string, mergeAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO merge_queue(pull_request_id, merge_at, merged_by) VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (pull_request_id) DO UPDATE SET merge_at = EXCLUDED.merge_at, merged_by = EXCLUDED.merged_by`, prID, mergeAt, mergedBy)
+	if err != nil {
+		return fmt.Errorf("queue merge: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetDueMerges(ctx context.Context, now time.Time) ([]models.QueuedMerge, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pull_request_id, merge_at, COALESCE(merged_by, '')
+		FROM merge_queue
+		WHERE merge_at <= $1
+		ORDER BY merge_at, pull_request_id`, now)
+	if err != nil {
+		return nil, fmt.Errorf("query due merges: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.QueuedMerge{}
+	for rows.Next() {
+		var q models.QueuedMerge
+		if err := rows.Scan(&q.PullRequestID, &q.MergeAt, &q.MergedBy); err != nil {
+			return nil, fmt.Errorf("scan queued merge: %w", err)
+		}
+		q.MergeAt = q.MergeAt.UTC()
+		res = append(res, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) DequeueMerge(ctx context.Context, prID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM merge_queue WHERE pull_request_id=$1`, prID); err != nil {
+		return fmt.Errorf("dequeue merge: %w", err)
+	}
+	return nil
+}
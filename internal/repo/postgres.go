@@ -3,9 +3,15 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"PR-reviewer/internal/models"
 )
 
@@ -17,6 +23,15 @@ func NewPostgresRepo(db *sql.DB) *PostgresRepo {
 	return &PostgresRepo{db: db}
 }
 
+// checkStatus guards the repo boundary: an unknown status is a programming
+// error on the way in and schema drift on the way out.
+func checkStatus(s models.PRStatus) error {
+	if !s.Valid() {
+		return fmt.Errorf("invalid pr status %q", s)
+	}
+	return nil
+}
+
 func (r *PostgresRepo) InsertTeam(ctx context.Context, team models.Team) error {
 	if _, err := r.db.ExecContext(ctx, `INSERT INTO teams(team_name) VALUES ($1) ON CONFLICT (team_name) DO NOTHING`, team.TeamName); err != nil {
 		return fmt.Errorf("insert team: %w", err)
@@ -30,7 +45,8 @@ func (r *PostgresRepo) InsertTeam(ctx context.Context, team models.Team) error {
 
 	stmt, err := tx.PrepareContext(ctx, `INSERT INTO users(user_id, username, team_name, is_active)
 		VALUES ($1,$2,$3,$4)
-		ON CONFLICT (user_id) DO UPDATE SET username=EXCLUDED.username, team_name=EXCLUDED.team_name, is_active=EXCLUDED.is_active`)
+		ON CONFLICT (user_id) DO UPDATE SET username=EXCLUDED.username, team_name=EXCLUDED.team_name, is_active=EXCLUDED.is_active,
+			joined_at=CASE WHEN users.team_name IS DISTINCT FROM EXCLUDED.team_name THEN NOW() ELSE users.joined_at END`)
 	if err != nil {
 		return fmt.Errorf("prepare: %w", err)
 	}
@@ -41,6 +57,9 @@ func (r *PostgresRepo) InsertTeam(ctx context.Context, team models.Team) error {
 			return fmt.Errorf("exec upsert user: %w", err)
 		}
 	}
+	if err := dropHomeMemberships(ctx, tx, team.TeamName); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("commit: %w", err)
@@ -48,6 +67,197 @@ func (r *PostgresRepo) InsertTeam(ctx context.Context, team models.Team) error {
 	return nil
 }
 
+// UpdateTeam renames the team, detaches removed members and upserts the
+// rest in one transaction. Detached users keep their history but lose their
+// team and are deactivated so they stop receiving reviews.
+func (r *PostgresRepo) UpdateTeam(ctx context.Context, upd models.TeamUpdate) (models.Team, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Team{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name=$1)`, upd.TeamName).Scan(&exists); err != nil {
+		return models.Team{}, fmt.Errorf("select team: %w", err)
+	}
+	if !exists {
+		return models.Team{}, fmt.Errorf("not found")
+	}
+
+	name := upd.TeamName
+	if upd.NewTeamName != "" && upd.NewTeamName != upd.TeamName {
+		res, err := tx.ExecContext(ctx, `INSERT INTO teams(team_name) VALUES ($1) ON CONFLICT (team_name) DO NOTHING`, upd.NewTeamName)
+		if err != nil {
+			return models.Team{}, fmt.Errorf("insert renamed team: %w", err)
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return models.Team{}, fmt.Errorf("team exists")
+		}
+		for _, table := range []string{"users", "pull_requests", "team_tokens", "team_settings", "team_memberships", "review_rotations", "team_stats"} {
+			if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET team_name=$1 WHERE team_name=$2`, upd.NewTeamName, upd.TeamName); err != nil {
+				return models.Team{}, fmt.Errorf("move %s to renamed team: %w", table, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM teams WHERE team_name=$1`, upd.TeamName); err != nil {
+			return models.Team{}, fmt.Errorf("delete old team: %w", err)
+		}
+		name = upd.NewTeamName
+	}
+
+	for _, uid := range upd.Remove {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET team_name=NULL, is_active=false WHERE user_id=$1 AND team_name=$2`, uid, name); err != nil {
+			return models.Team{}, fmt.Errorf("detach user: %w", err)
+		}
+	}
+
+	if len(upd.Upsert) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO users(user_id, username, team_name, is_active)
+			VALUES ($1,$2,$3,$4)
+			ON CONFLICT (user_id) DO UPDATE SET username=EXCLUDED.username, team_name=EXCLUDED.team_name, is_active=EXCLUDED.is_active,
+				joined_at=CASE WHEN users.team_name IS DISTINCT FROM EXCLUDED.team_name THEN NOW() ELSE users.joined_at END`)
+		if err != nil {
+			return models.Team{}, fmt.Errorf("prepare: %w", err)
+		}
+		defer stmt.Close()
+		for _, m := range upd.Upsert {
+			if _, err := stmt.ExecContext(ctx, m.UserID, m.Username, name, m.IsActive); err != nil {
+				return models.Team{}, fmt.Errorf("exec upsert user: %w", err)
+			}
+		}
+		if err := dropHomeMemberships(ctx, tx, name); err != nil {
+			return models.Team{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Team{}, fmt.Errorf("commit: %w", err)
+	}
+	return r.GetTeam(ctx, name)
+}
+
+// dropHomeMemberships removes extra memberships in teamName held by users
+// whose home team it now is.
+func dropHomeMemberships(ctx context.Context, tx *sql.Tx, teamName string) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM team_memberships m USING users u
+		WHERE m.user_id = u.user_id AND m.team_name = u.team_name AND m.team_name = $1
+	`, teamName)
+	if err != nil {
+		return fmt.Errorf("delete home memberships: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) DeleteTeam(ctx context.Context, teamName string, force bool, wantReviewers int) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name=$1)`, teamName).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("select team: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("not found")
+	}
+
+	// Home members are deleted with the team, so all their open reviews go.
+	// Extra members only lose the reviews they hold for the team's PRs.
+	rows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT pr.pull_request_id
+		FROM pr_reviewers rr
+		JOIN users u ON u.user_id = rr.user_id
+		JOIN pull_requests pr ON pr.pull_request_id = rr.pull_request_id
+		WHERE `+activeStatus("pr.status")+` AND (u.team_name = $1 OR (pr.team_name = $1 AND EXISTS (
+			SELECT 1 FROM team_memberships m WHERE m.user_id = rr.user_id AND m.team_name = $1)))
+		ORDER BY pr.pull_request_id
+	`, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("query open reviews: %w", err)
+	}
+	affected := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan pr id: %w", err)
+		}
+		affected = append(affected, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	if len(affected) > 0 && !force {
+		return affected, fmt.Errorf("open reviews")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE user_id IN (SELECT user_id FROM users WHERE team_name=$1)`, teamName); err != nil {
+		return nil, fmt.Errorf("delete team reviewers: %w", err)
+	}
+	if err := dropGuestReviews(ctx, tx, teamName); err != nil {
+		return nil, err
+	}
+	for _, prID := range affected {
+		if _, err := tx.ExecContext(ctx, needMoreReviewersUpdate, prID, wantReviewers); err != nil {
+			return nil, fmt.Errorf("recompute need_more_reviewers: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE team_name=$1`, teamName); err != nil {
+		return nil, fmt.Errorf("delete team users: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM teams WHERE team_name=$1`, teamName); err != nil {
+		return nil, fmt.Errorf("delete team: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return affected, nil
+}
+
+// dropGuestReviews unassigns the team's extra members from its open PRs.
+// Unlike home members they outlive the team, so their counters and history
+// are kept in step.
+func dropGuestReviews(ctx context.Context, tx *sql.Tx, teamName string) error {
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM pr_reviewers rr
+		USING pull_requests pr, team_memberships m
+		WHERE pr.pull_request_id = rr.pull_request_id AND pr.team_name = $1 AND `+activeStatus("pr.status")+`
+			AND m.user_id = rr.user_id AND m.team_name = $1
+		RETURNING rr.pull_request_id, rr.user_id
+	`, teamName)
+	if err != nil {
+		return fmt.Errorf("delete guest reviewers: %w", err)
+	}
+	var removed [][2]string
+	for rows.Next() {
+		var p [2]string
+		if err := rows.Scan(&p[0], &p[1]); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan removed reviewer: %w", err)
+		}
+		removed = append(removed, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows err: %w", err)
+	}
+
+	for _, p := range removed {
+		if err := adjustReviewerStats(ctx, tx, p[1], -1); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, tx, p[0], p[1], models.EventUnassigned); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *PostgresRepo) GetTeam(ctx context.Context, teamName string) (models.Team, error) {
 	var res models.Team
 	rows, err := r.db.QueryContext(ctx, `SELECT user_id, username, is_active FROM users WHERE team_name = $1 ORDER BY user_id`, teamName)
@@ -80,7 +290,10 @@ func (r *PostgresRepo) GetTeam(ctx context.Context, teamName string) (models.Tea
 func (r *PostgresRepo) UpdateUserActive(ctx context.Context, userID string, isActive bool) (models.User, error) {
 	var u models.User
 
-	res, err := r.db.ExecContext(ctx, `UPDATE users SET is_active = $1 WHERE user_id = $2`, isActive, userID)
+	// Setting the flag by hand overrides, and so cancels, any vacation.
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE users SET is_active = $1, vacation_from = NULL, vacation_until = NULL, vacation_active = FALSE
+		WHERE user_id = $2`, isActive, userID)
 	if err != nil {
 		return u, fmt.Errorf("update user active: %w", err)
 	}
@@ -89,7 +302,7 @@ func (r *PostgresRepo) UpdateUserActive(ctx context.Context, userID string, isAc
 		return u, fmt.Errorf("not found")
 	}
 
-	row := r.db.QueryRowContext(ctx, `SELECT user_id, username, team_name, is_active FROM users WHERE user_id = $1`, userID)
+	row := r.db.QueryRowContext(ctx, `SELECT user_id, username, COALESCE(team_name, ''), is_active FROM users WHERE user_id = $1`, userID)
 	if err := row.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
 		if err == sql.ErrNoRows {
 			return u, fmt.Errorf("not found")
@@ -99,20 +312,94 @@ func (r *PostgresRepo) UpdateUserActive(ctx context.Context, userID string, isAc
 	return u, nil
 }
 
+func (r *PostgresRepo) UpdateUserTeam(ctx context.Context, userID, teamName string, handoffs []models.ReviewHandoff) (models.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.User{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name=$1)`, teamName).Scan(&exists); err != nil {
+		return models.User{}, fmt.Errorf("check team: %w", err)
+	}
+	if !exists {
+		return models.User{}, fmt.Errorf("not found")
+	}
+
+	var u models.User
+	err = tx.QueryRowContext(ctx, `
+		UPDATE users SET team_name = $1,
+			joined_at = CASE WHEN team_name IS DISTINCT FROM $1 THEN NOW() ELSE joined_at END
+		WHERE user_id = $2
+		RETURNING user_id, username, team_name, is_active
+	`, teamName, userID).Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.User{}, fmt.Errorf("not found")
+		}
+		return models.User{}, fmt.Errorf("update user team: %w", err)
+	}
+
+	if err := dropHomeMemberships(ctx, tx, teamName); err != nil {
+		return models.User{}, err
+	}
+
+	for _, h := range handoffs {
+		if err := replaceReviewerTx(ctx, tx, h.PullRequestID, userID, h.NewReviewerID); err != nil {
+			return models.User{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.User{}, fmt.Errorf("commit: %w", err)
+	}
+	return u, nil
+}
+
 func (r *PostgresRepo) CreatePR(ctx context.Context, pr models.PullRequest) error {
+	if err := checkStatus(pr.Status); err != nil {
+		return err
+	}
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	// An ID is unique within its repository, archived PRs included.
+	if pr.ExternalID == "" {
+		pr.ExternalID = pr.PullRequestID
+	}
+	var live, archived bool
+	if err := tx.QueryRowContext(ctx, `SELECT
+		EXISTS (SELECT 1 FROM pull_requests WHERE repository=$1 AND external_id=$2),
+		EXISTS (SELECT 1 FROM archived_pull_requests WHERE repository=$1 AND external_id=$2)`,
+		pr.Repository, pr.ExternalID).Scan(&live, &archived); err != nil {
+		return fmt.Errorf("check pr: %w", err)
+	}
+	if live {
+		return fmt.Errorf("conflict: pr %s exists", pr.ExternalID)
+	}
+	if archived {
+		return fmt.Errorf("conflict: pr %s is archived", pr.ExternalID)
+	}
+	key, err := freePRKey(ctx, tx, pr)
+	if err != nil {
+		return err
+	}
+	pr.PullRequestID = key
+
 	_, err = tx.ExecContext(ctx,
-		`INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status, need_more_reviewers, created_at)
-         VALUES ($1,$2,$3,$4,$5,$6)`,
-		pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, pr.NeedMoreReviewers, pr.CreatedAt)
+		`INSERT INTO pull_requests(pull_request_id, external_id, pull_request_name, author_id, team_name, status, need_more_reviewers, created_at, labels, security_review, security_reviewer, priority, size, repository, target_branch, description)
+         VALUES ($1,$2,$3,$4,NULLIF($5,''),$6,$7,$8,COALESCE($9,'{}'),$10,NULLIF($11,''),COALESCE(NULLIF($12,''),'NORMAL'),NULLIF($13,''),$14,$15,$16)`,
+		pr.PullRequestID, pr.ExternalID, pr.PullRequestName, pr.AuthorID, pr.TeamName, pr.Status, pr.NeedMoreReviewers, pr.CreatedAt, pq.Array(pr.Labels), pr.SecurityReview, pr.SecurityReviewer, pr.Priority, pr.Size, pr.Repository, pr.TargetBranch, pr.Description)
 	if err != nil {
 		return fmt.Errorf("insert pr: %w", err)
 	}
+	if err := bumpTeamStat(ctx, tx, pr.PullRequestID, teamStatCreated); err != nil {
+		return err
+	}
 
 	if len(pr.Assigned) > 0 {
 		stmt, err := tx.PrepareContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES ($1,$2)`)
@@ -124,6 +411,12 @@ func (r *PostgresRepo) CreatePR(ctx context.Context, pr models.PullRequest) erro
 			if _, err := stmt.ExecContext(ctx, pr.PullRequestID, reviewer.UserID); err != nil {
 				return fmt.Errorf("insert reviewer: %w", err)
 			}
+			if err := adjustReviewerStats(ctx, tx, reviewer.UserID, 1); err != nil {
+				return err
+			}
+			if err := recordEvent(ctx, tx, pr.PullRequestID, reviewer.UserID, models.EventAssigned); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -133,28 +426,93 @@ func (r *PostgresRepo) CreatePR(ctx context.Context, pr models.PullRequest) erro
 	return nil
 }
 
+// freePRKey picks the row key of a new PR: its own ID if no PR, live or
+// archived, holds it yet, otherwise the ID qualified by the repository.
+func freePRKey(ctx context.Context, tx *sql.Tx, pr models.PullRequest) (string, error) {
+	key, qualified := pr.ExternalID, pr.ExternalID
+	if pr.Repository != "" {
+		qualified = pr.Repository + "#" + pr.ExternalID
+	}
+	for n := 1; ; n++ {
+		var taken bool
+		if err := tx.QueryRowContext(ctx, `SELECT
+			EXISTS (SELECT 1 FROM pull_requests WHERE pull_request_id=$1)
+			OR EXISTS (SELECT 1 FROM archived_pull_requests WHERE pull_request_id=$1)`, key).Scan(&taken); err != nil {
+			return "", fmt.Errorf("check pr key: %w", err)
+		}
+		if !taken {
+			return key, nil
+		}
+		if n == 1 && qualified != key {
+			key = qualified
+		} else {
+			key = qualified + "#" + strconv.Itoa(n)
+		}
+	}
+}
+
+// FindPR returns the row key of the PR created as id in repository, live or
+// archived.
+func (r *PostgresRepo) FindPR(ctx context.Context, repository, id string) (string, error) {
+	var key string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT pull_request_id FROM pull_requests WHERE repository=$1 AND external_id=$2
+		UNION ALL
+		SELECT pull_request_id FROM archived_pull_requests WHERE repository=$1 AND external_id=$2
+		LIMIT 1`, repository, id).Scan(&key)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("find pr: %w", err)
+	}
+	return key, nil
+}
+
 func (r *PostgresRepo) GetPR(ctx context.Context, prID string) (models.PullRequest, error) {
 	var pr models.PullRequest
-	var mergedAt sql.NullTime
+	var mergedAt, closedAt sql.NullTime
+	var teamName, securityReviewer sql.NullString
 
-	row := r.db.QueryRowContext(ctx, `SELECT pull_request_id, pull_request_name, author_id, status, need_more_reviewers, created_at, merged_at FROM pull_requests WHERE pull_request_id = $1`, prID)
-	if err := row.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.NeedMoreReviewers, &pr.CreatedAt, &mergedAt); err != nil {
+	row := r.db.QueryRowContext(ctx, `SELECT pull_request_id, external_id, pull_request_name, author_id, team_name, status, need_more_reviewers, created_at, merged_at, closed_at, labels, security_review, security_reviewer, priority, COALESCE(size, ''), repository, target_branch, description, COALESCE(merged_by, '') FROM pull_requests WHERE pull_request_id = $1`, prID)
+	if err := row.Scan(&pr.PullRequestID, &pr.ExternalID, &pr.PullRequestName, &pr.AuthorID, &teamName, &pr.Status, &pr.NeedMoreReviewers, &pr.CreatedAt, &mergedAt, &closedAt, pq.Array(&pr.Labels), &pr.SecurityReview, &securityReviewer, &pr.Priority, &pr.Size, &pr.Repository, &pr.TargetBranch, &pr.Description, &pr.MergedBy); err != nil {
 		if err == sql.ErrNoRows {
 			return pr, fmt.Errorf("not found")
 		}
 		return pr, fmt.Errorf("select pr: %w", err)
 	}
+	if err := checkStatus(pr.Status); err != nil {
+		return pr, err
+	}
+	pr.TeamName = teamName.String
+	pr.SecurityReviewer = securityReviewer.String
 	if mergedAt.Valid {
 		t := mergedAt.Time
 		pr.MergedAt = &t
 	}
+	if closedAt.Valid {
+		t := closedAt.Time
+		pr.ClosedAt = &t
+	}
 
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT u.user_id, u.username, u.is_active
-		FROM pr_reviewers rr
-		JOIN users u ON rr.user_id = u.user_id
-		WHERE rr.pull_request_id = $1
-		ORDER BY u.user_id
+		SELECT r.user_id, r.username, r.is_active, r.approved_at, r.assigned_at,
+			(SELECT MAX(e.created_at) FROM pr_events e
+			 WHERE e.pull_request_id = $1 AND e.user_id = r.user_id AND e.kind = 'acknowledged'
+			 AND e.created_at >= COALESCE(r.assigned_at, '-infinity')),
+			COALESCE(r.mentor_of, ''), r.role, COALESCE(r.decision, ''), r.decision_comment, r.decided_at
+		FROM (
+			SELECT u.user_id, u.username, u.is_active, a.approved_at, rr.role, rr.decision, rr.decision_comment, rr.decided_at,
+				(SELECT MIN(m.mentee_id) FROM mentorships m
+				 JOIN pr_reviewers mx ON mx.user_id = m.mentee_id AND mx.pull_request_id = rr.pull_request_id
+				 WHERE m.mentor_id = rr.user_id) AS mentor_of,
+				rr.assigned_at
+			FROM pr_reviewers rr
+			JOIN users u ON rr.user_id = u.user_id
+			LEFT JOIN pr_approvals a ON a.pull_request_id = rr.pull_request_id AND a.user_id = rr.user_id
+			WHERE rr.pull_request_id = $1
+		) r
+		ORDER BY r.user_id
 		`, prID)
 	if err != nil {
 		return pr, fmt.Errorf("query reviewers: %w", err)
@@ -164,163 +522,1635 @@ func (r *PostgresRepo) GetPR(ctx context.Context, prID string) (models.PullReque
 	revs := make([]models.PRReviewer, 0)
 	for rows.Next() {
 		var r models.PRReviewer
-		if err := rows.Scan(&r.UserID, &r.Username, &r.IsActive); err != nil {
+		var approvedAt, assignedAt, ackedAt, decidedAt sql.NullTime
+		var decision models.ReviewStatus
+		if err := rows.Scan(&r.UserID, &r.Username, &r.IsActive, &approvedAt, &assignedAt, &ackedAt, &r.MentorOf, &r.Role, &decision, &r.Comment, &decidedAt); err != nil {
 			return pr, fmt.Errorf("scan reviewer: %w", err)
 		}
+		r.Status = models.ReviewPending
+		if approvedAt.Valid {
+			r.Status = models.ReviewApproved
+			r.ApprovedAt = &approvedAt.Time
+		}
+		// Changes requested after approving take the approval back.
+		if decision == models.ReviewChangesRequested {
+			r.Status = models.ReviewChangesRequested
+		}
+		if decidedAt.Valid {
+			r.DecidedAt = &decidedAt.Time
+		}
+		if assignedAt.Valid {
+			r.AssignedAt = &assignedAt.Time
+		}
+		if ackedAt.Valid {
+			r.AcknowledgedAt = &ackedAt.Time
+		}
 		revs = append(revs, r)
 	}
 	if err := rows.Err(); err != nil {
 		return pr, fmt.Errorf("rows err: %w", err)
 	}
 	pr.Assigned = revs
+
+	approvals, err := r.getApprovals(ctx, prID)
+	if err != nil {
+		return pr, err
+	}
+	pr.Approvals = approvals
 	return pr, nil
 }
 
-func (r *PostgresRepo) MergePR(ctx context.Context, prID string, t time.Time) (models.PullRequest, error) {
-	if _, err := r.db.ExecContext(ctx, `UPDATE pull_requests SET status='MERGED', merged_at=$1 WHERE pull_request_id=$2`, t, prID); err != nil {
-		return models.PullRequest{}, fmt.Errorf("update merge: %w", err)
+func (r *PostgresRepo) getApprovals(ctx context.Context, prID string) ([]models.PRApproval, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, approved_at
+		FROM pr_approvals
+		WHERE pull_request_id = $1
+		ORDER BY approved_at, user_id
+		`, prID)
+	if err != nil {
+		return nil, fmt.Errorf("query approvals: %w", err)
 	}
-	return r.GetPR(ctx, prID)
+	defer rows.Close()
+
+	approvals := make([]models.PRApproval, 0)
+	for rows.Next() {
+		var a models.PRApproval
+		if err := rows.Scan(&a.UserID, &a.ApprovedAt); err != nil {
+			return nil, fmt.Errorf("scan approval: %w", err)
+		}
+		approvals = append(approvals, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return approvals, nil
 }
 
-func (r *PostgresRepo) ReplaceReviewer(ctx context.Context, prID, oldUID, newUID string) (models.PullRequest, error) {
+// MergePR merges an active PR, failing with a conflict when it was merged or
+// closed in the meantime.
+func (r *PostgresRepo) MergePR(ctx context.Context, prID, mergedBy string, t time.Time) (models.PullRequest, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return models.PullRequest{}, fmt.Errorf("begin tx: %w", err)
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	if oldUID != "" {
-		if _, err := tx.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2`, prID, oldUID); err != nil {
-			return models.PullRequest{}, fmt.Errorf("delete old reviewer: %w", err)
+	var authorID string
+	row := tx.QueryRowContext(ctx, `UPDATE pull_requests SET status='MERGED', merged_at=$1, merged_by=NULLIF($3,'') WHERE pull_request_id=$2 AND `+activeStatus("status")+` RETURNING author_id`, t, prID, mergedBy)
+	if err := row.Scan(&authorID); err != nil {
+		if err != sql.ErrNoRows {
+			return models.PullRequest{}, fmt.Errorf("update merge: %w", err)
 		}
-	}
-
-	if newUID != "" {
-		if _, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES ($1,$2)`, prID, newUID); err != nil {
-			return models.PullRequest{}, fmt.Errorf("insert new reviewer: %w", err)
+		var status models.PRStatus
+		if err := tx.QueryRowContext(ctx, `SELECT status FROM pull_requests WHERE pull_request_id=$1`, prID).Scan(&status); err != nil {
+			if err == sql.ErrNoRows {
+				return models.PullRequest{}, fmt.Errorf("not found")
+			}
+			return models.PullRequest{}, fmt.Errorf("check pr: %w", err)
 		}
+		return models.PullRequest{}, fmt.Errorf("conflict: pr %s is %s", prID, status)
 	}
-
-	if oldUID == "" && newUID == "" {
-		return models.PullRequest{}, fmt.Errorf("invalid replace: both old and new empty")
+	if err := recordEvent(ctx, tx, prID, authorID, models.EventMerged); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := bumpTeamStat(ctx, tx, prID, teamStatMerged); err != nil {
+		return models.PullRequest{}, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return models.PullRequest{}, fmt.Errorf("commit: %w", err)
 	}
+	return r.GetPR(ctx, prID)
+}
 
+// ClosePR closes an active PR, failing with a conflict when it was merged or
+// closed in the meantime.
+func (r *PostgresRepo) ClosePR(ctx context.Context, prID string, t time.Time) (models.PullRequest, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE pull_requests SET status='CLOSED', closed_at=$1 WHERE pull_request_id=$2 AND `+activeStatus("status"), t, prID)
+	if err != nil {
+		return models.PullRequest{}, fmt.Errorf("update close: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		var status models.PRStatus
+		if err := r.db.QueryRowContext(ctx, `SELECT status FROM pull_requests WHERE pull_request_id=$1`, prID).Scan(&status); err != nil {
+			if err == sql.ErrNoRows {
+				return models.PullRequest{}, fmt.Errorf("not found")
+			}
+			return models.PullRequest{}, fmt.Errorf("check pr: %w", err)
+		}
+		return models.PullRequest{}, fmt.Errorf("conflict: pr %s is %s", prID, status)
+	}
 	return r.GetPR(ctx, prID)
 }
 
-func (r *PostgresRepo) AddReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error) {
-	_, err := r.db.ExecContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES ($1,$2)`, prID, userID)
+// ReopenPR moves a closed PR back to OPEN, failing with a conflict when it
+// is no longer closed.
+func (r *PostgresRepo) ReopenPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE pull_requests SET status='OPEN', closed_at=NULL WHERE pull_request_id=$1 AND status='CLOSED'`, prID)
 	if err != nil {
-		return models.PullRequest{}, fmt.Errorf("insert reviewer: %w", err)
+		return models.PullRequest{}, fmt.Errorf("update reopen: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		var one int
+		if err := r.db.QueryRowContext(ctx, `SELECT 1 FROM pull_requests WHERE pull_request_id=$1`, prID).Scan(&one); err != nil {
+			if err == sql.ErrNoRows {
+				return models.PullRequest{}, fmt.Errorf("not found")
+			}
+			return models.PullRequest{}, fmt.Errorf("check pr: %w", err)
+		}
+		return models.PullRequest{}, fmt.Errorf("conflict: pr %s is no longer CLOSED", prID)
 	}
 	return r.GetPR(ctx, prID)
 }
 
-func (r *PostgresRepo) CleanupInactiveReviewers(ctx context.Context, prID string) error {
-	_, err := r.db.ExecContext(ctx, `
-        DELETE FROM pr_reviewers 
-        WHERE pull_request_id = $1 
-        AND user_id IN (SELECT user_id FROM users WHERE is_active = false)
-    `, prID)
+func (r *PostgresRepo) SetPRStatus(ctx context.Context, prID string, from, to models.PRStatus) error {
+	if err := checkStatus(to); err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE pull_requests SET status=$3 WHERE pull_request_id=$1 AND status=$2`, prID, from, to)
 	if err != nil {
-		return fmt.Errorf("cleanup inactive reviewers: %w", err)
+		return fmt.Errorf("update status: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		var one int
+		if err := r.db.QueryRowContext(ctx, `SELECT 1 FROM pull_requests WHERE pull_request_id=$1`, prID).Scan(&one); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("not found")
+			}
+			return fmt.Errorf("check pr: %w", err)
+		}
+		return fmt.Errorf("conflict: pr %s is no longer %s", prID, from)
 	}
 	return nil
 }
 
-func (r *PostgresRepo) GetActiveTeamMembersExcept(ctx context.Context, teamName, exceptUser string) ([]string, error) {
-	query := `SELECT user_id FROM users WHERE team_name=$1 AND is_active=true`
-	args := []interface{}{teamName}
-	if exceptUser != "" {
-		query += " AND user_id<>$2"
-		args = append(args, exceptUser)
+func (r *PostgresRepo) SetPRLabels(ctx context.Context, prID string, labels []string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE pull_requests SET labels=$2 WHERE pull_request_id=$1`, prID, pq.Array(labels))
+	if err != nil {
+		return fmt.Errorf("update labels: %w", err)
 	}
-	query += " ORDER BY user_id"
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+func (r *PostgresRepo) ReplaceReviewer(ctx context.Context, prID, oldUID, newUID string) (models.PullRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("query active members: %w", err)
+		return models.PullRequest{}, fmt.Errorf("begin tx: %w", err)
 	}
-	defer rows.Close()
+	defer func() { _ = tx.Rollback() }()
 
-	res := []string{}
-	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
-			return nil, fmt.Errorf("scan uid: %w", err)
-		}
-		res = append(res, uid)
+	if err := replaceReviewerTx(ctx, tx, prID, oldUID, newUID); err != nil {
+		return models.PullRequest{}, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows err: %w", err)
+	if err := tx.Commit(); err != nil {
+		return models.PullRequest{}, fmt.Errorf("commit: %w", err)
 	}
 
-	return res, nil
+	return r.GetPR(ctx, prID)
 }
 
-func (r *PostgresRepo) GetUserTeam(ctx context.Context, userID string) (string, error) {
-	var team string
-	row := r.db.QueryRowContext(ctx, `SELECT team_name FROM users WHERE user_id=$1`, userID)
-	if err := row.Scan(&team); err != nil {
-		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("not found")
+func replaceReviewerTx(ctx context.Context, tx *sql.Tx, prID, oldUID, newUID string) error {
+	if oldUID == "" && newUID == "" {
+		return fmt.Errorf("invalid replace: both old and new empty")
+	}
+
+	// The replacement inherits the role of the reviewer it replaces.
+	role := models.ReviewerRequired
+	removed := false
+	if oldUID != "" {
+		err := tx.QueryRowContext(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2 RETURNING role`, prID, oldUID).Scan(&role)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("delete old reviewer: %w", err)
+		}
+		if err == nil {
+			removed = true
+			if err := adjustReviewerStats(ctx, tx, oldUID, -1); err != nil {
+				return err
+			}
+			if err := recordEvent(ctx, tx, prID, oldUID, models.EventReassignedAway); err != nil {
+				return err
+			}
 		}
-		return "", fmt.Errorf("select user team: %w", err)
 	}
-	return team, nil
+
+	if newUID != "" {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id, role) VALUES ($1,$2,$3)`, prID, newUID, role); err != nil {
+			return fmt.Errorf("insert new reviewer: %w", err)
+		}
+		if err := adjustReviewerStats(ctx, tx, newUID, 1); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, tx, prID, newUID, models.EventAssigned); err != nil {
+			return err
+		}
+		if removed {
+			if err := bumpTeamStat(ctx, tx, prID, teamStatReassigned); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-func (r *PostgresRepo) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
-		FROM pull_requests pr
-		JOIN pr_reviewers rr ON pr.pull_request_id = rr.pull_request_id
-		WHERE rr.user_id = $1
-		ORDER BY pr.created_at DESC
-	`, userID)
+func (r *PostgresRepo) AddSecondaryReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("query prs by reviewer: %w", err)
+		return models.PullRequest{}, fmt.Errorf("begin tx: %w", err)
 	}
-	defer rows.Close()
+	defer func() { _ = tx.Rollback() }()
 
-	res := []models.PullRequestShort{}
-	for rows.Next() {
-		var p models.PullRequestShort
-		if err := rows.Scan(&p.PullRequestID, &p.PullRequestName, &p.AuthorID, &p.Status); err != nil {
-			return nil, fmt.Errorf("scan pr short: %w", err)
-		}
-		res = append(res, p)
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO pr_reviewers(pull_request_id, user_id, role) VALUES ($1,$2,'secondary')
+		ON CONFLICT DO NOTHING`, prID, userID)
+	if err != nil {
+		return models.PullRequest{}, fmt.Errorf("insert secondary reviewer: %w", err)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows err: %w", err)
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return models.PullRequest{}, fmt.Errorf("conflict")
 	}
-	return res, nil
-}
-
+	if err := adjustReviewerStats(ctx, tx, userID, 1); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := recordEvent(ctx, tx, prID, userID, models.EventAssigned); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PullRequest{}, fmt.Errorf("commit: %w", err)
+	}
+	return r.GetPR(ctx, prID)
+}
+
+func (r *PostgresRepo) AddReviewer(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.PullRequest{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES ($1,$2)`, prID, userID); err != nil {
+		return models.PullRequest{}, fmt.Errorf("insert reviewer: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, needMoreReviewersUpdate, prID, wantReviewers); err != nil {
+		return models.PullRequest{}, fmt.Errorf("recompute need_more_reviewers: %w", err)
+	}
+	if err := adjustReviewerStats(ctx, tx, userID, 1); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := recordEvent(ctx, tx, prID, userID, models.EventAssigned); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PullRequest{}, fmt.Errorf("commit: %w", err)
+	}
+	return r.GetPR(ctx, prID)
+}
+
+func (r *PostgresRepo) ApprovePR(ctx context.Context, prID, userID string, t time.Time) (models.PullRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.PullRequest{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO pr_approvals(pull_request_id, user_id, approved_at) VALUES ($1,$2,$3)
+		ON CONFLICT (pull_request_id, user_id) DO NOTHING
+	`, prID, userID, t)
+	if err != nil {
+		return models.PullRequest{}, fmt.Errorf("insert approval: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected > 0 {
+		if err := recordEvent(ctx, tx, prID, userID, models.EventApproved); err != nil {
+			return models.PullRequest{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PullRequest{}, fmt.Errorf("commit: %w", err)
+	}
+	return r.GetPR(ctx, prID)
+}
+
+// RecordReviewDecision stores the reviewer's latest decision on the PR and
+// the comment that came with it.
+func (r *PostgresRepo) RecordReviewDecision(ctx context.Context, prID, userID string, decision models.ReviewStatus, comment string, t time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE pr_reviewers SET decision=$3, decision_comment=$4, decided_at=$5
+		WHERE pull_request_id=$1 AND user_id=$2
+	`, prID, userID, decision, comment, t)
+	if err != nil {
+		return fmt.Errorf("update review decision: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not assigned")
+	}
+	return nil
+}
+
+// AcknowledgeReview records that the reviewer has seen their assignment.
+// A second acknowledgment of the same assignment is a no-op; after a
+// reassignment back to the user a new one is recorded.
+func (r *PostgresRepo) AcknowledgeReview(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	var one int
+	err := r.db.QueryRowContext(ctx, `SELECT 1 FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2`, prID, userID).Scan(&one)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.PullRequest{}, fmt.Errorf("not assigned")
+		}
+		return models.PullRequest{}, fmt.Errorf("select reviewer: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO pr_events(pull_request_id, user_id, kind)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM pr_events a
+			WHERE a.pull_request_id = $1 AND a.user_id = $2 AND a.kind = $3
+			AND a.created_at >= COALESCE((
+				SELECT MAX(e.created_at) FROM pr_events e
+				WHERE e.pull_request_id = $1 AND e.user_id = $2 AND e.kind = 'assigned'), '-infinity'))
+	`, prID, userID, models.EventAcknowledged); err != nil {
+		return models.PullRequest{}, fmt.Errorf("insert acknowledgment: %w", err)
+	}
+	return r.GetPR(ctx, prID)
+}
+
+// GetPendingReviews lists reviewers of open PRs who have not approved yet,
+// with when they were assigned, whether they acknowledged it and the PR's
+// current review round.
+func (r *PostgresRepo) GetPendingReviews(ctx context.Context) ([]models.PendingReview, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.pull_request_id, p.pull_request_name, p.team_name, p.user_id, p.assigned_at,
+			(SELECT MAX(e.created_at) FROM pr_events e
+			 WHERE e.pull_request_id = p.pull_request_id AND e.user_id = p.user_id AND e.kind = 'acknowledged'
+			 AND e.created_at >= p.assigned_at),
+			rnd.started_at, rnd.due_at
+		FROM (
+			SELECT pr.pull_request_id, pr.pull_request_name, rr.user_id, rr.assigned_at,
+				COALESCE(pr.team_name, au.team_name, '') AS team_name
+			FROM pr_reviewers rr
+			JOIN pull_requests pr ON pr.pull_request_id = rr.pull_request_id
+			JOIN users au ON au.user_id = pr.author_id
+			WHERE `+activeStatus("pr.status")+`
+			AND NOT EXISTS (SELECT 1 FROM pr_approvals a
+				WHERE a.pull_request_id = rr.pull_request_id AND a.user_id = rr.user_id)
+		) p
+		LEFT JOIN LATERAL (
+			SELECT r.started_at, r.due_at FROM review_rounds r
+			WHERE r.pull_request_id = p.pull_request_id
+			ORDER BY r.round DESC LIMIT 1) rnd ON TRUE
+		ORDER BY p.assigned_at, p.pull_request_id, p.user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query pending reviews: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.PendingReview{}
+	for rows.Next() {
+		var p models.PendingReview
+		var ackedAt, roundStart, roundDue sql.NullTime
+		if err := rows.Scan(&p.PullRequestID, &p.PullRequestName, &p.TeamName, &p.UserID, &p.AssignedAt, &ackedAt, &roundStart, &roundDue); err != nil {
+			return nil, fmt.Errorf("scan pending review: %w", err)
+		}
+		if ackedAt.Valid {
+			p.AcknowledgedAt = &ackedAt.Time
+		}
+		if roundStart.Valid {
+			p.RoundStartedAt = &roundStart.Time
+		}
+		if roundDue.Valid {
+			p.RoundDueAt = &roundDue.Time
+		}
+		res = append(res, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+// GetAssignmentRecords returns assignments made in [from, to) with the
+// reviewer's approval, the PR's merge and whether the reviewer was removed
+// later.
+func (r *PostgresRepo) GetStaleReviews(ctx context.Context, inactiveBefore time.Time) ([]models.PendingReview, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, COALESCE(pr.team_name, au.team_name, ''), rr.user_id, rr.assigned_at
+		FROM pr_reviewers rr
+		JOIN pull_requests pr ON pr.pull_request_id = rr.pull_request_id
+		JOIN users u ON u.user_id = rr.user_id
+		JOIN users au ON au.user_id = pr.author_id
+		WHERE `+activeStatus("pr.status")+` AND NOT u.is_active AND u.inactive_since < $1
+		AND NOT EXISTS (SELECT 1 FROM pr_approvals a
+			WHERE a.pull_request_id = rr.pull_request_id AND a.user_id = rr.user_id)
+		ORDER BY rr.assigned_at, pr.pull_request_id, rr.user_id
+	`, inactiveBefore)
+	if err != nil {
+		return nil, fmt.Errorf("query stale reviews: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.PendingReview{}
+	for rows.Next() {
+		var p models.PendingReview
+		if err := rows.Scan(&p.PullRequestID, &p.PullRequestName, &p.TeamName, &p.UserID, &p.AssignedAt); err != nil {
+			return nil, fmt.Errorf("scan stale review: %w", err)
+		}
+		res = append(res, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) GetAssignmentRecords(ctx context.Context, from, to time.Time) ([]models.AssignmentRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pr.pull_request_id, COALESCE(pr.team_name, ''), pr.author_id, e.user_id,
+			pr.created_at, e.created_at, a.approved_at, pr.merged_at, pr.status,
+			EXISTS (SELECT 1 FROM pr_events x
+				WHERE x.pull_request_id = e.pull_request_id AND x.user_id = e.user_id
+				AND x.kind IN ('unassigned', 'reassigned_away') AND x.created_at >= e.created_at)
+		FROM pr_events e
+		JOIN pull_requests pr ON pr.pull_request_id = e.pull_request_id
+		LEFT JOIN pr_approvals a ON a.pull_request_id = e.pull_request_id AND a.user_id = e.user_id
+		WHERE e.kind = 'assigned' AND e.created_at >= $1 AND e.created_at < $2
+		ORDER BY e.created_at, e.id
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query assignment records: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.AssignmentRecord{}
+	for rows.Next() {
+		var rec models.AssignmentRecord
+		var approvedAt, mergedAt sql.NullTime
+		if err := rows.Scan(&rec.PullRequestID, &rec.TeamName, &rec.AuthorID, &rec.ReviewerID,
+			&rec.PRCreatedAt, &rec.AssignedAt, &approvedAt, &mergedAt, &rec.Status, &rec.Removed); err != nil {
+			return nil, fmt.Errorf("scan assignment record: %w", err)
+		}
+		if approvedAt.Valid {
+			rec.ApprovedAt = &approvedAt.Time
+		}
+		if mergedAt.Valid {
+			rec.MergedAt = &mergedAt.Time
+		}
+		res = append(res, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) RemoveReviewer(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.PullRequest{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2`, prID, userID)
+	if err != nil {
+		return models.PullRequest{}, fmt.Errorf("delete reviewer: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return models.PullRequest{}, fmt.Errorf("not assigned")
+	}
+	if err := adjustReviewerStats(ctx, tx, userID, -1); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := recordEvent(ctx, tx, prID, userID, models.EventUnassigned); err != nil {
+		return models.PullRequest{}, err
+	}
+	if _, err := tx.ExecContext(ctx, needMoreReviewersUpdate, prID, wantReviewers); err != nil {
+		return models.PullRequest{}, fmt.Errorf("recompute need_more_reviewers: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PullRequest{}, fmt.Errorf("commit: %w", err)
+	}
+	return r.GetPR(ctx, prID)
+}
+
+func (r *PostgresRepo) CleanupInactiveReviewers(ctx context.Context, prID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `
+        DELETE FROM pr_reviewers 
+        WHERE pull_request_id = $1 
+        AND user_id IN (SELECT user_id FROM users WHERE is_active = false)
+        RETURNING user_id
+    `, prID)
+	if err != nil {
+		return fmt.Errorf("cleanup inactive reviewers: %w", err)
+	}
+	var removed []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan removed reviewer: %w", err)
+		}
+		removed = append(removed, uid)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows err: %w", err)
+	}
+
+	for _, uid := range removed {
+		if err := adjustReviewerStats(ctx, tx, uid, -1); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, tx, prID, uid, models.EventUnassigned); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// pairedMentor matches a reviewer row rv whose mentee also reviews the PR.
+// Such a mentor shares the mentee's slot and does not count towards the
+// reviewer limit.
+const pairedMentor = `EXISTS (SELECT 1 FROM mentorships m
+	JOIN pr_reviewers mx ON mx.user_id = m.mentee_id AND mx.pull_request_id = rv.pull_request_id
+	WHERE m.mentor_id = rv.user_id)`
+
+// needMoreReviewersUpdate recomputes need_more_reviewers of PR $1 against
+// the wanted number of required slots $2.
+const needMoreReviewersUpdate = `
+	UPDATE pull_requests
+	SET need_more_reviewers = (SELECT COUNT(*) FROM pr_reviewers rv
+		WHERE rv.pull_request_id=$1 AND rv.role = 'required' AND NOT ` + pairedMentor + `) < $2
+	WHERE pull_request_id=$1`
+
+// recordEvent appends to the per-user activity log, and assignment changes
+// also to the assignment history. Like the stats counter it runs in the
+// caller's transaction, so rolled back changes leave no trace.
+func recordEvent(ctx context.Context, tx *sql.Tx, prID, userID, kind string) error {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO pr_events(pull_request_id, user_id, kind) VALUES ($1,$2,$3)`, prID, userID, kind); err != nil {
+		return fmt.Errorf("insert event: %w", err)
+	}
+	switch kind {
+	case models.EventAssigned, models.EventUnassigned, models.EventReassignedAway:
+		return recordAssignment(ctx, tx, prID, userID, kind)
+	}
+	return nil
+}
+
+// adjustReviewerStats applies delta to the user's assignment counter. It must
+// run in the same transaction that changes pr_reviewers so the counter never
+// drifts from the table it summarises.
+func adjustReviewerStats(ctx context.Context, tx *sql.Tx, userID string, delta int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO reviewer_stats(user_id, assigned_count) VALUES ($1, GREATEST($2, 0))
+		ON CONFLICT (user_id) DO UPDATE SET assigned_count = GREATEST(reviewer_stats.assigned_count + $2, 0)
+	`, userID, delta)
+	if err != nil {
+		return fmt.Errorf("update reviewer stats: %w", err)
+	}
+	return nil
+}
+
+// Columns of team_stats that bumpTeamStat may increment.
+const (
+	teamStatCreated    = "created_count"
+	teamStatMerged     = "merged_count"
+	teamStatReassigned = "reassigned_count"
+)
+
+// bumpTeamStat increments one of the counters of the PR's team. Like
+// adjustReviewerStats it must run in the transaction that makes the change
+// it counts. PRs without a team are not counted.
+func bumpTeamStat(ctx context.Context, tx *sql.Tx, prID, column string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO team_stats(team_name, `+column+`)
+		SELECT team_name, 1 FROM pull_requests WHERE pull_request_id = $1 AND team_name IS NOT NULL
+		ON CONFLICT (team_name) DO UPDATE SET `+column+` = team_stats.`+column+` + 1
+	`, prID)
+	if err != nil {
+		return fmt.Errorf("update team stats: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetActiveTeamMembersExcept(ctx context.Context, teamName, exceptUser string) ([]string, error) {
+	query, args := newSelect(`SELECT u.user_id FROM users u`).
+		Where(`u.team_name = ? OR EXISTS (
+			SELECT 1 FROM team_memberships m
+			WHERE m.user_id = u.user_id AND m.team_name = ? AND m.role = 'member')`, teamName, teamName).
+		Where(`u.is_active`).
+		WhereIf(exceptUser != "", `u.user_id <> ?`, exceptUser).
+		OrderBy(`u.user_id`).
+		Build()
+	res, err := queryUserIDs(ctx, r.db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query active members: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) GetCandidateSignals(ctx context.Context, teamName, authorID string) ([]models.CandidateSignals, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.user_id, u.username, u.is_active,
+			COUNT(DISTINCT pr.pull_request_id) FILTER (WHERE `+activeStatus("pr.status")+`) AS open_reviews,
+			COUNT(DISTINCT pr.pull_request_id) FILTER (WHERE pr.author_id = $2) AS author_reviews,
+			MAX(pr.created_at) AS last_review_at
+		FROM users u
+		LEFT JOIN pr_reviewers rr ON rr.user_id = u.user_id
+		LEFT JOIN pull_requests pr ON pr.pull_request_id = rr.pull_request_id
+		WHERE (u.team_name = $1 OR EXISTS (
+			SELECT 1 FROM team_memberships m
+			WHERE m.user_id = u.user_id AND m.team_name = $1 AND m.role = 'member'))
+		AND u.user_id <> $2
+		GROUP BY u.user_id, u.username, u.is_active
+		ORDER BY u.user_id
+	`, teamName, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("query candidate signals: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.CandidateSignals{}
+	for rows.Next() {
+		var c models.CandidateSignals
+		var lastReview sql.NullTime
+		if err := rows.Scan(&c.UserID, &c.Username, &c.IsActive, &c.OpenReviews, &c.AuthorReviews, &lastReview); err != nil {
+			return nil, fmt.Errorf("scan candidate signals: %w", err)
+		}
+		if lastReview.Valid {
+			t := lastReview.Time
+			c.LastReviewAt = &t
+		}
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) GetActiveMembersOfTeams(ctx context.Context, teamNames []string, exceptUser string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT u.user_id FROM users u
+		WHERE (u.team_name = ANY($1) OR EXISTS (
+			SELECT 1 FROM team_memberships m
+			WHERE m.user_id=u.user_id AND m.team_name = ANY($1) AND m.role='member'))
+		AND u.is_active=true AND u.user_id<>$2
+		ORDER BY u.user_id`, pq.Array(teamNames), exceptUser)
+	if err != nil {
+		return nil, fmt.Errorf("query active members of teams: %w", err)
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, fmt.Errorf("scan uid: %w", err)
+		}
+		res = append(res, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+// sizeEffortSQL weighs a PR by the effort of its size in SQL, spelled out
+// from models.PRSize.Effort so the two can't drift apart.
+var sizeEffortSQL = func() string {
+	var sb strings.Builder
+	sb.WriteString("CASE pr.size")
+	for _, size := range models.PRSizes {
+		fmt.Fprintf(&sb, " WHEN '%s' THEN %d", size, size.Effort())
+	}
+	fmt.Fprintf(&sb, " ELSE %d END", models.PRSize("").Effort())
+	return sb.String()
+}()
+
+func (r *PostgresRepo) GetOpenReviewLoad(ctx context.Context, teamName string) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rv.user_id, SUM(`+sizeEffortSQL+`)
+		FROM (
+			SELECT DISTINCT u.user_id, rr.pull_request_id
+			FROM users u
+			JOIN pr_reviewers rr ON rr.user_id = u.user_id
+			WHERE u.team_name = $1 OR EXISTS (
+				SELECT 1 FROM team_memberships m
+				WHERE m.user_id = u.user_id AND m.team_name = $1 AND m.role = 'member')
+		) rv
+		JOIN pull_requests pr ON pr.pull_request_id = rv.pull_request_id AND `+activeStatus("pr.status")+`
+		GROUP BY rv.user_id
+	`, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("query open review load: %w", err)
+	}
+	defer rows.Close()
+
+	res := map[string]int{}
+	for rows.Next() {
+		var userID string
+		var n int
+		if err := rows.Scan(&userID, &n); err != nil {
+			return nil, fmt.Errorf("scan open review load: %w", err)
+		}
+		res[userID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) GetReviewLoadBySize(ctx context.Context, teamName string) ([]models.ReviewerLoad, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rv.user_id, COALESCE(pr.size, ''), COUNT(*)
+		FROM (
+			SELECT DISTINCT u.user_id, rr.pull_request_id
+			FROM users u
+			JOIN pr_reviewers rr ON rr.user_id = u.user_id
+			WHERE u.team_name = $1 OR EXISTS (
+				SELECT 1 FROM team_memberships m
+				WHERE m.user_id = u.user_id AND m.team_name = $1 AND m.role = 'member')
+		) rv
+		JOIN pull_requests pr ON pr.pull_request_id = rv.pull_request_id AND `+activeStatus("pr.status")+`
+		GROUP BY rv.user_id, pr.size
+	`, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("query review load by size: %w", err)
+	}
+	defer rows.Close()
+
+	byUser := map[string]*models.ReviewerLoad{}
+	for rows.Next() {
+		var userID, size string
+		var n int
+		if err := rows.Scan(&userID, &size, &n); err != nil {
+			return nil, fmt.Errorf("scan review load by size: %w", err)
+		}
+		l, ok := byUser[userID]
+		if !ok {
+			l = &models.ReviewerLoad{UserID: userID, BySize: map[string]int{}}
+			byUser[userID] = l
+		}
+		l.BySize[size] += n
+		l.Open += n
+		l.Effort += n * models.PRSize(size).Effort()
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+
+	res := make([]models.ReviewerLoad, 0, len(byUser))
+	for _, l := range byUser {
+		res = append(res, *l)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Effort != res[j].Effort {
+			return res[i].Effort > res[j].Effort
+		}
+		return res[i].UserID < res[j].UserID
+	})
+	return res, nil
+}
+
+func (r *PostgresRepo) GetRampUpLoads(ctx context.Context, joinedAfter time.Time, maxAssigned int) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.user_id,
+			(SELECT COUNT(*) FROM pr_reviewers rv
+				JOIN pull_requests pr ON pr.pull_request_id = rv.pull_request_id
+				WHERE rv.user_id = u.user_id AND `+activeStatus("pr.status")+`)
+		FROM users u
+		WHERE u.joined_at > $1
+			AND ($2 = 0 OR (SELECT COUNT(*) FROM pr_events e
+				WHERE e.user_id = u.user_id AND e.kind = 'assigned' AND e.created_at >= u.joined_at) < $2)
+	`, joinedAfter, maxAssigned)
+	if err != nil {
+		return nil, fmt.Errorf("query ramp-up loads: %w", err)
+	}
+	defer rows.Close()
+
+	res := map[string]int{}
+	for rows.Next() {
+		var userID string
+		var n int
+		if err := rows.Scan(&userID, &n); err != nil {
+			return nil, fmt.Errorf("scan ramp-up load: %w", err)
+		}
+		res[userID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) StartEscalation(ctx context.Context, prID, reason string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO pr_escalations(pull_request_id, reason) VALUES ($1, $2)
+		ON CONFLICT (pull_request_id, reason) DO NOTHING
+	`, prID, reason); err != nil {
+		return fmt.Errorf("insert escalation: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) StartSLAEscalations(ctx context.Context, cutoff time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO pr_escalations(pull_request_id, reason)
+		SELECT pull_request_id, 'sla' FROM pull_requests
+		WHERE `+activeStatus("status")+` AND created_at < $1
+		ON CONFLICT (pull_request_id, reason) DO NOTHING
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("insert sla escalations: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+func (r *PostgresRepo) GetActiveEscalations(ctx context.Context) ([]models.Escalation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, COALESCE(pr.team_name, ''), e.reason, e.started_at, e.next_hop,
+			ARRAY(SELECT rr.user_id FROM pr_reviewers rr WHERE rr.pull_request_id = pr.pull_request_id ORDER BY rr.user_id)
+		FROM pr_escalations e
+		JOIN pull_requests pr ON pr.pull_request_id = e.pull_request_id
+		WHERE `+activeStatus("pr.status")+` AND (e.reason <> 'reassign_failed' OR pr.need_more_reviewers)
+		ORDER BY e.started_at, pr.pull_request_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query escalations: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.Escalation{}
+	for rows.Next() {
+		var e models.Escalation
+		if err := rows.Scan(&e.PullRequestID, &e.PullRequestName, &e.TeamName, &e.Reason, &e.StartedAt, &e.NextHop,
+			pq.Array(&e.Reviewers)); err != nil {
+			return nil, fmt.Errorf("scan escalation: %w", err)
+		}
+		res = append(res, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) AdvanceEscalation(ctx context.Context, prID, reason string, hop int, recipients []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE pr_escalations SET next_hop = $3 + 1
+		WHERE pull_request_id = $1 AND reason = $2 AND next_hop = $3
+	`, prID, reason, hop)
+	if err != nil {
+		return fmt.Errorf("update escalation: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("conflict")
+	}
+	for _, userID := range recipients {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO pr_events(pull_request_id, user_id, kind) VALUES ($1,$2,$3)`,
+			prID, userID, models.EventEscalated); err != nil {
+			return fmt.Errorf("insert escalation event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetUserTeam(ctx context.Context, userID string) (string, error) {
+	var team string
+	row := r.db.QueryRowContext(ctx, `SELECT team_name FROM users WHERE user_id=$1 AND team_name IS NOT NULL`, userID)
+	if err := row.Scan(&team); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("not found")
+		}
+		return "", fmt.Errorf("select user team: %w", err)
+	}
+	return team, nil
+}
+
+func (r *PostgresRepo) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	query, args := newSelect(prShortSelect+` JOIN pr_reviewers rr ON pr.pull_request_id = rr.pull_request_id`).
+		Where(`rr.user_id = ?`, userID).
+		OrderBy(`pr.created_at DESC`).
+		Build()
+	res, err := queryPRShorts(ctx, r.db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query prs by reviewer: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) GetPRsByAuthor(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	query, args := newSelect(prShortSelect).
+		Where(`pr.author_id = ?`, userID).
+		OrderBy(`pr.created_at DESC`).
+		Build()
+	res, err := queryPRShorts(ctx, r.db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query prs by author: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) ListPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+	if filter.Status != "" {
+		if err := checkStatus(filter.Status); err != nil {
+			return nil, err
+		}
+	}
+	query, args := newSelect(prShortSelect).
+		WhereIf(filter.Status != "", `pr.status = ?`, filter.Status).
+		WhereIf(filter.TeamName != "", `pr.team_name = ?`, filter.TeamName).
+		WhereIf(filter.AuthorID != "", `pr.author_id = ?`, filter.AuthorID).
+		WhereIf(filter.Label != "", `pr.labels @> ARRAY[?]::text[]`, filter.Label).
+		WhereIf(filter.Repository != "", `pr.repository = ?`, filter.Repository).
+		OrderBy(`pr.created_at DESC, pr.pull_request_id`).
+		Page(page).
+		Build()
+	res, err := queryPRShorts(ctx, r.db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query prs: %w", err)
+	}
+	return res, nil
+}
+
+// SearchPRs matches query case-insensitively as a substring of the PR name,
+// the author's user_id or username. The team, label and repository of
+// filter limit results when non-empty.
+func (r *PostgresRepo) SearchPRs(ctx context.Context, query string, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	q, args := newSelect(prShortSelect+` LEFT JOIN users u ON u.user_id = pr.author_id`).
+		Where(`pr.pull_request_name ILIKE ? OR pr.author_id ILIKE ? OR u.username ILIKE ?`, pattern, pattern, pattern).
+		WhereIf(filter.TeamName != "", `pr.team_name = ?`, filter.TeamName).
+		WhereIf(filter.Label != "", `pr.labels @> ARRAY[?]::text[]`, filter.Label).
+		WhereIf(filter.Repository != "", `pr.repository = ?`, filter.Repository).
+		OrderBy(`pr.created_at DESC, pr.pull_request_id`).
+		Page(page).
+		Build()
+	res, err := queryPRShorts(ctx, r.db, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search prs: %w", err)
+	}
+	return res, nil
+}
+
+// prShortSelect selects the columns queryPRShorts scans from pull_requests
+// aliased pr.
+const prShortSelect = `SELECT pr.pull_request_id, pr.external_id, pr.pull_request_name, pr.author_id, COALESCE(pr.team_name, ''), pr.repository, pr.target_branch, pr.status, pr.priority FROM pull_requests pr`
+
+func queryPRShorts(ctx context.Context, q queryer, query string, args ...any) ([]models.PullRequestShort, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.PullRequestShort{}
+	for rows.Next() {
+		var p models.PullRequestShort
+		if err := rows.Scan(&p.PullRequestID, &p.ExternalID, &p.PullRequestName, &p.AuthorID, &p.TeamName, &p.Repository, &p.TargetBranch, &p.Status, &p.Priority); err != nil {
+			return nil, fmt.Errorf("scan pr short: %w", err)
+		}
+		if err := checkStatus(p.Status); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+// likeEscaper makes user input match literally inside a LIKE pattern
+// (backslash is the default escape character in PostgreSQL).
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 func (r *PostgresRepo) GetUser(ctx context.Context, userID string) (models.User, error) {
 	var u models.User
-	row := r.db.QueryRowContext(ctx, `SELECT user_id, username, team_name, is_active FROM users WHERE user_id=$1`, userID)
+	row := r.db.QueryRowContext(ctx, `SELECT user_id, username, COALESCE(team_name, ''), is_active FROM users WHERE user_id=$1`, userID)
 	if err := row.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
 		if err == sql.ErrNoRows {
-			return u, fmt.Errorf("not found")
+			return u, fmt.Errorf("not found")
+		}
+		return u, fmt.Errorf("select user: %w", err)
+	}
+	return u, nil
+}
+
+func (r *PostgresRepo) GetUserProfile(ctx context.Context, userID string) (models.UserProfile, error) {
+	var p models.UserProfile
+	row := r.db.QueryRowContext(ctx, `
+		SELECT u.user_id, u.username, COALESCE(u.team_name, ''), u.is_active,
+			(SELECT COUNT(*) FROM pr_reviewers rv
+				JOIN pull_requests pr ON pr.pull_request_id = rv.pull_request_id
+				WHERE rv.user_id = u.user_id AND `+activeStatus("pr.status")+`),
+			(SELECT COUNT(*) FROM pull_requests pr
+				WHERE pr.author_id = u.user_id AND `+activeStatus("pr.status")+`),
+			ARRAY(SELECT s.skill FROM user_skills s WHERE s.user_id = u.user_id ORDER BY s.skill),
+			u.vacation_from, u.vacation_until,
+			COALESCE((SELECT m.mentor_id FROM mentorships m WHERE m.mentee_id = u.user_id), ''),
+			u.timezone, u.joined_at, u.weight, u.notify_merged
+		FROM users u
+		WHERE u.user_id = $1`, userID)
+	var vacFrom, vacUntil, joinedAt sql.NullTime
+	if err := row.Scan(&p.UserID, &p.Username, &p.TeamName, &p.IsActive, &p.OpenReviews, &p.OpenAuthored, pq.Array(&p.Skills), &vacFrom, &vacUntil, &p.Mentor, &p.Timezone, &joinedAt, &p.Weight, &p.Notifications.Merged); err != nil {
+		if err == sql.ErrNoRows {
+			return p, fmt.Errorf("not found")
+		}
+		return p, fmt.Errorf("select user profile: %w", err)
+	}
+	if vacFrom.Valid && vacUntil.Valid {
+		p.Vacation = &models.Vacation{From: vacFrom.Time, Until: vacUntil.Time}
+	}
+	if joinedAt.Valid {
+		p.JoinedAt = &joinedAt.Time
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT team_name, user_id, role FROM team_memberships WHERE user_id=$1 ORDER BY team_name`, userID)
+	if err != nil {
+		return p, fmt.Errorf("query memberships: %w", err)
+	}
+	defer rows.Close()
+	p.Memberships = []models.Membership{}
+	for rows.Next() {
+		var m models.Membership
+		if err := rows.Scan(&m.TeamName, &m.UserID, &m.Role); err != nil {
+			return p, fmt.Errorf("scan membership: %w", err)
+		}
+		p.Memberships = append(p.Memberships, m)
+	}
+	if err := rows.Err(); err != nil {
+		return p, fmt.Errorf("rows err: %w", err)
+	}
+	return p, nil
+}
+
+func (r *PostgresRepo) GetUserStats(ctx context.Context, userID string, since time.Time) (models.UserStats, error) {
+	var st models.UserStats
+	var avg sql.NullFloat64
+	row := r.db.QueryRowContext(ctx, `
+		SELECT u.user_id, u.username, COALESCE(u.team_name, ''), u.is_active,
+			(SELECT COUNT(*) FROM pr_reviewers rv
+				JOIN pull_requests pr ON pr.pull_request_id = rv.pull_request_id
+				WHERE rv.user_id = u.user_id AND `+activeStatus("pr.status")+`),
+			(SELECT AVG(EXTRACT(EPOCH FROM a.approved_at - GREATEST(asg.assigned_at, rnd.started_at)))
+				FROM pr_approvals a
+				CROSS JOIN LATERAL (
+					SELECT MAX(e.created_at) AS assigned_at FROM pr_events e
+					WHERE e.pull_request_id = a.pull_request_id AND e.user_id = a.user_id
+					AND e.kind = 'assigned' AND e.created_at <= a.approved_at) asg
+				CROSS JOIN LATERAL (
+					SELECT MAX(r.started_at) AS started_at FROM review_rounds r
+					WHERE r.pull_request_id = a.pull_request_id AND r.started_at <= a.approved_at) rnd
+				WHERE a.user_id = u.user_id AND a.approved_at >= date_trunc('week', $2::timestamp)
+				AND asg.assigned_at IS NOT NULL),
+			(SELECT COUNT(*) FROM pr_events e
+				WHERE e.user_id = u.user_id AND e.kind IN ('unassigned', 'reassigned_away')
+				AND e.created_at >= date_trunc('week', $2::timestamp)),
+			(SELECT COUNT(*) FROM pull_requests pr
+				WHERE pr.merged_by = u.user_id AND pr.merged_at >= date_trunc('week', $2::timestamp))
+		FROM users u
+		WHERE u.user_id = $1`, userID, since)
+	if err := row.Scan(&st.UserID, &st.Username, &st.TeamName, &st.IsActive, &st.OpenReviews, &avg, &st.Declines, &st.Merged); err != nil {
+		if err == sql.ErrNoRows {
+			return st, fmt.Errorf("not found")
+		}
+		return st, fmt.Errorf("select user stats: %w", err)
+	}
+	st.AvgTurnaroundSec = avg.Float64
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT w.start, COUNT(a.user_id)
+		FROM generate_series(date_trunc('week', $2::timestamp), date_trunc('week', NOW()::timestamp), interval '1 week') AS w(start)
+		LEFT JOIN pr_approvals a ON a.user_id = $1
+			AND a.approved_at >= w.start AND a.approved_at < w.start + interval '1 week'
+		GROUP BY w.start
+		ORDER BY w.start`, userID, since)
+	if err != nil {
+		return st, fmt.Errorf("query review buckets: %w", err)
+	}
+	defer rows.Close()
+	st.Completed = []models.ReviewBucket{}
+	for rows.Next() {
+		var b models.ReviewBucket
+		if err := rows.Scan(&b.Start, &b.Count); err != nil {
+			return st, fmt.Errorf("scan review bucket: %w", err)
+		}
+		st.Completed = append(st.Completed, b)
+	}
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("rows err: %w", err)
+	}
+	if len(st.Completed) > 0 {
+		st.Since = st.Completed[0].Start
+	}
+	return st, nil
+}
+
+func (r *PostgresRepo) SetMentor(ctx context.Context, menteeID, mentorID string) error {
+	if mentorID == "" {
+		if _, err := r.db.ExecContext(ctx, `DELETE FROM mentorships WHERE mentee_id = $1`, menteeID); err != nil {
+			return fmt.Errorf("delete mentorship: %w", err)
+		}
+		return nil
+	}
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO mentorships(mentee_id, mentor_id)
+		SELECT u.user_id, m.user_id FROM users u, users m WHERE u.user_id = $1 AND m.user_id = $2
+		ON CONFLICT (mentee_id) DO UPDATE SET mentor_id = EXCLUDED.mentor_id, created_at = NOW()`, menteeID, mentorID)
+	if err != nil {
+		return fmt.Errorf("upsert mentorship: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetMentors(ctx context.Context, userIDs []string) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT mentee_id, mentor_id FROM mentorships WHERE mentee_id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query mentors: %w", err)
+	}
+	defer rows.Close()
+	mentors := make(map[string]string)
+	for rows.Next() {
+		var mentee, mentor string
+		if err := rows.Scan(&mentee, &mentor); err != nil {
+			return nil, fmt.Errorf("scan mentor: %w", err)
+		}
+		mentors[mentee] = mentor
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return mentors, nil
+}
+
+func (r *PostgresRepo) SetTimezone(ctx context.Context, userID, tz string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET timezone = $2 WHERE user_id = $1`, userID, tz)
+	if err != nil {
+		return fmt.Errorf("update timezone: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepo) SetNotificationPrefs(ctx context.Context, userID string, prefs models.NotificationPrefs) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET notify_merged = $2 WHERE user_id = $1`, userID, prefs.Merged)
+	if err != nil {
+		return fmt.Errorf("update notification prefs: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetMergeSubscribers(ctx context.Context, userIDs []string) ([]string, error) {
+	res, err := queryUserIDs(ctx, r.db, `SELECT user_id FROM users WHERE user_id = ANY($1) AND notify_merged ORDER BY user_id`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query merge subscribers: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) GetTimezones(ctx context.Context, userIDs []string) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id, timezone FROM users WHERE user_id = ANY($1) AND timezone <> ''`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query timezones: %w", err)
+	}
+	defer rows.Close()
+	zones := make(map[string]string)
+	for rows.Next() {
+		var userID, tz string
+		if err := rows.Scan(&userID, &tz); err != nil {
+			return nil, fmt.Errorf("scan timezone: %w", err)
+		}
+		zones[userID] = tz
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return zones, nil
+}
+
+func (r *PostgresRepo) SetUserWeight(ctx context.Context, userID string, weight int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET weight = $2 WHERE user_id = $1`, userID, weight)
+	if err != nil {
+		return fmt.Errorf("update weight: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetUserWeights(ctx context.Context, userIDs []string) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id, weight FROM users WHERE user_id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query weights: %w", err)
+	}
+	defer rows.Close()
+	weights := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var w int
+		if err := rows.Scan(&userID, &w); err != nil {
+			return nil, fmt.Errorf("scan weight: %w", err)
+		}
+		weights[userID] = w
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return weights, nil
+}
+
+func (r *PostgresRepo) SetVacation(ctx context.Context, userID string, v *models.Vacation) error {
+	var from, until sql.NullTime
+	if v != nil {
+		from = sql.NullTime{Time: v.From, Valid: true}
+		until = sql.NullTime{Time: v.Until, Valid: true}
+	}
+	// Cancelling a vacation in progress reactivates the user at once; a
+	// rescheduled one is settled by the next ApplyVacations.
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE users SET vacation_from = $2, vacation_until = $3,
+			is_active = is_active OR ($3::timestamp IS NULL AND vacation_active),
+			vacation_active = vacation_active AND $3::timestamp IS NOT NULL
+		WHERE user_id = $1`, userID, from, until)
+	if err != nil {
+		return fmt.Errorf("update vacation: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepo) StartVacations(ctx context.Context, now time.Time) ([]string, error) {
+	// Users already inactive are left alone, so the end of the vacation
+	// does not activate someone who was switched off for another reason.
+	ids, err := queryUserIDs(ctx, r.db, `
+		UPDATE users SET is_active = FALSE, vacation_active = TRUE
+		WHERE NOT vacation_active AND is_active AND vacation_from <= $1 AND vacation_until > $1
+		RETURNING user_id`, now)
+	if err != nil {
+		return nil, fmt.Errorf("start vacations: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *PostgresRepo) EndVacations(ctx context.Context, now time.Time) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ids, err := queryUserIDs(ctx, tx, `
+		UPDATE users SET is_active = TRUE, vacation_active = FALSE
+		WHERE vacation_active AND (vacation_until IS NULL OR vacation_until <= $1 OR vacation_from > $1)
+		RETURNING user_id`, now)
+	if err != nil {
+		return nil, fmt.Errorf("end vacations: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET vacation_from = NULL, vacation_until = NULL WHERE vacation_until <= $1`, now); err != nil {
+		return nil, fmt.Errorf("clear vacations: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return ids, nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func queryUserIDs(ctx context.Context, q queryer, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *PostgresRepo) GetUnderstaffedPRs(ctx context.Context, teamName, userID string) ([]string, error) {
+	ids, err := queryUserIDs(ctx, r.db, `
+		SELECT pr.pull_request_id
+		FROM pull_requests pr
+		LEFT JOIN users au ON au.user_id = pr.author_id
+		WHERE `+activeStatus("pr.status")+` AND pr.need_more_reviewers
+		AND COALESCE(pr.team_name, au.team_name) = $1
+		AND pr.author_id <> $2
+		AND NOT EXISTS (SELECT 1 FROM pr_reviewers rr WHERE rr.pull_request_id = pr.pull_request_id AND rr.user_id = $2)
+		ORDER BY pr.created_at, pr.pull_request_id`, teamName, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query understaffed prs: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *PostgresRepo) GetRecentReviewers(ctx context.Context, authorID string, since time.Time) ([]string, error) {
+	ids, err := queryUserIDs(ctx, r.db, `
+		SELECT rv.user_id
+		FROM pr_reviewers rv
+		JOIN pull_requests pr ON pr.pull_request_id = rv.pull_request_id
+		WHERE pr.author_id = $1 AND pr.created_at >= $2 AND rv.role = 'required'
+		GROUP BY rv.user_id
+		ORDER BY COUNT(*) DESC, MAX(pr.created_at) DESC, rv.user_id`, authorID, since)
+	if err != nil {
+		return nil, fmt.Errorf("query recent reviewers: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *PostgresRepo) ReserveReviewers(ctx context.Context, authorID string, userIDs []string, until time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_reservations WHERE author_id = $1 OR expires_at <= NOW()`, authorID); err != nil {
+		return fmt.Errorf("delete reservations: %w", err)
+	}
+	for _, userID := range userIDs {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO review_reservations(user_id, author_id, expires_at) VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING`, userID, authorID, until)
+		if err != nil {
+			return fmt.Errorf("insert reservation: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("conflict")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetReservations(ctx context.Context, now time.Time) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id, author_id FROM review_reservations WHERE expires_at > $1`, now)
+	if err != nil {
+		return nil, fmt.Errorf("query reservations: %w", err)
+	}
+	defer rows.Close()
+
+	res := map[string]string{}
+	for rows.Next() {
+		var userID, authorID string
+		if err := rows.Scan(&userID, &authorID); err != nil {
+			return nil, fmt.Errorf("scan reservation: %w", err)
+		}
+		res[userID] = authorID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) GetSecurityCoverage(ctx context.Context, teamName string, since time.Time) (models.SecurityCoverage, error) {
+	cov := models.SecurityCoverage{TeamName: teamName, Since: since, Uncovered: []string{}}
+	row := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE pr.security_reviewer IS NOT NULL),
+			COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM pr_approvals a
+				WHERE a.pull_request_id = pr.pull_request_id AND a.user_id = pr.security_reviewer))
+		FROM pull_requests pr
+		WHERE pr.team_name = $1 AND pr.security_review AND pr.created_at >= $2`, teamName, since)
+	if err := row.Scan(&cov.Required, &cov.Covered, &cov.Approved); err != nil {
+		return cov, fmt.Errorf("select security coverage: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pull_request_id FROM pull_requests
+		WHERE team_name = $1 AND security_review AND security_reviewer IS NULL AND `+activeStatus("status")+`
+		ORDER BY created_at`, teamName)
+	if err != nil {
+		return cov, fmt.Errorf("query uncovered prs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return cov, fmt.Errorf("scan uncovered pr: %w", err)
+		}
+		cov.Uncovered = append(cov.Uncovered, id)
+	}
+	if err := rows.Err(); err != nil {
+		return cov, fmt.Errorf("rows err: %w", err)
+	}
+	return cov, nil
+}
+
+func (r *PostgresRepo) SetUserSkills(ctx context.Context, userID string, skills []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var one int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM users WHERE user_id=$1 FOR UPDATE`, userID).Scan(&one); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("not found")
 		}
-		return u, fmt.Errorf("select user: %w", err)
+		return fmt.Errorf("select user: %w", err)
 	}
-	return u, nil
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_skills WHERE user_id=$1`, userID); err != nil {
+		return fmt.Errorf("delete skills: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO user_skills(user_id, skill) SELECT $1, unnest($2::text[])
+	`, userID, pq.Array(skills)); err != nil {
+		return fmt.Errorf("insert skills: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetSkillMatches(ctx context.Context, skills []string) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, COUNT(*) FROM user_skills WHERE skill = ANY($1) GROUP BY user_id
+	`, pq.Array(skills))
+	if err != nil {
+		return nil, fmt.Errorf("query skill matches: %w", err)
+	}
+	defer rows.Close()
+
+	res := map[string]int{}
+	for rows.Next() {
+		var userID string
+		var n int
+		if err := rows.Scan(&userID, &n); err != nil {
+			return nil, fmt.Errorf("scan skill match: %w", err)
+		}
+		res[userID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+// AddMembership adds the user to an extra team, or changes their role there.
+func (r *PostgresRepo) AddMembership(ctx context.Context, m models.Membership) error {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO team_memberships(team_name, user_id, role)
+		SELECT t.team_name, u.user_id, $3
+		FROM teams t, users u
+		WHERE t.team_name = $1 AND u.user_id = $2
+		ON CONFLICT (team_name, user_id) DO UPDATE SET role = EXCLUDED.role
+	`, m.TeamName, m.UserID, m.Role)
+	if err != nil {
+		return fmt.Errorf("upsert membership: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepo) RemoveMembership(ctx context.Context, teamName, userID string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM team_memberships WHERE team_name=$1 AND user_id=$2`, teamName, userID)
+	if err != nil {
+		return fmt.Errorf("delete membership: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetOrgSummary(ctx context.Context, limit int) (models.OrgSummary, error) {
+	var (
+		sum       models.OrgSummary
+		median    sql.NullFloat64
+		ackMedian sql.NullFloat64
+		avgRounds sql.NullFloat64
+		teamsJS   []byte
+	)
+	err := r.db.QueryRowContext(ctx, `
+		WITH open_prs AS (
+			SELECT pr.pull_request_id, pr.team_name,
+				NOT EXISTS (SELECT 1 FROM pr_reviewers rv WHERE rv.pull_request_id = pr.pull_request_id) AS no_reviewers
+			FROM pull_requests pr
+			WHERE `+activeStatus("pr.status")+`
+		), busiest AS (
+			SELECT team_name, COUNT(*) AS open_prs
+			FROM open_prs
+			WHERE team_name IS NOT NULL
+			GROUP BY team_name
+			ORDER BY open_prs DESC, team_name
+			LIMIT $1
+		)
+		SELECT
+			(SELECT COUNT(*) FROM open_prs),
+			(SELECT COUNT(*) FROM pull_requests WHERE status = 'MERGED'),
+			(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM merged_at - created_at))
+				FROM pull_requests WHERE status = 'MERGED' AND merged_at IS NOT NULL),
+			(SELECT COUNT(*) FROM open_prs WHERE no_reviewers),
+			(SELECT COALESCE(json_agg(json_build_object('team_name', team_name, 'open_prs', open_prs)), '[]') FROM busiest),
+			(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM ack.created_at - asg.assigned_at))
+				FROM pr_events ack
+				CROSS JOIN LATERAL (
+					SELECT MAX(e.created_at) AS assigned_at FROM pr_events e
+					WHERE e.pull_request_id = ack.pull_request_id AND e.user_id = ack.user_id
+					AND e.kind = 'assigned' AND e.created_at <= ack.created_at) asg
+				WHERE ack.kind = 'acknowledged' AND asg.assigned_at IS NOT NULL),
+			(SELECT AVG(1 + (SELECT COUNT(*) FROM review_rounds r WHERE r.pull_request_id = pr.pull_request_id))
+				FROM pull_requests pr WHERE pr.status = 'MERGED')
+	`, limit).Scan(&sum.OpenPRs, &sum.MergedPRs, &median, &sum.PRsWithoutReviewers, &teamsJS, &ackMedian, &avgRounds)
+	if err != nil {
+		return models.OrgSummary{}, fmt.Errorf("query org summary: %w", err)
+	}
+	if err := json.Unmarshal(teamsJS, &sum.BusiestTeams); err != nil {
+		return models.OrgSummary{}, fmt.Errorf("decode busiest teams: %w", err)
+	}
+	sum.MedianTurnaroundSec = median.Float64
+	sum.MedianAckLatencySec = ackMedian.Float64
+	sum.AvgReviewRounds = avgRounds.Float64
+	return sum, nil
+}
+
+func (r *PostgresRepo) GetAlertSignals(ctx context.Context, slaCutoff time.Time) (models.AlertSignals, error) {
+	var sig models.AlertSignals
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE NOT EXISTS (
+				SELECT 1 FROM pr_reviewers rv WHERE rv.pull_request_id = pr.pull_request_id)),
+			COUNT(*) FILTER (WHERE pr.created_at < $1)
+		FROM pull_requests pr
+		WHERE `+activeStatus("pr.status")+`
+	`, slaCutoff).Scan(&sig.OpenPRs, &sig.WithoutReviewers, &sig.OverSLA)
+	if err != nil {
+		return models.AlertSignals{}, fmt.Errorf("query alert signals: %w", err)
+	}
+	return sig, nil
 }
 
 func (r *PostgresRepo) GetReviewerStats(ctx context.Context) (map[string]int, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT u.user_id, COUNT(rr.pull_request_id) as assigned_count
+		SELECT u.user_id, COALESCE(s.assigned_count, 0) as assigned_count
 		FROM users u
-		LEFT JOIN pr_reviewers rr ON u.user_id = rr.user_id
-		GROUP BY u.user_id
+		LEFT JOIN reviewer_stats s ON u.user_id = s.user_id
 		ORDER BY assigned_count DESC
 	`)
 	if err != nil {
@@ -343,14 +2173,244 @@ func (r *PostgresRepo) GetReviewerStats(ctx context.Context) (map[string]int, er
 	return stats, nil
 }
 
+func (r *PostgresRepo) GetTeamStats(ctx context.Context, teamName string) (models.TeamStats, error) {
+	stats := models.TeamStats{TeamName: teamName}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(s.created_count, 0), COALESCE(s.merged_count, 0), COALESCE(s.reassigned_count, 0)
+		FROM teams t
+		LEFT JOIN team_stats s ON s.team_name = t.team_name
+		WHERE t.team_name = $1
+	`, teamName).Scan(&stats.CreatedPRs, &stats.MergedPRs, &stats.Reassignments)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.TeamStats{}, fmt.Errorf("not found")
+		}
+		return models.TeamStats{}, fmt.Errorf("query team stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (r *PostgresRepo) QueryReviewerStats(ctx context.Context, q models.StatsQuery) (map[string]int, error) {
+	var query string
+	args := []interface{}{}
+	if q.From.IsZero() && q.To.IsZero() && q.Repository == "" {
+		query = `
+			SELECT u.user_id, COALESCE(s.assigned_count, 0)
+			FROM users u
+			LEFT JOIN reviewer_stats s ON u.user_id = s.user_id
+			WHERE ($1 = '' OR u.team_name = $1)
+			ORDER BY u.user_id`
+		args = append(args, q.TeamName)
+	} else {
+		query = `
+			SELECT u.user_id, COUNT(pr.pull_request_id)
+			FROM users u
+			LEFT JOIN pr_reviewers rr ON u.user_id = rr.user_id
+			LEFT JOIN pull_requests pr ON pr.pull_request_id = rr.pull_request_id
+				AND ($2::timestamp IS NULL OR pr.created_at >= $2)
+				AND ($3::timestamp IS NULL OR pr.created_at < $3)
+				AND ($4 = '' OR pr.repository = $4)
+			WHERE ($1 = '' OR u.team_name = $1)
+			GROUP BY u.user_id
+			ORDER BY u.user_id`
+		args = append(args, q.TeamName, nullTime(q.From), nullTime(q.To), q.Repository)
+	}
+
+	stats := make(map[string]int)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return stats, fmt.Errorf("query reviewer stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return stats, fmt.Errorf("scan stats row: %w", err)
+		}
+		stats[userID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("rows err: %w", err)
+	}
+	return stats, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
 func (r *PostgresRepo) SetTeamActive(ctx context.Context, teamName string, isActive bool) error {
-	res, err := r.db.ExecContext(ctx, `UPDATE users SET is_active=$1 WHERE team_name=$2`, isActive, teamName)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `UPDATE users SET is_active=$1 WHERE team_name=$2`, isActive, teamName)
 	if err != nil {
 		return fmt.Errorf("update team users active: %w", err)
 	}
 	affected, _ := res.RowsAffected()
+	// Extra members stay active for their home teams and only stop being
+	// drawn for this one.
+	if !isActive {
+		res, err := tx.ExecContext(ctx, `UPDATE team_memberships SET role=$2 WHERE team_name=$1 AND role=$3`, teamName, models.RoleObserver, models.RoleMember)
+		if err != nil {
+			return fmt.Errorf("demote team memberships: %w", err)
+		}
+		demoted, _ := res.RowsAffected()
+		affected += demoted
+	}
 	if affected == 0 {
 		return fmt.Errorf("no users updated")
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetTeamMemberships(ctx context.Context, teamName string) ([]models.Membership, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT team_name, user_id, role FROM team_memberships WHERE team_name=$1 ORDER BY user_id`, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("query team memberships: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.Membership{}
+	for rows.Next() {
+		var m models.Membership
+		if err := rows.Scan(&m.TeamName, &m.UserID, &m.Role); err != nil {
+			return nil, fmt.Errorf("scan membership: %w", err)
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresRepo) CreateTeamToken(ctx context.Context, teamName, tokenHash string) error {
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO team_tokens(token_hash, team_name) VALUES ($1,$2)`, tokenHash, teamName); err != nil {
+		return fmt.Errorf("insert team token: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetTeamByToken(ctx context.Context, tokenHash string) (string, error) {
+	var team string
+	row := r.db.QueryRowContext(ctx, `SELECT team_name FROM team_tokens WHERE token_hash=$1`, tokenHash)
+	if err := row.Scan(&team); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("not found")
+		}
+		return "", fmt.Errorf("select token team: %w", err)
+	}
+	return team, nil
+}
+
+func (r *PostgresRepo) CreateUserToken(ctx context.Context, userID, tokenHash string) error {
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO user_tokens(token_hash, user_id) VALUES ($1,$2)`, tokenHash, userID); err != nil {
+		return fmt.Errorf("insert user token: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetUserByToken(ctx context.Context, tokenHash string) (string, error) {
+	var userID string
+	row := r.db.QueryRowContext(ctx, `SELECT user_id FROM user_tokens WHERE token_hash=$1`, tokenHash)
+	if err := row.Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("not found")
+		}
+		return "", fmt.Errorf("select token user: %w", err)
+	}
+	return userID, nil
+}
+
+func (r *PostgresRepo) GetTeamSettings(ctx context.Context, teamName string) (models.TeamSettings, error) {
+	settings := models.TeamSettings{TeamName: teamName}
+	var raw []byte
+	row := r.db.QueryRowContext(ctx, `SELECT settings FROM team_settings WHERE team_name=$1`, teamName)
+	if err := row.Scan(&raw); err != nil {
+		if err == sql.ErrNoRows {
+			return settings, fmt.Errorf("not found")
+		}
+		return settings, fmt.Errorf("select team settings: %w", err)
+	}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return settings, fmt.Errorf("decode team settings: %w", err)
+	}
+	settings.TeamName = teamName
+	return settings, nil
+}
+
+func (r *PostgresRepo) SaveTeamSettings(ctx context.Context, settings models.TeamSettings) error {
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("encode team settings: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO team_settings(team_name, settings, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (team_name) DO UPDATE SET settings = EXCLUDED.settings, updated_at = NOW()
+	`, settings.TeamName, raw)
+	if err != nil {
+		return fmt.Errorf("upsert team settings: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetRepositorySettings(ctx context.Context, repository string) (models.RepositorySettings, error) {
+	settings := models.RepositorySettings{Repository: repository}
+	row := r.db.QueryRowContext(ctx, `SELECT default_reviewers FROM repository_settings WHERE repository=$1`, repository)
+	if err := row.Scan(pq.Array(&settings.DefaultReviewers)); err != nil {
+		if err == sql.ErrNoRows {
+			return settings, fmt.Errorf("not found")
+		}
+		return settings, fmt.Errorf("select repository settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (r *PostgresRepo) SaveRepositorySettings(ctx context.Context, settings models.RepositorySettings) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO repository_settings(repository, default_reviewers, updated_at) VALUES ($1, COALESCE($2, '{}'), NOW())
+		ON CONFLICT (repository) DO UPDATE SET default_reviewers = EXCLUDED.default_reviewers, updated_at = NOW()
+	`, settings.Repository, pq.Array(settings.DefaultReviewers))
+	if err != nil {
+		return fmt.Errorf("upsert repository settings: %w", err)
+	}
 	return nil
 }
+
+func (r *PostgresRepo) GetUserEvents(ctx context.Context, userID string, page models.Page) ([]models.UserEvent, error) {
+	query, args := newSelect(`SELECT e.pull_request_id, COALESCE(pr.pull_request_name, ''), e.kind, e.created_at
+		FROM pr_events e
+		LEFT JOIN pull_requests pr ON pr.pull_request_id = e.pull_request_id`).
+		Where(`e.user_id = ?`, userID).
+		OrderBy(`e.created_at DESC, e.id DESC`).
+		Page(page).
+		Build()
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query user events: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.UserEvent{}
+	for rows.Next() {
+		var e models.UserEvent
+		if err := rows.Scan(&e.PullRequestID, &e.PullRequestName, &e.Kind, &e.At); err != nil {
+			return nil, fmt.Errorf("scan user event: %w", err)
+		}
+		res = append(res, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
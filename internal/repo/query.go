@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"strconv"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// selectQuery composes a filtered SELECT. Conditions are written with ?
+// for each argument and numbered $1, $2, ... when built, so optional
+// filters never have to track placeholder positions by hand. Only the
+// fixed SQL passed in by this package goes into the text; values always
+// travel as arguments. Since every ? is a placeholder, operators spelled
+// with ? (such as jsonb's) can't appear in the text.
+type selectQuery struct {
+	base    string
+	where   []string
+	args    []any
+	orderBy string
+	page    *models.Page
+}
+
+// newSelect starts a query from base, a SELECT ... FROM ... without WHERE.
+func newSelect(base string) *selectQuery {
+	return &selectQuery{base: base}
+}
+
+// Where adds a condition, ANDed with the others.
+func (q *selectQuery) Where(cond string, args ...any) *selectQuery {
+	q.where = append(q.where, cond)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// WhereIf adds the condition only when ok, for optional filters.
+func (q *selectQuery) WhereIf(ok bool, cond string, args ...any) *selectQuery {
+	if !ok {
+		return q
+	}
+	return q.Where(cond, args...)
+}
+
+// OrderBy sets the ORDER BY clause.
+func (q *selectQuery) OrderBy(order string) *selectQuery {
+	q.orderBy = order
+	return q
+}
+
+// Page adds LIMIT and OFFSET.
+func (q *selectQuery) Page(page models.Page) *selectQuery {
+	q.page = &page
+	return q
+}
+
+// Build returns the SQL text and its arguments.
+func (q *selectQuery) Build() (string, []any) {
+	var sb strings.Builder
+	sb.WriteString(q.base)
+	for i, cond := range q.where {
+		if i == 0 {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		sb.WriteString("(" + cond + ")")
+	}
+	if q.orderBy != "" {
+		sb.WriteString(" ORDER BY " + q.orderBy)
+	}
+	args := q.args
+	if q.page != nil {
+		sb.WriteString(" LIMIT ? OFFSET ?")
+		args = append(append([]any{}, args...), q.page.Limit, q.page.Offset)
+	}
+	return numberPlaceholders(sb.String()), args
+}
+
+// activeStatusList is models.ActiveStatuses as an SQL list. The statuses
+// are constants of this program, never user input.
+var activeStatusList = func() string {
+	quoted := make([]string, len(models.ActiveStatuses))
+	for i, s := range models.ActiveStatuses {
+		quoted[i] = "'" + string(s) + "'"
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}()
+
+// activeStatus is the condition that column holds an active PR status, for
+// queries written by hand and for Where alike.
+func activeStatus(column string) string {
+	return column + " IN " + activeStatusList
+}
+
+// numberPlaceholders replaces each ? with $1, $2, ... in order.
+func numberPlaceholders(query string) string {
+	var sb strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
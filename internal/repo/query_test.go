@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+
+	"PR-reviewer/internal/models"
+)
+
+func TestSelectQueryBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    *selectQuery
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "без условий",
+			query:    newSelect("SELECT a FROM t").OrderBy("a"),
+			wantSQL:  "SELECT a FROM t ORDER BY a",
+			wantArgs: nil,
+		},
+		{
+			name: "пропуск необязательных фильтров",
+			query: newSelect("SELECT a FROM t").
+				WhereIf(false, "x = ?", "skip").
+				WhereIf(true, "y = ? OR z = ?", "y", "z").
+				Page(models.Page{Limit: 10, Offset: 20}),
+			wantSQL:  "SELECT a FROM t WHERE (y = $1 OR z = $2) LIMIT $3 OFFSET $4",
+			wantArgs: []any{"y", "z", 10, 20},
+		},
+		{
+			name:     "активные статусы",
+			query:    newSelect("SELECT a FROM pull_requests pr").Where(activeStatus("pr.status")).Where("pr.team_name = ?", "a"),
+			wantSQL:  "SELECT a FROM pull_requests pr WHERE (pr.status IN ('OPEN', 'IN_REVIEW', 'APPROVED')) AND (pr.team_name = $1)",
+			wantArgs: []any{"a"},
+		},
+		{
+			name: "несколько условий",
+			query: newSelect("SELECT a FROM t").
+				Where("x = ?", 1).
+				Where("active").
+				WhereIf(true, "y <> ?", "u1"),
+			wantSQL:  "SELECT a FROM t WHERE (x = $1) AND (active) AND (y <> $2)",
+			wantArgs: []any{1, "u1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.query.Build()
+			if sql != tt.wantSQL {
+				t.Fatalf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Fatalf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
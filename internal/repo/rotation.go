@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"PR-reviewer/internal/models"
+)
+
+func (r *PostgresRepo) SaveRotation(ctx context.Context, teamName string, weeks []models.RotationWeek) error {
+	if len(weeks) == 0 {
+		return nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_rotations WHERE team_name=$1 AND week_start >= $2`, teamName, weeks[0].WeekStart); err != nil {
+		return fmt.Errorf("delete rotation: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO review_rotations(team_name, week_start, reviewers) VALUES ($1, $2, $3)`)
+	if err != nil {
+		return fmt.Errorf("prepare rotation insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, w := range weeks {
+		if _, err := stmt.ExecContext(ctx, teamName, w.WeekStart, pq.Array(w.Reviewers)); err != nil {
+			return fmt.Errorf("insert rotation week: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepo) GetRotation(ctx context.Context, teamName string, from time.Time) ([]models.RotationWeek, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT week_start, reviewers
+		FROM review_rotations
+		WHERE team_name = $1 AND week_start >= $2::date
+		ORDER BY week_start`, teamName, from)
+	if err != nil {
+		return nil, fmt.Errorf("query rotation: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.RotationWeek{}
+	for rows.Next() {
+		var w models.RotationWeek
+		if err := rows.Scan(&w.WeekStart, pq.Array(&w.Reviewers)); err != nil {
+			return nil, fmt.Errorf("scan rotation week: %w", err)
+		}
+		w.WeekStart = w.WeekStart.UTC()
+		res = append(res, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+func (r *PostgresRepo) StartReviewRound(ctx context.Context, prID, requestedBy, freshReviewer string, dueAt *time.Time) (models.ReviewRound, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ReviewRound{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Locking the PR serialises concurrent change requests, which would
+	// otherwise both take the same round number.
+	var one int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM pull_requests WHERE pull_request_id=$1 FOR UPDATE`, prID).Scan(&one); err != nil {
+		if err == sql.ErrNoRows {
+			return models.ReviewRound{}, fmt.Errorf("not found")
+		}
+		return models.ReviewRound{}, fmt.Errorf("lock pr: %w", err)
+	}
+
+	var (
+		round models.ReviewRound
+		fresh sql.NullString
+		due   sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO review_rounds(pull_request_id, round, requested_by, fresh_reviewer, due_at)
+		SELECT $1, COALESCE(MAX(round), 1) + 1, $2, NULLIF($3, ''), $4
+		FROM review_rounds WHERE pull_request_id = $1
+		RETURNING pull_request_id, round, requested_by, fresh_reviewer, started_at, due_at`,
+		prID, requestedBy, freshReviewer, dueAt).Scan(&round.PullRequestID, &round.Round, &round.RequestedBy, &fresh, &round.StartedAt, &due)
+	if err != nil {
+		return models.ReviewRound{}, fmt.Errorf("insert review round: %w", err)
+	}
+	if err := recordEvent(ctx, tx, prID, requestedBy, models.EventChangesRequested); err != nil {
+		return models.ReviewRound{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ReviewRound{}, fmt.Errorf("commit: %w", err)
+	}
+	return scanRound(round, fresh, due), nil
+}
+
+func (r *PostgresRepo) GetReviewRounds(ctx context.Context, prID string) ([]models.ReviewRound, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pull_request_id, round, requested_by, fresh_reviewer, started_at, due_at
+		FROM review_rounds
+		WHERE pull_request_id = $1
+		ORDER BY round`, prID)
+	if err != nil {
+		return nil, fmt.Errorf("query review rounds: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.ReviewRound{}
+	for rows.Next() {
+		var (
+			round models.ReviewRound
+			fresh sql.NullString
+			due   sql.NullTime
+		)
+		if err := rows.Scan(&round.PullRequestID, &round.Round, &round.RequestedBy, &fresh, &round.StartedAt, &due); err != nil {
+			return nil, fmt.Errorf("scan review round: %w", err)
+		}
+		res = append(res, scanRound(round, fresh, due))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
+
+func scanRound(round models.ReviewRound, fresh sql.NullString, due sql.NullTime) models.ReviewRound {
+	round.FreshReviewer = fresh.String
+	round.StartedAt = round.StartedAt.UTC()
+	if due.Valid {
+		t := due.Time.UTC()
+		round.DueAt = &t
+	}
+	return round
+}
@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// expectedSchema lists the tables and columns the queries in this package
+// rely on, as created by migrations.sql. Extend it with every migration.
+var expectedSchema = map[string][]string{
+	"teams":                  {"team_name"},
+	"users":                  {"user_id", "username", "team_name", "is_active", "vacation_from", "vacation_until", "vacation_active", "timezone", "joined_at", "weight", "inactive_since", "notify_merged"},
+	"pull_requests":          {"pull_request_id", "pull_request_name", "author_id", "status", "need_more_reviewers", "created_at", "merged_at", "closed_at", "team_name", "labels", "security_review", "security_reviewer", "priority", "size", "repository", "target_branch", "description", "merged_by", "external_id"},
+	"pr_reviewers":           {"pull_request_id", "user_id", "role", "assigned_at", "decision", "decision_comment", "decided_at"},
+	"team_tokens":            {"token_hash", "team_name", "created_at"},
+	"user_tokens":            {"token_hash", "user_id", "created_at"},
+	"pr_approvals":           {"pull_request_id", "user_id", "approved_at"},
+	"reviewer_stats":         {"user_id", "assigned_count"},
+	"team_stats":             {"team_name", "created_count", "merged_count", "reassigned_count"},
+	"repository_settings":    {"repository", "default_reviewers", "updated_at"},
+	"team_settings":          {"team_name", "settings", "updated_at"},
+	"pr_events":              {"id", "pull_request_id", "user_id", "kind", "created_at"},
+	"team_memberships":       {"team_name", "user_id", "role"},
+	"pr_escalations":         {"pull_request_id", "reason", "started_at", "next_hop"},
+	"user_skills":            {"user_id", "skill"},
+	"mentorships":            {"mentee_id", "mentor_id", "created_at"},
+	"cluster_leader":         {"id", "instance", "acquired_at", "renewed_at"},
+	"assignment_events":      {"id", "pull_request_id", "user_id", "action", "reason", "triggered_by", "created_at"},
+	"review_reservations":    {"user_id", "author_id", "created_at", "expires_at"},
+	"review_rounds":          {"pull_request_id", "round", "requested_by", "fresh_reviewer", "started_at", "due_at"},
+	"review_rotations":       {"team_name", "week_start", "reviewers", "generated_at"},
+	"merge_queue":            {"pull_request_id", "merge_at", "queued_at", "merged_by"},
+	"instances":              {"instance", "version", "commit", "started_at", "heartbeat_at", "workers", "busy_workers", "queue_depth", "longest_job_seconds"},
+	"archived_pull_requests": {"pull_request_id", "pull_request_name", "author_id", "team_name", "repository", "target_branch", "status", "priority", "labels", "created_at", "finished_at", "archived_at", "pr", "history", "external_id"},
+}
+
+// SchemaDrift describes how the database differs from expectedSchema.
+// Extra tables and columns are not drift.
+type SchemaDrift struct {
+	MissingTables  []string
+	MissingColumns []string // "table.column"
+}
+
+func (d SchemaDrift) Empty() bool {
+	return len(d.MissingTables) == 0 && len(d.MissingColumns) == 0
+}
+
+func (d SchemaDrift) String() string {
+	var parts []string
+	if len(d.MissingTables) > 0 {
+		parts = append(parts, "missing tables: "+strings.Join(d.MissingTables, ", "))
+	}
+	if len(d.MissingColumns) > 0 {
+		parts = append(parts, "missing columns: "+strings.Join(d.MissingColumns, ", "))
+	}
+	if len(parts) == 0 {
+		return "schema up to date"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// VerifySchema compares the current schema against expectedSchema.
+func (r *PostgresRepo) VerifySchema(ctx context.Context) (SchemaDrift, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT table_name, column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+	`)
+	if err != nil {
+		return SchemaDrift{}, fmt.Errorf("query schema: %w", err)
+	}
+	defer rows.Close()
+
+	actual := make(map[string]map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return SchemaDrift{}, fmt.Errorf("scan schema row: %w", err)
+		}
+		if actual[table] == nil {
+			actual[table] = make(map[string]bool)
+		}
+		actual[table][column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return SchemaDrift{}, fmt.Errorf("rows err: %w", err)
+	}
+	return diffSchema(expectedSchema, actual), nil
+}
+
+func diffSchema(expected map[string][]string, actual map[string]map[string]bool) SchemaDrift {
+	var d SchemaDrift
+	for table, columns := range expected {
+		have, ok := actual[table]
+		if !ok {
+			d.MissingTables = append(d.MissingTables, table)
+			continue
+		}
+		for _, c := range columns {
+			if !have[c] {
+				d.MissingColumns = append(d.MissingColumns, table+"."+c)
+			}
+		}
+	}
+	sort.Strings(d.MissingTables)
+	sort.Strings(d.MissingColumns)
+	return d
+}
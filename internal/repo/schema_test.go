@@ -0,0 +1,35 @@
+package repo
+
+import "testing"
+
+func TestDiffSchema(t *testing.T) {
+	expected := map[string][]string{
+		"teams":         {"team_name"},
+		"pull_requests": {"pull_request_id", "team_name"},
+		"pr_events":     {"id"},
+	}
+	actual := map[string]map[string]bool{
+		"teams":         {"team_name": true, "extra": true},
+		"pull_requests": {"pull_request_id": true},
+		"unrelated":     {"x": true},
+	}
+
+	d := diffSchema(expected, actual)
+	if len(d.MissingTables) != 1 || d.MissingTables[0] != "pr_events" {
+		t.Fatalf("unexpected missing tables %v", d.MissingTables)
+	}
+	if len(d.MissingColumns) != 1 || d.MissingColumns[0] != "pull_requests.team_name" {
+		t.Fatalf("unexpected missing columns %v", d.MissingColumns)
+	}
+	if d.Empty() {
+		t.Fatal("expected drift")
+	}
+
+	if d := diffSchema(expected, map[string]map[string]bool{
+		"teams":         {"team_name": true},
+		"pull_requests": {"pull_request_id": true, "team_name": true},
+		"pr_events":     {"id": true},
+	}); !d.Empty() {
+		t.Fatalf("expected no drift, got %s", d)
+	}
+}
@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+
+	"PR-reviewer/internal/models"
+)
+
+// appTables returns the tables migrations.sql creates, sorted.
+func appTables() []string {
+	tables := make([]string, 0, len(expectedSchema))
+	for t := range expectedSchema {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+func (r *PostgresRepo) GetTableStats(ctx context.Context) ([]models.TableStats, error) {
+	tables := appTables()
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.relname, s.n_live_tup, s.n_dead_tup, pg_total_relation_size(s.relid)
+		FROM pg_stat_user_tables s
+		WHERE s.schemaname = current_schema() AND s.relname = ANY($1)
+		ORDER BY s.relname
+	`, pq.Array(tables))
+	if err != nil {
+		return nil, fmt.Errorf("query table stats: %w", err)
+	}
+	defer rows.Close()
+
+	res := []models.TableStats{}
+	byTable := map[string]int{}
+	for rows.Next() {
+		var t models.TableStats
+		if err := rows.Scan(&t.Table, &t.Rows, &t.DeadRows, &t.TotalBytes); err != nil {
+			return nil, fmt.Errorf("scan table stats: %w", err)
+		}
+		byTable[t.Table] = len(res)
+		res = append(res, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+
+	// A btree entry takes about its key's average width plus an 8 byte
+	// tuple header and a 4 byte line pointer, with pages filled to 90%.
+	// Whatever the index holds beyond that is counted as bloat.
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT t.relname, i.relname, i.relpages::bigint * bs.size,
+			GREATEST(i.relpages - CEIL(GREATEST(i.reltuples, 0) * (12 + COALESCE(w.width, 8)) / ((bs.size - 24) * 0.9)), 0)::bigint * bs.size
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace AND n.nspname = current_schema()
+		JOIN pg_am am ON am.oid = i.relam AND am.amname = 'btree'
+		CROSS JOIN (SELECT current_setting('block_size')::bigint AS size) bs
+		LEFT JOIN LATERAL (
+			SELECT SUM(st.avg_width) AS width
+			FROM pg_attribute a
+			JOIN pg_stats st ON st.schemaname = n.nspname AND st.tablename = t.relname AND st.attname = a.attname
+			WHERE a.attrelid = t.oid AND a.attnum = ANY(x.indkey)
+		) w ON TRUE
+		WHERE t.relname = ANY($1)
+		ORDER BY t.relname, i.relname
+	`, pq.Array(tables))
+	if err != nil {
+		return nil, fmt.Errorf("query index stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table string
+		var idx models.IndexStats
+		if err := rows.Scan(&table, &idx.Name, &idx.Bytes, &idx.BloatBytes); err != nil {
+			return nil, fmt.Errorf("scan index stats: %w", err)
+		}
+		if i, ok := byTable[table]; ok {
+			res[i].Indexes = append(res[i].Indexes, idx)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,669 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"PR-reviewer/internal/logger"
+	"PR-reviewer/internal/models"
+)
+
+type operationKey struct{}
+
+// WithOperation attaches the repo operation name to ctx for logging and tracing.
+func WithOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+func OperationFromContext(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
+
+// timeoutRepo bounds every call with a deadline so a client without one
+// can't keep a query running forever. An earlier caller deadline wins.
+// Failures come back as *OpError and unexpected ones are logged with the
+// operation, request ID and job type; log may be nil.
+type timeoutRepo struct {
+	next    Repo
+	timeout time.Duration
+	log     logger.Logger
+	slow    time.Duration
+	faults  FaultInjector
+}
+
+var _ Repo = (*timeoutRepo)(nil)
+
+type Option func(*timeoutRepo)
+
+// WithSlowThreshold logs calls that take longer than d at warn level and
+// counts them in repo_slow_calls_total. Zero disables it.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(r *timeoutRepo) { r.slow = d }
+}
+
+// FaultInjector slows down or fails calls on purpose, for resilience
+// testing. Inject is called with "repo:<Method>" before each repo call and
+// "job:<type>" before each job; a non-nil error replaces the result.
+type FaultInjector interface {
+	Inject(ctx context.Context, target string) error
+}
+
+// WithFaults runs every call through f first.
+func WithFaults(f FaultInjector) Option {
+	return func(r *timeoutRepo) { r.faults = f }
+}
+
+func WithTimeout(next Repo, timeout time.Duration, log logger.Logger, opts ...Option) Repo {
+	r := &timeoutRepo{next: next, timeout: timeout, log: log}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// call runs f under the deadline. args are the call's parameters, only
+// used to describe slow calls in the log.
+func call[T any](r *timeoutRepo, ctx context.Context, op string, args []any, f func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(WithOperation(ctx, op), r.timeout)
+	defer cancel()
+	start := time.Now()
+	var res T
+	var err error
+	if r.faults != nil {
+		err = r.faults.Inject(ctx, "repo:"+op)
+	}
+	if err == nil {
+		res, err = f(ctx)
+	}
+	if elapsed := time.Since(start); r.slow > 0 && elapsed > r.slow {
+		logSlowCall(ctx, r.log, op, args, elapsed)
+	}
+	if err == nil {
+		return res, nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%s timed out: %w", op, err)
+	}
+	opErr := &OpError{
+		Op:        op,
+		RequestID: RequestIDFromContext(ctx),
+		JobType:   JobTypeFromContext(ctx),
+		Err:       err,
+	}
+	logOpError(r.log, opErr)
+	return res, opErr
+}
+
+func callErr(r *timeoutRepo, ctx context.Context, op string, args []any, f func(context.Context) error) error {
+	_, err := call(r, ctx, op, args, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, f(ctx)
+	})
+	return err
+}
+
+func (r *timeoutRepo) InsertTeam(ctx context.Context, team models.Team) error {
+	return callErr(r, ctx, "InsertTeam", []any{team}, func(ctx context.Context) error {
+		return r.next.InsertTeam(ctx, team)
+	})
+}
+
+func (r *timeoutRepo) GetTeam(ctx context.Context, teamName string) (models.Team, error) {
+	return call(r, ctx, "GetTeam", []any{teamName}, func(ctx context.Context) (models.Team, error) {
+		return r.next.GetTeam(ctx, teamName)
+	})
+}
+
+func (r *timeoutRepo) UpdateUserActive(ctx context.Context, userID string, isActive bool) (models.User, error) {
+	return call(r, ctx, "UpdateUserActive", []any{userID, isActive}, func(ctx context.Context) (models.User, error) {
+		return r.next.UpdateUserActive(ctx, userID, isActive)
+	})
+}
+
+func (r *timeoutRepo) CreatePR(ctx context.Context, pr models.PullRequest) error {
+	return callErr(r, ctx, "CreatePR", []any{pr}, func(ctx context.Context) error {
+		return r.next.CreatePR(ctx, pr)
+	})
+}
+
+func (r *timeoutRepo) GetPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	return call(r, ctx, "GetPR", []any{prID}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.GetPR(ctx, prID)
+	})
+}
+
+func (r *timeoutRepo) MergePR(ctx context.Context, prID, mergedBy string, t time.Time) (models.PullRequest, error) {
+	return call(r, ctx, "MergePR", []any{prID, mergedBy, t}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.MergePR(ctx, prID, mergedBy, t)
+	})
+}
+
+func (r *timeoutRepo) ReplaceReviewer(ctx context.Context, prID, oldUID, newUID string) (models.PullRequest, error) {
+	return call(r, ctx, "ReplaceReviewer", []any{prID, oldUID, newUID}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.ReplaceReviewer(ctx, prID, oldUID, newUID)
+	})
+}
+
+func (r *timeoutRepo) AddReviewer(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+	return call(r, ctx, "AddReviewer", []any{prID, userID, wantReviewers}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.AddReviewer(ctx, prID, userID, wantReviewers)
+	})
+}
+
+func (r *timeoutRepo) CleanupInactiveReviewers(ctx context.Context, prID string) error {
+	return callErr(r, ctx, "CleanupInactiveReviewers", []any{prID}, func(ctx context.Context) error {
+		return r.next.CleanupInactiveReviewers(ctx, prID)
+	})
+}
+
+func (r *timeoutRepo) GetActiveTeamMembersExcept(ctx context.Context, teamName, exceptUser string) ([]string, error) {
+	return call(r, ctx, "GetActiveTeamMembersExcept", []any{teamName, exceptUser}, func(ctx context.Context) ([]string, error) {
+		return r.next.GetActiveTeamMembersExcept(ctx, teamName, exceptUser)
+	})
+}
+
+func (r *timeoutRepo) GetUserTeam(ctx context.Context, userID string) (string, error) {
+	return call(r, ctx, "GetUserTeam", []any{userID}, func(ctx context.Context) (string, error) {
+		return r.next.GetUserTeam(ctx, userID)
+	})
+}
+
+func (r *timeoutRepo) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	return call(r, ctx, "GetPRsByReviewer", []any{userID}, func(ctx context.Context) ([]models.PullRequestShort, error) {
+		return r.next.GetPRsByReviewer(ctx, userID)
+	})
+}
+
+func (r *timeoutRepo) GetUser(ctx context.Context, userID string) (models.User, error) {
+	return call(r, ctx, "GetUser", []any{userID}, func(ctx context.Context) (models.User, error) {
+		return r.next.GetUser(ctx, userID)
+	})
+}
+
+func (r *timeoutRepo) GetReviewerStats(ctx context.Context) (map[string]int, error) {
+	return call(r, ctx, "GetReviewerStats", nil, func(ctx context.Context) (map[string]int, error) {
+		return r.next.GetReviewerStats(ctx)
+	})
+}
+
+func (r *timeoutRepo) SetTeamActive(ctx context.Context, teamName string, isActive bool) error {
+	return callErr(r, ctx, "SetTeamActive", []any{teamName, isActive}, func(ctx context.Context) error {
+		return r.next.SetTeamActive(ctx, teamName, isActive)
+	})
+}
+
+func (r *timeoutRepo) CreateTeamToken(ctx context.Context, teamName, tokenHash string) error {
+	return callErr(r, ctx, "CreateTeamToken", []any{teamName, tokenHash}, func(ctx context.Context) error {
+		return r.next.CreateTeamToken(ctx, teamName, tokenHash)
+	})
+}
+
+func (r *timeoutRepo) GetTeamByToken(ctx context.Context, tokenHash string) (string, error) {
+	return call(r, ctx, "GetTeamByToken", []any{tokenHash}, func(ctx context.Context) (string, error) {
+		return r.next.GetTeamByToken(ctx, tokenHash)
+	})
+}
+
+func (r *timeoutRepo) ApprovePR(ctx context.Context, prID, userID string, t time.Time) (models.PullRequest, error) {
+	return call(r, ctx, "ApprovePR", []any{prID, userID, t}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.ApprovePR(ctx, prID, userID, t)
+	})
+}
+
+func (r *timeoutRepo) ClosePR(ctx context.Context, prID string, t time.Time) (models.PullRequest, error) {
+	return call(r, ctx, "ClosePR", []any{prID, t}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.ClosePR(ctx, prID, t)
+	})
+}
+
+func (r *timeoutRepo) ReopenPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	return call(r, ctx, "ReopenPR", []any{prID}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.ReopenPR(ctx, prID)
+	})
+}
+
+func (r *timeoutRepo) QueryReviewerStats(ctx context.Context, q models.StatsQuery) (map[string]int, error) {
+	return call(r, ctx, "QueryReviewerStats", []any{q}, func(ctx context.Context) (map[string]int, error) {
+		return r.next.QueryReviewerStats(ctx, q)
+	})
+}
+
+func (r *timeoutRepo) ListPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+	return call(r, ctx, "ListPRs", []any{filter, page}, func(ctx context.Context) ([]models.PullRequestShort, error) {
+		return r.next.ListPRs(ctx, filter, page)
+	})
+}
+
+func (r *timeoutRepo) GetCandidateSignals(ctx context.Context, teamName, authorID string) ([]models.CandidateSignals, error) {
+	return call(r, ctx, "GetCandidateSignals", []any{teamName, authorID}, func(ctx context.Context) ([]models.CandidateSignals, error) {
+		return r.next.GetCandidateSignals(ctx, teamName, authorID)
+	})
+}
+
+func (r *timeoutRepo) GetTeamSettings(ctx context.Context, teamName string) (models.TeamSettings, error) {
+	return call(r, ctx, "GetTeamSettings", []any{teamName}, func(ctx context.Context) (models.TeamSettings, error) {
+		return r.next.GetTeamSettings(ctx, teamName)
+	})
+}
+
+func (r *timeoutRepo) SaveTeamSettings(ctx context.Context, settings models.TeamSettings) error {
+	return callErr(r, ctx, "SaveTeamSettings", []any{settings}, func(ctx context.Context) error {
+		return r.next.SaveTeamSettings(ctx, settings)
+	})
+}
+
+func (r *timeoutRepo) UpdateTeam(ctx context.Context, upd models.TeamUpdate) (models.Team, error) {
+	return call(r, ctx, "UpdateTeam", []any{upd}, func(ctx context.Context) (models.Team, error) {
+		return r.next.UpdateTeam(ctx, upd)
+	})
+}
+
+func (r *timeoutRepo) DeleteTeam(ctx context.Context, teamName string, force bool, wantReviewers int) ([]string, error) {
+	return call(r, ctx, "DeleteTeam", []any{teamName, force, wantReviewers}, func(ctx context.Context) ([]string, error) {
+		return r.next.DeleteTeam(ctx, teamName, force, wantReviewers)
+	})
+}
+
+func (r *timeoutRepo) GetUserEvents(ctx context.Context, userID string, page models.Page) ([]models.UserEvent, error) {
+	return call(r, ctx, "GetUserEvents", []any{userID, page}, func(ctx context.Context) ([]models.UserEvent, error) {
+		return r.next.GetUserEvents(ctx, userID, page)
+	})
+}
+
+func (r *timeoutRepo) GetOrgSummary(ctx context.Context, limit int) (models.OrgSummary, error) {
+	return call(r, ctx, "GetOrgSummary", []any{limit}, func(ctx context.Context) (models.OrgSummary, error) {
+		return r.next.GetOrgSummary(ctx, limit)
+	})
+}
+
+func (r *timeoutRepo) GetAlertSignals(ctx context.Context, slaCutoff time.Time) (models.AlertSignals, error) {
+	return call(r, ctx, "GetAlertSignals", []any{slaCutoff}, func(ctx context.Context) (models.AlertSignals, error) {
+		return r.next.GetAlertSignals(ctx, slaCutoff)
+	})
+}
+
+func (r *timeoutRepo) UpdateUserTeam(ctx context.Context, userID, teamName string, handoffs []models.ReviewHandoff) (models.User, error) {
+	return call(r, ctx, "UpdateUserTeam", []any{userID, teamName, handoffs}, func(ctx context.Context) (models.User, error) {
+		return r.next.UpdateUserTeam(ctx, userID, teamName, handoffs)
+	})
+}
+
+func (r *timeoutRepo) CheckConsistency(ctx context.Context, wantReviewers int, repair bool) (models.ConsistencyReport, error) {
+	return call(r, ctx, "CheckConsistency", []any{wantReviewers, repair}, func(ctx context.Context) (models.ConsistencyReport, error) {
+		return r.next.CheckConsistency(ctx, wantReviewers, repair)
+	})
+}
+
+func (r *timeoutRepo) GetPRsByAuthor(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	return call(r, ctx, "GetPRsByAuthor", []any{userID}, func(ctx context.Context) ([]models.PullRequestShort, error) {
+		return r.next.GetPRsByAuthor(ctx, userID)
+	})
+}
+
+func (r *timeoutRepo) GetUserProfile(ctx context.Context, userID string) (models.UserProfile, error) {
+	return call(r, ctx, "GetUserProfile", []any{userID}, func(ctx context.Context) (models.UserProfile, error) {
+		return r.next.GetUserProfile(ctx, userID)
+	})
+}
+
+func (r *timeoutRepo) AddMembership(ctx context.Context, m models.Membership) error {
+	return callErr(r, ctx, "AddMembership", []any{m}, func(ctx context.Context) error {
+		return r.next.AddMembership(ctx, m)
+	})
+}
+
+func (r *timeoutRepo) RemoveMembership(ctx context.Context, teamName, userID string) error {
+	return callErr(r, ctx, "RemoveMembership", []any{teamName, userID}, func(ctx context.Context) error {
+		return r.next.RemoveMembership(ctx, teamName, userID)
+	})
+}
+
+func (r *timeoutRepo) RemoveReviewer(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+	return call(r, ctx, "RemoveReviewer", []any{prID, userID, wantReviewers}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.RemoveReviewer(ctx, prID, userID, wantReviewers)
+	})
+}
+
+func (r *timeoutRepo) SearchPRs(ctx context.Context, query string, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+	return call(r, ctx, "SearchPRs", []any{query, filter, page}, func(ctx context.Context) ([]models.PullRequestShort, error) {
+		return r.next.SearchPRs(ctx, query, filter, page)
+	})
+}
+
+func (r *timeoutRepo) AcknowledgeReview(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	return call(r, ctx, "AcknowledgeReview", []any{prID, userID}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.AcknowledgeReview(ctx, prID, userID)
+	})
+}
+
+func (r *timeoutRepo) GetPendingReviews(ctx context.Context) ([]models.PendingReview, error) {
+	return call(r, ctx, "GetPendingReviews", nil, func(ctx context.Context) ([]models.PendingReview, error) {
+		return r.next.GetPendingReviews(ctx)
+	})
+}
+
+func (r *timeoutRepo) GetAssignmentRecords(ctx context.Context, from, to time.Time) ([]models.AssignmentRecord, error) {
+	return call(r, ctx, "GetAssignmentRecords", []any{from, to}, func(ctx context.Context) ([]models.AssignmentRecord, error) {
+		return r.next.GetAssignmentRecords(ctx, from, to)
+	})
+}
+
+func (r *timeoutRepo) GetOpenReviewLoad(ctx context.Context, teamName string) (map[string]int, error) {
+	return call(r, ctx, "GetOpenReviewLoad", []any{teamName}, func(ctx context.Context) (map[string]int, error) {
+		return r.next.GetOpenReviewLoad(ctx, teamName)
+	})
+}
+
+func (r *timeoutRepo) GetActiveMembersOfTeams(ctx context.Context, teamNames []string, exceptUser string) ([]string, error) {
+	return call(r, ctx, "GetActiveMembersOfTeams", []any{teamNames, exceptUser}, func(ctx context.Context) ([]string, error) {
+		return r.next.GetActiveMembersOfTeams(ctx, teamNames, exceptUser)
+	})
+}
+
+func (r *timeoutRepo) StartEscalation(ctx context.Context, prID, reason string) error {
+	return callErr(r, ctx, "StartEscalation", []any{prID, reason}, func(ctx context.Context) error {
+		return r.next.StartEscalation(ctx, prID, reason)
+	})
+}
+
+func (r *timeoutRepo) StartSLAEscalations(ctx context.Context, cutoff time.Time) (int, error) {
+	return call(r, ctx, "StartSLAEscalations", []any{cutoff}, func(ctx context.Context) (int, error) {
+		return r.next.StartSLAEscalations(ctx, cutoff)
+	})
+}
+
+func (r *timeoutRepo) GetActiveEscalations(ctx context.Context) ([]models.Escalation, error) {
+	return call(r, ctx, "GetActiveEscalations", nil, func(ctx context.Context) ([]models.Escalation, error) {
+		return r.next.GetActiveEscalations(ctx)
+	})
+}
+
+func (r *timeoutRepo) AdvanceEscalation(ctx context.Context, prID, reason string, hop int, recipients []string) error {
+	return callErr(r, ctx, "AdvanceEscalation", []any{prID, reason, hop, recipients}, func(ctx context.Context) error {
+		return r.next.AdvanceEscalation(ctx, prID, reason, hop, recipients)
+	})
+}
+
+func (r *timeoutRepo) GetUserStats(ctx context.Context, userID string, since time.Time) (models.UserStats, error) {
+	return call(r, ctx, "GetUserStats", []any{userID, since}, func(ctx context.Context) (models.UserStats, error) {
+		return r.next.GetUserStats(ctx, userID, since)
+	})
+}
+
+func (r *timeoutRepo) SetUserSkills(ctx context.Context, userID string, skills []string) error {
+	return callErr(r, ctx, "SetUserSkills", []any{userID, skills}, func(ctx context.Context) error {
+		return r.next.SetUserSkills(ctx, userID, skills)
+	})
+}
+
+func (r *timeoutRepo) GetSkillMatches(ctx context.Context, skills []string) (map[string]int, error) {
+	return call(r, ctx, "GetSkillMatches", []any{skills}, func(ctx context.Context) (map[string]int, error) {
+		return r.next.GetSkillMatches(ctx, skills)
+	})
+}
+
+func (r *timeoutRepo) GetSecurityCoverage(ctx context.Context, teamName string, since time.Time) (models.SecurityCoverage, error) {
+	return call(r, ctx, "GetSecurityCoverage", []any{teamName, since}, func(ctx context.Context) (models.SecurityCoverage, error) {
+		return r.next.GetSecurityCoverage(ctx, teamName, since)
+	})
+}
+
+func (r *timeoutRepo) SetVacation(ctx context.Context, userID string, v *models.Vacation) error {
+	return callErr(r, ctx, "SetVacation", []any{userID, v}, func(ctx context.Context) error {
+		return r.next.SetVacation(ctx, userID, v)
+	})
+}
+
+func (r *timeoutRepo) StartVacations(ctx context.Context, now time.Time) ([]string, error) {
+	return call(r, ctx, "StartVacations", []any{now}, func(ctx context.Context) ([]string, error) {
+		return r.next.StartVacations(ctx, now)
+	})
+}
+
+func (r *timeoutRepo) EndVacations(ctx context.Context, now time.Time) ([]string, error) {
+	return call(r, ctx, "EndVacations", []any{now}, func(ctx context.Context) ([]string, error) {
+		return r.next.EndVacations(ctx, now)
+	})
+}
+
+func (r *timeoutRepo) SetMentor(ctx context.Context, menteeID, mentorID string) error {
+	return callErr(r, ctx, "SetMentor", []any{menteeID, mentorID}, func(ctx context.Context) error {
+		return r.next.SetMentor(ctx, menteeID, mentorID)
+	})
+}
+
+func (r *timeoutRepo) GetMentors(ctx context.Context, userIDs []string) (map[string]string, error) {
+	return call(r, ctx, "GetMentors", []any{userIDs}, func(ctx context.Context) (map[string]string, error) {
+		return r.next.GetMentors(ctx, userIDs)
+	})
+}
+
+func (r *timeoutRepo) SetTimezone(ctx context.Context, userID, tz string) error {
+	return callErr(r, ctx, "SetTimezone", []any{userID, tz}, func(ctx context.Context) error {
+		return r.next.SetTimezone(ctx, userID, tz)
+	})
+}
+
+func (r *timeoutRepo) GetTimezones(ctx context.Context, userIDs []string) (map[string]string, error) {
+	return call(r, ctx, "GetTimezones", []any{userIDs}, func(ctx context.Context) (map[string]string, error) {
+		return r.next.GetTimezones(ctx, userIDs)
+	})
+}
+
+func (r *timeoutRepo) GetRampUpLoads(ctx context.Context, joinedAfter time.Time, maxAssigned int) (map[string]int, error) {
+	return call(r, ctx, "GetRampUpLoads", []any{joinedAfter, maxAssigned}, func(ctx context.Context) (map[string]int, error) {
+		return r.next.GetRampUpLoads(ctx, joinedAfter, maxAssigned)
+	})
+}
+
+func (r *timeoutRepo) SetUserWeight(ctx context.Context, userID string, weight int) error {
+	return callErr(r, ctx, "SetUserWeight", []any{userID, weight}, func(ctx context.Context) error {
+		return r.next.SetUserWeight(ctx, userID, weight)
+	})
+}
+
+func (r *timeoutRepo) GetUserWeights(ctx context.Context, userIDs []string) (map[string]int, error) {
+	return call(r, ctx, "GetUserWeights", []any{userIDs}, func(ctx context.Context) (map[string]int, error) {
+		return r.next.GetUserWeights(ctx, userIDs)
+	})
+}
+
+func (r *timeoutRepo) AddSecondaryReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	return call(r, ctx, "AddSecondaryReviewer", []any{prID, userID}, func(ctx context.Context) (models.PullRequest, error) {
+		return r.next.AddSecondaryReviewer(ctx, prID, userID)
+	})
+}
+
+func (r *timeoutRepo) GetStaleReviews(ctx context.Context, inactiveBefore time.Time) ([]models.PendingReview, error) {
+	return call(r, ctx, "GetStaleReviews", []any{inactiveBefore}, func(ctx context.Context) ([]models.PendingReview, error) {
+		return r.next.GetStaleReviews(ctx, inactiveBefore)
+	})
+}
+
+func (r *timeoutRepo) GetUnderstaffedPRs(ctx context.Context, teamName, userID string) ([]string, error) {
+	return call(r, ctx, "GetUnderstaffedPRs", []any{teamName, userID}, func(ctx context.Context) ([]string, error) {
+		return r.next.GetUnderstaffedPRs(ctx, teamName, userID)
+	})
+}
+
+func (r *timeoutRepo) ReserveReviewers(ctx context.Context, authorID string, userIDs []string, until time.Time) error {
+	return callErr(r, ctx, "ReserveReviewers", []any{authorID, userIDs, until}, func(ctx context.Context) error {
+		return r.next.ReserveReviewers(ctx, authorID, userIDs, until)
+	})
+}
+
+func (r *timeoutRepo) GetReservations(ctx context.Context, now time.Time) (map[string]string, error) {
+	return call(r, ctx, "GetReservations", []any{now}, func(ctx context.Context) (map[string]string, error) {
+		return r.next.GetReservations(ctx, now)
+	})
+}
+
+func (r *timeoutRepo) GetAssignmentHistory(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+	return call(r, ctx, "GetAssignmentHistory", []any{prID}, func(ctx context.Context) ([]models.AssignmentEvent, error) {
+		return r.next.GetAssignmentHistory(ctx, prID)
+	})
+}
+
+func (r *timeoutRepo) StartReviewRound(ctx context.Context, prID, requestedBy, freshReviewer string, dueAt *time.Time) (models.ReviewRound, error) {
+	return call(r, ctx, "StartReviewRound", []any{prID, requestedBy, freshReviewer, dueAt}, func(ctx context.Context) (models.ReviewRound, error) {
+		return r.next.StartReviewRound(ctx, prID, requestedBy, freshReviewer, dueAt)
+	})
+}
+
+func (r *timeoutRepo) GetReviewRounds(ctx context.Context, prID string) ([]models.ReviewRound, error) {
+	return call(r, ctx, "GetReviewRounds", []any{prID}, func(ctx context.Context) ([]models.ReviewRound, error) {
+		return r.next.GetReviewRounds(ctx, prID)
+	})
+}
+
+func (r *timeoutRepo) GetRecentReviewers(ctx context.Context, authorID string, since time.Time) ([]string, error) {
+	return call(r, ctx, "GetRecentReviewers", []any{authorID, since}, func(ctx context.Context) ([]string, error) {
+		return r.next.GetRecentReviewers(ctx, authorID, since)
+	})
+}
+
+func (r *timeoutRepo) SaveRotation(ctx context.Context, teamName string, weeks []models.RotationWeek) error {
+	return callErr(r, ctx, "SaveRotation", []any{teamName, weeks}, func(ctx context.Context) error {
+		return r.next.SaveRotation(ctx, teamName, weeks)
+	})
+}
+
+func (r *timeoutRepo) GetRotation(ctx context.Context, teamName string, from time.Time) ([]models.RotationWeek, error) {
+	return call(r, ctx, "GetRotation", []any{teamName, from}, func(ctx context.Context) ([]models.RotationWeek, error) {
+		return r.next.GetRotation(ctx, teamName, from)
+	})
+}
+
+func (r *timeoutRepo) SetPRStatus(ctx context.Context, prID string, from, to models.PRStatus) error {
+	return callErr(r, ctx, "SetPRStatus", []any{prID, from, to}, func(ctx context.Context) error {
+		return r.next.SetPRStatus(ctx, prID, from, to)
+	})
+}
+
+func (r *timeoutRepo) SetNotificationPrefs(ctx context.Context, userID string, prefs models.NotificationPrefs) error {
+	return callErr(r, ctx, "SetNotificationPrefs", []any{userID, prefs}, func(ctx context.Context) error {
+		return r.next.SetNotificationPrefs(ctx, userID, prefs)
+	})
+}
+
+func (r *timeoutRepo) GetMergeSubscribers(ctx context.Context, userIDs []string) ([]string, error) {
+	return call(r, ctx, "GetMergeSubscribers", []any{userIDs}, func(ctx context.Context) ([]string, error) {
+		return r.next.GetMergeSubscribers(ctx, userIDs)
+	})
+}
+
+func (r *timeoutRepo) CreateUserToken(ctx context.Context, userID, tokenHash string) error {
+	return callErr(r, ctx, "CreateUserToken", []any{userID, tokenHash}, func(ctx context.Context) error {
+		return r.next.CreateUserToken(ctx, userID, tokenHash)
+	})
+}
+
+func (r *timeoutRepo) GetUserByToken(ctx context.Context, tokenHash string) (string, error) {
+	return call(r, ctx, "GetUserByToken", []any{tokenHash}, func(ctx context.Context) (string, error) {
+		return r.next.GetUserByToken(ctx, tokenHash)
+	})
+}
+
+func (r *timeoutRepo) QueueMerge(ctx context.Context, prID, mergedBy string, mergeAt time.Time) error {
+	return callErr(r, ctx, "QueueMerge", []any{prID, mergedBy, mergeAt}, func(ctx context.Context) error {
+		return r.next.QueueMerge(ctx, prID, mergedBy, mergeAt)
+	})
+}
+
+func (r *timeoutRepo) GetDueMerges(ctx context.Context, now time.Time) ([]models.QueuedMerge, error) {
+	return call(r, ctx, "GetDueMerges", []any{now}, func(ctx context.Context) ([]models.QueuedMerge, error) {
+		return r.next.GetDueMerges(ctx, now)
+	})
+}
+
+func (r *timeoutRepo) DequeueMerge(ctx context.Context, prID string) error {
+	return callErr(r, ctx, "DequeueMerge", []any{prID}, func(ctx context.Context) error {
+		return r.next.DequeueMerge(ctx, prID)
+	})
+}
+
+func (r *timeoutRepo) SetPRLabels(ctx context.Context, prID string, labels []string) error {
+	return callErr(r, ctx, "SetPRLabels", []any{prID, labels}, func(ctx context.Context) error {
+		return r.next.SetPRLabels(ctx, prID, labels)
+	})
+}
+
+func (r *timeoutRepo) GetReviewLoadBySize(ctx context.Context, teamName string) ([]models.ReviewerLoad, error) {
+	return call(r, ctx, "GetReviewLoadBySize", []any{teamName}, func(ctx context.Context) ([]models.ReviewerLoad, error) {
+		return r.next.GetReviewLoadBySize(ctx, teamName)
+	})
+}
+
+func (r *timeoutRepo) GetTableStats(ctx context.Context) ([]models.TableStats, error) {
+	return call(r, ctx, "GetTableStats", nil, func(ctx context.Context) ([]models.TableStats, error) {
+		return r.next.GetTableStats(ctx)
+	})
+}
+
+func (r *timeoutRepo) GetArchivablePRs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	return call(r, ctx, "GetArchivablePRs", []any{before, limit}, func(ctx context.Context) ([]string, error) {
+		return r.next.GetArchivablePRs(ctx, before, limit)
+	})
+}
+
+func (r *timeoutRepo) ArchivePR(ctx context.Context, a models.ArchivedPR) error {
+	return callErr(r, ctx, "ArchivePR", []any{a}, func(ctx context.Context) error {
+		return r.next.ArchivePR(ctx, a)
+	})
+}
+
+func (r *timeoutRepo) GetArchivedPR(ctx context.Context, prID string) (models.ArchivedPR, error) {
+	return call(r, ctx, "GetArchivedPR", []any{prID}, func(ctx context.Context) (models.ArchivedPR, error) {
+		return r.next.GetArchivedPR(ctx, prID)
+	})
+}
+
+func (r *timeoutRepo) ListArchivedPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.ArchivedPRShort, error) {
+	return call(r, ctx, "ListArchivedPRs", []any{filter, page}, func(ctx context.Context) ([]models.ArchivedPRShort, error) {
+		return r.next.ListArchivedPRs(ctx, filter, page)
+	})
+}
+
+func (r *timeoutRepo) RecordReviewDecision(ctx context.Context, prID, userID string, decision models.ReviewStatus, comment string, t time.Time) error {
+	return callErr(r, ctx, "RecordReviewDecision", []any{prID, userID, decision, comment, t}, func(ctx context.Context) error {
+		return r.next.RecordReviewDecision(ctx, prID, userID, decision, comment, t)
+	})
+}
+
+func (r *timeoutRepo) ForgetUser(ctx context.Context, userID string) (models.User, error) {
+	return call(r, ctx, "ForgetUser", []any{userID}, func(ctx context.Context) (models.User, error) {
+		return r.next.ForgetUser(ctx, userID)
+	})
+}
+
+func (r *timeoutRepo) GetTeamStats(ctx context.Context, teamName string) (models.TeamStats, error) {
+	return call(r, ctx, "GetTeamStats", []any{teamName}, func(ctx context.Context) (models.TeamStats, error) {
+		return r.next.GetTeamStats(ctx, teamName)
+	})
+}
+
+func (r *timeoutRepo) GetRepositorySettings(ctx context.Context, repository string) (models.RepositorySettings, error) {
+	return call(r, ctx, "GetRepositorySettings", []any{repository}, func(ctx context.Context) (models.RepositorySettings, error) {
+		return r.next.GetRepositorySettings(ctx, repository)
+	})
+}
+
+func (r *timeoutRepo) SaveRepositorySettings(ctx context.Context, settings models.RepositorySettings) error {
+	return callErr(r, ctx, "SaveRepositorySettings", []any{settings}, func(ctx context.Context) error {
+		return r.next.SaveRepositorySettings(ctx, settings)
+	})
+}
+
+func (r *timeoutRepo) GetTeamMemberships(ctx context.Context, teamName string) ([]models.Membership, error) {
+	return call(r, ctx, "GetTeamMemberships", []any{teamName}, func(ctx context.Context) ([]models.Membership, error) {
+		return r.next.GetTeamMemberships(ctx, teamName)
+	})
+}
+
+func (r *timeoutRepo) FindPR(ctx context.Context, repository, id string) (string, error) {
+	return call(r, ctx, "FindPR", []any{repository, id}, func(ctx context.Context) (string, error) {
+		return r.next.FindPR(ctx, repository, id)
+	})
+}
@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"PR-reviewer/internal/logger"
+	"PR-reviewer/internal/models"
+)
+
+type slowRepo struct {
+	Repo
+	op string
+}
+
+func (r *slowRepo) GetPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	r.op = OperationFromContext(ctx)
+	<-ctx.Done()
+	return models.PullRequest{}, ctx.Err()
+}
+
+func TestWithTimeout(t *testing.T) {
+	inner := &slowRepo{}
+	r := WithTimeout(inner, 10*time.Millisecond, nil)
+
+	start := time.Now()
+	_, err := r.GetPR(context.Background(), "pr1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "GetPR timed out") {
+		t.Fatalf("expected operation in error, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("call was not bounded by the timeout")
+	}
+	if inner.op != "GetPR" {
+		t.Fatalf("expected operation GetPR in context, got %q", inner.op)
+	}
+}
+
+type failingRepo struct {
+	Repo
+	err error
+}
+
+func (r *failingRepo) GetPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	return models.PullRequest{}, r.err
+}
+
+func TestWithTimeout_ErrorMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithJobType(WithRequestID(context.Background(), "req-1"), "get_pr")
+
+	r := WithTimeout(&failingRepo{err: errors.New("select pr: connection reset")}, time.Second, logger.NewStdLogger(&buf, "info"))
+	_, err := r.GetPR(ctx, "pr1")
+
+	var opErr *OpError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("expected *OpError, got %T", err)
+	}
+	if opErr.Op != "GetPR" || opErr.RequestID != "req-1" || opErr.JobType != "get_pr" {
+		t.Fatalf("unexpected metadata %+v", opErr)
+	}
+	if err.Error() != "select pr: connection reset" {
+		t.Fatalf("message must be unchanged, got %q", err.Error())
+	}
+	for _, want := range []string{"op=GetPR", "request_id=req-1", "job=get_pr"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log line does not contain %q: %s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	r = WithTimeout(&failingRepo{err: errors.New("not found")}, time.Second, logger.NewStdLogger(&buf, "info"))
+	if _, err := r.GetPR(ctx, "pr1"); err == nil || err.Error() != "not found" {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected errors must not be logged, got %s", buf.String())
+	}
+}
+
+type sleepyRepo struct {
+	Repo
+}
+
+func (r *sleepyRepo) GetReviewerStats(ctx context.Context) (map[string]int, error) {
+	time.Sleep(20 * time.Millisecond)
+	return map[string]int{}, nil
+}
+
+func (r *sleepyRepo) GetPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	time.Sleep(20 * time.Millisecond)
+	return models.PullRequest{}, nil
+}
+
+func TestWithTimeout_SlowCalls(t *testing.T) {
+	var buf bytes.Buffer
+	r := WithTimeout(&sleepyRepo{}, time.Second, logger.NewStdLogger(&buf, "info"), WithSlowThreshold(5*time.Millisecond))
+
+	if _, err := r.GetReviewerStats(WithRequestID(context.Background(), "req-7")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"slow repo call", "op=GetReviewerStats", "request_id=req-7"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log line does not contain %q: %s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if _, err := r.GetPR(context.Background(), strings.Repeat("x", 100)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), strings.Repeat("x", maxLoggedArgLen)+"...]") || strings.Contains(buf.String(), strings.Repeat("x", maxLoggedArgLen+1)) {
+		t.Errorf("expected truncated argument: %s", buf.String())
+	}
+
+	buf.Reset()
+	r = WithTimeout(&sleepyRepo{}, time.Second, logger.NewStdLogger(&buf, "info"))
+	if _, err := r.GetReviewerStats(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("threshold disabled by default, got %s", buf.String())
+	}
+}
+
+type faultFunc func(ctx context.Context, target string) error
+
+func (f faultFunc) Inject(ctx context.Context, target string) error { return f(ctx, target) }
+
+func TestWithTimeout_Faults(t *testing.T) {
+	injected := errors.New("injected")
+	var target string
+	inner := &failingRepo{}
+	r := WithTimeout(inner, time.Second, nil, WithFaults(faultFunc(func(ctx context.Context, tg string) error {
+		target = tg
+		return injected
+	})))
+
+	_, err := r.GetPR(context.Background(), "pr1")
+	var opErr *OpError
+	if !errors.Is(err, injected) || !errors.As(err, &opErr) || opErr.Op != "GetPR" {
+		t.Fatalf("expected the injected error as an OpError for GetPR, got %v", err)
+	}
+	if target != "repo:GetPR" {
+		t.Fatalf("expected fault target repo:GetPR, got %q", target)
+	}
+}
@@ -0,0 +1,71 @@
+package selftest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+)
+
+var (
+	dependencyUp = metrics.NewGauge("readiness_dependency_up", "Whether a dependency passed its last readiness check (1) or not (0).", "dependency", "soft")
+	degraded     = metrics.NewGauge("readiness_degraded", "1 while a soft dependency is failing and the service runs degraded.")
+)
+
+type readyCheck struct {
+	Name       string `json:"name"`
+	Soft       bool   `json:"soft"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type readyResponse struct {
+	// Status is "ok", "degraded" when only soft dependencies fail, or
+	// "unavailable" when a hard one does.
+	Status string       `json:"status"`
+	Checks []readyCheck `json:"checks"`
+}
+
+// ReadyHandler runs checks on every probe. Optional checks are soft
+// dependencies: their failure is reported as degraded but the probe still
+// answers 200. A failed required check answers 503.
+func ReadyHandler(checks []Check, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := Run(r.Context(), timeout, checks)
+
+		resp := readyResponse{Status: "ok", Checks: make([]readyCheck, 0, len(rep.Results))}
+		soft := false
+		for _, res := range rep.Results {
+			c := readyCheck{Name: res.Name, Soft: res.Optional, OK: res.Err == nil, DurationMS: res.Duration.Milliseconds()}
+			up := 1.0
+			if res.Err != nil {
+				c.Error = res.Err.Error()
+				up = 0
+				soft = soft || res.Optional
+			}
+			dependencyUp.Set(up, res.Name, strconv.FormatBool(res.Optional))
+			resp.Checks = append(resp.Checks, c)
+		}
+
+		code := http.StatusOK
+		switch {
+		case !rep.Passed():
+			resp.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		case soft:
+			resp.Status = "degraded"
+		}
+		if resp.Status == "degraded" {
+			degraded.Set(1)
+		} else {
+			degraded.Set(0)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
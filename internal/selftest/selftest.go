@@ -0,0 +1,90 @@
+// Package selftest runs the dependency checks done at startup and by the
+// healthcheck subcommand, and renders them as one pass/fail report.
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+type Check struct {
+	Name string
+	// Optional failures are reported but do not fail the run.
+	Optional bool
+	Run      func(ctx context.Context) error
+}
+
+type Result struct {
+	Name     string
+	Optional bool
+	Err      error
+	Duration time.Duration
+}
+
+type Report struct {
+	Results []Result
+}
+
+// Run executes the checks in order, each bounded by timeout.
+func Run(ctx context.Context, timeout time.Duration, checks []Check) Report {
+	var rep Report
+	for _, c := range checks {
+		cctx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err := c.Run(cctx)
+		cancel()
+		rep.Results = append(rep.Results, Result{
+			Name:     c.Name,
+			Optional: c.Optional,
+			Err:      err,
+			Duration: time.Since(start),
+		})
+	}
+	return rep
+}
+
+// Passed reports whether every required check succeeded.
+func (r Report) Passed() bool {
+	for _, res := range r.Results {
+		if res.Err != nil && !res.Optional {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed reports whether the named check ran and failed.
+func (r Report) Failed(name string) bool {
+	for _, res := range r.Results {
+		if res.Name == name {
+			return res.Err != nil
+		}
+	}
+	return false
+}
+
+// Write renders one line per check followed by the overall verdict.
+func (r Report) Write(w io.Writer) error {
+	for _, res := range r.Results {
+		status := "PASS"
+		detail := ""
+		if res.Err != nil {
+			status = "FAIL"
+			if res.Optional {
+				status = "WARN"
+			}
+			detail = ": " + res.Err.Error()
+		}
+		if _, err := fmt.Fprintf(w, "%s %s (%s)%s\n", status, res.Name, res.Duration.Round(time.Millisecond), detail); err != nil {
+			return err
+		}
+	}
+	verdict := "self-test passed"
+	if !r.Passed() {
+		verdict = "self-test failed"
+	}
+	_, err := fmt.Fprintln(w, verdict)
+	return err
+}
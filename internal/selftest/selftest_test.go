@@ -0,0 +1,74 @@
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		{Name: "database", Run: func(ctx context.Context) error { return nil }},
+		{Name: "schema", Optional: true, Run: func(ctx context.Context) error { return errors.New("missing users.team_name") }},
+		{Name: "slow", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+
+	rep := Run(context.Background(), 10*time.Millisecond, checks)
+	if rep.Passed() {
+		t.Fatal("expected failure from the timed out required check")
+	}
+	if !rep.Failed("schema") || rep.Failed("database") {
+		t.Fatalf("unexpected results %+v", rep.Results)
+	}
+
+	var buf bytes.Buffer
+	if err := rep.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"PASS database", "WARN schema", "FAIL slow", "self-test failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report does not contain %q:\n%s", want, out)
+		}
+	}
+
+	if !Run(context.Background(), time.Second, checks[:2]).Passed() {
+		t.Fatal("optional failures must not fail the run")
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	testCases := []struct {
+		name           string
+		checks         []Check
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"all up", []Check{{Name: "database", Run: ok}, {Name: "notifier", Optional: true, Run: ok}}, http.StatusOK, `"status":"ok"`},
+		{"soft down", []Check{{Name: "database", Run: ok}, {Name: "notifier", Optional: true, Run: fail}}, http.StatusOK, `"status":"degraded"`},
+		{"hard down", []Check{{Name: "database", Run: fail}, {Name: "notifier", Optional: true, Run: ok}}, http.StatusServiceUnavailable, `"status":"unavailable"`},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ReadyHandler(tt.checks, time.Second).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain %s, got %s", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/notify"
+)
+
+// Alert rule names.
+const (
+	AlertQueueDepth          = "queue_depth"
+	AlertPRsWithoutReviewers = "prs_without_reviewers"
+	AlertSLABreachRate       = "sla_breach_rate"
+)
+
+// AlertConfig holds the alert thresholds. A zero threshold disables its
+// rule; a zero Interval disables alerting entirely.
+type AlertConfig struct {
+	Interval               time.Duration
+	MaxQueueDepth          int
+	MaxPRsWithoutReviewers int
+	// MaxSLABreachPct is the share of open PRs older than SLA, in percent.
+	MaxSLABreachPct float64
+	SLA             time.Duration
+}
+
+type alertReading struct {
+	value     float64
+	threshold float64
+}
+
+type alertState struct {
+	mu     sync.Mutex
+	active map[string]models.Alert
+}
+
+var alertsFired = metrics.NewCounter("alerts_fired_total", "Alerts that started firing.", "rule")
+
+// StartAlerts evaluates the alert rules on the scheduler until StopWorkers.
+func (s *PRService) StartAlerts(cfg AlertConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+	// Every replica watches its own queue and serves its own /alerts.
+	s.schedule("alerts", cfg.Interval, false, func(ctx context.Context) {
+		s.evaluateAlerts(ctx, cfg, time.Now())
+	})
+}
+
+// ActiveAlerts lists the currently firing alerts ordered by rule. They cover
+// every team, so team-scoped tokens are rejected.
+func (s *PRService) ActiveAlerts(ctx context.Context) ([]models.Alert, error) {
+	if _, ok := ScopeFromContext(ctx); ok {
+		return nil, ErrForbidden
+	}
+
+	s.alerts.mu.Lock()
+	defer s.alerts.mu.Unlock()
+
+	out := make([]models.Alert, 0, len(s.alerts.active))
+	for _, a := range s.alerts.active {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Rule < out[j].Rule })
+	return out, nil
+}
+
+func (s *PRService) evaluateAlerts(ctx context.Context, cfg AlertConfig, now time.Time) {
+	workerLog := s.log.WithWorker("scheduler-alerts")
+
+	observed := make(map[string]alertReading, 3)
+	if cfg.MaxQueueDepth > 0 {
+		observed[AlertQueueDepth] = alertReading{float64(len(s.jobs)), float64(cfg.MaxQueueDepth)}
+	}
+
+	needSLA := cfg.MaxSLABreachPct > 0 && cfg.SLA > 0
+	if cfg.MaxPRsWithoutReviewers > 0 || needSLA {
+		sig, err := s.repo.GetAlertSignals(ctx, now.Add(-cfg.SLA))
+		if err != nil {
+			// Keep the previous state of the PR rules rather than resolving
+			// them because the database is briefly unreachable.
+			workerLog.Warn("failed to load alert signals", "error", err)
+		} else {
+			if cfg.MaxPRsWithoutReviewers > 0 {
+				observed[AlertPRsWithoutReviewers] = alertReading{float64(sig.WithoutReviewers), float64(cfg.MaxPRsWithoutReviewers)}
+			}
+			if needSLA {
+				var rate float64
+				if sig.OpenPRs > 0 {
+					rate = float64(sig.OverSLA) * 100 / float64(sig.OpenPRs)
+				}
+				observed[AlertSLABreachRate] = alertReading{rate, cfg.MaxSLABreachPct}
+			}
+		}
+	}
+
+	var msgs []notify.Message
+	s.alerts.mu.Lock()
+	if s.alerts.active == nil {
+		s.alerts.active = make(map[string]models.Alert)
+	}
+	for rule, r := range observed {
+		value, threshold := r.value, r.threshold
+		prev, firing := s.alerts.active[rule]
+		switch {
+		case value > threshold && firing:
+			prev.Value = value
+			s.alerts.active[rule] = prev
+		case value > threshold:
+			s.alerts.active[rule] = models.Alert{Rule: rule, Value: value, Threshold: threshold, Since: now}
+			alertsFired.Inc(rule)
+			msgs = append(msgs, alertMessage("alert.firing", rule, value, threshold, now))
+		case firing:
+			delete(s.alerts.active, rule)
+			msgs = append(msgs, alertMessage("alert.resolved", rule, value, threshold, now))
+		}
+	}
+	s.alerts.mu.Unlock()
+
+	for _, msg := range msgs {
+		workerLog.Warn(msg.Title, "rule", msg.Fields["rule"], "value", msg.Fields["value"], "threshold", msg.Fields["threshold"])
+		if err := s.notifier.Notify(ctx, msg); err != nil {
+			workerLog.Error("failed to send alert notification", "rule", msg.Fields["rule"], "error", err)
+		}
+	}
+}
+
+func alertMessage(kind, rule string, value, threshold float64, now time.Time) notify.Message {
+	title := "alert firing: " + rule
+	if kind == "alert.resolved" {
+		title = "alert resolved: " + rule
+	}
+	return notify.Message{
+		Kind:  kind,
+		Title: title,
+		Text:  fmt.Sprintf("%s is %.2f (threshold %.2f)", rule, value, threshold),
+		Fields: map[string]string{
+			"rule":      rule,
+			"value":     fmt.Sprintf("%.2f", value),
+			"threshold": fmt.Sprintf("%.2f", threshold),
+		},
+		At: now.UTC(),
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// checkApprovals returns ErrNotEnoughApprovals while fewer of pr's assigned
+// reviewers have approved than its team requires. A team without settings
+// requires none.
+func (s *PRService) checkApprovals(ctx context.Context, pr models.PullRequest) error {
+	if pr.TeamName == "" {
+		return nil
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, pr.TeamName)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		s.log.Error("failed to load approval quorum", "team", pr.TeamName, "error", err)
+		return err
+	}
+	if settings.RequiredApprovals == 0 {
+		return nil
+	}
+
+	approved := 0
+	for _, r := range pr.Assigned {
+		if r.Status == models.ReviewApproved {
+			approved++
+		}
+	}
+	if approved < settings.RequiredApprovals {
+		return fmt.Errorf("%w: %d of %d", ErrNotEnoughApprovals, approved, settings.RequiredApprovals)
+	}
+	return nil
+}
+
+func validateRequiredApprovals(n int) error {
+	if n < 0 || n > maxReviewers {
+		return fmt.Errorf("%w: required_approvals must be 0..%d", ErrInvalidSettings, maxReviewers)
+	}
+	return nil
+}
+
+// SubmitReview records reviewer userID's decision on the PR with an
+// optional comment. APPROVED approves it like ApprovePR; CHANGES_REQUESTED
+// starts the next review round like RequestChanges, due after the team's
+// review SLA.
+func (s *PRService) SubmitReview(ctx context.Context, prID, userID string, decision models.ReviewStatus, comment string) (models.PRResult, error) {
+	switch decision {
+	case models.ReviewApproved:
+		pr, err := s.approve(ctx, prID, userID, comment)
+		if err != nil {
+			return models.PRResult{}, err
+		}
+		return models.PRResult{PR: pr}, nil
+	case models.ReviewChangesRequested:
+		return s.requestChanges(ctx, prID, userID, 0, false, comment)
+	}
+	return models.PRResult{}, fmt.Errorf("%w: decision must be APPROVED or CHANGES_REQUESTED", ErrInvalidReview)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/models"
+)
+
+// archiveBatch caps the PRs one archiver run moves, so a first run over a
+// long history doesn't hold the scheduler for long.
+const archiveBatch = 500
+
+var archivedPRs = metrics.NewCounter("archived_prs_total", "Merged and closed PRs moved into the archive.")
+
+// ArchiveConfig controls moving PRs merged or closed more than After ago
+// out of the hot tables. A zero Interval disables it.
+type ArchiveConfig struct {
+	Interval time.Duration
+	After    time.Duration
+}
+
+// StartArchiver archives finished PRs on the scheduler until StopWorkers.
+func (s *PRService) StartArchiver(cfg ArchiveConfig) {
+	if cfg.Interval <= 0 || cfg.After <= 0 {
+		return
+	}
+	s.schedule("archive", cfg.Interval, true, func(ctx context.Context) {
+		s.archivePRs(ctx, time.Now().Add(-cfg.After))
+	})
+}
+
+// archivePRs snapshots every PR merged or closed before before, with its
+// assignment history, into the archive. A PR that fails is retried on the
+// next run.
+func (s *PRService) archivePRs(ctx context.Context, before time.Time) {
+	workerLog := s.log.WithWorker("scheduler-archive")
+
+	ids, err := s.repo.GetArchivablePRs(ctx, before.UTC(), archiveBatch)
+	if err != nil {
+		workerLog.Warn("failed to get archivable PRs", "error", err)
+		return
+	}
+	archived := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		pr, err := s.repo.GetPR(ctx, id)
+		if err != nil {
+			workerLog.Warn("failed to fetch PR to archive", "pr", id, "error", err)
+			continue
+		}
+		history, err := s.repo.GetAssignmentHistory(ctx, id)
+		if err != nil {
+			workerLog.Warn("failed to fetch history of PR to archive", "pr", id, "error", err)
+			continue
+		}
+		a := models.ArchivedPR{PR: pr, History: history, ArchivedAt: time.Now().UTC()}
+		if err := s.repo.ArchivePR(ctx, a); err != nil {
+			workerLog.Warn("failed to archive PR", "pr", id, "error", err)
+			continue
+		}
+		archived++
+		archivedPRs.Inc()
+	}
+	if archived > 0 {
+		workerLog.Success("PRs archived", "count", archived)
+	}
+}
+
+// GetArchivedPR returns an archived PR with its assignment history. Team
+// tokens only see their own team's PRs.
+func (s *PRService) GetArchivedPR(ctx context.Context, prID string) (models.ArchivedPR, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.ArchivedPR{}, err
+	}
+	a, err := s.repo.GetArchivedPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.ArchivedPR{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch archived PR", "pr", prID, "error", err)
+		return models.ArchivedPR{}, err
+	}
+	if _, ok := ScopeFromContext(ctx); ok {
+		teamName, err := s.prTeam(ctx, a.PR)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return models.ArchivedPR{}, ErrNotFound
+			}
+			return models.ArchivedPR{}, err
+		}
+		if err := checkTeamScope(ctx, teamName); err != nil {
+			return models.ArchivedPR{}, err
+		}
+	}
+	return a, nil
+}
+
+// ListArchivedPRs lists archived PRs like ListPRs lists live ones.
+func (s *PRService) ListArchivedPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.ArchivedPRShort, error) {
+	if scope, ok := ScopeFromContext(ctx); ok {
+		if filter.TeamName != "" && filter.TeamName != scope.TeamName {
+			return nil, ErrForbidden
+		}
+		filter.TeamName = scope.TeamName
+	}
+	filter.Label = strings.ToLower(strings.TrimSpace(filter.Label))
+	return s.repo.ListArchivedPRs(ctx, filter, normalizePage(page))
+}
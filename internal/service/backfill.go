@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+
+	"PR-reviewer/internal/models"
+)
+
+// backfillReviewer assigns a user who has just become active to the open PRs
+// of their team that are short of reviewers, oldest first, so understaffed
+// PRs heal when people come back. Each PR takes the user into a free slot
+// only, and a user still ramping up stops at their cap. It returns how many
+// PRs the user joined; failures are logged and end the backfill.
+func (s *PRService) backfillReviewer(ctx context.Context, u models.User) int {
+	if !u.IsActive || u.TeamName == "" {
+		return 0
+	}
+	prIDs, err := s.repo.GetUnderstaffedPRs(ctx, u.TeamName, u.UserID)
+	if err != nil {
+		s.log.Warn("failed to get understaffed PRs", "user", u.UserID, "team", u.TeamName, "error", err)
+		return 0
+	}
+
+	added := 0
+	for _, prID := range prIDs {
+		if len(s.withinRampUp(ctx, []string{u.UserID})) == 0 {
+			break
+		}
+		updated, err := s.repo.AddReviewer(withCause(ctx, "backfill"), prID, u.UserID, maxReviewers)
+		if err != nil {
+			s.log.Warn("failed to backfill reviewer", "pr", prID, "user", u.UserID, "error", err)
+			break
+		}
+		reviewerAssignments.Inc("backfill")
+		s.pairMentor(ctx, updated, u.UserID)
+		added++
+	}
+	if added > 0 {
+		s.log.Success("reviewer backfilled", "user", u.UserID, "team", u.TeamName, "prs", added)
+	}
+	return added
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+type callerKey struct{}
+
+// WithCaller records which user a request acts on behalf of. It only drives
+// response filtering such as blind review, not authorization.
+func WithCaller(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, callerKey{}, userID)
+}
+
+func CallerFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(callerKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+type blindBypassKey struct{}
+
+// WithBlindReviewBypass lets an admin request see blind-reviewed PRs in
+// full.
+func WithBlindReviewBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, blindBypassKey{}, true)
+}
+
+func blindReviewBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(blindBypassKey{}).(bool)
+	return bypass
+}
+
+// redactResult applies blind review to a job result for the calling user:
+// until merge the author does not see reviewers and nobody else sees the
+// author. Calls without an identified caller could be either, so they see
+// neither unless the admin bypass is set.
+func (s *PRService) redactResult(ctx context.Context, data interface{}) interface{} {
+	if blindReviewBypassed(ctx) {
+		return data
+	}
+	caller, _ := CallerFromContext(ctx)
+	blind := make(map[string]bool)
+
+	switch v := data.(type) {
+	case models.PullRequest:
+		return s.redactPR(ctx, caller, v, blind)
+	case models.PRResult:
+		if v.ReplacedBy != "" && (caller == "" || caller == v.PR.AuthorID) && v.PR.Status != models.StatusMerged && s.isBlind(ctx, v.PR.TeamName, blind) {
+			v.ReplacedBy = ""
+		}
+		v.PR = s.redactPR(ctx, caller, v.PR, blind)
+		return v
+	case []models.PullRequestShort:
+		out := make([]models.PullRequestShort, len(v))
+		for i, pr := range v {
+			if pr.Status != models.StatusMerged && pr.AuthorID != caller && s.isBlind(ctx, pr.TeamName, blind) {
+				pr.AuthorID = ""
+			}
+			out[i] = pr
+		}
+		return out
+	}
+	return data
+}
+
+func (s *PRService) redactPR(ctx context.Context, caller string, pr models.PullRequest, blind map[string]bool) models.PullRequest {
+	if pr.Status == models.StatusMerged || !s.isBlind(ctx, pr.TeamName, blind) {
+		return pr
+	}
+	if caller == "" || caller == pr.AuthorID {
+		pr.Assigned = []models.PRReviewer{}
+		pr.Approvals = []models.PRApproval{}
+	}
+	if caller != pr.AuthorID {
+		pr.AuthorID = ""
+	}
+	return pr
+}
+
+// hidesReviewers reports whether blind review keeps the reviewers of pr
+// from the caller in ctx: the author or an unidentified caller, until merge.
+func (s *PRService) hidesReviewers(ctx context.Context, pr models.PullRequest) bool {
+	if pr.Status == models.StatusMerged || blindReviewBypassed(ctx) {
+		return false
+	}
+	if caller, ok := CallerFromContext(ctx); ok && caller != pr.AuthorID {
+		return false
+	}
+	return s.isBlind(ctx, pr.TeamName, map[string]bool{})
+}
+
+// isBlind reports whether teamName reviews blind. Teams without settings
+// don't; when the settings can't be read it fails closed and redacts.
+func (s *PRService) isBlind(ctx context.Context, teamName string, cache map[string]bool) bool {
+	if teamName == "" {
+		return false
+	}
+	if v, ok := cache[teamName]; ok {
+		return v
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	switch {
+	case err == nil:
+		cache[teamName] = settings.BlindReview
+	case strings.Contains(err.Error(), "not found"):
+		cache[teamName] = false
+	default:
+		s.log.Error("failed to load blind review setting", "team", teamName, "error", err)
+		cache[teamName] = true
+	}
+	return cache[teamName]
+}
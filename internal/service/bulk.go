@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// BulkReassign moves every open review held by fromUID, for offboarding.
+// Without toUID each PR gets a random free member of its team, as with
+// /pullRequest/reassign; with toUID that user takes them all. PRs that
+// cannot be handed over are reported and keep fromUID.
+func (s *PRService) BulkReassign(ctx context.Context, fromUID, toUID string) (models.BulkReassignReport, error) {
+	if err := validateUserID(fromUID); err != nil {
+		return models.BulkReassignReport{}, err
+	}
+	if fromUID == toUID {
+		return models.BulkReassignReport{}, fmt.Errorf("to_user_id must differ from from_user_id")
+	}
+	if err := s.checkBulkUsers(ctx, fromUID, toUID); err != nil {
+		return models.BulkReassignReport{}, err
+	}
+
+	prs, err := s.repo.GetPRsByReviewer(ctx, fromUID)
+	if err != nil {
+		s.log.Error("failed to get PRs for bulk reassign", "user", fromUID, "error", err)
+		return models.BulkReassignReport{}, err
+	}
+
+	report := models.BulkReassignReport{FromUserID: fromUID, ToUserID: toUID, Results: []models.BulkReassignResult{}}
+	cache := newOpCache(s.repo)
+	for _, prShort := range prs {
+		if !prShort.Status.Active() {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		default:
+		}
+
+		res := models.BulkReassignResult{PullRequestID: prShort.PullRequestID}
+		newUID, err := s.bulkReassignOne(ctx, cache, prShort.PullRequestID, fromUID, toUID)
+		if err != nil {
+			res.Error = err.Error()
+			if errors.Is(err, ErrNoCandidate) {
+				s.escalateReassignFailure(ctx, prShort.PullRequestID)
+			}
+			if !errors.Is(err, ErrNoCandidate) && !errors.Is(err, ErrAuthorReviewer) && !errors.Is(err, ErrAlreadyAssigned) {
+				s.log.Error("failed to reassign review", "pr", prShort.PullRequestID, "user", fromUID, "error", err)
+			}
+		} else {
+			res.NewReviewerID = newUID
+		}
+		report.Results = append(report.Results, res)
+	}
+
+	s.log.Success("bulk reassign finished", "from", fromUID, "to", toUID, "prs", len(report.Results))
+	return report, nil
+}
+
+func (s *PRService) checkBulkUsers(ctx context.Context, fromUID, toUID string) error {
+	if _, err := s.repo.GetUser(ctx, fromUID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrNotFound
+		}
+		return err
+	}
+	if toUID == "" {
+		return nil
+	}
+	to, err := s.repo.GetUser(ctx, toUID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrNotFound
+		}
+		return err
+	}
+	if !to.IsActive {
+		return ErrUserInactive
+	}
+	return nil
+}
+
+func (s *PRService) bulkReassignOne(ctx context.Context, cache *opCache, prID, fromUID, toUID string) (string, error) {
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		return "", err
+	}
+	if toUID == "" {
+		teamName, err := s.prTeam(ctx, pr)
+		if err != nil {
+			return "", err
+		}
+		return s.reassignReviewer(ctx, cache, prID, fromUID, teamName, "bulk")
+	}
+
+	if pr.AuthorID == toUID {
+		return "", ErrAuthorReviewer
+	}
+	for _, r := range pr.Assigned {
+		if r.UserID == toUID {
+			return "", ErrAlreadyAssigned
+		}
+	}
+	updated, err := s.repo.ReplaceReviewer(withCause(ctx, "bulk"), prID, fromUID, toUID)
+	if err != nil {
+		return "", err
+	}
+	reviewerReplacements.Inc("bulk")
+	cache.setPR(updated)
+	return toUID, nil
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"time"
+
+	"PR-reviewer/internal/metrics"
+)
+
+var changesApplied = metrics.NewCounter("data_changes_total", "Change notifications received from the database, by table; \"\" after a reconnect.", "table")
+
+// ApplyChange drops what the service has cached from table after a write by
+// this or another replica. An empty table means anything may have changed.
+func (s *PRService) ApplyChange(table string) {
+	changesApplied.Inc(table)
+	switch table {
+	case "", "teams", "users", "pull_requests", "pr_reviewers":
+		s.orgCache.mu.Lock()
+		s.orgCache.expiresAt = time.Time{}
+		s.orgCache.mu.Unlock()
+	}
+}
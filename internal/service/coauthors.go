@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"PR-reviewer/internal/models"
+)
+
+// splitCoAuthors sets aside the candidates who co-authored the PR, so an
+// independent reviewer is always preferred over one reviewing their own
+// work.
+func splitCoAuthors(candidateIDs, coAuthors []string) (independent, coAuthored []string) {
+	if len(coAuthors) == 0 {
+		return candidateIDs, nil
+	}
+	co := make(map[string]bool, len(coAuthors))
+	for _, id := range coAuthors {
+		co[id] = true
+	}
+	independent = make([]string, 0, len(candidateIDs))
+	for _, id := range candidateIDs {
+		if co[id] {
+			coAuthored = append(coAuthored, id)
+			continue
+		}
+		independent = append(independent, id)
+	}
+	return independent, coAuthored
+}
+
+// withCoAuthors fills the slots no independent reviewer was left for with
+// the PR's co-authors, best matching and least loaded first, and returns
+// the co-authors left over.
+func (s *PRService) withCoAuthors(ctx context.Context, teamName string, selected []models.PRReviewer, coAuthored []string, match map[string]int) ([]models.PRReviewer, []string, error) {
+	if len(selected) >= maxReviewers || len(coAuthored) == 0 {
+		return selected, coAuthored, nil
+	}
+	load, err := s.repo.GetOpenReviewLoad(ctx, teamName)
+	if err != nil {
+		s.log.Warn("failed to get open review load", "team", teamName, "error", err)
+	}
+	return s.pickReviewers(ctx, selected, coAuthored, load, match, s.selectionWeights(ctx, teamName, coAuthored))
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+
+	"PR-reviewer/internal/models"
+)
+
+// PreviewDeactivation reports what DeactivateTeam would do without changing
+// anything: which active PRs would lose reviewers and whether enough active
+// users outside the team are left to replace them. Each PR draws on its own
+// team, like the deactivation does, and is counted as if it were the only
+// one, so candidates shared by several PRs are not used up. The team's extra
+// members keep their reviews but are no candidates for its PRs any more.
+func (s *PRService) PreviewDeactivation(ctx context.Context, teamName string) (models.DeactivationPreview, error) {
+	team, err := s.GetTeam(ctx, teamName)
+	if err != nil {
+		return models.DeactivationPreview{}, err
+	}
+
+	leaving := make(map[string]bool, len(team.Members))
+	for _, m := range team.Members {
+		leaving[m.UserID] = true
+	}
+	memberships, err := s.repo.GetTeamMemberships(ctx, teamName)
+	if err != nil {
+		s.log.Error("failed to get team memberships", "team", teamName, "error", err)
+		return models.DeactivationPreview{}, err
+	}
+	demoted := make(map[string]bool, len(team.Members)+len(memberships))
+	for id := range leaving {
+		demoted[id] = true
+	}
+	for _, m := range memberships {
+		demoted[m.UserID] = true
+	}
+	preview := models.DeactivationPreview{
+		TeamName:   teamName,
+		Members:    len(team.Members),
+		Candidates: map[string]int{},
+		Stranded:   []models.StrandedPR{},
+	}
+
+	cache := newOpCache(s.repo)
+	processed := make(map[string]struct{})
+	for _, member := range team.Members {
+		if err := ctx.Err(); err != nil {
+			return models.DeactivationPreview{}, err
+		}
+		prs, err := s.repo.GetPRsByReviewer(ctx, member.UserID)
+		if err != nil {
+			s.log.Error("failed to get PRs for member", "user", member.UserID, "error", err)
+			return models.DeactivationPreview{}, err
+		}
+
+		for _, prShort := range prs {
+			if _, ok := processed[prShort.PullRequestID]; ok {
+				continue
+			}
+			processed[prShort.PullRequestID] = struct{}{}
+
+			pr, err := cache.getPR(ctx, prShort.PullRequestID)
+			if err != nil {
+				s.log.Error("failed to get full PR", "pr", prShort.PullRequestID, "error", err)
+				return models.DeactivationPreview{}, err
+			}
+			if !pr.Status.Active() {
+				continue
+			}
+
+			replace := 0
+			for _, rev := range pr.Assigned {
+				if leaving[rev.UserID] {
+					replace++
+					continue
+				}
+				if user, err := cache.getUser(ctx, rev.UserID); err == nil && !user.IsActive {
+					replace++
+				}
+			}
+			if replace == 0 {
+				continue
+			}
+
+			candidateTeam := teamName
+			if pr.TeamName != "" {
+				candidateTeam = pr.TeamName
+			}
+			avail, err := s.replacementCandidates(ctx, cache, pr, candidateTeam)
+			if err != nil {
+				s.log.Error("failed to get replacement candidates", "team", candidateTeam, "error", err)
+				return models.DeactivationPreview{}, err
+			}
+			gone := leaving
+			if candidateTeam == teamName {
+				gone = demoted
+			}
+			avail = withoutLeaving(avail, gone)
+			if _, ok := preview.Candidates[candidateTeam]; !ok {
+				members, err := cache.activeMembers(ctx, candidateTeam)
+				if err != nil {
+					return models.DeactivationPreview{}, err
+				}
+				preview.Candidates[candidateTeam] = len(withoutLeaving(members, gone))
+			}
+
+			preview.PRs++
+			preview.Reviews += replace
+			reassignable := min(replace, len(avail))
+			preview.Reassignable += reassignable
+			if reassignable < replace {
+				preview.Stranded = append(preview.Stranded, models.StrandedPR{
+					PullRequestID: pr.PullRequestID,
+					TeamName:      candidateTeam,
+					Missing:       replace - reassignable,
+				})
+			}
+		}
+	}
+	preview.Enough = preview.Reassignable == preview.Reviews
+	return preview, nil
+}
+
+func withoutLeaving(ids []string, leaving map[string]bool) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !leaving[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
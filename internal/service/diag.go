@@ -0,0 +1,119 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// WorkerState is what one worker is doing right now. JobType is empty for
+// an idle worker.
+type WorkerState struct {
+	ID        int       `json:"id"`
+	JobType   string    `json:"job_type,omitempty"`
+	BusySince time.Time `json:"busy_since,omitempty"`
+}
+
+// SchedulerState is a scheduled task and its last run that finished within
+// its interval. LastRun is zero until then.
+type SchedulerState struct {
+	Name     string    `json:"name"`
+	Interval string    `json:"interval"`
+	LastRun  time.Time `json:"last_run,omitempty"`
+}
+
+// Diagnostics is a point-in-time view of the job queue, workers and
+// scheduled tasks, used by the diagnostic dump and the status page.
+type Diagnostics struct {
+	QueueDepth    int              `json:"queue_depth"`
+	QueueCapacity int              `json:"queue_capacity"`
+	QueuedByType  map[string]int   `json:"queued_by_type"`
+	Workers       []WorkerState    `json:"workers"`
+	Schedulers    []SchedulerState `json:"schedulers"`
+}
+
+// diagState tracks queued job types and worker activity. Buffered channel
+// contents cannot be inspected, so queued jobs are counted on the way in
+// and out.
+type diagState struct {
+	mu         sync.Mutex
+	queued     map[string]int
+	workers    []WorkerState
+	schedulers map[string]SchedulerState
+}
+
+func newDiagState(workers int) *diagState {
+	d := &diagState{queued: make(map[string]int), workers: make([]WorkerState, workers), schedulers: make(map[string]SchedulerState)}
+	for i := range d.workers {
+		d.workers[i].ID = i + 1
+	}
+	return d
+}
+
+func (d *diagState) enqueued(jobType string) {
+	d.mu.Lock()
+	d.queued[jobType]++
+	d.mu.Unlock()
+}
+
+// dropped undoes enqueued for a job that never made it into the queue.
+func (d *diagState) dropped(jobType string) {
+	d.mu.Lock()
+	d.dequeue(jobType)
+	d.mu.Unlock()
+}
+
+func (d *diagState) started(workerID int, jobType string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dequeue(jobType)
+	d.workers[workerID-1] = WorkerState{ID: workerID, JobType: jobType, BusySince: time.Now()}
+}
+
+func (d *diagState) dequeue(jobType string) {
+	if d.queued[jobType]--; d.queued[jobType] <= 0 {
+		delete(d.queued, jobType)
+	}
+}
+
+func (d *diagState) finished(workerID int) {
+	d.mu.Lock()
+	d.workers[workerID-1] = WorkerState{ID: workerID}
+	d.mu.Unlock()
+}
+
+func (d *diagState) scheduled(name string, interval time.Duration) {
+	d.mu.Lock()
+	d.schedulers[name] = SchedulerState{Name: name, Interval: interval.String()}
+	d.mu.Unlock()
+}
+
+func (d *diagState) ran(name string, at time.Time) {
+	d.mu.Lock()
+	st := d.schedulers[name]
+	st.LastRun = at
+	d.schedulers[name] = st
+	d.mu.Unlock()
+}
+
+func (s *PRService) Diagnostics() Diagnostics {
+	s.diag.mu.Lock()
+	defer s.diag.mu.Unlock()
+
+	out := Diagnostics{
+		QueueDepth:    len(s.jobs),
+		QueueCapacity: cap(s.jobs),
+		QueuedByType:  make(map[string]int, len(s.diag.queued)),
+		Workers:       append([]WorkerState(nil), s.diag.workers...),
+		Schedulers:    make([]SchedulerState, 0, len(s.diag.schedulers)),
+	}
+	for t, n := range s.diag.queued {
+		out.QueuedByType[t] = n
+	}
+	for _, st := range s.diag.schedulers {
+		out.Schedulers = append(out.Schedulers, st)
+	}
+	sort.Slice(out.Workers, func(i, j int) bool { return out.Workers[i].ID < out.Workers[j].ID })
+	sort.Slice(out.Schedulers, func(i, j int) bool { return out.Schedulers[i].Name < out.Schedulers[j].Name })
+	return out
+}
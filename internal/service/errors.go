@@ -1,14 +1,88 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
 
 var (
 	ErrNotFound       = errors.New("not found")
 	ErrPRExists       = errors.New("pr exists")
+	ErrTeamExists     = errors.New("team exists")
+	ErrTeamInUse      = errors.New("team in use")
 	ErrPRMerged       = errors.New("pr merged")
+	ErrPRClosed       = errors.New("pr closed")
+	ErrBadTransition  = errors.New("illegal status transition")
 	ErrNotAssigned    = errors.New("not assigned")
 	ErrNoCandidate    = errors.New("no candidate")
 	ErrUnknownJobType = errors.New("unknown job type")
 	ErrJobQueueFull   = errors.New("job queue full")
 	ErrUserInactive   = errors.New("user inactive")
+	ErrUnauthorized   = errors.New("unauthorized")
+	ErrForbidden      = errors.New("forbidden")
+	ErrHomeTeam       = errors.New("home team")
+
+	ErrAuthorReviewer  = errors.New("author cannot review")
+	ErrAlreadyAssigned = errors.New("already assigned")
+	ErrReviewersFull   = errors.New("reviewers full")
+	ErrSecondaryTaken  = errors.New("secondary taken")
+	ErrReviewerHeld    = errors.New("reviewer reserved")
+
+	ErrNotEnoughApprovals = errors.New("not enough approvals")
+	ErrMergeWindowClosed  = errors.New("merge window closed")
+	ErrUnknownMerger      = errors.New("unknown merged_by user")
+
+	ErrInvalidSettings = errors.New("invalid settings")
+	ErrExportDisabled  = errors.New("export disabled")
+	ErrInvalidVacation = errors.New("invalid vacation")
+	ErrInvalidMentor   = errors.New("invalid mentor")
+	ErrInvalidTimezone = errors.New("invalid timezone")
+	ErrInvalidWeight   = errors.New("invalid weight")
+
+	ErrInvalidReservation = errors.New("invalid reservation")
+	ErrInvalidRound       = errors.New("invalid round")
+	ErrInvalidReview      = errors.New("invalid review")
+	ErrInvalidRotation    = errors.New("invalid rotation")
 )
+
+// StatusError is a status change the PR's current status doesn't allow. It
+// matches ErrBadTransition, and ErrPRMerged or ErrPRClosed when the PR is
+// already merged or closed.
+type StatusError struct {
+	From models.PRStatus
+	To   models.PRStatus
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("cannot move pr from %s to %s", e.From, e.To)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrBadTransition:
+		return true
+	case ErrPRMerged:
+		return e.From == models.StatusMerged
+	case ErrPRClosed:
+		return e.From == models.StatusClosed
+	}
+	return false
+}
+
+// MergeWindowError is a merge attempted while the PR's team has merges
+// frozen. It matches ErrMergeWindowClosed.
+type MergeWindowError struct {
+	TeamName string
+	OpensAt  time.Time
+}
+
+func (e *MergeWindowError) Error() string {
+	return fmt.Sprintf("merge window of team %s is closed until %s", e.TeamName, e.OpensAt.UTC().Format(time.RFC3339))
+}
+
+func (e *MergeWindowError) Is(target error) bool {
+	return target == ErrMergeWindowClosed
+}
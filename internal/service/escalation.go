@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/notify"
+)
+
+const maxEscalationHops = 5
+
+// EscalationConfig controls escalation chains. A zero Interval disables
+// them; a zero SLA leaves only failed auto-reassignments escalating.
+type EscalationConfig struct {
+	Interval time.Duration
+	SLA      time.Duration
+}
+
+var escalationsSent = metrics.NewCounter("escalation_hops_total", "Escalation hops sent, by reason and hop number.", "reason", "hop")
+
+// StartEscalations walks the teams' escalation chains on the scheduler
+// until StopWorkers.
+func (s *PRService) StartEscalations(cfg EscalationConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+	s.schedule("escalations", cfg.Interval, true, func(ctx context.Context) {
+		s.runEscalations(ctx, cfg, time.Now())
+	})
+}
+
+func (s *PRService) runEscalations(ctx context.Context, cfg EscalationConfig, now time.Time) {
+	workerLog := s.log.WithWorker("scheduler-escalations")
+
+	if cfg.SLA > 0 {
+		if n, err := s.repo.StartSLAEscalations(ctx, now.Add(-cfg.SLA)); err != nil {
+			workerLog.Warn("failed to start sla escalations", "error", err)
+		} else if n > 0 {
+			workerLog.Info("sla escalations started", "count", n)
+		}
+	}
+
+	active, err := s.repo.GetActiveEscalations(ctx)
+	if err != nil {
+		workerLog.Warn("failed to load escalations", "error", err)
+		return
+	}
+	chains := make(map[string][]models.EscalationHop)
+	for _, e := range active {
+		chain, ok := chains[e.TeamName]
+		if !ok {
+			if settings, err := s.repo.GetTeamSettings(ctx, e.TeamName); err == nil {
+				chain = settings.Escalation
+			} else if !strings.Contains(err.Error(), "not found") {
+				workerLog.Warn("failed to load escalation chain", "team", e.TeamName, "error", err)
+				continue
+			}
+			chains[e.TeamName] = chain
+		}
+
+		for hop := e.NextHop; hop < len(chain); hop++ {
+			step := chain[hop]
+			if now.Before(e.StartedAt.Add(time.Duration(step.DelayMinutes) * time.Minute)) {
+				break
+			}
+			recipients := []string{step.To}
+			if step.To == models.EscalationTarget {
+				recipients = e.Reviewers
+			}
+			if err := s.notifier.Notify(ctx, escalationMessage(e, hop, step, recipients, now)); err != nil {
+				workerLog.Error("failed to send escalation", "pr", e.PullRequestID, "reason", e.Reason, "hop", hop, "error", err)
+				break
+			}
+			if err := s.repo.AdvanceEscalation(ctx, e.PullRequestID, e.Reason, hop, recipients); err != nil {
+				if !strings.Contains(err.Error(), "conflict") {
+					workerLog.Error("failed to record escalation", "pr", e.PullRequestID, "reason", e.Reason, "hop", hop, "error", err)
+				}
+				break
+			}
+			escalationsSent.Inc(e.Reason, strconv.Itoa(hop+1))
+			workerLog.Info("escalation sent", "pr", e.PullRequestID, "reason", e.Reason, "hop", hop+1, "to", strings.Join(recipients, ","))
+		}
+	}
+}
+
+func escalationMessage(e models.Escalation, hop int, step models.EscalationHop, recipients []string, now time.Time) notify.Message {
+	title := step.Title
+	if title == "" {
+		title = "escalation: " + e.PullRequestName
+	}
+	text := fmt.Sprintf("%s has been open past the review SLA", e.PullRequestID)
+	if e.Reason == models.EscalationReassignFailed {
+		text = fmt.Sprintf("%s lost a reviewer and no replacement was found", e.PullRequestID)
+	}
+	return notify.Message{
+		Kind:  "review.escalation",
+		Title: title,
+		Text:  fmt.Sprintf("%s (hop %d, escalating since %s)", text, hop+1, e.StartedAt.UTC().Format(time.RFC3339)),
+		Fields: map[string]string{
+			"pull_request_id": e.PullRequestID,
+			"team_name":       e.TeamName,
+			"reason":          e.Reason,
+			"hop":             strconv.Itoa(hop + 1),
+			"to":              strings.Join(recipients, ","),
+		},
+		At: now.UTC(),
+	}
+}
+
+// escalateReassignFailure starts the reassign_failed escalation of a PR an
+// automatic handoff could not staff. Failures only cost the escalation.
+func (s *PRService) escalateReassignFailure(ctx context.Context, prID string) {
+	if err := s.repo.StartEscalation(ctx, prID, models.EscalationReassignFailed); err != nil {
+		s.log.Warn("failed to start escalation", "pr", prID, "error", err)
+	}
+}
+
+func (s *PRService) validateEscalation(ctx context.Context, chain []models.EscalationHop) error {
+	if len(chain) > maxEscalationHops {
+		return fmt.Errorf("%w: at most %d escalation hops", ErrInvalidSettings, maxEscalationHops)
+	}
+	prev := 0
+	for _, hop := range chain {
+		if hop.DelayMinutes < prev {
+			return fmt.Errorf("%w: escalation delays must not decrease", ErrInvalidSettings)
+		}
+		prev = hop.DelayMinutes
+		if hop.To == models.EscalationTarget {
+			continue
+		}
+		if hop.To == "" {
+			return fmt.Errorf("%w: escalation hop needs a recipient", ErrInvalidSettings)
+		}
+		if _, err := s.repo.GetUser(ctx, hop.To); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return fmt.Errorf("%w: unknown escalation recipient %q", ErrInvalidSettings, hop.To)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"PR-reviewer/internal/export"
+)
+
+const defaultExportWindow = 24 * time.Hour
+
+// ExportAssignments returns the anonymized assignments made in [from, to).
+// A zero to means now and a zero from means a day before to. It is off
+// unless an export salt is configured, and admin only.
+func (s *PRService) ExportAssignments(ctx context.Context, from, to time.Time) ([]export.Record, error) {
+	if _, ok := ScopeFromContext(ctx); ok {
+		return nil, ErrForbidden
+	}
+	if s.exportSalt == "" {
+		return nil, ErrExportDisabled
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultExportWindow)
+	}
+
+	recs, err := s.repo.GetAssignmentRecords(ctx, from, to)
+	if err != nil {
+		s.log.Error("failed to load assignment records", "error", err)
+		return nil, err
+	}
+	return export.NewAnonymizer(s.exportSalt).Records(recs), nil
+}
+
+// StartAssignmentExport writes the assignments made since the previous run
+// to sink every interval. A failed run is retried with the same window on
+// the next tick.
+func (s *PRService) StartAssignmentExport(interval time.Duration, sink export.Sink) {
+	if interval <= 0 || sink == nil || s.exportSalt == "" {
+		return
+	}
+	from := time.Now().Add(-interval)
+	s.schedule("export", interval, true, func(ctx context.Context) {
+		taskLog := s.log.WithWorker("scheduler-export")
+		to := time.Now()
+		recs, err := s.ExportAssignments(ctx, from, to)
+		if err != nil {
+			taskLog.Warn("assignment export failed", "error", err)
+			return
+		}
+		if len(recs) > 0 {
+			var buf bytes.Buffer
+			if err := export.Write(&buf, recs); err != nil {
+				taskLog.Error("failed to encode assignment export", "error", err)
+				return
+			}
+			name := "assignments-" + from.UTC().Format("20060102T150405Z") + "-" + to.UTC().Format("20060102T150405Z") + ".ndjson"
+			if err := sink.Put(ctx, name, buf.Bytes()); err != nil {
+				taskLog.Warn("failed to store assignment export", "name", name, "error", err)
+				return
+			}
+			taskLog.Info("assignment export written", "name", name, "records", len(recs))
+		}
+		from = to
+	})
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/repo"
+)
+
+type taskKey struct{}
+
+// withCause tags ctx with why reviewers are about to change, for the
+// assignment history.
+func withCause(ctx context.Context, reason string) context.Context {
+	return repo.WithAssignmentCause(ctx, repo.AssignmentCause{Reason: reason, TriggeredBy: triggeredBy(ctx)})
+}
+
+// triggeredBy names who asked for the change in ctx: a scheduled task, a
+// team token or, without either, an admin.
+func triggeredBy(ctx context.Context) string {
+	if task, ok := ctx.Value(taskKey{}).(string); ok {
+		return "scheduler:" + task
+	}
+	if scope, ok := ScopeFromContext(ctx); ok {
+		return "team:" + scope.TeamName
+	}
+	return "admin"
+}
+
+// AssignmentHistory lists every reviewer assignment and removal on the PR
+// with its reason, oldest first. Under blind review the author, like any
+// unidentified caller, gets an empty history until merge, as it names the
+// reviewers.
+func (s *PRService) AssignmentHistory(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+	if err := validatePRID(prID); err != nil {
+		return nil, err
+	}
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
+		return nil, err
+	}
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for history", "pr", prID, "error", err)
+		return nil, err
+	}
+	if s.hidesReviewers(ctx, pr) {
+		return []models.AssignmentEvent{}, nil
+	}
+
+	events, err := s.repo.GetAssignmentHistory(ctx, prID)
+	if err != nil {
+		s.log.Error("failed to get assignment history", "pr", prID, "error", err)
+		return nil, err
+	}
+	return events, nil
+}
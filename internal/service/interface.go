@@ -1,20 +1,54 @@
 package service
 
 import (
+	"PR-reviewer/internal/export"
 	"PR-reviewer/internal/models"
 	"context"
+	"time"
 )
 
 type Service interface {
 	AddTeam(ctx context.Context, m models.Team) error
 	GetTeam(ctx context.Context, name string) (models.Team, error)
+	UpdateTeam(ctx context.Context, upd models.TeamUpdate) (models.Team, error)
+	AddTeamMember(ctx context.Context, teamName string, member models.TeamMember) (models.Team, error)
+	MoveUserTeam(ctx context.Context, userID, teamName string, reassign bool) (models.UserMove, error)
+	RemoveTeamMember(ctx context.Context, teamName, userID string) (models.MemberHandoff, error)
 	SetUserActive(ctx context.Context, userID string, active bool) (models.User, error)
 	CreatePR(ctx context.Context, pr models.PullRequest) (models.PullRequest, error)
-	MergePR(ctx context.Context, prID string) (models.PullRequest, error)
+	GetPR(ctx context.Context, prID string) (models.PullRequest, error)
+	MergePR(ctx context.Context, prID, mergedBy string, override bool) (models.PullRequest, error)
+	ClosePR(ctx context.Context, prID string) (models.PullRequest, error)
+	ReopenPR(ctx context.Context, prID string) (models.PullRequest, error)
 	Reassign(ctx context.Context, prID, oldUser string) (models.PullRequest, string, error)
+	ApprovePR(ctx context.Context, prID, userID string) (models.PullRequest, error)
 	GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error)
+	GetPRsByAuthor(ctx context.Context, userID string) ([]models.PullRequestShort, error)
+	GetUserActivity(ctx context.Context, userID string, page models.Page) ([]models.UserEvent, error)
+	GetUserStats(ctx context.Context, userID string, weeks int) (models.UserStats, error)
+	GetSecurityCoverage(ctx context.Context, teamName string, weeks int) (models.SecurityCoverage, error)
+	SetUserSkills(ctx context.Context, userID string, skills []string) (models.UserProfile, error)
+	SetVacation(ctx context.Context, userID string, from, until time.Time) (models.UserProfile, error)
+	SetMentor(ctx context.Context, userID, mentorID string) (models.UserProfile, error)
+	SetTimezone(ctx context.Context, userID, tz string) (models.UserProfile, error)
+	SetUserWeight(ctx context.Context, userID string, weight int) (models.UserProfile, error)
+	AcknowledgeReview(ctx context.Context, prID, userID string) (models.PullRequest, error)
+	ListPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error)
+	SearchPRs(ctx context.Context, query string, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error)
+	SuggestReviewers(ctx context.Context, authorID string, count int) ([]models.ReviewerSuggestion, error)
 	GetStats(ctx context.Context) (map[string]int, error)
+	GetOrgSummary(ctx context.Context) (models.OrgSummary, error)
+	ExportAssignments(ctx context.Context, from, to time.Time) ([]export.Record, error)
+	BulkReassign(ctx context.Context, fromUID, toUID string) (models.BulkReassignReport, error)
+	CheckConsistency(ctx context.Context, repair bool) (models.ConsistencyReport, error)
+	ActiveAlerts(ctx context.Context) ([]models.Alert, error)
+	QueryStats(ctx context.Context, q models.StatsQuery, budget time.Duration) (models.StatsReport, error)
 	DeactivateTeam(ctx context.Context, teamName string) error
+	DeleteTeam(ctx context.Context, teamName string, force bool) ([]string, error)
+	GetTeamSettings(ctx context.Context, teamName string) (models.TeamSettings, error)
+	UpdateTeamSettings(ctx context.Context, settings models.TeamSettings) (models.TeamSettings, error)
+	IssueTeamToken(ctx context.Context, teamName string) (string, error)
+	ResolveTokenScope(ctx context.Context, token string) (Scope, error)
 
 	EnqueueJob(job Job)
 	StopWorkers()
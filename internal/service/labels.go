@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// SetPRLabels replaces the PR's labels. Labels route reviewer picks, so the
+// new ones steer later fills rather than reshuffling current reviewers.
+func (s *PRService) SetPRLabels(ctx context.Context, prID string, labels []string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for labels", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+	if err := checkActive(pr.Status); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	labels = normalizeLabels(labels)
+	if err := s.repo.SetPRLabels(ctx, prID, labels); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to set labels", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+	s.log.Success("labels updated", "pr", prID, "labels", strings.Join(labels, ","))
+	pr.Labels = labels
+	return pr, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"PR-reviewer/internal/models"
+)
+
+// IssueUserToken issues a token bound to userID. It authenticates the user
+// to the /me endpoints and nothing else, so a bot acting for the user never
+// holds the authority to read anyone else.
+func (s *PRService) IssueUserToken(ctx context.Context, userID string) (string, error) {
+	if err := validateUserID(userID); err != nil {
+		return "", err
+	}
+	if _, ok := ScopeFromContext(ctx); ok {
+		return "", ErrForbidden
+	}
+	if _, err := s.GetUserProfile(ctx, userID); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, teamTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("crypto rand failed: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := s.repo.CreateUserToken(ctx, userID, hashToken(token)); err != nil {
+		s.log.Error("failed to store user token", "user", userID, "error", err)
+		return "", err
+	}
+	s.log.Success("user token issued", "user", userID)
+	return token, nil
+}
+
+// me returns the user the request's token is bound to, or ErrUnauthorized
+// without a user-bound token. X-User-ID is only a hint and doesn't count.
+func me(ctx context.Context) (string, error) {
+	if scope, ok := ScopeFromContext(ctx); ok && scope.UserID != "" {
+		return scope.UserID, nil
+	}
+	return "", ErrUnauthorized
+}
+
+// MyReviews returns the PRs the token's user reviews, most urgent first.
+func (s *PRService) MyReviews(ctx context.Context) ([]models.PullRequestShort, error) {
+	userID, err := me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prs, err := s.repo.GetPRsByReviewer(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to get own reviews", "user", userID, "error", err)
+		return nil, err
+	}
+	sortByPriority(prs)
+	return prs, nil
+}
+
+// MyStats returns the token's user's review stats, like GetUserStats.
+func (s *PRService) MyStats(ctx context.Context, weeks int) (models.UserStats, error) {
+	userID, err := me(ctx)
+	if err != nil {
+		return models.UserStats{}, err
+	}
+	return s.userStats(ctx, userID, weeks)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// AddMembership lets a user review for a team besides their home team, or
+// changes their role there. Load is per user, so reviews from every team
+// count against the same person when candidates are ranked.
+func (s *PRService) AddMembership(ctx context.Context, m models.Membership) error {
+	if err := validateTeamName(m.TeamName); err != nil {
+		return err
+	}
+	if err := validateUserID(m.UserID); err != nil {
+		return err
+	}
+	if m.Role == "" {
+		m.Role = models.RoleMember
+	}
+	if !m.Role.Valid() {
+		return fmt.Errorf("invalid role %q", m.Role)
+	}
+
+	user, err := s.repo.GetUser(ctx, m.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrNotFound
+		}
+		return err
+	}
+	if user.TeamName == m.TeamName {
+		return ErrHomeTeam
+	}
+
+	if err := s.repo.AddMembership(ctx, m); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrNotFound
+		}
+		s.log.Error("failed to add membership", "team", m.TeamName, "user", m.UserID, "error", err)
+		return err
+	}
+	s.log.Success("membership added", "team", m.TeamName, "user", m.UserID, "role", m.Role)
+	return nil
+}
+
+// RemoveMembership drops an extra membership. Reviews the user already holds
+// on the team's PRs stay assigned.
+func (s *PRService) RemoveMembership(ctx context.Context, teamName, userID string) error {
+	if err := validateTeamName(teamName); err != nil {
+		return err
+	}
+	if err := validateUserID(userID); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveMembership(ctx, teamName, userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrNotFound
+		}
+		s.log.Error("failed to remove membership", "team", teamName, "user", userID, "error", err)
+		return err
+	}
+	s.log.Success("membership removed", "team", teamName, "user", userID)
+	return nil
+}
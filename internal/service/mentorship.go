@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// reviewerSlots counts the reviewer slots taken by assigned. A mentor
+// reviewing alongside their mentee shares the mentee's slot, and a secondary
+// reviewer takes none.
+func reviewerSlots(assigned []models.PRReviewer) int {
+	n := 0
+	for _, r := range assigned {
+		if r.MentorOf == "" && r.Role != models.ReviewerSecondary {
+			n++
+		}
+	}
+	return n
+}
+
+// withMentors pairs each selected reviewer who has a mentor with that
+// mentor, who joins without taking a slot. A mentor already selected on
+// their own becomes the pair's second half, freeing a slot. Inactive
+// mentors and the author are skipped. It also returns how many mentors were
+// added.
+func (s *PRService) withMentors(ctx context.Context, authorID string, selected []models.PRReviewer) ([]models.PRReviewer, int) {
+	ids := make([]string, 0, len(selected))
+	for _, r := range selected {
+		ids = append(ids, r.UserID)
+	}
+	mentors, err := s.repo.GetMentors(ctx, ids)
+	if err != nil {
+		s.log.Warn("failed to get mentors", "error", err)
+		return selected, 0
+	}
+
+	added := 0
+	for _, mentee := range ids {
+		mentor, ok := mentors[mentee]
+		if !ok || mentor == authorID {
+			continue
+		}
+		paired := false
+		for i := range selected {
+			if selected[i].UserID == mentor {
+				if selected[i].MentorOf == "" {
+					selected[i].MentorOf = mentee
+				}
+				paired = true
+				break
+			}
+		}
+		if paired {
+			continue
+		}
+		u, err := s.repo.GetUser(ctx, mentor)
+		if err != nil || !u.IsActive {
+			continue
+		}
+		selected = append(selected, models.PRReviewer{UserID: u.UserID, Username: u.Username, IsActive: u.IsActive, MentorOf: mentee})
+		added++
+	}
+	return selected, added
+}
+
+// pairMentor adds the mentor of userID, just assigned to pr, as a reviewer.
+// Failures leave the PR without the mentor.
+func (s *PRService) pairMentor(ctx context.Context, pr models.PullRequest, userID string) models.PullRequest {
+	mentors, err := s.repo.GetMentors(ctx, []string{userID})
+	if err != nil {
+		s.log.Warn("failed to get mentor", "user", userID, "error", err)
+		return pr
+	}
+	mentor, ok := mentors[userID]
+	if !ok || mentor == pr.AuthorID {
+		return pr
+	}
+	for _, r := range pr.Assigned {
+		if r.UserID == mentor {
+			return pr
+		}
+	}
+	if u, err := s.repo.GetUser(ctx, mentor); err != nil || !u.IsActive {
+		return pr
+	}
+	updated, err := s.repo.AddReviewer(withCause(ctx, "mentor"), pr.PullRequestID, mentor, maxReviewers)
+	if err != nil {
+		s.log.Warn("failed to pair mentor", "pr", pr.PullRequestID, "mentor", mentor, "error", err)
+		return pr
+	}
+	reviewerAssignments.Inc("mentor")
+	return updated
+}
+
+// SetMentor pairs userID with mentorID for reviews; an empty mentorID ends
+// the mentorship.
+func (s *PRService) SetMentor(ctx context.Context, userID, mentorID string) (models.UserProfile, error) {
+	if _, err := s.GetUserProfile(ctx, userID); err != nil {
+		return models.UserProfile{}, err
+	}
+	if mentorID != "" {
+		if mentorID == userID {
+			return models.UserProfile{}, ErrInvalidMentor
+		}
+		// Two users mentoring each other would both share a slot that
+		// neither takes.
+		mentors, err := s.repo.GetMentors(ctx, []string{mentorID})
+		if err != nil {
+			s.log.Error("failed to get mentor", "user", mentorID, "error", err)
+			return models.UserProfile{}, err
+		}
+		if mentors[mentorID] == userID {
+			return models.UserProfile{}, ErrInvalidMentor
+		}
+	}
+
+	if err := s.repo.SetMentor(ctx, userID, mentorID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserProfile{}, ErrNotFound
+		}
+		s.log.Error("failed to set mentor", "user", userID, "error", err)
+		return models.UserProfile{}, err
+	}
+	s.log.Success("mentor updated", "user", userID, "mentor", mentorID)
+	return s.GetUserProfile(ctx, userID)
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/models"
+)
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+	// maxMergeFreezes caps a team's weekly merge freezes.
+	maxMergeFreezes = 14
+)
+
+var weekdayNames = map[string]int{"mon": 0, "tue": 1, "wed": 2, "thu": 3, "fri": 4, "sat": 5, "sun": 6}
+
+var queuedMerges = metrics.NewCounter("queued_merges_total", "Queued merges handled, by outcome: merged, rescheduled or dropped.", "outcome")
+
+// StartMergeQueue merges the queued PRs whose merge window has opened, on
+// the scheduler until StopWorkers.
+func (s *PRService) StartMergeQueue(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.schedule("merge_queue", interval, true, func(ctx context.Context) {
+		s.runMergeQueue(ctx, time.Now())
+	})
+}
+
+// QueueMerge queues the PR to be merged once its team's merge window opens,
+// right on the next run of the merge queue if it's open now. The approval
+// quorum is checked now and again at merge time. mergedBy, when set, is
+// recorded as the PR's merged_by like MergePR does.
+func (s *PRService) QueueMerge(ctx context.Context, prID, mergedBy string) (models.QueuedMerge, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.QueuedMerge{}, err
+	}
+	if err := s.checkMerger(ctx, prID, mergedBy); err != nil {
+		return models.QueuedMerge{}, err
+	}
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.QueuedMerge{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for queued merge", "pr", prID, "error", err)
+		return models.QueuedMerge{}, err
+	}
+	if err := checkTransition(pr.Status, models.StatusMerged); err != nil {
+		return models.QueuedMerge{}, err
+	}
+	if err := s.checkApprovals(ctx, pr); err != nil {
+		return models.QueuedMerge{}, err
+	}
+
+	mergeAt := time.Now().UTC()
+	if err := s.checkMergeWindow(ctx, pr, mergeAt); err != nil {
+		var closed *MergeWindowError
+		if !errors.As(err, &closed) {
+			return models.QueuedMerge{}, err
+		}
+		mergeAt = closed.OpensAt.UTC()
+	}
+	if err := s.repo.QueueMerge(ctx, prID, mergedBy, mergeAt); err != nil {
+		s.log.Error("failed to queue merge", "pr", prID, "error", err)
+		return models.QueuedMerge{}, err
+	}
+	s.log.Success("merge queued", "pr", prID, "merge_at", mergeAt.Format(time.RFC3339))
+	return models.QueuedMerge{PullRequestID: prID, MergeAt: mergeAt, MergedBy: mergedBy}, nil
+}
+
+// runMergeQueue merges the queued PRs due at now. A merge that finds the
+// window shut again is pushed to its next opening, one that can never go
+// through is dropped, and one that failed otherwise is retried next run.
+func (s *PRService) runMergeQueue(ctx context.Context, now time.Time) {
+	due, err := s.repo.GetDueMerges(ctx, now)
+	if err != nil {
+		s.log.Warn("failed to get due merges", "error", err)
+		return
+	}
+	for _, q := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		_, err := s.MergePR(ctx, q.PullRequestID, q.MergedBy, false)
+		var closed *MergeWindowError
+		switch {
+		case err == nil:
+			queuedMerges.Inc("merged")
+			s.log.Success("queued merge done", "pr", q.PullRequestID)
+		case errors.As(err, &closed):
+			if err := s.repo.QueueMerge(ctx, q.PullRequestID, q.MergedBy, closed.OpensAt.UTC()); err != nil {
+				s.log.Warn("failed to reschedule queued merge", "pr", q.PullRequestID, "error", err)
+				continue
+			}
+			queuedMerges.Inc("rescheduled")
+			s.log.Info("queued merge rescheduled", "pr", q.PullRequestID, "merge_at", closed.OpensAt.UTC().Format(time.RFC3339))
+			continue
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrBadTransition), errors.Is(err, ErrPRClosed), errors.Is(err, ErrNotEnoughApprovals), errors.Is(err, ErrUnknownMerger):
+			queuedMerges.Inc("dropped")
+			s.log.Warn("queued merge dropped", "pr", q.PullRequestID, "reason", err)
+		default:
+			s.log.Warn("queued merge failed", "pr", q.PullRequestID, "error", err)
+			continue
+		}
+		if err := s.repo.DequeueMerge(ctx, q.PullRequestID); err != nil {
+			s.log.Warn("failed to dequeue merge", "pr", q.PullRequestID, "error", err)
+		}
+	}
+}
+
+// checkMergeWindow returns a *MergeWindowError when pr's team has merges
+// frozen at now.
+func (s *PRService) checkMergeWindow(ctx context.Context, pr models.PullRequest, now time.Time) error {
+	if pr.TeamName == "" {
+		return nil
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, pr.TeamName)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		s.log.Error("failed to load merge window", "team", pr.TeamName, "error", err)
+		return err
+	}
+	if settings.MergeWindow == nil {
+		return nil
+	}
+	opensAt, err := mergeWindowOpensAt(*settings.MergeWindow, now)
+	if err != nil {
+		s.log.Error("invalid merge window", "team", pr.TeamName, "error", err)
+		return err
+	}
+	if opensAt.After(now) {
+		return &MergeWindowError{TeamName: pr.TeamName, OpensAt: opensAt}
+	}
+	return nil
+}
+
+// mergeWindowOpensAt returns the first time from now on outside all of w's
+// freezes, which is now itself while the window is open. Freezes that
+// follow one another are skipped together.
+func mergeWindowOpensAt(w models.MergeWindow, now time.Time) (time.Time, error) {
+	loc := time.UTC
+	if w.Timezone != "" {
+		var err error
+		if loc, err = loadTimezone(w.Timezone); err != nil {
+			return time.Time{}, err
+		}
+	}
+	freezes, err := parseMergeFreezes(w.Freezes)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	at := now.In(loc)
+	for range len(freezes) + 1 {
+		frozen := false
+		m := weekMinute(at)
+		for _, f := range freezes {
+			if inFreeze(m, f[0], f[1]) {
+				at = nextWeekMinute(at, f[1])
+				frozen = true
+				break
+			}
+		}
+		if !frozen {
+			return at, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: merge_window never opens", ErrInvalidSettings)
+}
+
+func validateMergeWindow(w *models.MergeWindow) error {
+	if w == nil {
+		return nil
+	}
+	if w.Timezone != "" {
+		if _, err := loadTimezone(w.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown merge_window.timezone %q", ErrInvalidSettings, w.Timezone)
+		}
+	}
+	if len(w.Freezes) > maxMergeFreezes {
+		return fmt.Errorf("%w: at most %d merge_window.freezes", ErrInvalidSettings, maxMergeFreezes)
+	}
+	freezes, err := parseMergeFreezes(w.Freezes)
+	if err != nil {
+		return err
+	}
+	// The window opens somewhere iff some freeze ends outside all others.
+	for _, f := range freezes {
+		open := true
+		for _, g := range freezes {
+			if inFreeze(f[1], g[0], g[1]) {
+				open = false
+				break
+			}
+		}
+		if open {
+			return nil
+		}
+	}
+	if len(freezes) > 0 {
+		return fmt.Errorf("%w: merge_window.freezes cover the whole week", ErrInvalidSettings)
+	}
+	return nil
+}
+
+// parseMergeFreezes turns each freeze into its from and until minute of the
+// week.
+func parseMergeFreezes(freezes []models.MergeFreeze) ([][2]int, error) {
+	out := make([][2]int, 0, len(freezes))
+	for _, f := range freezes {
+		from, err := parseWeekMinute(f.From)
+		if err != nil {
+			return nil, err
+		}
+		until, err := parseWeekMinute(f.Until)
+		if err != nil {
+			return nil, err
+		}
+		if from == until {
+			return nil, fmt.Errorf("%w: merge freeze from %q until %q is empty", ErrInvalidSettings, f.From, f.Until)
+		}
+		out = append(out, [2]int{from, until})
+	}
+	return out, nil
+}
+
+// parseWeekMinute parses a weekday and time such as "Fri 16:00" into
+// minutes since Monday 00:00.
+func parseWeekMinute(s string) (int, error) {
+	bad := fmt.Errorf("%w: merge freeze time %q must look like \"Fri 16:00\"", ErrInvalidSettings, s)
+	day, hm, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return 0, bad
+	}
+	d, ok := weekdayNames[strings.ToLower(day)]
+	if !ok {
+		return 0, bad
+	}
+	hh, mm, ok := strings.Cut(strings.TrimSpace(hm), ":")
+	if !ok || len(mm) != 2 {
+		return 0, bad
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 23 {
+		return 0, bad
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 || m > 59 {
+		return 0, bad
+	}
+	return d*minutesPerDay + h*60 + m, nil
+}
+
+// weekMinute returns t's minutes since Monday 00:00 in t's location.
+func weekMinute(t time.Time) int {
+	return (int(t.Weekday())+6)%7*minutesPerDay + t.Hour()*60 + t.Minute()
+}
+
+// inFreeze reports whether minute m of the week falls in [from, until),
+// wrapping over the week's end when until comes first.
+func inFreeze(m, from, until int) bool {
+	if from < until {
+		return m >= from && m < until
+	}
+	return m >= from || m < until
+}
+
+// nextWeekMinute returns the first time after t, in t's location, that is
+// minute m of a week.
+func nextWeekMinute(t time.Time, m int) time.Time {
+	y, mo, d := t.Date()
+	monday := d - (int(t.Weekday())+6)%7
+	at := time.Date(y, mo, monday+m/minutesPerDay, m%minutesPerDay/60, m%60, 0, 0, t.Location())
+	if !at.After(t) {
+		at = time.Date(y, mo, monday+7+m/minutesPerDay, m%minutesPerDay/60, m%60, 0, 0, t.Location())
+	}
+	return at
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/notify"
+)
+
+var mergeNotifications = metrics.NewCounter("merge_notifications_total", "Merge notifications sent, by outcome.", "outcome")
+
+// SetNotificationPrefs stores which notifications the user wants.
+func (s *PRService) SetNotificationPrefs(ctx context.Context, userID string, prefs models.NotificationPrefs) (models.UserProfile, error) {
+	if _, err := s.GetUserProfile(ctx, userID); err != nil {
+		return models.UserProfile{}, err
+	}
+
+	if err := s.repo.SetNotificationPrefs(ctx, userID, prefs); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserProfile{}, ErrNotFound
+		}
+		s.log.Error("failed to set notification prefs", "user", userID, "error", err)
+		return models.UserProfile{}, err
+	}
+	s.log.Success("notification prefs updated", "user", userID, "merged", prefs.Merged)
+	return s.GetUserProfile(ctx, userID)
+}
+
+// notifyMerged tells pr's author and reviewers, those who haven't opted out,
+// that it was merged. The merge has happened either way, so failures are
+// only logged.
+func (s *PRService) notifyMerged(ctx context.Context, pr models.PullRequest) {
+	involved := []string{pr.AuthorID}
+	for _, r := range pr.Assigned {
+		if r.UserID != pr.AuthorID {
+			involved = append(involved, r.UserID)
+		}
+	}
+	recipients, err := s.repo.GetMergeSubscribers(ctx, involved)
+	if err != nil {
+		s.log.Warn("failed to get merge subscribers", "pr", pr.PullRequestID, "error", err)
+		return
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	if err := s.notifier.Notify(ctx, mergedMessage(pr, recipients, time.Now())); err != nil {
+		mergeNotifications.Inc("failed")
+		s.log.Warn("failed to send merge notification", "pr", pr.PullRequestID, "error", err)
+		return
+	}
+	mergeNotifications.Inc("sent")
+	s.log.Info("merge notification sent", "pr", pr.PullRequestID, "to", strings.Join(recipients, ","))
+}
+
+func mergedMessage(pr models.PullRequest, recipients []string, now time.Time) notify.Message {
+	pr = presentPR(pr)
+	return notify.Message{
+		Kind:  "pr.merged",
+		Title: "merged: " + pr.PullRequestName,
+		Text:  fmt.Sprintf("%s by %s has been merged", pr.PullRequestID, pr.AuthorID),
+		Fields: map[string]string{
+			"pull_request_id": pr.PullRequestID,
+			"team_name":       pr.TeamName,
+			"author_id":       pr.AuthorID,
+			"to":              strings.Join(recipients, ","),
+		},
+		At: now.UTC(),
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+
+	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/repo"
+)
+
+// opCache memoizes entity loads for the duration of a single operation so
+// multi-step jobs like Reassign and DeactivateTeam hit the repo at most
+// once per entity. Callers must refresh entries they mutate.
+type opCache struct {
+	repo    repo.Repo
+	prs     map[string]models.PullRequest
+	users   map[string]models.User
+	members map[string][]string
+}
+
+func newOpCache(r repo.Repo) *opCache {
+	return &opCache{
+		repo:    r,
+		prs:     make(map[string]models.PullRequest),
+		users:   make(map[string]models.User),
+		members: make(map[string][]string),
+	}
+}
+
+func (c *opCache) getPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	if pr, ok := c.prs[prID]; ok {
+		return pr, nil
+	}
+	pr, err := c.repo.GetPR(ctx, prID)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	c.prs[prID] = pr
+	return pr, nil
+}
+
+func (c *opCache) setPR(pr models.PullRequest) {
+	c.prs[pr.PullRequestID] = pr
+}
+
+func (c *opCache) forgetPR(prID string) {
+	delete(c.prs, prID)
+}
+
+func (c *opCache) getUser(ctx context.Context, userID string) (models.User, error) {
+	if u, ok := c.users[userID]; ok {
+		return u, nil
+	}
+	u, err := c.repo.GetUser(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	c.users[userID] = u
+	return u, nil
+}
+
+func (c *opCache) activeMembers(ctx context.Context, teamName string) ([]string, error) {
+	if ids, ok := c.members[teamName]; ok {
+		return ids, nil
+	}
+	ids, err := c.repo.GetActiveTeamMembersExcept(ctx, teamName, "")
+	if err != nil {
+		return nil, err
+	}
+	c.members[teamName] = ids
+	return ids, nil
+}
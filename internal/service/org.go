@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+const (
+	orgSummaryTTL       = 30 * time.Second
+	orgSummaryTeamLimit = 5
+)
+
+// orgSummaryCache holds the last org summary. The query scans every open and
+// merged PR, so dashboards polling /stats/org share one result per TTL, or
+// until ApplyChange reports a write.
+type orgSummaryCache struct {
+	mu        sync.Mutex
+	summary   models.OrgSummary
+	expiresAt time.Time
+}
+
+// GetOrgSummary returns PR totals across all teams. Team-scoped tokens are
+// rejected since the summary covers other teams.
+func (s *PRService) GetOrgSummary(ctx context.Context) (models.OrgSummary, error) {
+	if _, ok := ScopeFromContext(ctx); ok {
+		return models.OrgSummary{}, ErrForbidden
+	}
+
+	s.orgCache.mu.Lock()
+	defer s.orgCache.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(s.orgCache.expiresAt) {
+		return s.orgCache.summary, nil
+	}
+
+	sum, err := s.repo.GetOrgSummary(ctx, orgSummaryTeamLimit)
+	if err != nil {
+		return models.OrgSummary{}, err
+	}
+	if sum.BusiestTeams == nil {
+		sum.BusiestTeams = []models.TeamLoad{}
+	}
+	sum.GeneratedAt = now.UTC()
+	s.orgCache.summary = sum
+	s.orgCache.expiresAt = now.Add(orgSummaryTTL)
+
+	workerLog := s.log.WithWorker("worker-stats")
+	workerLog.Success("get_org_summary succeeded", "duration", fmt.Sprintf("%.1fms", float64(time.Since(now).Nanoseconds())/1e6))
+	return sum, nil
+}
+
+// GetUserStats returns the user's review stats over the last weeks calendar
+// weeks, the current one included.
+func (s *PRService) GetUserStats(ctx context.Context, userID string, weeks int) (models.UserStats, error) {
+	stats, err := s.userStats(ctx, userID, weeks)
+	if err != nil {
+		return models.UserStats{}, err
+	}
+	if err := s.checkUserScope(ctx, userID, stats.TeamName); err != nil {
+		return models.UserStats{}, err
+	}
+	return stats, nil
+}
+
+func (s *PRService) userStats(ctx context.Context, userID string, weeks int) (models.UserStats, error) {
+	if err := validateUserID(userID); err != nil {
+		return models.UserStats{}, err
+	}
+	if weeks <= 0 {
+		weeks = DefaultStatsWeeks
+	}
+	if weeks > MaxStatsWeeks {
+		weeks = MaxStatsWeeks
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -7*(weeks-1))
+	stats, err := s.repo.GetUserStats(ctx, userID, since)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserStats{}, ErrNotFound
+		}
+		s.log.Error("failed to get user stats", "user", userID, "error", err)
+		return models.UserStats{}, err
+	}
+	return stats, nil
+}
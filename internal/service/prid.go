@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// resolvePRID points a job's "pr_id" at the PR's key. Clients address a PR
+// by the ID they created it with and its repository, since the same ID may
+// be used in several repositories. Without a repository an ID that matches
+// no PR is still tried as a key, as older releases stored PRs of a
+// repository as "<repository>#<id>".
+func (s *PRService) resolvePRID(ctx context.Context, payload map[string]interface{}) error {
+	id, ok := payload["pr_id"].(string)
+	if !ok || id == "" {
+		return nil
+	}
+	repository, _ := payload["repository"].(string)
+	key, err := s.repo.FindPR(ctx, repository, id)
+	switch {
+	case err == nil:
+		payload["pr_id"] = key
+	case !strings.Contains(err.Error(), "not found"):
+		return err
+	case repository != "":
+		return ErrNotFound
+	}
+	return nil
+}
+
+// presentPRIDs shows the PRs of a job result under the IDs they were
+// created with rather than their keys.
+func presentPRIDs(data interface{}) interface{} {
+	switch v := data.(type) {
+	case models.PullRequest:
+		return presentPR(v)
+	case models.PRResult:
+		v.PR = presentPR(v.PR)
+		return v
+	case models.ArchivedPR:
+		v.PR = presentPR(v.PR)
+		return v
+	case []models.PullRequestShort:
+		out := make([]models.PullRequestShort, len(v))
+		for i, pr := range v {
+			out[i] = presentPRShort(pr)
+		}
+		return out
+	case []models.ArchivedPRShort:
+		out := make([]models.ArchivedPRShort, len(v))
+		for i, pr := range v {
+			pr.PullRequestShort = presentPRShort(pr.PullRequestShort)
+			out[i] = pr
+		}
+		return out
+	}
+	return data
+}
+
+func presentPR(pr models.PullRequest) models.PullRequest {
+	if pr.ExternalID != "" {
+		pr.PullRequestID = pr.ExternalID
+	}
+	return pr
+}
+
+func presentPRShort(pr models.PullRequestShort) models.PullRequestShort {
+	if pr.ExternalID != "" {
+		pr.PullRequestID = pr.ExternalID
+	}
+	return pr
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+// RampUpConfig caps the open reviews of members new to their team, who
+// have no history and would otherwise look idle to every load-based pick.
+// Ramp-up ends after Days days or Reviews assignments, whichever comes
+// first; a zero limit does not apply. Zero Days and Reviews disable it.
+type RampUpConfig struct {
+	Days    int
+	Reviews int
+	MaxOpen int
+}
+
+func (c RampUpConfig) enabled() bool {
+	return c.Days > 0 || c.Reviews > 0
+}
+
+// WithRampUp applies cfg to automatic reviewer picks.
+func WithRampUp(cfg RampUpConfig) Option {
+	return func(s *PRService) { s.rampUp = cfg }
+}
+
+// withinRampUp drops the candidates still ramping up who already hold
+// MaxOpen open reviews. Without the loads nobody is dropped.
+func (s *PRService) withinRampUp(ctx context.Context, candidateIDs []string) []string {
+	if !s.rampUp.enabled() || len(candidateIDs) == 0 {
+		return candidateIDs
+	}
+	var joinedAfter time.Time
+	if s.rampUp.Days > 0 {
+		joinedAfter = time.Now().UTC().AddDate(0, 0, -s.rampUp.Days)
+	}
+	loads, err := s.repo.GetRampUpLoads(ctx, joinedAfter, s.rampUp.Reviews)
+	if err != nil {
+		s.log.Warn("failed to get ramp-up loads", "error", err)
+		return candidateIDs
+	}
+
+	kept := make([]string, 0, len(candidateIDs))
+	for _, id := range candidateIDs {
+		if n, ok := loads[id]; ok && n >= s.rampUp.MaxOpen {
+			candidatesFiltered.Inc("ramp_up")
+			continue
+		}
+		kept = append(kept, id)
+	}
+	return kept
+}
+
+// withinRampUpFor is withinRampUp for picks on a PR of the given priority.
+// Urgent PRs may go past the cap.
+func (s *PRService) withinRampUpFor(ctx context.Context, priority models.PRPriority, candidateIDs []string) []string {
+	if priority == models.PriorityUrgent {
+		return candidateIDs
+	}
+	return s.withinRampUp(ctx, candidateIDs)
+}
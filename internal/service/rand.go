@@ -0,0 +1,54 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"sync"
+)
+
+// RandSource draws the random numbers behind reviewer picks. Intn returns a
+// uniform int in [0, n).
+type RandSource interface {
+	Intn(n int) (int, error)
+}
+
+// WithRandSource replaces the default crypto/rand source, so tests and the
+// deterministic mode can tell which reviewer gets picked.
+func WithRandSource(r RandSource) Option {
+	return func(s *PRService) { s.rand = r }
+}
+
+type cryptoRand struct{}
+
+func (cryptoRand) Intn(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid n for Intn: %d", n)
+	}
+	r, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("crypto rand failed: %w", err)
+	}
+	return int(r.Int64()), nil
+}
+
+// SeededRand is a deterministic RandSource: services seeded alike pick the
+// same reviewers given the same calls. It is safe for concurrent use.
+type SeededRand struct {
+	mu  sync.Mutex
+	rnd *mrand.Rand
+}
+
+func NewSeededRand(seed int64) *SeededRand {
+	return &SeededRand{rnd: mrand.New(mrand.NewSource(seed))}
+}
+
+func (r *SeededRand) Intn(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid n for Intn: %d", n)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Intn(n), nil
+}
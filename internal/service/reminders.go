@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/notify"
+)
+
+// ReminderConfig controls review reminders. A zero Interval disables them.
+// Unacknowledged assignments are nudged after UnackedAfter and again every
+// UnackedAfter; acknowledged ones wait the longer After.
+type ReminderConfig struct {
+	Interval     time.Duration
+	After        time.Duration
+	UnackedAfter time.Duration
+}
+
+// reminderState remembers when each assignment was last nudged so a
+// reminder is not repeated on every scheduler tick.
+type reminderState struct {
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+var remindersSent = metrics.NewCounter("review_reminders_total", "Review reminders sent, by whether the assignment was acknowledged.", "acknowledged")
+
+// AcknowledgeReview records that an assigned reviewer has seen the PR. It
+// only quiets the more aggressive reminders; approving is still required.
+// The first acknowledgement puts an OPEN PR in review.
+func (s *PRService) AcknowledgeReview(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := validateUserID(userID); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for ack", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+	if err := checkActive(pr.Status); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	acked, err := s.repo.AcknowledgeReview(ctx, prID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not assigned") {
+			return models.PullRequest{}, ErrNotAssigned
+		}
+		s.log.Error("failed to acknowledge review", "pr", prID, "user", userID, "error", err)
+		return models.PullRequest{}, err
+	}
+	if acked.Status == models.StatusOpen {
+		return s.moveStatus(ctx, acked, models.StatusInReview)
+	}
+	return acked, nil
+}
+
+// StartReminders sends review reminders on the scheduler until StopWorkers.
+func (s *PRService) StartReminders(cfg ReminderConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+	s.schedule("reminders", cfg.Interval, true, func(ctx context.Context) {
+		s.sendReminders(ctx, cfg, time.Now())
+	})
+}
+
+func (s *PRService) sendReminders(ctx context.Context, cfg ReminderConfig, now time.Time) {
+	workerLog := s.log.WithWorker("scheduler-reminders")
+
+	pending, err := s.repo.GetPendingReviews(ctx)
+	if err != nil {
+		workerLog.Warn("failed to load pending reviews", "error", err)
+		return
+	}
+
+	var msgs []notify.Message
+	s.reminders.mu.Lock()
+	if s.reminders.sent == nil {
+		s.reminders.sent = make(map[string]time.Time)
+	}
+	live := make(map[string]bool, len(pending))
+	for _, p := range pending {
+		key := p.PullRequestID + "/" + p.UserID
+		live[key] = true
+
+		wait := cfg.After
+		if p.AcknowledgedAt == nil {
+			wait = cfg.UnackedAfter
+		}
+		if wait <= 0 || now.Sub(p.AssignedAt) < wait {
+			continue
+		}
+		// A reminder sent before the current assignment belongs to an
+		// earlier one and does not count.
+		if last, ok := s.reminders.sent[key]; ok && last.After(p.AssignedAt) && now.Sub(last) < wait {
+			continue
+		}
+		s.reminders.sent[key] = now
+		msgs = append(msgs, reminderMessage(p, now))
+	}
+	for key := range s.reminders.sent {
+		if !live[key] {
+			delete(s.reminders.sent, key)
+		}
+	}
+	s.reminders.mu.Unlock()
+
+	for _, msg := range msgs {
+		remindersSent.Inc(msg.Fields["acknowledged"])
+		if err := s.notifier.Notify(ctx, msg); err != nil {
+			workerLog.Error("failed to send review reminder", "pr", msg.Fields["pull_request_id"], "user", msg.Fields["user_id"], "error", err)
+		}
+	}
+	if len(msgs) > 0 {
+		workerLog.Info("review reminders sent", "count", len(msgs))
+	}
+}
+
+func reminderMessage(p models.PendingReview, now time.Time) notify.Message {
+	acked := p.AcknowledgedAt != nil
+	text := fmt.Sprintf("%s has been waiting for review by %s for %s", p.PullRequestID, p.UserID, now.Sub(p.AssignedAt).Round(time.Minute))
+	if !acked {
+		text += "; the assignment has not been acknowledged"
+	}
+	return notify.Message{
+		Kind:  "review.reminder",
+		Title: "review reminder: " + p.PullRequestName,
+		Text:  text,
+		Fields: map[string]string{
+			"pull_request_id": p.PullRequestID,
+			"user_id":         p.UserID,
+			"acknowledged":    fmt.Sprint(acked),
+		},
+		At: now.UTC(),
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+// maxReservation is how far ahead an author may hold reviewers.
+const maxReservation = 14 * 24 * time.Hour
+
+// ReserveReviewers holds reviewerIDs for authorID's upcoming PR until until:
+// automatic assignments of other PRs pass them over, and the author's next
+// PR takes them first, which ends the reservation. It replaces the author's
+// previous reservation; no reviewerIDs just cancels it.
+func (s *PRService) ReserveReviewers(ctx context.Context, authorID string, reviewerIDs []string, until time.Time) (models.ReviewReservation, error) {
+	if err := validateUserID(authorID); err != nil {
+		return models.ReviewReservation{}, err
+	}
+	teamName, err := s.repo.GetUserTeam(ctx, authorID)
+	if err != nil {
+		return models.ReviewReservation{}, ErrNotFound
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return models.ReviewReservation{}, err
+	}
+
+	res := models.ReviewReservation{AuthorID: authorID, Reviewers: []string{}}
+	if len(reviewerIDs) > 0 {
+		now := time.Now().UTC()
+		if len(reviewerIDs) > maxReviewers || !until.After(now) || until.Sub(now) > maxReservation {
+			return models.ReviewReservation{}, ErrInvalidReservation
+		}
+		seen := make(map[string]bool, len(reviewerIDs))
+		for _, id := range reviewerIDs {
+			if id == authorID {
+				return models.ReviewReservation{}, ErrAuthorReviewer
+			}
+			if seen[id] {
+				return models.ReviewReservation{}, ErrInvalidReservation
+			}
+			seen[id] = true
+			u, err := s.repo.GetUser(ctx, id)
+			if err != nil {
+				return models.ReviewReservation{}, ErrNotFound
+			}
+			if !u.IsActive {
+				return models.ReviewReservation{}, ErrUserInactive
+			}
+		}
+		res.Reviewers, res.ExpiresAt = reviewerIDs, until.UTC()
+	}
+
+	if err := s.repo.ReserveReviewers(ctx, authorID, res.Reviewers, res.ExpiresAt); err != nil {
+		if strings.Contains(err.Error(), "conflict") {
+			return models.ReviewReservation{}, ErrReviewerHeld
+		}
+		s.log.Error("failed to reserve reviewers", "author", authorID, "error", err)
+		return models.ReviewReservation{}, err
+	}
+	s.log.Success("reviewers reserved", "author", authorID, "reviewers", strings.Join(res.Reviewers, ","), "until", res.ExpiresAt)
+	return res, nil
+}
+
+// withoutReserved drops the candidates held for an author other than
+// authorID. Without the reservations nobody is dropped.
+func (s *PRService) withoutReserved(ctx context.Context, authorID string, candidateIDs []string) []string {
+	if len(candidateIDs) == 0 {
+		return candidateIDs
+	}
+	held, err := s.repo.GetReservations(ctx, time.Now().UTC())
+	if err != nil {
+		s.log.Warn("failed to get reservations", "error", err)
+		return candidateIDs
+	}
+	if len(held) == 0 {
+		return candidateIDs
+	}
+
+	kept := make([]string, 0, len(candidateIDs))
+	for _, id := range candidateIDs {
+		if author, ok := held[id]; ok && author != authorID {
+			candidatesFiltered.Inc("reserved")
+			continue
+		}
+		kept = append(kept, id)
+	}
+	return kept
+}
+
+// reservedReviewers returns the active reviewers held for authorID.
+func (s *PRService) reservedReviewers(ctx context.Context, authorID string) []models.PRReviewer {
+	out := []models.PRReviewer{}
+	held, err := s.repo.GetReservations(ctx, time.Now().UTC())
+	if err != nil {
+		s.log.Warn("failed to get reservations", "author", authorID, "error", err)
+		return out
+	}
+	for id, author := range held {
+		if author != authorID || len(out) >= maxReviewers {
+			continue
+		}
+		u, err := s.repo.GetUser(ctx, id)
+		if err != nil || !u.IsActive {
+			continue
+		}
+		out = append(out, models.PRReviewer{UserID: u.UserID, Username: u.Username, IsActive: u.IsActive})
+	}
+	return out
+}
+
+func hasReviewer(reviewers []models.PRReviewer, userID string) bool {
+	for _, r := range reviewers {
+		if r.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
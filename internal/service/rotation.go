@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+const (
+	// defaultRotationWeeks is how far ahead a rotation is generated unless
+	// asked otherwise, including when a team's runs out.
+	defaultRotationWeeks = 4
+	maxRotationWeeks     = 26
+)
+
+// GetRotation returns the team's review rotation from the current week on.
+func (s *PRService) GetRotation(ctx context.Context, teamName string) (models.ReviewRotation, error) {
+	if err := validateTeamName(teamName); err != nil {
+		return models.ReviewRotation{}, err
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return models.ReviewRotation{}, err
+	}
+	if _, err := s.GetTeam(ctx, teamName); err != nil {
+		return models.ReviewRotation{}, err
+	}
+
+	weeks, err := s.repo.GetRotation(ctx, teamName, weekStart(time.Now()))
+	if err != nil {
+		s.log.Error("failed to get rotation", "team", teamName, "error", err)
+		return models.ReviewRotation{}, err
+	}
+	return models.ReviewRotation{TeamName: teamName, Weeks: weeks}, nil
+}
+
+// RegenerateRotation replaces the team's rotation with weeks new weeks of
+// pairs, starting with the current one; weeks 0 means the default.
+func (s *PRService) RegenerateRotation(ctx context.Context, teamName string, weeks int) (models.ReviewRotation, error) {
+	if err := validateTeamName(teamName); err != nil {
+		return models.ReviewRotation{}, err
+	}
+	if weeks == 0 {
+		weeks = defaultRotationWeeks
+	}
+	if weeks < 1 || weeks > maxRotationWeeks {
+		return models.ReviewRotation{}, ErrInvalidRotation
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return models.ReviewRotation{}, err
+	}
+	if _, err := s.GetTeam(ctx, teamName); err != nil {
+		return models.ReviewRotation{}, err
+	}
+
+	rotation, err := s.generateRotation(ctx, teamName, weeks)
+	if err != nil {
+		return models.ReviewRotation{}, err
+	}
+	if err := s.repo.SaveRotation(ctx, teamName, rotation); err != nil {
+		s.log.Error("failed to save rotation", "team", teamName, "error", err)
+		return models.ReviewRotation{}, err
+	}
+	s.log.Success("rotation regenerated", "team", teamName, "weeks", weeks)
+	return models.ReviewRotation{TeamName: teamName, Weeks: rotation}, nil
+}
+
+// generateRotation shuffles the team's active members and deals them out
+// two per week, wrapping around, so everyone is on duty equally often.
+func (s *PRService) generateRotation(ctx context.Context, teamName string, weeks int) ([]models.RotationWeek, error) {
+	members, err := s.repo.GetActiveTeamMembersExcept(ctx, teamName, "")
+	if err != nil {
+		s.log.Error("failed to get members for rotation", "team", teamName, "error", err)
+		return nil, err
+	}
+	if len(members) < 2 {
+		return nil, ErrNoCandidate
+	}
+	for i := len(members) - 1; i > 0; i-- {
+		j, err := s.rand.Intn(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		members[i], members[j] = members[j], members[i]
+	}
+
+	start := weekStart(time.Now())
+	out := make([]models.RotationWeek, weeks)
+	for i := range out {
+		out[i] = models.RotationWeek{
+			WeekStart: start.AddDate(0, 0, 7*i),
+			Reviewers: []string{members[(2*i)%len(members)], members[(2*i+1)%len(members)]},
+		}
+	}
+	return out, nil
+}
+
+// withRotation fills the free slots of selected with this week's pair when
+// the team runs a rotation, extending the rotation if it has run out. Only
+// candidateIDs are considered, so an author on duty is skipped. It also
+// returns how many were added.
+func (s *PRService) withRotation(ctx context.Context, teamName string, selected []models.PRReviewer, candidateIDs []string) ([]models.PRReviewer, int) {
+	if len(selected) >= maxReviewers || len(candidateIDs) == 0 {
+		return selected, 0
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			s.log.Warn("failed to load review rotation setting", "team", teamName, "error", err)
+		}
+		return selected, 0
+	}
+	if !settings.ReviewRotation {
+		return selected, 0
+	}
+
+	week := weekStart(time.Now())
+	weeks, err := s.repo.GetRotation(ctx, teamName, week)
+	if err != nil {
+		s.log.Warn("failed to get rotation", "team", teamName, "error", err)
+		return selected, 0
+	}
+	if len(weeks) == 0 || !weeks[0].WeekStart.Equal(week) {
+		if weeks, err = s.generateRotation(ctx, teamName, defaultRotationWeeks); err != nil {
+			s.log.Warn("failed to extend rotation", "team", teamName, "error", err)
+			return selected, 0
+		}
+		if err := s.repo.SaveRotation(ctx, teamName, weeks); err != nil {
+			s.log.Warn("failed to save rotation", "team", teamName, "error", err)
+			return selected, 0
+		}
+		s.log.Info("rotation extended", "team", teamName, "weeks", len(weeks))
+	}
+
+	eligible := make(map[string]bool, len(candidateIDs))
+	for _, id := range candidateIDs {
+		eligible[id] = true
+	}
+	added := 0
+	for _, id := range weeks[0].Reviewers {
+		if len(selected) >= maxReviewers {
+			break
+		}
+		if !eligible[id] || hasReviewer(selected, id) {
+			continue
+		}
+		u, err := s.repo.GetUser(ctx, id)
+		if err != nil || !u.IsActive {
+			continue
+		}
+		selected = append(selected, models.PRReviewer{UserID: u.UserID, Username: u.Username, IsActive: u.IsActive})
+		added++
+	}
+	return selected, added
+}
+
+// weekStart returns the Monday 00:00 UTC starting t's week.
+func weekStart(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
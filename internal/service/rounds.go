@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+// RequestChanges has reviewer userID ask for changes on an open PR, which
+// starts its next review round. The round is due in hours, or after the
+// team's review SLA when hours is 0; without either it has no deadline.
+// With fresh a reviewer not yet on the PR joins as its secondary, to look at
+// the rework with new eyes.
+func (s *PRService) RequestChanges(ctx context.Context, prID, userID string, hours int, fresh bool) (models.PRResult, error) {
+	return s.requestChanges(ctx, prID, userID, hours, fresh, "")
+}
+
+// requestChanges is RequestChanges with the comment the reviewer left.
+func (s *PRService) requestChanges(ctx context.Context, prID, userID string, hours int, fresh bool, comment string) (models.PRResult, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PRResult{}, err
+	}
+	if err := validateUserID(userID); err != nil {
+		return models.PRResult{}, err
+	}
+	if hours < 0 || hours > maxReviewSLAHours {
+		return models.PRResult{}, ErrInvalidRound
+	}
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
+		return models.PRResult{}, err
+	}
+
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PRResult{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for change request", "pr", prID, "error", err)
+		return models.PRResult{}, err
+	}
+	if err := checkActive(pr.Status); err != nil {
+		return models.PRResult{}, err
+	}
+	if !hasReviewer(pr.Assigned, userID) {
+		return models.PRResult{}, ErrNotAssigned
+	}
+	teamName, err := s.prTeam(ctx, pr)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PRResult{}, ErrNotFound
+		}
+		return models.PRResult{}, err
+	}
+
+	var dueAt *time.Time
+	if hours == 0 {
+		settings, err := s.repo.GetTeamSettings(ctx, teamName)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			s.log.Warn("failed to load review sla", "team", teamName, "error", err)
+		}
+		if err == nil && settings.ReviewSLA != nil {
+			hours = settings.ReviewSLA.Hours
+		}
+	}
+	if hours > 0 {
+		due := time.Now().UTC().Add(time.Duration(hours) * time.Hour)
+		dueAt = &due
+	}
+
+	var freshUID string
+	if fresh {
+		freshUID, err = s.pickFreshReviewer(ctx, cache, pr, teamName)
+		if err != nil {
+			return models.PRResult{}, err
+		}
+		if _, err := s.repo.AddSecondaryReviewer(withCause(ctx, "round"), prID, freshUID); err != nil {
+			if strings.Contains(err.Error(), "conflict") {
+				return models.PRResult{}, ErrSecondaryTaken
+			}
+			s.log.Error("failed to add fresh reviewer", "pr", prID, "user", freshUID, "error", err)
+			return models.PRResult{}, err
+		}
+		reviewerAssignments.Inc("round")
+	}
+
+	if err := s.repo.RecordReviewDecision(ctx, prID, userID, models.ReviewChangesRequested, comment, time.Now().UTC()); err != nil {
+		s.log.Error("failed to record review decision", "pr", prID, "user", userID, "error", err)
+		return models.PRResult{}, err
+	}
+	round, err := s.repo.StartReviewRound(ctx, prID, userID, freshUID, dueAt)
+	if err != nil {
+		s.log.Error("failed to start review round", "pr", prID, "user", userID, "error", err)
+		return models.PRResult{}, err
+	}
+	updated, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		s.log.Error("failed to fetch PR after change request", "pr", prID, "error", err)
+		return models.PRResult{}, err
+	}
+	if updated, err = s.moveStatus(ctx, updated, models.StatusInReview); err != nil {
+		return models.PRResult{}, err
+	}
+	s.log.Success("changes requested", "pr", prID, "user", userID, "round", round.Round, "fresh_reviewer", freshUID)
+	return models.PRResult{PR: updated, Round: &round}, nil
+}
+
+// pickFreshReviewer picks an active member of teamName who is neither the
+// author nor already on pr, the way a reassignment would.
+func (s *PRService) pickFreshReviewer(ctx context.Context, cache *opCache, pr models.PullRequest, teamName string) (string, error) {
+	cands, err := cache.activeMembers(ctx, teamName)
+	if err != nil {
+		s.log.Error("failed to get active candidates for fresh reviewer", "team", teamName, "error", err)
+		return "", err
+	}
+	cands = s.withoutReserved(ctx, pr.AuthorID, s.withinRampUpFor(ctx, pr.Priority, cands))
+
+	avail := make([]string, 0, len(cands))
+	for _, c := range cands {
+		if c != pr.AuthorID && !hasReviewer(pr.Assigned, c) {
+			avail = append(avail, c)
+		}
+	}
+	if len(avail) == 0 {
+		return "", ErrNoCandidate
+	}
+	idx, err := weightedRandInt(s.rand, avail, s.selectionWeights(ctx, teamName, avail))
+	if err != nil {
+		return "", err
+	}
+	return avail[idx], nil
+}
+
+// ReviewRounds lists the PR's review rounds after the first, oldest first.
+// Under blind review the author, like any unidentified caller, sees them
+// without who requested the changes or joined until merge.
+func (s *PRService) ReviewRounds(ctx context.Context, prID string) ([]models.ReviewRound, error) {
+	if err := validatePRID(prID); err != nil {
+		return nil, err
+	}
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
+		return nil, err
+	}
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for rounds", "pr", prID, "error", err)
+		return nil, err
+	}
+
+	rounds, err := s.repo.GetReviewRounds(ctx, prID)
+	if err != nil {
+		s.log.Error("failed to get review rounds", "pr", prID, "error", err)
+		return nil, err
+	}
+	if s.hidesReviewers(ctx, pr) {
+		for i := range rounds {
+			rounds[i].RequestedBy, rounds[i].FreshReviewer = "", ""
+		}
+	}
+	return rounds, nil
+}
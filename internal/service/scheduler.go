@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// WithLeader makes tasks scheduled as leaderOnly run only while isLeader
+// reports true, so replicas do not each send the same reminders.
+func WithLeader(isLeader func() bool) Option {
+	return func(s *PRService) { s.isLeader = isLeader }
+}
+
+// schedule runs task every interval until StopWorkers. Tasks call the repo
+// directly rather than enqueueing jobs, so StopWorkers waits for them before
+// closing the job queue. A run may take at most one interval. A leaderOnly
+// task skips its ticks while another replica leads.
+func (s *PRService) schedule(name string, interval time.Duration, leaderOnly bool, task func(ctx context.Context)) {
+	s.diag.scheduled(name, interval)
+	s.tasks.Add(1)
+	go func() {
+		defer s.tasks.Done()
+		taskLog := s.log.WithWorker("scheduler-" + name)
+		taskLog.Info("scheduled task started", "interval", interval.String())
+
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), taskKey{}, name))
+		defer cancel()
+		go func() {
+			select {
+			case <-s.stopped:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if leaderOnly && s.isLeader != nil && !s.isLeader() {
+					continue
+				}
+				runCtx, runCancel := context.WithTimeout(ctx, interval)
+				task(runCtx)
+				if runCtx.Err() == nil {
+					s.diag.ran(name, time.Now().UTC())
+				}
+				runCancel()
+			}
+		}
+	}()
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const teamTokenBytes = 32
+
+// Scope is what a bearer token is bound to: a team, or a single user whose
+// token only reaches the /me endpoints.
+type Scope struct {
+	TeamName string
+	UserID   string
+}
+
+type scopeKey struct{}
+
+// WithScope marks ctx as coming from a team- or user-bound token.
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+func ScopeFromContext(ctx context.Context) (Scope, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(Scope)
+	return scope, ok
+}
+
+// scopedJobTypes lists the only jobs a team-bound token may run.
+var scopedJobTypes = map[string]bool{
+	"create_pr":                 true,
+	"reassign_pr":               true,
+	"assign_reviewer":           true,
+	"assign_secondary_reviewer": true,
+	"remove_reviewer":           true,
+	"fill_reviewers":            true,
+	"list_prs":                  true,
+	"search_prs":                true,
+	"set_labels":                true,
+	"get_pr":                    true,
+	"get_assignment_history":    true,
+	"get_review_rounds":         true,
+	"request_changes":           true,
+	"suggest_reviewers":         true,
+	"get_team_settings":         true,
+	"get_rotation":              true,
+	"regenerate_rotation":       true,
+	"get_user":                  true,
+	"get_user_activity":         true,
+	"get_user_stats":            true,
+	"get_security_coverage":     true,
+	"get_review_load":           true,
+	"get_team_stats":            true,
+	"get_archived_pr":           true,
+	"list_archived_prs":         true,
+	"list_overdue_reviews":      true,
+	"reserve_reviewers":         true,
+	"set_user_skills":           true,
+	"set_vacation":              true,
+	"set_mentor":                true,
+	"set_timezone":              true,
+	"set_notifications":         true,
+	"set_user_weight":           true,
+}
+
+// selfJobTypes lists the only jobs a user-bound token may run.
+var selfJobTypes = map[string]bool{
+	"get_my_reviews": true,
+	"get_my_stats":   true,
+}
+
+func checkJobScope(ctx context.Context, jobType string) error {
+	scope, ok := ScopeFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if scope.UserID != "" {
+		if !selfJobTypes[jobType] {
+			return ErrForbidden
+		}
+		return nil
+	}
+	if !scopedJobTypes[jobType] {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func checkTeamScope(ctx context.Context, teamName string) error {
+	if scope, ok := ScopeFromContext(ctx); ok && scope.TeamName != teamName {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// checkUserScope lets a team token reach a user whose home team it is or
+// who holds an extra membership in its team.
+func (s *PRService) checkUserScope(ctx context.Context, userID, homeTeam string) error {
+	scope, ok := ScopeFromContext(ctx)
+	if !ok || scope.TeamName == homeTeam {
+		return nil
+	}
+	memberships, err := s.repo.GetTeamMemberships(ctx, scope.TeamName)
+	if err != nil {
+		return err
+	}
+	for _, m := range memberships {
+		if m.UserID == userID {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+func (s *PRService) IssueTeamToken(ctx context.Context, teamName string) (string, error) {
+	if err := validateTeamName(teamName); err != nil {
+		return "", err
+	}
+	if _, ok := ScopeFromContext(ctx); ok {
+		return "", ErrForbidden
+	}
+	if _, err := s.GetTeam(ctx, teamName); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, teamTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("crypto rand failed: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := s.repo.CreateTeamToken(ctx, teamName, hashToken(token)); err != nil {
+		s.log.Error("failed to store team token", "team", teamName, "error", err)
+		return "", err
+	}
+	s.log.Success("team token issued", "team", teamName)
+	return token, nil
+}
+
+func (s *PRService) ResolveTokenScope(ctx context.Context, token string) (Scope, error) {
+	if token == "" {
+		return Scope{}, ErrUnauthorized
+	}
+	teamName, err := s.repo.GetTeamByToken(ctx, hashToken(token))
+	if err == nil {
+		return Scope{TeamName: teamName}, nil
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		s.log.Error("failed to resolve team token", "error", err)
+		return Scope{}, err
+	}
+	userID, err := s.repo.GetUserByToken(ctx, hashToken(token))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return Scope{}, ErrUnauthorized
+		}
+		s.log.Error("failed to resolve user token", "error", err)
+		return Scope{}, err
+	}
+	return Scope{UserID: userID}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+// recencyHorizon is how long after a review a candidate counts as fully
+// rested for the recency scorer.
+const recencyHorizon = 7 * 24 * time.Hour
+
+type candidateFilter func(c models.CandidateSignals) bool
+
+// candidateScorer returns a value in [0, 1]; higher means a better fit.
+type candidateScorer func(c models.CandidateSignals, pool scoringPool) float64
+
+// scoringPool carries values derived from the whole candidate set that
+// individual scorers normalise against.
+type scoringPool struct {
+	now              time.Time
+	maxAuthorReviews int
+}
+
+var candidateFilters = map[string]candidateFilter{
+	"active": func(c models.CandidateSignals) bool {
+		return c.IsActive
+	},
+	"reviewed_author": func(c models.CandidateSignals) bool {
+		return c.AuthorReviews > 0
+	},
+}
+
+var candidateScorers = map[string]candidateScorer{
+	"load": func(c models.CandidateSignals, _ scoringPool) float64 {
+		return 1 / float64(1+c.OpenReviews)
+	},
+	"expertise": func(c models.CandidateSignals, pool scoringPool) float64 {
+		if pool.maxAuthorReviews == 0 {
+			return 0
+		}
+		return float64(c.AuthorReviews) / float64(pool.maxAuthorReviews)
+	},
+	"recency": func(c models.CandidateSignals, pool scoringPool) float64 {
+		if c.LastReviewAt == nil {
+			return 1
+		}
+		idle := pool.now.Sub(*c.LastReviewAt)
+		if idle >= recencyHorizon {
+			return 1
+		}
+		if idle <= 0 {
+			return 0
+		}
+		return float64(idle) / float64(recencyHorizon)
+	},
+}
+
+func defaultScoringConfig() models.ScoringConfig {
+	return models.ScoringConfig{
+		Filters: []string{},
+		Weights: map[string]float64{"load": 0.6, "expertise": 0.4},
+	}
+}
+
+func validateScoringConfig(cfg models.ScoringConfig) error {
+	for _, name := range cfg.Filters {
+		if _, ok := candidateFilters[name]; !ok {
+			return fmt.Errorf("%w: unknown filter %q", ErrInvalidSettings, name)
+		}
+	}
+	if len(cfg.Weights) == 0 {
+		return fmt.Errorf("%w: at least one scorer weight required", ErrInvalidSettings)
+	}
+	for name, w := range cfg.Weights {
+		if _, ok := candidateScorers[name]; !ok {
+			return fmt.Errorf("%w: unknown scorer %q", ErrInvalidSettings, name)
+		}
+		if w < 0 {
+			return fmt.Errorf("%w: negative weight for %q", ErrInvalidSettings, name)
+		}
+	}
+	return nil
+}
+
+// rankCandidates runs signals through cfg's filters, combines its weighted
+// scorers and orders the result best first. Inactive candidates that survive
+// the filters keep their component scores but get a zero total.
+func rankCandidates(signals []models.CandidateSignals, cfg models.ScoringConfig, now time.Time) []models.ReviewerSuggestion {
+	kept := make([]models.CandidateSignals, 0, len(signals))
+next:
+	for _, c := range signals {
+		for _, name := range cfg.Filters {
+			if f, ok := candidateFilters[name]; ok && !f(c) {
+				continue next
+			}
+		}
+		kept = append(kept, c)
+	}
+
+	pool := scoringPool{now: now}
+	for _, c := range kept {
+		if c.AuthorReviews > pool.maxAuthorReviews {
+			pool.maxAuthorReviews = c.AuthorReviews
+		}
+	}
+
+	res := make([]models.ReviewerSuggestion, 0, len(kept))
+	for _, c := range kept {
+		sg := models.ReviewerSuggestion{
+			UserID:    c.UserID,
+			Username:  c.Username,
+			Load:      candidateScorers["load"](c, pool),
+			Expertise: candidateScorers["expertise"](c, pool),
+		}
+		if c.IsActive {
+			sg.Availability = 1
+		}
+		for name, w := range cfg.Weights {
+			if score, ok := candidateScorers[name]; ok {
+				sg.Score += w * score(c, pool)
+			}
+		}
+		sg.Score *= sg.Availability
+		res = append(res, sg)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].UserID < res[j].UserID
+	})
+	return res
+}
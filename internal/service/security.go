@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+const (
+	maxSecurityLabels = 10
+	maxSecurityPaths  = 20
+)
+
+// securityRule returns the team's security review rule if it matches pr.
+func (s *PRService) securityRule(ctx context.Context, teamName string, pr models.PullRequest) *models.SecurityReviewRule {
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			s.log.Warn("failed to load security rule", "team", teamName, "error", err)
+		}
+		return nil
+	}
+	rule := settings.SecurityReview
+	if rule == nil || !matchesSecurityRule(*rule, pr.Labels, pr.Paths) {
+		return nil
+	}
+	return rule
+}
+
+func matchesSecurityRule(rule models.SecurityReviewRule, labels, paths []string) bool {
+	for _, want := range rule.Labels {
+		for _, l := range labels {
+			if l == want {
+				return true
+			}
+		}
+	}
+	for _, prefix := range rule.Paths {
+		prefix = strings.TrimPrefix(prefix, "/")
+		for _, p := range paths {
+			if strings.HasPrefix(strings.TrimPrefix(p, "/"), prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// securityReviewer makes sure selected holds an active member of the rule's
+// team. If none of the default reviewers is one, the least loaded member is
+// put first, and the last default gives way when the set is already full.
+// It returns the security reviewer, empty if nobody could take the PR, and
+// whether it was added.
+func (s *PRService) securityReviewer(ctx context.Context, rule models.SecurityReviewRule, authorID string, selected []models.PRReviewer) ([]models.PRReviewer, string, bool) {
+	ids, err := s.repo.GetActiveTeamMembersExcept(ctx, rule.Team, authorID)
+	if err != nil {
+		s.log.Warn("failed to get security reviewers", "team", rule.Team, "error", err)
+		return selected, "", false
+	}
+	members := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		members[id] = true
+	}
+	for _, r := range selected {
+		if members[r.UserID] {
+			return selected, r.UserID, false
+		}
+	}
+
+	ids = s.withoutReserved(ctx, authorID, s.withinRampUp(ctx, ids))
+	load, err := s.repo.GetOpenReviewLoad(ctx, rule.Team)
+	if err != nil {
+		s.log.Warn("failed to get open review counts", "team", rule.Team, "error", err)
+	}
+	for len(ids) > 0 {
+		idx, err := pickCandidate(s.rand, ids, load, nil, nil)
+		if err != nil {
+			break
+		}
+		user, err := s.repo.GetUser(ctx, ids[idx])
+		ids = append(ids[:idx], ids[idx+1:]...)
+		if err != nil || !user.IsActive {
+			continue
+		}
+		if len(selected) >= maxReviewers {
+			selected = selected[:maxReviewers-1]
+		}
+		reviewer := models.PRReviewer{UserID: user.UserID, Username: user.Username, IsActive: user.IsActive}
+		return append([]models.PRReviewer{reviewer}, selected...), user.UserID, true
+	}
+	return selected, "", false
+}
+
+func (s *PRService) validateSecurityReview(ctx context.Context, rule *models.SecurityReviewRule) error {
+	if rule == nil {
+		return nil
+	}
+	if len(rule.Labels) == 0 && len(rule.Paths) == 0 {
+		return fmt.Errorf("%w: security review needs labels or paths", ErrInvalidSettings)
+	}
+	if len(rule.Labels) > maxSecurityLabels || len(rule.Paths) > maxSecurityPaths {
+		return fmt.Errorf("%w: at most %d security labels and %d paths", ErrInvalidSettings, maxSecurityLabels, maxSecurityPaths)
+	}
+	for _, p := range rule.Paths {
+		if strings.TrimPrefix(p, "/") == "" {
+			return fmt.Errorf("%w: empty security path", ErrInvalidSettings)
+		}
+	}
+	if _, err := s.repo.GetTeam(ctx, rule.Team); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return fmt.Errorf("%w: unknown security team %q", ErrInvalidSettings, rule.Team)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetSecurityCoverage reports how the team's PRs that needed a security
+// review over the last weeks weeks were served.
+func (s *PRService) GetSecurityCoverage(ctx context.Context, teamName string, weeks int) (models.SecurityCoverage, error) {
+	if err := validateTeamName(teamName); err != nil {
+		return models.SecurityCoverage{}, err
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return models.SecurityCoverage{}, err
+	}
+	if _, err := s.GetTeam(ctx, teamName); err != nil {
+		return models.SecurityCoverage{}, err
+	}
+	if weeks <= 0 {
+		weeks = DefaultStatsWeeks
+	}
+	if weeks > MaxStatsWeeks {
+		weeks = MaxStatsWeeks
+	}
+
+	cov, err := s.repo.GetSecurityCoverage(ctx, teamName, time.Now().UTC().AddDate(0, 0, -7*weeks))
+	if err != nil {
+		s.log.Error("failed to get security coverage", "team", teamName, "error", err)
+		return models.SecurityCoverage{}, err
+	}
+	cov.Coverage = 1
+	if cov.Required > 0 {
+		cov.Coverage = float64(cov.Covered) / float64(cov.Required)
+	}
+	return cov, nil
+}
@@ -2,16 +2,18 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
-	"math/big"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"PR-reviewer/internal/logger"
+	"PR-reviewer/internal/metrics"
 	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/notify"
 	"PR-reviewer/internal/repo"
 )
 
@@ -20,6 +22,20 @@ const (
 	jobQueueSize = 200
 	maxReviewers = 2
 	kvsInitCap   = 10
+
+	maxFallbackTeams = 5
+)
+
+// Page limits for list endpoints.
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// Period limits for /stats/user, in weeks.
+const (
+	DefaultStatsWeeks = 8
+	MaxStatsWeeks     = 52
 )
 
 type JobResult struct {
@@ -34,20 +50,111 @@ type Job struct {
 	RespCh  chan JobResult
 }
 
+var (
+	jobResultsDropped   = metrics.NewCounter("job_results_dropped_total", "Job results that could not be delivered because the response channel was full.", "type")
+	jobResultsAbandoned = metrics.NewCounter("job_results_abandoned_total", "Job results produced after the caller stopped waiting.", "type", "outcome")
+
+	// Assignment outcomes, for watching how load gets balanced over time.
+	reviewerAssignments  = metrics.NewCounter("reviewer_assignments_total", "Reviewers added to PRs, by how they were chosen: random, default, security, fallback, mentor, manual, reserved, rotation, sticky, secondary, fill, backfill, round.", "strategy")
+	candidatesFiltered   = metrics.NewCounter("assignment_candidates_filtered_total", "Candidates skipped when picking reviewers: absent, inactive and capacity for new PRs, ramp_up and reserved for any automatic pick.", "reason")
+	reviewerReplacements = metrics.NewCounter("reviewer_reassignments_total", "Reviewers replaced on a PR, by cause: manual, reopen, member_removed, user_moved, team_deactivated, bulk, sla, stale.", "cause")
+	needMoreReviewers    = metrics.NewCounter("need_more_reviewers_total", "Times a PR was left with fewer reviewers than wanted, by team.", "team")
+)
+
+// NewJob builds a job with a single-slot response channel, so delivering
+// its one result never blocks a worker.
+func NewJob(ctx context.Context, jobType string, payload map[string]interface{}) Job {
+	return Job{
+		Ctx:     ctx,
+		Type:    jobType,
+		Payload: payload,
+		RespCh:  make(chan JobResult, 1),
+	}
+}
+
+// WithRequestID tags ctx with the HTTP request ID; repo errors and job logs
+// for the request carry it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return repo.WithRequestID(ctx, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	return repo.RequestIDFromContext(ctx)
+}
+
 type PRService struct {
 	repo    repo.Repo
 	log     logger.Logger
 	jobs    chan Job
 	wg      sync.WaitGroup
 	stopped chan struct{}
+	tasks   sync.WaitGroup
+	// enqueueMu orders EnqueueJob's check of stopped and its send against
+	// StopWorkers closing stopped, so no job lands in jobs after the
+	// workers have drained it.
+	enqueueMu sync.RWMutex
+
+	notifier  notify.Notifier
+	orgCache  orgSummaryCache
+	alerts    alertState
+	reminders reminderState
+	sla       slaState
+	diag      *diagState
+
+	exportSalt string
+
+	fallback    bool
+	globalTeams []string
+
+	workingHours   *WorkingHours
+	rampUp         RampUpConfig
+	stickyLookback time.Duration
+
+	isLeader func() bool
+	rand     RandSource
+	faults   repo.FaultInjector
+}
+
+type Option func(*PRService)
+
+// WithNotifier sets the channel used for alerts and other notifications.
+func WithNotifier(n notify.Notifier) Option {
+	return func(s *PRService) { s.notifier = n }
+}
+
+// WithFaults runs every job through f before handling it, for resilience
+// testing.
+func WithFaults(f repo.FaultInjector) Option {
+	return func(s *PRService) { s.faults = f }
+}
+
+// WithAssignmentExport enables the anonymized assignment export; salt keys
+// the ID hashes and must stay secret.
+func WithAssignmentExport(salt string) Option {
+	return func(s *PRService) { s.exportSalt = salt }
 }
 
-func NewService(r repo.Repo, l logger.Logger) *PRService {
+// WithCrossTeamFallback lets CreatePR take missing reviewers from backup
+// teams: the team's fallback_teams setting, or globalTeams when it has none.
+func WithCrossTeamFallback(globalTeams []string) Option {
+	return func(s *PRService) {
+		s.fallback = true
+		s.globalTeams = globalTeams
+	}
+}
+
+func NewService(r repo.Repo, l logger.Logger, opts ...Option) *PRService {
 	s := &PRService{
-		repo:    r,
-		log:     l,
-		jobs:    make(chan Job, jobQueueSize),
-		stopped: make(chan struct{}),
+		repo:     r,
+		log:      l,
+		jobs:     make(chan Job, jobQueueSize),
+		stopped:  make(chan struct{}),
+		notifier: notify.Nop{},
+		diag:     newDiagState(numWorkers),
+		rand:     cryptoRand{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
 
 	for i := 1; i <= numWorkers; i++ {
@@ -59,10 +166,20 @@ func NewService(r repo.Repo, l logger.Logger) *PRService {
 	return s
 }
 
+// StopWorkers stops the scheduler and the workers and cancels the jobs
+// still queued. Jobs enqueued afterwards are canceled right away. jobs is
+// never closed, so a late EnqueueJob can't send on a closed channel.
 func (s *PRService) StopWorkers() {
+	s.enqueueMu.Lock()
 	close(s.stopped)
-	close(s.jobs)
+	s.enqueueMu.Unlock()
+
+	s.tasks.Wait()
 	s.wg.Wait()
+
+	for len(s.jobs) > 0 {
+		s.deliver(s.log, <-s.jobs, JobResult{Error: context.Canceled})
+	}
 	s.log.Info("all workers stopped")
 }
 
@@ -76,20 +193,32 @@ func (s *PRService) workerLoop(id int) {
 			workerLog.Info("stop signal received, worker exiting")
 			return
 
-		case job, ok := <-s.jobs:
-			if !ok {
-				workerLog.Info("jobs channel closed, worker exiting")
-				return
-			}
-
+		case job := <-s.jobs:
 			ctx := job.Ctx
 			if ctx == nil {
 				ctx = context.Background()
 			}
+			ctx = repo.WithJobType(ctx, job.Type)
 
 			start := time.Now()
+			s.diag.started(id, job.Type)
 
-			res, kvs := s.handleJob(ctx, job, workerLog)
+			var res JobResult
+			var kvs []any
+			if s.faults != nil {
+				if err := s.faults.Inject(ctx, "job:"+job.Type); err != nil {
+					res = JobResult{Error: err}
+				}
+			}
+			if res.Error == nil {
+				res, kvs = s.handleJob(ctx, job, workerLog)
+			}
+			if id := repo.RequestIDFromContext(ctx); id != "" {
+				kvs = append(kvs, "request_id", id)
+			}
+			if res.Error == nil {
+				res.Data = presentPRIDs(s.redactResult(ctx, res.Data))
+			}
 
 			duration := time.Since(start)
 			ms := float64(duration.Nanoseconds()) / 1e6
@@ -97,17 +226,35 @@ func (s *PRService) workerLoop(id int) {
 
 			s.logJobResult(workerLog, job.Type, durationStr, kvs, res.Error)
 
-			if job.RespCh != nil {
-				select {
-				case job.RespCh <- res:
-				default:
-					workerLog.Warn("response channel blocked, dropping result", "type", job.Type)
-				}
-			}
+			s.deliver(workerLog, job, res)
+			s.diag.finished(id)
 		}
 	}
 }
 
+// deliver hands res to the job's caller without ever blocking. Results the
+// caller can no longer receive are logged and counted instead of vanishing.
+func (s *PRService) deliver(log logger.Logger, job Job, res JobResult) {
+	if job.Ctx != nil && job.Ctx.Err() != nil {
+		outcome := "success"
+		if res.Error != nil {
+			outcome = "error"
+		}
+		jobResultsAbandoned.Inc(job.Type, outcome)
+		log.Warn("caller gone before job result was read", "type", job.Type, "outcome", outcome, "error", res.Error)
+	}
+
+	if job.RespCh == nil {
+		return
+	}
+	select {
+	case job.RespCh <- res:
+	default:
+		jobResultsDropped.Inc(job.Type)
+		log.Warn("response channel full, dropping result", "type", job.Type, "error", res.Error)
+	}
+}
+
 func (s *PRService) handleJob(ctx context.Context, job Job, workerLog logger.Logger) (JobResult, []any) {
 	kvs := make([]any, 0, kvsInitCap)
 
@@ -117,6 +264,13 @@ func (s *PRService) handleJob(ctx context.Context, job Job, workerLog logger.Log
 	default:
 	}
 
+	if err := checkJobScope(ctx, job.Type); err != nil {
+		return JobResult{Data: nil, Error: err}, kvs
+	}
+	if err := s.resolvePRID(ctx, job.Payload); err != nil {
+		return JobResult{Data: nil, Error: err}, kvs
+	}
+
 	switch job.Type {
 	case "create_pr":
 		v, ok := job.Payload["pr"].(models.PullRequest)
@@ -129,17 +283,108 @@ func (s *PRService) handleJob(ctx context.Context, job Job, workerLog logger.Log
 		}
 		return JobResult{Data: created, Error: err}, kvs
 
+	case "get_pr":
+		v, ok := job.Payload["pr_id"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		pr, err := s.GetPR(ctx, v)
+		kvs = append(kvs, "pr", v)
+		return JobResult{Data: pr, Error: err}, kvs
+
+	case "get_assignment_history":
+		v, ok := job.Payload["pr_id"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.AssignmentHistory(ctx, v)
+		kvs = append(kvs, "pr", v)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "get_review_rounds":
+		v, ok := job.Payload["pr_id"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.ReviewRounds(ctx, v)
+		kvs = append(kvs, "pr", v)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "request_changes":
+		prID, ok1 := job.Payload["pr_id"].(string)
+		uid, ok2 := job.Payload["uid"].(string)
+		hours, ok3 := job.Payload["hours"].(int)
+		fresh, ok4 := job.Payload["fresh_reviewer"].(bool)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		res, err := s.RequestChanges(ctx, prID, uid, hours, fresh)
+		kvs = append(kvs, "pr", prID, "user", uid, "hours", hours, "fresh_reviewer", fresh)
+		return JobResult{Data: res, Error: err}, kvs
+
 	case "merge_pr":
 		v, ok := job.Payload["pr_id"].(string)
 		if !ok {
 			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
 		}
-		merged, err := s.MergePR(ctx, v)
+		override, _ := job.Payload["override"].(bool)
+		mergedBy, _ := job.Payload["merged_by"].(string)
+		merged, err := s.MergePR(ctx, v, mergedBy, override)
 		if err == nil {
-			kvs = append(kvs, "pr", v)
+			kvs = append(kvs, "pr", v, "merged_by", mergedBy)
 		}
 		return JobResult{Data: merged, Error: err}, kvs
 
+	case "set_labels":
+		v, ok1 := job.Payload["pr_id"].(string)
+		labels, ok2 := job.Payload["labels"].([]string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		pr, err := s.SetPRLabels(ctx, v, labels)
+		if err == nil {
+			kvs = append(kvs, "pr", v)
+		}
+		return JobResult{Data: pr, Error: err}, kvs
+
+	case "queue_merge":
+		v, ok := job.Payload["pr_id"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		mergedBy, _ := job.Payload["merged_by"].(string)
+		queued, err := s.QueueMerge(ctx, v, mergedBy)
+		if err == nil {
+			kvs = append(kvs, "pr", v, "merged_by", mergedBy)
+		}
+		return JobResult{Data: queued, Error: err}, kvs
+
+	case "close_pr":
+		v, ok := job.Payload["pr_id"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		closed, err := s.ClosePR(ctx, v)
+		kvs = append(kvs, "pr", v)
+		return JobResult{Data: closed, Error: err}, kvs
+
+	case "reopen_pr":
+		v, ok := job.Payload["pr_id"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		reopened, err := s.ReopenPR(ctx, v)
+		if err == nil {
+			kvs = append(kvs, "pr", v, "assigned", reopened.Assigned)
+		}
+		return JobResult{Data: reopened, Error: err}, kvs
+
 	case "reassign_pr":
 		prID, ok1 := job.Payload["pr_id"].(string)
 		oldUser, ok2 := job.Payload["old_user"].(string)
@@ -149,10 +394,95 @@ func (s *PRService) handleJob(ctx context.Context, job Job, workerLog logger.Log
 		pr, newUID, err := s.Reassign(ctx, prID, oldUser)
 		if err == nil {
 			kvs = append(kvs, "pr", prID, "old_user", oldUser, "new_user", newUID)
-			return JobResult{Data: map[string]interface{}{"pr": pr, "new_user": newUID}, Error: nil}, kvs
+		} else {
+			kvs = append(kvs, "pr", prID, "old_user", oldUser)
+		}
+		return JobResult{Data: models.PRResult{PR: pr, ReplacedBy: newUID}, Error: err}, kvs
+
+	case "assign_reviewer":
+		prID, ok1 := job.Payload["pr_id"].(string)
+		uid, ok2 := job.Payload["uid"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		pr, err := s.AssignReviewer(ctx, prID, uid)
+		kvs = append(kvs, "pr", prID, "user", uid)
+		return JobResult{Data: pr, Error: err}, kvs
+
+	case "assign_secondary_reviewer":
+		prID, ok1 := job.Payload["pr_id"].(string)
+		uid, ok2 := job.Payload["uid"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		pr, err := s.AssignSecondaryReviewer(ctx, prID, uid)
+		kvs = append(kvs, "pr", prID, "user", uid)
+		return JobResult{Data: pr, Error: err}, kvs
+
+	case "reserve_reviewers":
+		uid, ok1 := job.Payload["uid"].(string)
+		reviewers, ok2 := job.Payload["reviewers"].([]string)
+		until, ok3 := job.Payload["until"].(time.Time)
+		if !ok1 || !ok2 || !ok3 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.ReserveReviewers(ctx, uid, reviewers, until)
+		kvs = append(kvs, "author", uid, "reviewers", len(reviewers), "until", until)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "fill_reviewers":
+		prID, ok := job.Payload["pr_id"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		pr, err := s.FillReviewers(ctx, prID)
+		kvs = append(kvs, "pr", prID)
+		if err == nil {
+			kvs = append(kvs, "reviewers", len(pr.Assigned))
+		}
+		return JobResult{Data: pr, Error: err}, kvs
+
+	case "remove_reviewer":
+		prID, ok1 := job.Payload["pr_id"].(string)
+		uid, ok2 := job.Payload["uid"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		pr, err := s.RemoveReviewer(ctx, prID, uid)
+		kvs = append(kvs, "pr", prID, "user", uid)
+		return JobResult{Data: pr, Error: err}, kvs
+
+	case "submit_review":
+		prID, ok1 := job.Payload["pr_id"].(string)
+		uid, ok2 := job.Payload["uid"].(string)
+		decision, ok3 := job.Payload["decision"].(models.ReviewStatus)
+		comment, ok4 := job.Payload["comment"].(string)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		res, err := s.SubmitReview(ctx, prID, uid, decision, comment)
+		kvs = append(kvs, "pr", prID, "user", uid, "decision", decision)
+		return JobResult{Data: res, Error: err}, kvs
+
+	case "approve_pr":
+		prID, ok1 := job.Payload["pr_id"].(string)
+		uid, ok2 := job.Payload["uid"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		pr, err := s.ApprovePR(ctx, prID, uid)
+		kvs = append(kvs, "pr", prID, "user", uid)
+		return JobResult{Data: pr, Error: err}, kvs
+
+	case "ack_review":
+		prID, ok1 := job.Payload["pr_id"].(string)
+		uid, ok2 := job.Payload["uid"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
 		}
-		kvs = append(kvs, "pr", prID, "old_user", oldUser)
-		return JobResult{Data: map[string]interface{}{"pr": pr, "new_user": newUID}, Error: err}, kvs
+		pr, err := s.AcknowledgeReview(ctx, prID, uid)
+		kvs = append(kvs, "pr", prID, "user", uid)
+		return JobResult{Data: pr, Error: err}, kvs
 
 	case "get_team":
 		name, ok := job.Payload["team"].(string)
@@ -167,6 +497,97 @@ func (s *PRService) handleJob(ctx context.Context, job Job, workerLog logger.Log
 		}
 		return JobResult{Data: t, Error: err}, kvs
 
+	case "update_team":
+		v, ok := job.Payload["update"].(models.TeamUpdate)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		t, err := s.UpdateTeam(ctx, v)
+		kvs = append(kvs, "team", v.TeamName)
+		if v.NewTeamName != "" {
+			kvs = append(kvs, "new_team", v.NewTeamName)
+		}
+		return JobResult{Data: t, Error: err}, kvs
+
+	case "add_team_member":
+		teamName, ok1 := job.Payload["team_name"].(string)
+		member, ok2 := job.Payload["member"].(models.TeamMember)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		t, err := s.AddTeamMember(ctx, teamName, member)
+		kvs = append(kvs, "team", teamName, "user", member.UserID)
+		return JobResult{Data: t, Error: err}, kvs
+
+	case "add_membership":
+		m, ok := job.Payload["membership"].(models.Membership)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		err := s.AddMembership(ctx, m)
+		kvs = append(kvs, "team", m.TeamName, "user", m.UserID, "role", m.Role)
+		return JobResult{Data: m, Error: err}, kvs
+
+	case "remove_membership":
+		teamName, ok1 := job.Payload["team_name"].(string)
+		userID, ok2 := job.Payload["user_id"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		err := s.RemoveMembership(ctx, teamName, userID)
+		kvs = append(kvs, "team", teamName, "user", userID)
+		return JobResult{Data: nil, Error: err}, kvs
+
+	case "remove_team_member":
+		teamName, ok1 := job.Payload["team_name"].(string)
+		userID, ok2 := job.Payload["user_id"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		h, err := s.RemoveTeamMember(ctx, teamName, userID)
+		kvs = append(kvs, "team", teamName, "user", userID, "reassigned", len(h.Reassigned))
+		return JobResult{Data: h, Error: err}, kvs
+
+	case "move_user_team":
+		userID, ok1 := job.Payload["user_id"].(string)
+		teamName, ok2 := job.Payload["team_name"].(string)
+		reassign, ok3 := job.Payload["reassign_reviews"].(bool)
+		if !ok1 || !ok2 || !ok3 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		m, err := s.MoveUserTeam(ctx, userID, teamName, reassign)
+		kvs = append(kvs, "user", userID, "team", teamName, "reassigned", len(m.Reassigned))
+		return JobResult{Data: m, Error: err}, kvs
+
+	case "reassign_all":
+		from, ok1 := job.Payload["from"].(string)
+		to, ok2 := job.Payload["to"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		report, err := s.BulkReassign(ctx, from, to)
+		kvs = append(kvs, "from", from, "to", to, "prs", len(report.Results))
+		return JobResult{Data: report, Error: err}, kvs
+
+	case "export_assignments":
+		from, ok1 := job.Payload["from"].(time.Time)
+		to, ok2 := job.Payload["to"].(time.Time)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		recs, err := s.ExportAssignments(ctx, from, to)
+		kvs = append(kvs, "records", len(recs))
+		return JobResult{Data: recs, Error: err}, kvs
+
+	case "check_consistency":
+		repair, ok := job.Payload["repair"].(bool)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		report, err := s.CheckConsistency(ctx, repair)
+		kvs = append(kvs, "repair", repair, "violations", len(report.Violations))
+		return JobResult{Data: report, Error: err}, kvs
+
 	case "set_user_active":
 		uid, ok1 := job.Payload["uid"].(string)
 		active, ok2 := job.Payload["active"].(bool)
@@ -177,12 +598,21 @@ func (s *PRService) handleJob(ctx context.Context, job Job, workerLog logger.Log
 		kvs = append(kvs, "user", uid, "active", active)
 		return JobResult{Data: u, Error: err}, kvs
 
-	case "get_reviews":
+	case "forget_user":
 		uid, ok := job.Payload["uid"].(string)
 		if !ok {
 			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
 		}
-		data, err := s.GetPRsByReviewer(ctx, uid)
+		u, err := s.ForgetUser(ctx, uid)
+		kvs = append(kvs, "user", uid)
+		return JobResult{Data: u, Error: err}, kvs
+
+	case "get_authored":
+		uid, ok := job.Payload["uid"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.GetPRsByAuthor(ctx, uid)
 		if err == nil {
 			kvs = append(kvs, "user", uid, "count", len(data))
 		} else {
@@ -190,58 +620,369 @@ func (s *PRService) handleJob(ctx context.Context, job Job, workerLog logger.Log
 		}
 		return JobResult{Data: data, Error: err}, kvs
 
-	case "deactivate_team":
-		teamName, ok := job.Payload["team_name"].(string)
+	case "get_reviews":
+		uid, ok := job.Payload["uid"].(string)
 		if !ok {
 			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
 		}
-		err := s.DeactivateTeam(ctx, teamName)
+		data, err := s.GetPRsByReviewer(ctx, uid)
 		if err == nil {
-			kvs = append(kvs, "team", teamName)
-			return JobResult{Data: map[string]string{"team": teamName}, Error: nil}, kvs
+			kvs = append(kvs, "user", uid, "count", len(data))
+		} else {
+			kvs = append(kvs, "user", uid)
 		}
-		kvs = append(kvs, "team", teamName)
-		return JobResult{Data: map[string]string{"team": teamName}, Error: err}, kvs
+		return JobResult{Data: data, Error: err}, kvs
 
-	default:
-		return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
-	}
-}
+	case "list_prs":
+		filter, ok1 := job.Payload["filter"].(models.PRFilter)
+		page, ok2 := job.Payload["page"].(models.Page)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.ListPRs(ctx, filter, page)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
 
-func (s *PRService) logJobResult(workerLog logger.Logger, jobType, durationStr string, kvs []any, err error) {
-	if err == nil {
-		args := append([]any{"duration", durationStr}, kvs...)
-		workerLog.Success(jobType+" succeeded", args...)
-		return
-	}
-	workerLog.Error(err.Error(), kvs...)
-}
+	case "search_prs":
+		query, ok1 := job.Payload["q"].(string)
+		page, ok2 := job.Payload["page"].(models.Page)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		filter, _ := job.Payload["filter"].(models.PRFilter)
+		data, err := s.SearchPRs(ctx, query, filter, page)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
 
-func (s *PRService) EnqueueJob(job Job) {
-	select {
-	case <-s.stopped:
-		if job.RespCh != nil {
-			select {
-			case job.RespCh <- JobResult{Error: context.Canceled}:
-			default:
-			}
+	case "suggest_reviewers":
+		authorID, ok1 := job.Payload["author_id"].(string)
+		count, ok2 := job.Payload["count"].(int)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
 		}
-		return
-	default:
-	}
+		data, err := s.SuggestReviewers(ctx, authorID, count)
+		kvs = append(kvs, "author", authorID)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
 
-	select {
-	case s.jobs <- job:
-	default:
-		s.log.Warn("job queue full, dropping job", "type", job.Type)
-		if job.RespCh != nil {
-			select {
-			case job.RespCh <- JobResult{Error: ErrJobQueueFull}:
-			default:
-			}
+	case "get_user":
+		uid, ok := job.Payload["uid"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
 		}
-	}
-}
+		data, err := s.GetUserProfile(ctx, uid)
+		kvs = append(kvs, "user", uid)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "set_user_skills":
+		uid, ok1 := job.Payload["uid"].(string)
+		skills, ok2 := job.Payload["skills"].([]string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.SetUserSkills(ctx, uid, skills)
+		kvs = append(kvs, "user", uid, "skills", len(skills))
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "set_user_weight":
+		uid, ok1 := job.Payload["uid"].(string)
+		weight, ok2 := job.Payload["weight"].(int)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.SetUserWeight(ctx, uid, weight)
+		kvs = append(kvs, "user", uid, "weight", weight)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "set_timezone":
+		uid, ok1 := job.Payload["uid"].(string)
+		tz, ok2 := job.Payload["timezone"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.SetTimezone(ctx, uid, tz)
+		kvs = append(kvs, "user", uid, "timezone", tz)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "set_notifications":
+		uid, ok1 := job.Payload["uid"].(string)
+		merged, ok2 := job.Payload["merged"].(bool)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.SetNotificationPrefs(ctx, uid, models.NotificationPrefs{Merged: merged})
+		kvs = append(kvs, "user", uid, "merged", merged)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "set_mentor":
+		uid, ok1 := job.Payload["uid"].(string)
+		mentor, ok2 := job.Payload["mentor"].(string)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.SetMentor(ctx, uid, mentor)
+		kvs = append(kvs, "user", uid, "mentor", mentor)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "set_vacation":
+		uid, ok1 := job.Payload["uid"].(string)
+		from, ok2 := job.Payload["from"].(time.Time)
+		until, ok3 := job.Payload["until"].(time.Time)
+		if !ok1 || !ok2 || !ok3 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.SetVacation(ctx, uid, from, until)
+		kvs = append(kvs, "user", uid, "until", until)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "get_user_activity":
+		uid, ok1 := job.Payload["uid"].(string)
+		page, ok2 := job.Payload["page"].(models.Page)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.GetUserActivity(ctx, uid, page)
+		kvs = append(kvs, "user", uid)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "list_overdue_reviews":
+		teamName, ok := job.Payload["team_name"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.OverdueReviews(ctx, teamName)
+		kvs = append(kvs, "team", teamName)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "get_security_coverage":
+		teamName, ok1 := job.Payload["team_name"].(string)
+		weeks, ok2 := job.Payload["weeks"].(int)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.GetSecurityCoverage(ctx, teamName, weeks)
+		kvs = append(kvs, "team", teamName, "weeks", weeks)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "get_archived_pr":
+		prID, ok := job.Payload["pr_id"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.GetArchivedPR(ctx, prID)
+		kvs = append(kvs, "pr", prID)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "list_archived_prs":
+		filter, ok1 := job.Payload["filter"].(models.PRFilter)
+		page, ok2 := job.Payload["page"].(models.Page)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.ListArchivedPRs(ctx, filter, page)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "get_review_load":
+		teamName, ok := job.Payload["team_name"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.GetReviewLoad(ctx, teamName)
+		kvs = append(kvs, "team", teamName)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "get_team_stats":
+		teamName, ok := job.Payload["team_name"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.GetTeamStats(ctx, teamName)
+		kvs = append(kvs, "team", teamName)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "get_user_stats":
+		uid, ok1 := job.Payload["uid"].(string)
+		weeks, ok2 := job.Payload["weeks"].(int)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.GetUserStats(ctx, uid, weeks)
+		kvs = append(kvs, "user", uid, "weeks", weeks)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "preview_deactivation":
+		teamName, ok := job.Payload["team_name"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		preview, err := s.PreviewDeactivation(ctx, teamName)
+		kvs = append(kvs, "team", teamName)
+		if err == nil {
+			kvs = append(kvs, "reviews", preview.Reviews, "stranded", len(preview.Stranded))
+		}
+		return JobResult{Data: preview, Error: err}, kvs
+
+	case "deactivate_team":
+		teamName, ok := job.Payload["team_name"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		err := s.DeactivateTeam(ctx, teamName)
+		if err == nil {
+			kvs = append(kvs, "team", teamName)
+			return JobResult{Data: map[string]string{"team": teamName}, Error: nil}, kvs
+		}
+		kvs = append(kvs, "team", teamName)
+		return JobResult{Data: map[string]string{"team": teamName}, Error: err}, kvs
+
+	case "get_team_settings":
+		teamName, ok := job.Payload["team_name"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		settings, err := s.GetTeamSettings(ctx, teamName)
+		kvs = append(kvs, "team", teamName)
+		return JobResult{Data: settings, Error: err}, kvs
+
+	case "get_rotation":
+		teamName, ok := job.Payload["team_name"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		rotation, err := s.GetRotation(ctx, teamName)
+		kvs = append(kvs, "team", teamName)
+		return JobResult{Data: rotation, Error: err}, kvs
+
+	case "regenerate_rotation":
+		teamName, ok1 := job.Payload["team_name"].(string)
+		weeks, ok2 := job.Payload["weeks"].(int)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		rotation, err := s.RegenerateRotation(ctx, teamName, weeks)
+		kvs = append(kvs, "team", teamName, "weeks", weeks)
+		return JobResult{Data: rotation, Error: err}, kvs
+
+	case "get_repository_settings":
+		repository, ok := job.Payload["repository"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		settings, err := s.GetRepositorySettings(ctx, repository)
+		kvs = append(kvs, "repository", repository)
+		return JobResult{Data: settings, Error: err}, kvs
+
+	case "update_repository_settings":
+		v, ok := job.Payload["settings"].(models.RepositorySettings)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		settings, err := s.UpdateRepositorySettings(ctx, v)
+		kvs = append(kvs, "repository", v.Repository)
+		return JobResult{Data: settings, Error: err}, kvs
+
+	case "update_team_settings":
+		v, ok := job.Payload["settings"].(models.TeamSettings)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		settings, err := s.UpdateTeamSettings(ctx, v)
+		kvs = append(kvs, "team", v.TeamName)
+		return JobResult{Data: settings, Error: err}, kvs
+
+	case "delete_team":
+		teamName, ok1 := job.Payload["team_name"].(string)
+		force, ok2 := job.Payload["force"].(bool)
+		if !ok1 || !ok2 {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		affected, err := s.DeleteTeam(ctx, teamName, force)
+		kvs = append(kvs, "team", teamName, "force", force, "affected_prs", len(affected))
+		return JobResult{Data: affected, Error: err}, kvs
+
+	case "issue_user_token":
+		uid, ok := job.Payload["uid"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		token, err := s.IssueUserToken(ctx, uid)
+		kvs = append(kvs, "user", uid)
+		return JobResult{Data: map[string]string{"user_id": uid, "token": token}, Error: err}, kvs
+
+	case "get_my_reviews":
+		data, err := s.MyReviews(ctx)
+		if err == nil {
+			kvs = append(kvs, "count", len(data))
+		}
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "get_my_stats":
+		weeks, ok := job.Payload["weeks"].(int)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		data, err := s.MyStats(ctx, weeks)
+		kvs = append(kvs, "weeks", weeks)
+		return JobResult{Data: data, Error: err}, kvs
+
+	case "issue_team_token":
+		teamName, ok := job.Payload["team_name"].(string)
+		if !ok {
+			return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+		}
+		token, err := s.IssueTeamToken(ctx, teamName)
+		kvs = append(kvs, "team", teamName)
+		return JobResult{Data: map[string]string{"team_name": teamName, "token": token}, Error: err}, kvs
+
+	default:
+		return JobResult{Data: nil, Error: ErrUnknownJobType}, kvs
+	}
+}
+
+func (s *PRService) logJobResult(workerLog logger.Logger, jobType, durationStr string, kvs []any, err error) {
+	if err == nil {
+		args := append([]any{"duration", durationStr}, kvs...)
+		workerLog.Success(jobType+" succeeded", args...)
+		return
+	}
+	workerLog.Error(err.Error(), kvs...)
+}
+
+func (s *PRService) EnqueueJob(job Job) {
+	s.enqueueMu.RLock()
+	defer s.enqueueMu.RUnlock()
+
+	select {
+	case <-s.stopped:
+		s.deliver(s.log, job, JobResult{Error: context.Canceled})
+		return
+	default:
+	}
+
+	s.diag.enqueued(job.Type)
+	select {
+	case s.jobs <- job:
+	default:
+		s.diag.dropped(job.Type)
+		s.log.Warn("job queue full, dropping job", "type", job.Type)
+		s.deliver(s.log, job, JobResult{Error: ErrJobQueueFull})
+	}
+}
 
 func (s *PRService) AddTeam(ctx context.Context, team models.Team) error {
 	if err := validateTeam(team); err != nil {
@@ -255,276 +996,1207 @@ func (s *PRService) AddTeam(ctx context.Context, team models.Team) error {
 	return nil
 }
 
-func (s *PRService) GetTeam(ctx context.Context, name string) (models.Team, error) {
-	if err := validateTeamName(name); err != nil {
+func (s *PRService) UpdateTeam(ctx context.Context, upd models.TeamUpdate) (models.Team, error) {
+	if err := validateTeamUpdate(upd); err != nil {
 		return models.Team{}, err
 	}
-	t, err := s.repo.GetTeam(ctx, name)
+	team, err := s.repo.UpdateTeam(ctx, upd)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
 			return models.Team{}, ErrNotFound
+		case strings.Contains(err.Error(), "team exists"):
+			return models.Team{}, ErrTeamExists
 		}
-		s.log.Error("failed to get team", "team", name, "error", err)
+		s.log.Error("failed to update team", "team", upd.TeamName, "error", err)
 		return models.Team{}, err
 	}
-	return t, nil
+	s.log.Success("team updated", "team", team.TeamName, "upserted", len(upd.Upsert), "removed", len(upd.Remove))
+	return team, nil
 }
 
-func (s *PRService) SetUserActive(ctx context.Context, userID string, active bool) (models.User, error) {
+// AddTeamMember upserts a single member into an existing team.
+func (s *PRService) AddTeamMember(ctx context.Context, teamName string, member models.TeamMember) (models.Team, error) {
+	if err := validateUserID(member.UserID); err != nil {
+		return models.Team{}, err
+	}
+	return s.UpdateTeam(ctx, models.TeamUpdate{TeamName: teamName, Upsert: []models.TeamMember{member}})
+}
+
+// RemoveTeamMember detaches a user from the team and hands their open reviews
+// to other active teammates. The user is removed first so they are no longer
+// a candidate; PRs nobody can take over just lose the reviewer.
+func (s *PRService) RemoveTeamMember(ctx context.Context, teamName, userID string) (models.MemberHandoff, error) {
 	if err := validateUserID(userID); err != nil {
-		return models.User{}, err
+		return models.MemberHandoff{}, err
 	}
-	u, err := s.repo.UpdateUserActive(ctx, userID, active)
+	team, err := s.GetTeam(ctx, teamName)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			return models.User{}, ErrNotFound
-		}
-		s.log.Error("failed to set user active", "user", userID, "error", err)
-		return models.User{}, err
+		return models.MemberHandoff{}, err
 	}
-	return u, nil
-}
-
-func (s *PRService) CreatePR(ctx context.Context, pullRequest models.PullRequest) (models.PullRequest, error) {
-	if err := validatePullRequest(pullRequest); err != nil {
-		return models.PullRequest{}, err
+	member := false
+	for _, m := range team.Members {
+		if m.UserID == userID {
+			member = true
+			break
+		}
 	}
-	if _, err := s.repo.GetPR(ctx, pullRequest.PullRequestID); err == nil {
-		return models.PullRequest{}, ErrPRExists
-	} else if !strings.Contains(err.Error(), "not found") {
-		s.log.Error("failed to check PR existence", "pr", pullRequest.PullRequestID, "error", err)
-		return models.PullRequest{}, err
+	if !member {
+		return models.MemberHandoff{}, ErrNotFound
 	}
 
-	teamName, err := s.repo.GetUserTeam(ctx, pullRequest.AuthorID)
+	prs, err := s.repo.GetPRsByReviewer(ctx, userID)
 	if err != nil {
-		return models.PullRequest{}, ErrNotFound
+		s.log.Error("failed to get PRs for member", "user", userID, "error", err)
+		return models.MemberHandoff{}, err
 	}
 
-	candidateIDs, err := s.repo.GetActiveTeamMembersExcept(ctx, teamName, pullRequest.AuthorID)
-	if err != nil {
-		s.log.Error("failed to get active candidates", "author", pullRequest.AuthorID, "error", err)
-		return models.PullRequest{}, err
+	if _, err := s.UpdateTeam(ctx, models.TeamUpdate{TeamName: teamName, Remove: []string{userID}}); err != nil {
+		return models.MemberHandoff{}, err
 	}
 
-	selected := []models.PRReviewer{}
-	if len(candidateIDs) > 0 {
-		for len(selected) < maxReviewers && len(candidateIDs) > 0 {
+	handoff := models.MemberHandoff{
+		TeamName:   teamName,
+		UserID:     userID,
+		Reassigned: []models.ReviewHandoff{},
+		Unassigned: []string{},
+	}
+	cache := newOpCache(s.repo)
+	for _, prShort := range prs {
+		if !prShort.Status.Active() {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return handoff, ctx.Err()
+		default:
+		}
 
-			select {
-			case <-ctx.Done():
-				return models.PullRequest{}, ctx.Err()
-			default:
+		pr, err := cache.getPR(ctx, prShort.PullRequestID)
+		if err != nil {
+			s.log.Error("failed to get full PR", "pr", prShort.PullRequestID, "error", err)
+			continue
+		}
+		candidateTeam := teamName
+		if pr.TeamName != "" {
+			candidateTeam = pr.TeamName
+		}
+		newUID, err := s.reassignReviewer(ctx, cache, pr.PullRequestID, userID, candidateTeam, "member_removed")
+		if err != nil {
+			if !errors.Is(err, ErrNoCandidate) {
+				s.log.Error("failed to hand off review", "pr", pr.PullRequestID, "user", userID, "error", err)
 			}
-
-			idx, err := cryptoRandInt(len(candidateIDs))
-			if err != nil {
-				continue
+			if err := s.repo.CleanupInactiveReviewers(withCause(ctx, "inactive"), pr.PullRequestID); err != nil {
+				s.log.Warn("failed to cleanup inactive reviewers", "pr", pr.PullRequestID, "error", err)
 			}
-			userID := candidateIDs[idx]
+			handoff.Unassigned = append(handoff.Unassigned, pr.PullRequestID)
+			s.escalateReassignFailure(ctx, pr.PullRequestID)
+			continue
+		}
+		handoff.Reassigned = append(handoff.Reassigned, models.ReviewHandoff{PullRequestID: pr.PullRequestID, NewReviewerID: newUID})
+	}
 
-			user, err := s.repo.GetUser(ctx, userID)
-			if err != nil {
-				candidateIDs = append(candidateIDs[:idx], candidateIDs[idx+1:]...)
-				continue
-			}
-			if !user.IsActive {
-				candidateIDs = append(candidateIDs[:idx], candidateIDs[idx+1:]...)
-				continue
-			}
+	s.log.Success("team member removed", "team", teamName, "user", userID,
+		"reassigned", len(handoff.Reassigned), "unassigned", len(handoff.Unassigned))
+	return handoff, nil
+}
 
-			selected = append(selected, models.PRReviewer{
-				UserID:   user.UserID,
-				Username: user.Username,
-				IsActive: user.IsActive,
-			})
+// MoveUserTeam switches the user to another team. With reassign, their open
+// reviews on the old team's PRs are handed to active old-team members in the
+// same transaction as the move; reviews nobody can take stay with the user.
+func (s *PRService) MoveUserTeam(ctx context.Context, userID, teamName string, reassign bool) (models.UserMove, error) {
+	if err := validateUserID(userID); err != nil {
+		return models.UserMove{}, err
+	}
+	if err := validateTeamName(teamName); err != nil {
+		return models.UserMove{}, err
+	}
+
+	cache := newOpCache(s.repo)
+	u, err := cache.getUser(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserMove{}, ErrNotFound
+		}
+		return models.UserMove{}, err
+	}
+
+	move := models.UserMove{FromTeam: u.TeamName, Reassigned: []models.ReviewHandoff{}, Kept: []string{}}
+	if u.TeamName != "" && u.TeamName != teamName {
+		prs, err := s.repo.GetPRsByReviewer(ctx, userID)
+		if err != nil {
+			s.log.Error("failed to get PRs for user", "user", userID, "error", err)
+			return models.UserMove{}, err
+		}
+		for _, prShort := range prs {
+			if !prShort.Status.Active() {
+				continue
+			}
+			if !reassign {
+				move.Kept = append(move.Kept, prShort.PullRequestID)
+				continue
+			}
+			newUID, err := s.pickHandoff(ctx, cache, prShort.PullRequestID, userID, u.TeamName)
+			if err != nil {
+				return models.UserMove{}, err
+			}
+			if newUID == "" {
+				move.Kept = append(move.Kept, prShort.PullRequestID)
+				continue
+			}
+			move.Reassigned = append(move.Reassigned, models.ReviewHandoff{PullRequestID: prShort.PullRequestID, NewReviewerID: newUID})
+		}
+	}
+
+	move.User, err = s.repo.UpdateUserTeam(withCause(ctx, "user_moved"), userID, teamName, move.Reassigned)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserMove{}, ErrNotFound
+		}
+		s.log.Error("failed to move user", "user", userID, "team", teamName, "error", err)
+		return models.UserMove{}, err
+	}
+	reviewerReplacements.Add(float64(len(move.Reassigned)), "user_moved")
+	s.log.Success("user moved", "user", userID, "from", move.FromTeam, "to", teamName,
+		"reassigned", len(move.Reassigned), "kept", len(move.Kept))
+	return move, nil
+}
+
+// pickHandoff chooses a replacement for userID on prID among active members
+// of fromTeam. It returns "" when the PR belongs to another team or nobody
+// is free.
+func (s *PRService) pickHandoff(ctx context.Context, cache *opCache, prID, userID, fromTeam string) (string, error) {
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		return "", err
+	}
+	prTeam, err := s.prTeam(ctx, pr)
+	if err != nil || prTeam != fromTeam {
+		return "", nil
+	}
+
+	cands, err := cache.activeMembers(ctx, fromTeam)
+	if err != nil {
+		return "", err
+	}
+	cands = s.withinRampUpFor(ctx, pr.Priority, cands)
+	cands = s.withoutReserved(ctx, pr.AuthorID, cands)
+	taken := map[string]struct{}{pr.AuthorID: {}, userID: {}}
+	for _, a := range pr.Assigned {
+		taken[a.UserID] = struct{}{}
+	}
+	avail := make([]string, 0, len(cands))
+	for _, c := range cands {
+		if _, ok := taken[c]; !ok {
+			avail = append(avail, c)
+		}
+	}
+	if len(avail) == 0 {
+		return "", nil
+	}
+	idx, err := weightedRandInt(s.rand, avail, s.selectionWeights(ctx, fromTeam, avail))
+	if err != nil {
+		return "", err
+	}
+	return avail[idx], nil
+}
+
+// CheckConsistency reports broken data invariants, typically left behind by
+// manual DB interventions, and with repair fixes them.
+func (s *PRService) CheckConsistency(ctx context.Context, repair bool) (models.ConsistencyReport, error) {
+	report, err := s.repo.CheckConsistency(withCause(ctx, "consistency_repair"), maxReviewers, repair)
+	if err != nil {
+		s.log.Error("consistency check failed", "repair", repair, "error", err)
+		return models.ConsistencyReport{}, err
+	}
+	if len(report.Violations) > 0 {
+		s.log.Warn("consistency violations found", "count", len(report.Violations), "repaired", repair)
+	}
+	return report, nil
+}
+
+func (s *PRService) GetTeam(ctx context.Context, name string) (models.Team, error) {
+	if err := validateTeamName(name); err != nil {
+		return models.Team{}, err
+	}
+	t, err := s.repo.GetTeam(ctx, name)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.Team{}, ErrNotFound
+		}
+		s.log.Error("failed to get team", "team", name, "error", err)
+		return models.Team{}, err
+	}
+	return t, nil
+}
+
+func (s *PRService) SetUserActive(ctx context.Context, userID string, active bool) (models.User, error) {
+	if err := validateUserID(userID); err != nil {
+		return models.User{}, err
+	}
+	u, err := s.repo.UpdateUserActive(ctx, userID, active)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.User{}, ErrNotFound
+		}
+		s.log.Error("failed to set user active", "user", userID, "error", err)
+		return models.User{}, err
+	}
+	if active {
+		s.backfillReviewer(ctx, u)
+	}
+	return u, nil
+}
+
+// ForgetUser erases the user's personal data on their request. They are
+// deactivated like SetUserActive does and renamed to ForgottenUsername;
+// their ID stays, so assignments, approvals and stats still count them.
+func (s *PRService) ForgetUser(ctx context.Context, userID string) (models.User, error) {
+	if err := validateUserID(userID); err != nil {
+		return models.User{}, err
+	}
+	u, err := s.repo.ForgetUser(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.User{}, ErrNotFound
+		}
+		s.log.Error("failed to forget user", "user", userID, "error", err)
+		return models.User{}, err
+	}
+	s.log.Success("user forgotten", "user", userID)
+	return u, nil
+}
+
+func (s *PRService) CreatePR(ctx context.Context, pullRequest models.PullRequest) (models.PullRequest, error) {
+	if err := validatePullRequest(pullRequest); err != nil {
+		return models.PullRequest{}, err
+	}
+	// IDs are unique per repository; the repo layer picks the PR's key.
+	if _, err := s.repo.FindPR(ctx, pullRequest.Repository, pullRequest.PullRequestID); err == nil {
+		return models.PullRequest{}, ErrPRExists
+	} else if !strings.Contains(err.Error(), "not found") {
+		s.log.Error("failed to check PR existence", "pr", pullRequest.PullRequestID, "error", err)
+		return models.PullRequest{}, err
+	}
+
+	teamName, err := s.repo.GetUserTeam(ctx, pullRequest.AuthorID)
+	if err != nil {
+		return models.PullRequest{}, ErrNotFound
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	candidateIDs, err := s.repo.GetActiveTeamMembersExcept(ctx, teamName, pullRequest.AuthorID)
+	if err != nil {
+		s.log.Error("failed to get active candidates", "author", pullRequest.AuthorID, "error", err)
+		return models.PullRequest{}, err
+	}
+	if pullRequest.Priority == "" {
+		pullRequest.Priority = models.PriorityNormal
+	}
+	candidateIDs = s.withinRampUpFor(ctx, pullRequest.Priority, candidateIDs)
+	candidateIDs = s.withoutReserved(ctx, pullRequest.AuthorID, candidateIDs)
+	candidateIDs, coAuthored := splitCoAuthors(candidateIDs, pullRequest.CoAuthors)
+
+	pullRequest.Labels = normalizeLabels(pullRequest.Labels)
+	var match map[string]int
+	if len(pullRequest.Labels) > 0 {
+		if match, err = s.repo.GetSkillMatches(ctx, pullRequest.Labels); err != nil {
+			s.log.Warn("failed to get skill matches", "pr", pullRequest.PullRequestID, "error", err)
+		}
+	}
+
+	// Reviewers the author reserved come first, then the repository's and
+	// the team's defaults.
+	held := s.reservedReviewers(ctx, pullRequest.AuthorID)
+	selected := append([]models.PRReviewer{}, held...)
+	for _, d := range s.defaultReviewers(ctx, teamName, pullRequest.Repository, pullRequest.AuthorID) {
+		if len(selected) < maxReviewers && !hasReviewer(selected, d.UserID) {
+			selected = append(selected, d)
+		}
+	}
+	security := 0
+	if rule := s.securityRule(ctx, teamName, pullRequest); rule != nil {
+		var added bool
+		pullRequest.SecurityReview = true
+		selected, pullRequest.SecurityReviewer, added = s.securityReviewer(ctx, *rule, pullRequest.AuthorID, selected)
+		if added {
+			security = 1
+		}
+		if pullRequest.SecurityReviewer == "" {
+			s.log.Warn("no security reviewer available", "pr", pullRequest.PullRequestID, "security_team", rule.Team)
+		}
+	}
+	// reasons records how each reviewer was picked for the assignment
+	// history; those missing were picked at random.
+	reasons := make(map[string]string, maxReviewers)
+	for _, r := range selected {
+		reasons[r.UserID] = "default"
+	}
+	if security > 0 {
+		reasons[pullRequest.SecurityReviewer] = "security"
+	}
+	reserved := 0
+	for _, r := range held {
+		if hasReviewer(selected, r.UserID) {
+			reasons[r.UserID] = "reserved"
+			reserved++
+		}
+	}
+	defaults := len(selected) - security - reserved
+	// Then the pair on duty in the team's rotation and whoever reviewed the
+	// author's recent PRs, before the random pick.
+	picked := len(selected)
+	selected, rotation := s.withRotation(ctx, teamName, selected, candidateIDs)
+	for _, r := range selected[picked:] {
+		reasons[r.UserID] = "rotation"
+	}
+	picked = len(selected)
+	selected, sticky := s.withStickyReviewers(ctx, pullRequest.AuthorID, selected, candidateIDs)
+	for _, r := range selected[picked:] {
+		reasons[r.UserID] = "sticky"
+	}
+	for _, d := range selected {
+		for i, id := range candidateIDs {
+			if id == d.UserID {
+				candidateIDs = append(candidateIDs[:i], candidateIDs[i+1:]...)
+				break
+			}
+		}
+	}
+	if len(candidateIDs) > 0 {
+		// Without load every candidate looks idle and the pick is
+		// plain random, which is still a valid assignment.
+		load, err := s.repo.GetOpenReviewLoad(ctx, teamName)
+		if err != nil {
+			s.log.Warn("failed to get open review counts", "team", teamName, "error", err)
+		}
+		candidateIDs, load = s.scoredCandidates(ctx, teamName, pullRequest.AuthorID, candidateIDs, load)
+		near, far := candidateIDs, []string(nil)
+		if s.workingHours != nil {
+			near, far = s.splitByOverlap(ctx, pullRequest.AuthorID, candidateIDs, time.Now())
+		}
+		weights := s.selectionWeights(ctx, teamName, candidateIDs)
+		if selected, near, err = s.pickReviewers(ctx, selected, near, load, match, weights); err != nil {
+			return models.PullRequest{}, err
+		}
+		if selected, far, err = s.pickReviewers(ctx, selected, far, load, match, weights); err != nil {
+			return models.PullRequest{}, err
+		}
+		candidateIDs = append(near, far...)
+	}
+	fallback := 0
+	if s.fallback && len(selected) < maxReviewers {
+		before := len(selected)
+		selected, err = s.fallbackReviewers(ctx, teamName, pullRequest.AuthorID, selected, match)
+		if err != nil {
+			return models.PullRequest{}, err
+		}
+		fallback = len(selected) - before
+		for _, r := range selected[before:] {
+			reasons[r.UserID] = "fallback"
+		}
+	}
+	// Co-authors review only when nobody independent is left.
+	before := len(selected)
+	selected, coAuthored, err = s.withCoAuthors(ctx, teamName, selected, coAuthored, match)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	coAuthors := len(selected) - before
+	for _, r := range selected[before:] {
+		reasons[r.UserID] = "co_author"
+	}
+
+	selected, mentors := s.withMentors(ctx, pullRequest.AuthorID, selected)
+	for _, r := range selected {
+		if _, ok := reasons[r.UserID]; !ok && r.MentorOf != "" {
+			reasons[r.UserID] = "mentor"
+		}
+	}
+
+	pullRequest.TeamName = teamName
+	pullRequest.Assigned = selected
+	pullRequest.NeedMoreReviewers = reviewerSlots(selected) < maxReviewers
+	pullRequest.Status = models.StatusOpen
+	pullRequest.CreatedAt = time.Now().UTC()
+
+	createCtx := repo.WithAssignmentCause(ctx, repo.AssignmentCause{Reason: "random", TriggeredBy: triggeredBy(ctx), Reasons: reasons})
+	if err := s.repo.CreatePR(createCtx, pullRequest); err != nil {
+		if strings.Contains(err.Error(), "conflict") {
+			return models.PullRequest{}, ErrPRExists
+		}
+		s.log.Error("failed to create PR", "pr", pullRequest.PullRequestID, "error", err)
+		return models.PullRequest{}, err
+	}
+	reviewerAssignments.Add(float64(reserved), "reserved")
+	reviewerAssignments.Add(float64(defaults), "default")
+	reviewerAssignments.Add(float64(security), "security")
+	reviewerAssignments.Add(float64(mentors), "mentor")
+	reviewerAssignments.Add(float64(rotation), "rotation")
+	reviewerAssignments.Add(float64(sticky), "sticky")
+	reviewerAssignments.Add(float64(len(selected)-mentors-security-reserved-defaults-rotation-sticky-fallback-coAuthors), "random")
+	reviewerAssignments.Add(float64(fallback), "fallback")
+	reviewerAssignments.Add(float64(coAuthors), "co_author")
+	candidatesFiltered.Add(float64(len(candidateIDs)), "capacity")
+	candidatesFiltered.Add(float64(len(coAuthored)), "co_author")
+	if pullRequest.NeedMoreReviewers {
+		needMoreReviewers.Inc(teamName)
+	}
+	if len(held) > 0 {
+		if err := s.repo.ReserveReviewers(ctx, pullRequest.AuthorID, nil, time.Time{}); err != nil {
+			s.log.Warn("failed to release reservation", "author", pullRequest.AuthorID, "error", err)
+		}
+	}
+
+	key, err := s.repo.FindPR(ctx, pullRequest.Repository, pullRequest.PullRequestID)
+	if err != nil {
+		s.log.Error("failed to find created PR", "pr", pullRequest.PullRequestID, "error", err)
+		return models.PullRequest{}, err
+	}
+	created, err := s.repo.GetPR(ctx, key)
+	if err != nil {
+		s.log.Error("failed to fetch created PR", "pr", key, "error", err)
+		return models.PullRequest{}, err
+	}
+
+	return created, nil
+}
+
+func (s *PRService) GetPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+	return pr, nil
+}
+
+// checkMerger returns ErrUnknownMerger when mergedBy is set but no such user
+// exists.
+func (s *PRService) checkMerger(ctx context.Context, prID, mergedBy string) error {
+	if mergedBy == "" {
+		return nil
+	}
+	if _, err := s.repo.GetUser(ctx, mergedBy); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrUnknownMerger
+		}
+		s.log.Error("failed to fetch merging user", "pr", prID, "user", mergedBy, "error", err)
+		return err
+	}
+	return nil
+}
+
+// MergePR merges an open PR once enough of its reviewers have approved, as
+// set by the team's RequiredApprovals. With override an admin merges it
+// regardless. mergedBy, when set, must be an existing user and is kept as
+// the one who merged the PR.
+func (s *PRService) MergePR(ctx context.Context, prID, mergedBy string, override bool) (models.PullRequest, error) {
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for merge", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+
+	if pr.Status == models.StatusMerged {
+		return pr, nil
+	}
+	if err := checkTransition(pr.Status, models.StatusMerged); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := s.checkMerger(ctx, prID, mergedBy); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := s.checkApprovals(ctx, pr); err != nil {
+		if !override || !errors.Is(err, ErrNotEnoughApprovals) {
+			return models.PullRequest{}, err
+		}
+		s.log.Warn("merging without required approvals", "pr", prID, "reason", err)
+	}
+
+	t := time.Now().UTC()
+	if err := s.checkMergeWindow(ctx, pr, t); err != nil {
+		if !override || !errors.Is(err, ErrMergeWindowClosed) {
+			return models.PullRequest{}, err
+		}
+		s.log.Warn("merging outside the merge window", "pr", prID, "reason", err)
+	}
+
+	merged, err := s.repo.MergePR(ctx, prID, mergedBy, t)
+	if err != nil {
+		if strings.Contains(err.Error(), "conflict") {
+			// Merged by another request or closed since we read it.
+			return s.finalStatusConflict(ctx, prID, models.StatusMerged, err)
+		}
+		s.log.Error("failed to merge PR", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+	s.notifyMerged(ctx, merged)
+
+	return merged, nil
+}
+
+func (s *PRService) ClosePR(ctx context.Context, prID string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for close", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+
+	if pr.Status == models.StatusClosed {
+		return pr, nil
+	}
+	if err := checkTransition(pr.Status, models.StatusClosed); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	closed, err := s.repo.ClosePR(ctx, prID, time.Now().UTC())
+	if err != nil {
+		if strings.Contains(err.Error(), "conflict") {
+			return s.finalStatusConflict(ctx, prID, models.StatusClosed, err)
+		}
+		s.log.Error("failed to close PR", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+	return closed, nil
+}
+
+// ReopenPR moves a CLOSED PR back to OPEN; an active PR is left as is.
+// Reviewers that went inactive while the PR was closed are replaced from the
+// PR's team; merged PRs stay final.
+func (s *PRService) ReopenPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for reopen", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+
+	if pr.Status.Active() {
+		return pr, nil
+	}
+	if err := checkTransition(pr.Status, models.StatusOpen); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	reopened, err := s.repo.ReopenPR(ctx, prID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			return models.PullRequest{}, ErrNotFound
+		case strings.Contains(err.Error(), "conflict"):
+			// Another request moved the PR on since we read it: it was
+			// reopened already, or merged and can't be reopened.
+			s.log.Warn("pr status changed concurrently", "pr", prID, "from", pr.Status, "to", models.StatusOpen)
+			current, getErr := s.repo.GetPR(ctx, prID)
+			if getErr != nil {
+				return models.PullRequest{}, getErr
+			}
+			if current.Status.Active() {
+				return current, nil
+			}
+			if err := checkTransition(current.Status, models.StatusOpen); err != nil {
+				return models.PullRequest{}, err
+			}
+		}
+		s.log.Error("failed to reopen PR", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+
+	teamName, err := s.prTeam(ctx, reopened)
+	if err != nil {
+		s.log.Warn("could not resolve PR team on reopen", "pr", prID, "error", err)
+		return reopened, nil
+	}
+
+	cache := newOpCache(s.repo)
+	cache.setPR(reopened)
+	for _, rev := range reopened.Assigned {
+		if rev.IsActive {
+			continue
+		}
+		newUID, err := s.reassignReviewer(ctx, cache, prID, rev.UserID, teamName, "reopen")
+		if err != nil {
+			s.log.Warn("no replacement found for inactive reviewer", "pr", prID, "user", rev.UserID)
+			continue
+		}
+		s.log.Info("reviewer replaced", "pr", prID, "old_user", rev.UserID, "new_user", newUID)
+	}
+
+	final, err := cache.getPR(ctx, prID)
+	if err == nil && final.NeedMoreReviewers {
+		needMoreReviewers.Inc(teamName)
+	}
+	return final, err
+}
+
+func (s *PRService) Reassign(ctx context.Context, prID, oldUser string) (models.PullRequest, string, error) {
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
+		return models.PullRequest{}, "", err
+	}
+
+	err := s.repo.CleanupInactiveReviewers(withCause(ctx, "inactive"), prID)
+	if err != nil {
+		s.log.Warn("failed to cleanup inactive reviewers", "pr", prID, "error", err)
+	}
+	cache.forgetPR(prID)
+
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, "", ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for reassign", "pr", prID, "error", err)
+		return models.PullRequest{}, "", err
+	}
+
+	for i, r := range pr.Assigned {
+		usr, err := cache.getUser(ctx, r.UserID)
+		if err == nil {
+			pr.Assigned[i].IsActive = usr.IsActive
+		}
+	}
+
+	if err := checkActive(pr.Status); err != nil {
+		return models.PullRequest{}, "", err
+	}
+
+	assigned := false
+	for _, r := range pr.Assigned {
+		if r.UserID == oldUser {
+			assigned = true
+			break
+		}
+	}
+	if !assigned {
+		return models.PullRequest{}, "", ErrNotAssigned
+	}
+
+	u, err := cache.getUser(ctx, oldUser)
+	if err != nil {
+		return models.PullRequest{}, "", ErrNotFound
+	}
+	if !u.IsActive {
+		return models.PullRequest{}, "", ErrUserInactive
+	}
+	// The replacement comes from the PR's team: the old reviewer may review
+	// for it through an extra membership while living in another team.
+	teamName, err := s.prTeam(ctx, pr)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, "", ErrNotFound
+		}
+		return models.PullRequest{}, "", err
+	}
+
+	cands, err := cache.activeMembers(ctx, teamName)
+	if err != nil {
+		s.log.Error("failed to get active candidates for reassign", "team", teamName, "error", err)
+		return models.PullRequest{}, "", err
+	}
+	cands = s.withinRampUpFor(ctx, pr.Priority, cands)
+	cands = s.withoutReserved(ctx, pr.AuthorID, cands)
+
+	assignedSet := map[string]struct{}{pr.AuthorID: {}}
+	for _, a := range pr.Assigned {
+		assignedSet[a.UserID] = struct{}{}
+	}
+
+	avail := make([]string, 0, len(cands))
+	for _, c := range cands {
+		if c == oldUser {
+			continue
+		}
+		if _, ok := assignedSet[c]; ok {
+			continue
+		}
+		avail = append(avail, c)
+	}
+
+	if len(avail) == 0 {
+		return models.PullRequest{}, "", ErrNoCandidate
+	}
+
+	select {
+	case <-ctx.Done():
+		return models.PullRequest{}, "", ctx.Err()
+	default:
+	}
+
+	// The best scored candidates come first, also for the extra slots.
+	avail, ranks := s.scoredCandidates(ctx, teamName, pr.AuthorID, avail, nil)
+	sort.SliceStable(avail, func(i, j int) bool { return ranks[avail[i]] < ranks[avail[j]] })
+	idx, err := pickCandidate(s.rand, avail, ranks, nil, s.selectionWeights(ctx, teamName, avail))
+	if err != nil {
+		return models.PullRequest{}, "", err
+	}
+	newUID := avail[idx]
+
+	nu, err := cache.getUser(ctx, newUID)
+	if err != nil || !nu.IsActive {
+		return models.PullRequest{}, "", ErrNoCandidate
+	}
+
+	currentAssigned := pr.Assigned
+	newAssignments := []string{newUID}
+
+	for _, candidate := range avail {
+		if candidate == newUID {
+			continue
+		}
+		if reviewerSlots(currentAssigned)+len(newAssignments)-1 >= maxReviewers {
+			break
+		}
+		newAssignments = append(newAssignments, candidate)
+	}
+
+	var updatedPR models.PullRequest
+	if len(newAssignments) == 1 {
+		updatedPR, err = s.repo.ReplaceReviewer(withCause(ctx, "manual"), prID, oldUser, newUID)
+		if err == nil {
+			updatedPR = s.pairMentor(ctx, updatedPR, newUID)
+		}
+	} else {
+		updatedPR, err = s.repo.ReplaceReviewer(withCause(ctx, "manual"), prID, oldUser, newUID)
+		if err == nil {
+			updatedPR = s.pairMentor(ctx, updatedPR, newUID)
+			for i := 1; i < len(newAssignments); i++ {
+				additionalUser := newAssignments[i]
+				updatedPR, err = s.repo.AddReviewer(withCause(ctx, "random"), prID, additionalUser, maxReviewers)
+				if err != nil {
+					s.log.Error("failed to add additional reviewer", "pr", prID, "user", additionalUser, "error", err)
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		s.log.Error("failed to replace reviewer", "pr", prID, "oldUser", oldUser, "error", err)
+		return models.PullRequest{}, "", err
+	}
+
+	updatedPR.NeedMoreReviewers = reviewerSlots(updatedPR.Assigned) < maxReviewers
+	reviewerReplacements.Inc("manual")
+	reviewerAssignments.Add(float64(len(newAssignments)-1), "random")
+
+	return updatedPR, newUID, nil
+}
+
+// AssignReviewer adds a chosen user as a reviewer on an open PR. Unlike
+// automatic assignment the user does not have to be in the PR's team.
+func (s *PRService) AssignReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	pr, err := s.checkAssignable(ctx, prID, userID)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	if reviewerSlots(pr.Assigned) >= maxReviewers {
+		return models.PullRequest{}, ErrReviewersFull
+	}
+
+	updated, err := s.repo.AddReviewer(withCause(ctx, "manual"), prID, userID, maxReviewers)
+	if err != nil {
+		s.log.Error("failed to assign reviewer", "pr", prID, "user", userID, "error", err)
+		return models.PullRequest{}, err
+	}
+	reviewerAssignments.Inc("manual")
+	return updated, nil
+}
+
+// AssignSecondaryReviewer adds a chosen user as the PR's optional secondary
+// reviewer. The secondary takes no reviewer slot and is not waited for by
+// need_more_reviewers; a PR has at most one.
+func (s *PRService) AssignSecondaryReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	if _, err := s.checkAssignable(ctx, prID, userID); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	updated, err := s.repo.AddSecondaryReviewer(withCause(ctx, "secondary"), prID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "conflict") {
+			return models.PullRequest{}, ErrSecondaryTaken
+		}
+		s.log.Error("failed to assign secondary reviewer", "pr", prID, "user", userID, "error", err)
+		return models.PullRequest{}, err
+	}
+	reviewerAssignments.Inc("secondary")
+	return updated, nil
+}
+
+// checkAssignable returns the PR if userID may be manually added to it as a
+// reviewer.
+func (s *PRService) checkAssignable(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := validateUserID(userID); err != nil {
+		return models.PullRequest{}, err
+	}
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for assign", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+	if err := checkActive(pr.Status); err != nil {
+		return models.PullRequest{}, err
+	}
+	if pr.AuthorID == userID {
+		return models.PullRequest{}, ErrAuthorReviewer
+	}
+	for _, r := range pr.Assigned {
+		if r.UserID == userID {
+			return models.PullRequest{}, ErrAlreadyAssigned
+		}
+	}
+
+	u, err := cache.getUser(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		return models.PullRequest{}, err
+	}
+	if !u.IsActive {
+		return models.PullRequest{}, ErrUserInactive
+	}
+	return pr, nil
+}
+
+// FillReviewers tops up an open PR to maxReviewers with random active members
+// of its team. A PR that already has enough reviewers is returned as is.
+func (s *PRService) FillReviewers(ctx context.Context, prID string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		s.log.Error("failed to fetch PR for fill", "pr", prID, "error", err)
+		return models.PullRequest{}, err
+	}
+	if err := checkActive(pr.Status); err != nil {
+		return models.PullRequest{}, err
+	}
+	if reviewerSlots(pr.Assigned) >= maxReviewers {
+		return pr, nil
+	}
+
+	teamName, err := s.prTeam(ctx, pr)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.PullRequest{}, ErrNotFound
+		}
+		return models.PullRequest{}, err
+	}
+	cands, err := cache.activeMembers(ctx, teamName)
+	if err != nil {
+		s.log.Error("failed to get active candidates for fill", "team", teamName, "error", err)
+		return models.PullRequest{}, err
+	}
+	cands = s.withinRampUpFor(ctx, pr.Priority, cands)
+	cands = s.withoutReserved(ctx, pr.AuthorID, cands)
+
+	taken := map[string]struct{}{pr.AuthorID: {}}
+	for _, a := range pr.Assigned {
+		taken[a.UserID] = struct{}{}
+	}
+	avail := make([]string, 0, len(cands))
+	for _, c := range cands {
+		if _, ok := taken[c]; !ok {
+			avail = append(avail, c)
+		}
+	}
+	if len(avail) == 0 {
+		return models.PullRequest{}, ErrNoCandidate
+	}
 
-			candidateIDs = append(candidateIDs[:idx], candidateIDs[idx+1:]...)
+	avail, ranks := s.scoredCandidates(ctx, teamName, pr.AuthorID, avail, nil)
+	weights := s.selectionWeights(ctx, teamName, avail)
+	var match map[string]int
+	if len(pr.Labels) > 0 {
+		if match, err = s.repo.GetSkillMatches(ctx, pr.Labels); err != nil {
+			s.log.Warn("failed to get skill matches", "pr", prID, "error", err)
 		}
 	}
+	updated := pr
+	for missing := maxReviewers - reviewerSlots(pr.Assigned); missing > 0 && len(avail) > 0; missing-- {
+		idx, err := pickCandidate(s.rand, avail, ranks, match, weights)
+		if err != nil {
+			return models.PullRequest{}, err
+		}
+		uid := avail[idx]
+		avail = append(avail[:idx], avail[idx+1:]...)
 
-	pullRequest.Assigned = selected
-	pullRequest.NeedMoreReviewers = len(selected) < maxReviewers
-	pullRequest.Status = "OPEN"
-	pullRequest.CreatedAt = time.Now().UTC()
+		updated, err = s.repo.AddReviewer(withCause(ctx, "fill"), prID, uid, maxReviewers)
+		if err != nil {
+			s.log.Error("failed to add reviewer on fill", "pr", prID, "user", uid, "error", err)
+			return models.PullRequest{}, err
+		}
+		reviewerAssignments.Inc("fill")
+		updated = s.pairMentor(ctx, updated, uid)
+	}
+	if updated.NeedMoreReviewers {
+		needMoreReviewers.Inc(teamName)
+	}
+	return updated, nil
+}
 
-	if err := s.repo.CreatePR(ctx, pullRequest); err != nil {
-		s.log.Error("failed to create PR", "pr", pullRequest.PullRequestID, "error", err)
+// RemoveReviewer takes a reviewer off a PR without picking a replacement, so
+// the PR may end up needing more reviewers.
+func (s *PRService) RemoveReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
 		return models.PullRequest{}, err
 	}
-
-	created, err := s.repo.GetPR(ctx, pullRequest.PullRequestID)
-	if err != nil {
-		s.log.Error("failed to fetch created PR", "pr", pullRequest.PullRequestID, "error", err)
+	if err := validateUserID(userID); err != nil {
+		return models.PullRequest{}, err
+	}
+	cache := newOpCache(s.repo)
+	if err := s.checkPRScope(ctx, cache, prID); err != nil {
 		return models.PullRequest{}, err
 	}
 
-	return created, nil
-}
-
-func (s *PRService) MergePR(ctx context.Context, prID string) (models.PullRequest, error) {
-	pr, err := s.repo.GetPR(ctx, prID)
+	pr, err := cache.getPR(ctx, prID)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return models.PullRequest{}, ErrNotFound
 		}
-		s.log.Error("failed to fetch PR for merge", "pr", prID, "error", err)
+		s.log.Error("failed to fetch PR for remove reviewer", "pr", prID, "error", err)
 		return models.PullRequest{}, err
 	}
-
-	if pr.Status == "MERGED" {
-		return pr, nil
+	if pr.Status == models.StatusMerged {
+		return models.PullRequest{}, ErrPRMerged
 	}
 
-	t := time.Now().UTC()
-	merged, err := s.repo.MergePR(ctx, prID, t)
+	updated, err := s.repo.RemoveReviewer(withCause(ctx, "manual"), prID, userID, maxReviewers)
 	if err != nil {
-		s.log.Error("failed to merge PR", "pr", prID, "error", err)
+		if strings.Contains(err.Error(), "not assigned") {
+			return models.PullRequest{}, ErrNotAssigned
+		}
+		s.log.Error("failed to remove reviewer", "pr", prID, "user", userID, "error", err)
 		return models.PullRequest{}, err
 	}
-
-	return merged, nil
+	if updated.NeedMoreReviewers {
+		team, err := s.prTeam(ctx, updated)
+		if err == nil {
+			needMoreReviewers.Inc(team)
+		}
+	}
+	return updated, nil
 }
 
-func (s *PRService) Reassign(ctx context.Context, prID, oldUser string) (models.PullRequest, string, error) {
+func (s *PRService) ApprovePR(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	return s.approve(ctx, prID, userID, "")
+}
 
-	err := s.repo.CleanupInactiveReviewers(ctx, prID)
-	if err != nil {
-		s.log.Warn("failed to cleanup inactive reviewers", "pr", prID, "error", err)
+// approve records userID's approval of the PR, with comment, and moves the
+// PR on if that completes its review.
+func (s *PRService) approve(ctx context.Context, prID, userID, comment string) (models.PullRequest, error) {
+	if err := validatePRID(prID); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := validateUserID(userID); err != nil {
+		return models.PullRequest{}, err
 	}
 
 	pr, err := s.repo.GetPR(ctx, prID)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			return models.PullRequest{}, "", ErrNotFound
-		}
-		s.log.Error("failed to fetch PR for reassign", "pr", prID, "error", err)
-		return models.PullRequest{}, "", err
-	}
-
-	for i, r := range pr.Assigned {
-		usr, err := s.repo.GetUser(ctx, r.UserID)
-		if err == nil {
-			pr.Assigned[i].IsActive = usr.IsActive
+			return models.PullRequest{}, ErrNotFound
 		}
+		s.log.Error("failed to fetch PR for approve", "pr", prID, "error", err)
+		return models.PullRequest{}, err
 	}
 
-	if pr.Status == "MERGED" {
-		return models.PullRequest{}, "", ErrPRMerged
+	if err := checkActive(pr.Status); err != nil {
+		return models.PullRequest{}, err
 	}
 
 	assigned := false
 	for _, r := range pr.Assigned {
-		if r.UserID == oldUser {
+		if r.UserID == userID {
 			assigned = true
 			break
 		}
 	}
 	if !assigned {
-		return models.PullRequest{}, "", ErrNotAssigned
+		return models.PullRequest{}, ErrNotAssigned
 	}
 
-	u, err := s.repo.GetUser(ctx, oldUser)
+	now := time.Now().UTC()
+	if err := s.repo.RecordReviewDecision(ctx, prID, userID, models.ReviewApproved, comment, now); err != nil {
+		s.log.Error("failed to record review decision", "pr", prID, "user", userID, "error", err)
+		return models.PullRequest{}, err
+	}
+	approved, err := s.repo.ApprovePR(ctx, prID, userID, now)
 	if err != nil {
-		return models.PullRequest{}, "", ErrNotFound
+		s.log.Error("failed to approve PR", "pr", prID, "user", userID, "error", err)
+		return models.PullRequest{}, err
 	}
-	if !u.IsActive {
-		return models.PullRequest{}, "", ErrUserInactive
+	return s.moveStatus(ctx, approved, s.reviewStatus(ctx, approved))
+}
+
+// GetPRsByReviewer returns the PRs userID reviews, most urgent first and
+// newest first within a priority.
+func (s *PRService) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	prs, err := s.repo.GetPRsByReviewer(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
+	sortByPriority(prs)
+	return prs, nil
+}
+
+// sortByPriority puts the most urgent PRs first, keeping the order within a
+// priority.
+func sortByPriority(prs []models.PullRequestShort) {
+	sort.SliceStable(prs, func(i, j int) bool {
+		return prs[i].Priority.Rank() < prs[j].Priority.Rank()
+	})
+}
+
+func (s *PRService) GetPRsByAuthor(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	return s.repo.GetPRsByAuthor(ctx, userID)
+}
 
-	teamName, err := s.repo.GetUserTeam(ctx, oldUser)
+// GetUserProfile returns the user with counts of their open reviews and
+// open authored PRs.
+func (s *PRService) GetUserProfile(ctx context.Context, userID string) (models.UserProfile, error) {
+	if err := validateUserID(userID); err != nil {
+		return models.UserProfile{}, err
+	}
+	profile, err := s.repo.GetUserProfile(ctx, userID)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			return models.PullRequest{}, "", ErrNotFound
+			return models.UserProfile{}, ErrNotFound
 		}
-		s.log.Error("failed to get user's team", "user", oldUser, "error", err)
-		return models.PullRequest{}, "", err
+		return models.UserProfile{}, err
 	}
+	if err := s.checkUserScope(ctx, userID, profile.TeamName); err != nil {
+		return models.UserProfile{}, err
+	}
+	return profile, nil
+}
 
-	cands, err := s.repo.GetActiveTeamMembersExcept(ctx, teamName, "")
+// SetUserSkills replaces the skills new PRs' labels are matched against and
+// returns the updated profile.
+func (s *PRService) SetUserSkills(ctx context.Context, userID string, skills []string) (models.UserProfile, error) {
+	profile, err := s.GetUserProfile(ctx, userID)
 	if err != nil {
-		s.log.Error("failed to get active candidates for reassign", "team", teamName, "error", err)
-		return models.PullRequest{}, "", err
+		return models.UserProfile{}, err
 	}
-
-	assignedSet := map[string]struct{}{}
-	for _, a := range pr.Assigned {
-		assignedSet[a.UserID] = struct{}{}
+	if err := s.repo.SetUserSkills(ctx, userID, normalizeLabels(skills)); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserProfile{}, ErrNotFound
+		}
+		s.log.Error("failed to set user skills", "user", userID, "error", err)
+		return models.UserProfile{}, err
 	}
+	s.log.Success("user skills updated", "user", userID, "team", profile.TeamName)
+	return s.GetUserProfile(ctx, userID)
+}
 
-	avail := make([]string, 0, len(cands))
-	for _, c := range cands {
-		if c == oldUser {
-			continue
-		}
-		if _, ok := assignedSet[c]; ok {
-			continue
+// GetUserActivity returns the user's events newest first: assignments,
+// approvals, merges of their own PRs and reviews taken away from them.
+func (s *PRService) GetUserActivity(ctx context.Context, userID string, page models.Page) ([]models.UserEvent, error) {
+	if err := validateUserID(userID); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrNotFound
 		}
-		avail = append(avail, c)
+		return nil, err
 	}
-
-	if len(avail) == 0 {
-		return models.PullRequest{}, "", ErrNoCandidate
+	if err := s.checkUserScope(ctx, userID, user.TeamName); err != nil {
+		return nil, err
 	}
+	return s.repo.GetUserEvents(ctx, userID, normalizePage(page))
+}
 
-	select {
-	case <-ctx.Done():
-		return models.PullRequest{}, "", ctx.Err()
-	default:
+func normalizePage(page models.Page) models.Page {
+	if page.Limit <= 0 {
+		page.Limit = DefaultPageLimit
 	}
-
-	idx, err := cryptoRandInt(len(avail))
-	if err != nil {
-		return models.PullRequest{}, "", err
+	if page.Limit > MaxPageLimit {
+		page.Limit = MaxPageLimit
 	}
-	newUID := avail[idx]
-
-	nu, err := s.repo.GetUser(ctx, newUID)
-	if err != nil || !nu.IsActive {
-		return models.PullRequest{}, "", ErrNoCandidate
+	if page.Offset < 0 {
+		page.Offset = 0
 	}
+	return page
+}
 
-	currentAssigned := pr.Assigned
-	newAssignments := []string{newUID}
-
-	for _, candidate := range avail {
-		if candidate == newUID {
-			continue
+// ListPRs pages through PRs matching filter, newest first. A team-bound
+// caller only sees its own team's PRs.
+func (s *PRService) ListPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+	if scope, ok := ScopeFromContext(ctx); ok {
+		if filter.TeamName != "" && filter.TeamName != scope.TeamName {
+			return nil, ErrForbidden
 		}
-		if len(currentAssigned)+len(newAssignments)-1 >= maxReviewers {
-			break
-		}
-		newAssignments = append(newAssignments, candidate)
+		filter.TeamName = scope.TeamName
 	}
+	filter.Label = strings.ToLower(strings.TrimSpace(filter.Label))
+	return s.repo.ListPRs(ctx, filter, normalizePage(page))
+}
 
-	var updatedPR models.PullRequest
-	if len(newAssignments) == 1 {
-		updatedPR, err = s.repo.ReplaceReviewer(ctx, prID, oldUser, newUID)
-	} else {
-		updatedPR, err = s.repo.ReplaceReviewer(ctx, prID, oldUser, newUID)
-		if err == nil {
-			for i := 1; i < len(newAssignments); i++ {
-				additionalUser := newAssignments[i]
-				updatedPR, err = s.repo.AddReviewer(ctx, prID, additionalUser)
-				if err != nil {
-					s.log.Error("failed to add additional reviewer", "pr", prID, "user", additionalUser, "error", err)
-				}
-			}
-		}
+// SearchPRs finds PRs whose name or author contains query, ignoring case,
+// and that match filter's label and repository when they're non-empty. Team
+// tokens only see their own team's PRs.
+func (s *PRService) SearchPRs(ctx context.Context, query string, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("search query is empty")
 	}
-
-	if err != nil {
-		s.log.Error("failed to replace reviewer", "pr", prID, "oldUser", oldUser, "error", err)
-		return models.PullRequest{}, "", err
+	filter = models.PRFilter{
+		Label:      strings.ToLower(strings.TrimSpace(filter.Label)),
+		Repository: filter.Repository,
 	}
-
-	updatedPR.NeedMoreReviewers = len(updatedPR.Assigned) < maxReviewers
-
-	return updatedPR, newUID, nil
-}
-
-func (s *PRService) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
-	return s.repo.GetPRsByReviewer(ctx, userID)
+	if scope, ok := ScopeFromContext(ctx); ok {
+		filter.TeamName = scope.TeamName
+	}
+	return s.repo.SearchPRs(ctx, query, filter, normalizePage(page))
 }
 
 func (s *PRService) DeactivateTeam(ctx context.Context, teamName string) error {
@@ -538,6 +2210,8 @@ func (s *PRService) DeactivateTeam(ctx context.Context, teamName string) error {
 		return err
 	}
 
+	cache := newOpCache(s.repo)
+	processed := make(map[string]struct{})
 	for _, member := range team.Members {
 
 		select {
@@ -560,25 +2234,34 @@ func (s *PRService) DeactivateTeam(ctx context.Context, teamName string) error {
 			default:
 			}
 
-			pr, err := s.repo.GetPR(ctx, prShort.PullRequestID)
+			if _, ok := processed[prShort.PullRequestID]; ok {
+				continue
+			}
+			processed[prShort.PullRequestID] = struct{}{}
+
+			pr, err := cache.getPR(ctx, prShort.PullRequestID)
 			if err != nil {
 				s.log.Error("failed to get full PR", "pr", prShort.PullRequestID, "error", err)
 				continue
 			}
 
-			if pr.Status != "OPEN" {
+			if !pr.Status.Active() {
 				continue
 			}
 
 			updated := false
 			for _, rev := range pr.Assigned {
-				user, err := s.repo.GetUser(ctx, rev.UserID)
+				user, err := cache.getUser(ctx, rev.UserID)
 				if err != nil {
 					s.log.Warn("could not fetch user while deactivating team", "user", rev.UserID, "pr", pr.PullRequestID, "error", err)
 					continue
 				}
 				if !user.IsActive {
-					newUID, err := s.reassignReviewer(ctx, pr.PullRequestID, rev.UserID, teamName)
+					candidateTeam := teamName
+					if pr.TeamName != "" {
+						candidateTeam = pr.TeamName
+					}
+					newUID, err := s.reassignReviewer(ctx, cache, pr.PullRequestID, rev.UserID, candidateTeam, "team_deactivated")
 					if err != nil {
 						s.log.Warn("no replacement found for inactive reviewer", "pr", pr.PullRequestID, "user", rev.UserID)
 						continue
@@ -588,7 +2271,7 @@ func (s *PRService) DeactivateTeam(ctx context.Context, teamName string) error {
 				}
 			}
 			if updated {
-				pr.NeedMoreReviewers = len(pr.Assigned) < maxReviewers
+				pr.NeedMoreReviewers = reviewerSlots(pr.Assigned) < maxReviewers
 			}
 		}
 	}
@@ -597,28 +2280,36 @@ func (s *PRService) DeactivateTeam(ctx context.Context, teamName string) error {
 	return nil
 }
 
-func (s *PRService) reassignReviewer(ctx context.Context, prID, oldUID, teamName string) (string, error) {
-	cands, err := s.repo.GetActiveTeamMembersExcept(ctx, teamName, "")
+// DeleteTeam removes the team and its users. Open PRs still reviewed by team
+// members block deletion unless force is set, in which case those reviewers
+// are dropped. The affected PR IDs are returned either way.
+func (s *PRService) DeleteTeam(ctx context.Context, teamName string, force bool) ([]string, error) {
+	if err := validateTeamName(teamName); err != nil {
+		return nil, err
+	}
+	affected, err := s.repo.DeleteTeam(ctx, teamName, force, maxReviewers)
 	if err != nil {
-		return "", err
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			return nil, ErrNotFound
+		case strings.Contains(err.Error(), "open reviews"):
+			return affected, ErrTeamInUse
+		}
+		s.log.Error("failed to delete team", "team", teamName, "error", err)
+		return nil, err
 	}
+	s.log.Success("team deleted", "team", teamName, "force", force, "affected_prs", len(affected))
+	return affected, nil
+}
 
-	pr, err := s.repo.GetPR(ctx, prID)
+func (s *PRService) reassignReviewer(ctx context.Context, cache *opCache, prID, oldUID, teamName, cause string) (string, error) {
+	pr, err := cache.getPR(ctx, prID)
 	if err != nil {
 		return "", err
 	}
-
-	assignedSet := map[string]struct{}{}
-	for _, a := range pr.Assigned {
-		assignedSet[a.UserID] = struct{}{}
-	}
-
-	avail := []string{}
-	for _, c := range cands {
-		if _, ok := assignedSet[c]; ok {
-			continue
-		}
-		avail = append(avail, c)
+	avail, err := s.replacementCandidates(ctx, cache, pr, teamName)
+	if err != nil {
+		return "", err
 	}
 	if len(avail) == 0 {
 		return "", ErrNoCandidate
@@ -630,22 +2321,86 @@ func (s *PRService) reassignReviewer(ctx context.Context, prID, oldUID, teamName
 	default:
 	}
 
-	idx, err := cryptoRandInt(len(avail))
+	// Without a scoring pipeline every candidate ranks the same and the
+	// pick stays a plain weighted draw.
+	avail, ranks := s.scoredCandidates(ctx, teamName, pr.AuthorID, avail, nil)
+	idx, err := pickCandidate(s.rand, avail, ranks, nil, s.selectionWeights(ctx, teamName, avail))
 	if err != nil {
 		return "", err
 	}
 	newUID := avail[idx]
 
-	_, err = s.repo.ReplaceReviewer(ctx, prID, oldUID, newUID)
+	updated, err := s.repo.ReplaceReviewer(withCause(ctx, cause), prID, oldUID, newUID)
 	if err != nil {
 		return "", err
 	}
+	updated = s.pairMentor(ctx, updated, newUID)
+	reviewerReplacements.Inc(cause)
+	cache.setPR(updated)
 	return newUID, nil
 }
 
+// replacementCandidates lists the active members of teamName who could take
+// over a review of pr: neither its author nor already assigned, and not held
+// back by ramp-up or reservations.
+func (s *PRService) replacementCandidates(ctx context.Context, cache *opCache, pr models.PullRequest, teamName string) ([]string, error) {
+	cands, err := cache.activeMembers(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	cands = s.withoutReserved(ctx, pr.AuthorID, s.withinRampUpFor(ctx, pr.Priority, cands))
+
+	assignedSet := map[string]struct{}{pr.AuthorID: {}}
+	for _, a := range pr.Assigned {
+		assignedSet[a.UserID] = struct{}{}
+	}
+
+	avail := []string{}
+	for _, c := range cands {
+		if _, ok := assignedSet[c]; ok {
+			continue
+		}
+		avail = append(avail, c)
+	}
+	return avail, nil
+}
+
+func (s *PRService) checkPRScope(ctx context.Context, cache *opCache, prID string) error {
+	if _, ok := ScopeFromContext(ctx); !ok {
+		return nil
+	}
+	pr, err := cache.getPR(ctx, prID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrNotFound
+		}
+		return err
+	}
+	teamName, err := s.prTeam(ctx, pr)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrNotFound
+		}
+		return err
+	}
+	return checkTeamScope(ctx, teamName)
+}
+
+// prTeam returns the team that owned pr when it was created, falling back
+// to the author's current team for rows that predate team ownership.
+func (s *PRService) prTeam(ctx context.Context, pr models.PullRequest) (string, error) {
+	if pr.TeamName != "" {
+		return pr.TeamName, nil
+	}
+	return s.repo.GetUserTeam(ctx, pr.AuthorID)
+}
+
 func (s *PRService) GetStats(ctx context.Context) (map[string]int, error) {
 	start := time.Now()
 	stats, err := s.repo.GetReviewerStats(ctx)
+	if err == nil {
+		stats, err = s.scopeStats(ctx, stats)
+	}
 	ms := float64(time.Since(start).Nanoseconds()) / 1e6
 	durationStr := fmt.Sprintf("%.1fms", ms)
 
@@ -656,14 +2411,148 @@ func (s *PRService) GetStats(ctx context.Context) (map[string]int, error) {
 	return stats, err
 }
 
-func cryptoRandInt(n int) (int, error) {
-	if n <= 0 {
-		return 0, fmt.Errorf("invalid n for cryptoRandInt: %d", n)
+// QueryStats is GetStats with scope hints and a time budget. When the budget
+// runs out before the query finishes, the rows read so far are returned with
+// Truncated set instead of failing the whole request.
+func (s *PRService) QueryStats(ctx context.Context, q models.StatsQuery, budget time.Duration) (models.StatsReport, error) {
+	if scope, ok := ScopeFromContext(ctx); ok {
+		if q.TeamName != "" && q.TeamName != scope.TeamName {
+			return models.StatsReport{}, ErrForbidden
+		}
+		q.TeamName = scope.TeamName
+	}
+
+	start := time.Now()
+	qctx := ctx
+	if budget > 0 {
+		var cancel context.CancelFunc
+		qctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	stats, err := s.repo.QueryReviewerStats(qctx, q)
+	report := models.StatsReport{Stats: stats}
+	if err != nil {
+		if budget <= 0 || ctx.Err() != nil || !errors.Is(qctx.Err(), context.DeadlineExceeded) {
+			return models.StatsReport{}, err
+		}
+		report.Truncated = true
+	}
+	if report.Stats == nil {
+		report.Stats = map[string]int{}
+	}
+
+	ms := float64(time.Since(start).Nanoseconds()) / 1e6
+	workerLog := s.log.WithWorker("worker-stats")
+	workerLog.Success("query_stats succeeded", "duration", fmt.Sprintf("%.1fms", ms), "team", q.TeamName, "truncated", report.Truncated)
+	return report, nil
+}
+
+func (s *PRService) scopeStats(ctx context.Context, stats map[string]int) (map[string]int, error) {
+	scope, ok := ScopeFromContext(ctx)
+	if !ok {
+		return stats, nil
+	}
+	team, err := s.GetTeam(ctx, scope.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.repo.GetTeamMemberships(ctx, scope.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	scoped := make(map[string]int, len(team.Members)+len(memberships))
+	for _, m := range team.Members {
+		scoped[m.UserID] = stats[m.UserID]
+	}
+	for _, m := range memberships {
+		scoped[m.UserID] = stats[m.UserID]
+	}
+	return scoped, nil
+}
+
+// GetTeamStats reads the team's PR counters, which the repo keeps up to date
+// as PRs are created, merged and reassigned.
+func (s *PRService) GetTeamStats(ctx context.Context, teamName string) (models.TeamStats, error) {
+	if err := validateTeamName(teamName); err != nil {
+		return models.TeamStats{}, err
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return models.TeamStats{}, err
+	}
+	stats, err := s.repo.GetTeamStats(ctx, teamName)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.TeamStats{}, ErrNotFound
+		}
+		s.log.Error("failed to get team stats", "team", teamName, "error", err)
+		return models.TeamStats{}, err
+	}
+	return stats, nil
+}
+
+// pickReviewers adds the best matching, least loaded active candidates to
+// selected until it is full and returns it with the candidates left over.
+func (s *PRService) pickReviewers(ctx context.Context, selected []models.PRReviewer, candidateIDs []string, load, match map[string]int, weights map[string]float64) ([]models.PRReviewer, []string, error) {
+	for len(selected) < maxReviewers && len(candidateIDs) > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		default:
+		}
+
+		idx, err := pickCandidate(s.rand, candidateIDs, load, match, weights)
+		if err != nil {
+			continue
+		}
+		userID := candidateIDs[idx]
+
+		user, err := s.repo.GetUser(ctx, userID)
+		if err != nil {
+			candidatesFiltered.Inc("absent")
+			candidateIDs = append(candidateIDs[:idx], candidateIDs[idx+1:]...)
+			continue
+		}
+		if !user.IsActive {
+			candidatesFiltered.Inc("inactive")
+			candidateIDs = append(candidateIDs[:idx], candidateIDs[idx+1:]...)
+			continue
+		}
+
+		selected = append(selected, models.PRReviewer{
+			UserID:   user.UserID,
+			Username: user.Username,
+			IsActive: user.IsActive,
+		})
+
+		candidateIDs = append(candidateIDs[:idx], candidateIDs[idx+1:]...)
+	}
+	return selected, candidateIDs, nil
+}
+
+// pickCandidate returns the index of a random candidate, drawn by weights,
+// among those with the most skills matching the PR's labels and, among them,
+// the least open review load.
+func pickCandidate(rng RandSource, candidateIDs []string, load, match map[string]int, weights map[string]float64) (int, error) {
+	var best []int
+	least, most := -1, 0
+	for i, id := range candidateIDs {
+		n, m := load[id], match[id]
+		switch {
+		case least < 0 || m > most || (m == most && n < least):
+			least, most = n, m
+			best = append(best[:0], i)
+		case m == most && n == least:
+			best = append(best, i)
+		}
+	}
+	ids := make([]string, len(best))
+	for j, i := range best {
+		ids[j] = candidateIDs[i]
 	}
-	bigN := big.NewInt(int64(n))
-	r, err := rand.Int(rand.Reader, bigN)
+	j, err := weightedRandInt(rng, ids, weights)
 	if err != nil {
-		return 0, fmt.Errorf("crypto rand failed: %w", err)
+		return 0, err
 	}
-	return int(r.Int64()), nil
+	return best[j], nil
 }
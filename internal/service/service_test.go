@@ -3,12 +3,19 @@ package service_test
 import (
 	"context"
 	"errors"
+	"net/http/httptest"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"PR-reviewer/internal/logger"
+	"PR-reviewer/internal/metrics"
 	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/notify"
+	"PR-reviewer/internal/repo"
 	"PR-reviewer/internal/service"
 )
 
@@ -26,8 +33,8 @@ type mockRepo struct {
 	UpdateUserActiveFunc           func(ctx context.Context, userID string, active bool) (models.User, error)
 	GetPRFunc                      func(ctx context.Context, prID string) (models.PullRequest, error)
 	CreatePRFunc                   func(ctx context.Context, pr models.PullRequest) error
-	MergePRFunc                    func(ctx context.Context, prID string, t time.Time) (models.PullRequest, error)
-	AddReviewerFunc                func(ctx context.Context, prID, userID string) error
+	MergePRFunc                    func(ctx context.Context, prID, mergedBy string, t time.Time) (models.PullRequest, error)
+	AddReviewerFunc                func(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error)
 	CleanupInactiveReviewersFunc   func(ctx context.Context, prID string) error
 	GetUserTeamFunc                func(ctx context.Context, userID string) (string, error)
 	GetActiveTeamMembersExceptFunc func(ctx context.Context, teamName, exclude string) ([]string, error)
@@ -36,6 +43,85 @@ type mockRepo struct {
 	GetPRsByReviewerFunc           func(ctx context.Context, userID string) ([]models.PullRequestShort, error)
 	SetTeamActiveFunc              func(ctx context.Context, teamName string, active bool) error
 	GetReviewerStatsFunc           func(ctx context.Context) (map[string]int, error)
+	CreateTeamTokenFunc            func(ctx context.Context, teamName, tokenHash string) error
+	GetTeamByTokenFunc             func(ctx context.Context, tokenHash string) (string, error)
+	ApprovePRFunc                  func(ctx context.Context, prID, userID string, t time.Time) (models.PullRequest, error)
+	ClosePRFunc                    func(ctx context.Context, prID string, t time.Time) (models.PullRequest, error)
+	ReopenPRFunc                   func(ctx context.Context, prID string) (models.PullRequest, error)
+	QueryReviewerStatsFunc         func(ctx context.Context, q models.StatsQuery) (map[string]int, error)
+	ListPRsFunc                    func(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error)
+	GetCandidateSignalsFunc        func(ctx context.Context, teamName, authorID string) ([]models.CandidateSignals, error)
+	GetTeamSettingsFunc            func(ctx context.Context, teamName string) (models.TeamSettings, error)
+	SaveTeamSettingsFunc           func(ctx context.Context, settings models.TeamSettings) error
+	UpdateTeamFunc                 func(ctx context.Context, upd models.TeamUpdate) (models.Team, error)
+	DeleteTeamFunc                 func(ctx context.Context, teamName string, force bool, wantReviewers int) ([]string, error)
+	GetUserEventsFunc              func(ctx context.Context, userID string, page models.Page) ([]models.UserEvent, error)
+	GetOrgSummaryFunc              func(ctx context.Context, limit int) (models.OrgSummary, error)
+	GetAlertSignalsFunc            func(ctx context.Context, slaCutoff time.Time) (models.AlertSignals, error)
+	UpdateUserTeamFunc             func(ctx context.Context, userID, teamName string, handoffs []models.ReviewHandoff) (models.User, error)
+	CheckConsistencyFunc           func(ctx context.Context, wantReviewers int, repair bool) (models.ConsistencyReport, error)
+	GetPRsByAuthorFunc             func(ctx context.Context, userID string) ([]models.PullRequestShort, error)
+	GetUserProfileFunc             func(ctx context.Context, userID string) (models.UserProfile, error)
+	AddMembershipFunc              func(ctx context.Context, m models.Membership) error
+	RemoveMembershipFunc           func(ctx context.Context, teamName, userID string) error
+	RemoveReviewerFunc             func(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error)
+	SearchPRsFunc                  func(ctx context.Context, query string, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error)
+	AcknowledgeReviewFunc          func(ctx context.Context, prID, userID string) (models.PullRequest, error)
+	GetPendingReviewsFunc          func(ctx context.Context) ([]models.PendingReview, error)
+	GetAssignmentRecordsFunc       func(ctx context.Context, from, to time.Time) ([]models.AssignmentRecord, error)
+	GetOpenReviewLoadFunc          func(ctx context.Context, teamName string) (map[string]int, error)
+	GetActiveMembersOfTeamsFunc    func(ctx context.Context, teamNames []string, exceptUser string) ([]string, error)
+	StartEscalationFunc            func(ctx context.Context, prID, reason string) error
+	StartSLAEscalationsFunc        func(ctx context.Context, cutoff time.Time) (int, error)
+	GetActiveEscalationsFunc       func(ctx context.Context) ([]models.Escalation, error)
+	AdvanceEscalationFunc          func(ctx context.Context, prID, reason string, hop int, recipients []string) error
+	GetUserStatsFunc               func(ctx context.Context, userID string, since time.Time) (models.UserStats, error)
+	SetUserSkillsFunc              func(ctx context.Context, userID string, skills []string) error
+	GetSkillMatchesFunc            func(ctx context.Context, skills []string) (map[string]int, error)
+	GetSecurityCoverageFunc        func(ctx context.Context, teamName string, since time.Time) (models.SecurityCoverage, error)
+	SetVacationFunc                func(ctx context.Context, userID string, v *models.Vacation) error
+	StartVacationsFunc             func(ctx context.Context, now time.Time) ([]string, error)
+	EndVacationsFunc               func(ctx context.Context, now time.Time) ([]string, error)
+	SetMentorFunc                  func(ctx context.Context, menteeID, mentorID string) error
+	GetMentorsFunc                 func(ctx context.Context, userIDs []string) (map[string]string, error)
+	SetTimezoneFunc                func(ctx context.Context, userID, tz string) error
+	GetTimezonesFunc               func(ctx context.Context, userIDs []string) (map[string]string, error)
+	GetRampUpLoadsFunc             func(ctx context.Context, joinedAfter time.Time, maxAssigned int) (map[string]int, error)
+	SetUserWeightFunc              func(ctx context.Context, userID string, weight int) error
+	GetUserWeightsFunc             func(ctx context.Context, userIDs []string) (map[string]int, error)
+	AddSecondaryReviewerFunc       func(ctx context.Context, prID, userID string) (models.PullRequest, error)
+	GetStaleReviewsFunc            func(ctx context.Context, inactiveBefore time.Time) ([]models.PendingReview, error)
+	GetUnderstaffedPRsFunc         func(ctx context.Context, teamName, userID string) ([]string, error)
+	ReserveReviewersFunc           func(ctx context.Context, authorID string, userIDs []string, until time.Time) error
+	GetReservationsFunc            func(ctx context.Context, now time.Time) (map[string]string, error)
+	GetAssignmentHistoryFunc       func(ctx context.Context, prID string) ([]models.AssignmentEvent, error)
+	StartReviewRoundFunc           func(ctx context.Context, prID, requestedBy, freshReviewer string, dueAt *time.Time) (models.ReviewRound, error)
+	GetReviewRoundsFunc            func(ctx context.Context, prID string) ([]models.ReviewRound, error)
+	GetRecentReviewersFunc         func(ctx context.Context, authorID string, since time.Time) ([]string, error)
+	SaveRotationFunc               func(ctx context.Context, teamName string, weeks []models.RotationWeek) error
+	GetRotationFunc                func(ctx context.Context, teamName string, from time.Time) ([]models.RotationWeek, error)
+	SetPRStatusFunc                func(ctx context.Context, prID string, from, to models.PRStatus) error
+	SetNotificationPrefsFunc       func(ctx context.Context, userID string, prefs models.NotificationPrefs) error
+	GetMergeSubscribersFunc        func(ctx context.Context, userIDs []string) ([]string, error)
+	CreateUserTokenFunc            func(ctx context.Context, userID, tokenHash string) error
+	GetUserByTokenFunc             func(ctx context.Context, tokenHash string) (string, error)
+	QueueMergeFunc                 func(ctx context.Context, prID, mergedBy string, mergeAt time.Time) error
+	GetDueMergesFunc               func(ctx context.Context, now time.Time) ([]models.QueuedMerge, error)
+	DequeueMergeFunc               func(ctx context.Context, prID string) error
+	SetPRLabelsFunc                func(ctx context.Context, prID string, labels []string) error
+	GetReviewLoadBySizeFunc        func(ctx context.Context, teamName string) ([]models.ReviewerLoad, error)
+	GetTableStatsFunc              func(ctx context.Context) ([]models.TableStats, error)
+	GetArchivablePRsFunc           func(ctx context.Context, before time.Time, limit int) ([]string, error)
+	ArchivePRFunc                  func(ctx context.Context, a models.ArchivedPR) error
+	GetArchivedPRFunc              func(ctx context.Context, prID string) (models.ArchivedPR, error)
+	ListArchivedPRsFunc            func(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.ArchivedPRShort, error)
+	RecordReviewDecisionFunc       func(ctx context.Context, prID, userID string, decision models.ReviewStatus, comment string, t time.Time) error
+	ForgetUserFunc                 func(ctx context.Context, userID string) (models.User, error)
+	GetTeamStatsFunc               func(ctx context.Context, teamName string) (models.TeamStats, error)
+	GetRepositorySettingsFunc      func(ctx context.Context, repository string) (models.RepositorySettings, error)
+	SaveRepositorySettingsFunc     func(ctx context.Context, settings models.RepositorySettings) error
+	GetTeamMembershipsFunc         func(ctx context.Context, teamName string) ([]models.Membership, error)
+	FindPRFunc                     func(ctx context.Context, repository, id string) (string, error)
 }
 
 func (m *mockRepo) InsertTeam(ctx context.Context, t models.Team) error {
@@ -68,17 +154,23 @@ func (m *mockRepo) CreatePR(ctx context.Context, pr models.PullRequest) error {
 	}
 	return nil
 }
-func (m *mockRepo) MergePR(ctx context.Context, prID string, t time.Time) (models.PullRequest, error) {
+func (m *mockRepo) MergePR(ctx context.Context, prID, mergedBy string, t time.Time) (models.PullRequest, error) {
 	if m.MergePRFunc != nil {
-		return m.MergePRFunc(ctx, prID, t)
+		return m.MergePRFunc(ctx, prID, mergedBy, t)
 	}
 	return models.PullRequest{}, nil
 }
-func (m *mockRepo) AddReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error) {
-	return models.PullRequest{}, m.AddReviewerFunc(ctx, prID, userID)
+func (m *mockRepo) AddReviewer(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+	if m.AddReviewerFunc != nil {
+		return m.AddReviewerFunc(ctx, prID, userID, wantReviewers)
+	}
+	return models.PullRequest{}, nil
 }
 func (m *mockRepo) CleanupInactiveReviewers(ctx context.Context, prID string) error {
-	return m.CleanupInactiveReviewersFunc(ctx, prID)
+	if m.CleanupInactiveReviewersFunc != nil {
+		return m.CleanupInactiveReviewersFunc(ctx, prID)
+	}
+	return nil
 }
 func (m *mockRepo) GetUserTeam(ctx context.Context, userID string) (string, error) {
 	if m.GetUserTeamFunc != nil {
@@ -122,6 +214,483 @@ func (m *mockRepo) GetReviewerStats(ctx context.Context) (map[string]int, error)
 	}
 	return nil, nil
 }
+func (m *mockRepo) CreateTeamToken(ctx context.Context, teamName, tokenHash string) error {
+	if m.CreateTeamTokenFunc != nil {
+		return m.CreateTeamTokenFunc(ctx, teamName, tokenHash)
+	}
+	return nil
+}
+func (m *mockRepo) GetTeamByToken(ctx context.Context, tokenHash string) (string, error) {
+	if m.GetTeamByTokenFunc != nil {
+		return m.GetTeamByTokenFunc(ctx, tokenHash)
+	}
+	return "", errors.New("not found")
+}
+func (m *mockRepo) ApprovePR(ctx context.Context, prID, userID string, t time.Time) (models.PullRequest, error) {
+	if m.ApprovePRFunc != nil {
+		return m.ApprovePRFunc(ctx, prID, userID, t)
+	}
+	return models.PullRequest{}, nil
+}
+func (m *mockRepo) ClosePR(ctx context.Context, prID string, t time.Time) (models.PullRequest, error) {
+	if m.ClosePRFunc != nil {
+		return m.ClosePRFunc(ctx, prID, t)
+	}
+	return models.PullRequest{}, nil
+}
+func (m *mockRepo) ReopenPR(ctx context.Context, prID string) (models.PullRequest, error) {
+	if m.ReopenPRFunc != nil {
+		return m.ReopenPRFunc(ctx, prID)
+	}
+	return models.PullRequest{}, nil
+}
+func (m *mockRepo) QueryReviewerStats(ctx context.Context, q models.StatsQuery) (map[string]int, error) {
+	if m.QueryReviewerStatsFunc != nil {
+		return m.QueryReviewerStatsFunc(ctx, q)
+	}
+	return nil, nil
+}
+func (m *mockRepo) ListPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+	if m.ListPRsFunc != nil {
+		return m.ListPRsFunc(ctx, filter, page)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetCandidateSignals(ctx context.Context, teamName, authorID string) ([]models.CandidateSignals, error) {
+	if m.GetCandidateSignalsFunc != nil {
+		return m.GetCandidateSignalsFunc(ctx, teamName, authorID)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetTeamSettings(ctx context.Context, teamName string) (models.TeamSettings, error) {
+	if m.GetTeamSettingsFunc != nil {
+		return m.GetTeamSettingsFunc(ctx, teamName)
+	}
+	return models.TeamSettings{}, nil
+}
+func (m *mockRepo) SaveTeamSettings(ctx context.Context, settings models.TeamSettings) error {
+	if m.SaveTeamSettingsFunc != nil {
+		return m.SaveTeamSettingsFunc(ctx, settings)
+	}
+	return nil
+}
+func (m *mockRepo) UpdateTeam(ctx context.Context, upd models.TeamUpdate) (models.Team, error) {
+	if m.UpdateTeamFunc != nil {
+		return m.UpdateTeamFunc(ctx, upd)
+	}
+	return models.Team{}, nil
+}
+func (m *mockRepo) DeleteTeam(ctx context.Context, teamName string, force bool, wantReviewers int) ([]string, error) {
+	if m.DeleteTeamFunc != nil {
+		return m.DeleteTeamFunc(ctx, teamName, force, wantReviewers)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetUserEvents(ctx context.Context, userID string, page models.Page) ([]models.UserEvent, error) {
+	if m.GetUserEventsFunc != nil {
+		return m.GetUserEventsFunc(ctx, userID, page)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetOrgSummary(ctx context.Context, limit int) (models.OrgSummary, error) {
+	if m.GetOrgSummaryFunc != nil {
+		return m.GetOrgSummaryFunc(ctx, limit)
+	}
+	return models.OrgSummary{}, nil
+}
+func (m *mockRepo) GetAlertSignals(ctx context.Context, slaCutoff time.Time) (models.AlertSignals, error) {
+	if m.GetAlertSignalsFunc != nil {
+		return m.GetAlertSignalsFunc(ctx, slaCutoff)
+	}
+	return models.AlertSignals{}, nil
+}
+func (m *mockRepo) UpdateUserTeam(ctx context.Context, userID, teamName string, handoffs []models.ReviewHandoff) (models.User, error) {
+	if m.UpdateUserTeamFunc != nil {
+		return m.UpdateUserTeamFunc(ctx, userID, teamName, handoffs)
+	}
+	return models.User{}, nil
+}
+func (m *mockRepo) CheckConsistency(ctx context.Context, wantReviewers int, repair bool) (models.ConsistencyReport, error) {
+	if m.CheckConsistencyFunc != nil {
+		return m.CheckConsistencyFunc(ctx, wantReviewers, repair)
+	}
+	return models.ConsistencyReport{}, nil
+}
+func (m *mockRepo) GetPRsByAuthor(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	if m.GetPRsByAuthorFunc != nil {
+		return m.GetPRsByAuthorFunc(ctx, userID)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetUserProfile(ctx context.Context, userID string) (models.UserProfile, error) {
+	if m.GetUserProfileFunc != nil {
+		return m.GetUserProfileFunc(ctx, userID)
+	}
+	return models.UserProfile{}, nil
+}
+func (m *mockRepo) AddMembership(ctx context.Context, mem models.Membership) error {
+	if m.AddMembershipFunc != nil {
+		return m.AddMembershipFunc(ctx, mem)
+	}
+	return nil
+}
+func (m *mockRepo) RemoveMembership(ctx context.Context, teamName, userID string) error {
+	if m.RemoveMembershipFunc != nil {
+		return m.RemoveMembershipFunc(ctx, teamName, userID)
+	}
+	return nil
+}
+func (m *mockRepo) RemoveReviewer(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+	if m.RemoveReviewerFunc != nil {
+		return m.RemoveReviewerFunc(ctx, prID, userID, wantReviewers)
+	}
+	return models.PullRequest{}, nil
+}
+func (m *mockRepo) SearchPRs(ctx context.Context, query string, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+	if m.SearchPRsFunc != nil {
+		return m.SearchPRsFunc(ctx, query, filter, page)
+	}
+	return nil, nil
+}
+func (m *mockRepo) AcknowledgeReview(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	if m.AcknowledgeReviewFunc != nil {
+		return m.AcknowledgeReviewFunc(ctx, prID, userID)
+	}
+	return models.PullRequest{}, nil
+}
+func (m *mockRepo) GetPendingReviews(ctx context.Context) ([]models.PendingReview, error) {
+	if m.GetPendingReviewsFunc != nil {
+		return m.GetPendingReviewsFunc(ctx)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetAssignmentRecords(ctx context.Context, from, to time.Time) ([]models.AssignmentRecord, error) {
+	if m.GetAssignmentRecordsFunc != nil {
+		return m.GetAssignmentRecordsFunc(ctx, from, to)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetOpenReviewLoad(ctx context.Context, teamName string) (map[string]int, error) {
+	if m.GetOpenReviewLoadFunc != nil {
+		return m.GetOpenReviewLoadFunc(ctx, teamName)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetActiveMembersOfTeams(ctx context.Context, teamNames []string, exceptUser string) ([]string, error) {
+	if m.GetActiveMembersOfTeamsFunc != nil {
+		return m.GetActiveMembersOfTeamsFunc(ctx, teamNames, exceptUser)
+	}
+	return nil, nil
+}
+func (m *mockRepo) StartEscalation(ctx context.Context, prID, reason string) error {
+	if m.StartEscalationFunc != nil {
+		return m.StartEscalationFunc(ctx, prID, reason)
+	}
+	return nil
+}
+func (m *mockRepo) StartSLAEscalations(ctx context.Context, cutoff time.Time) (int, error) {
+	if m.StartSLAEscalationsFunc != nil {
+		return m.StartSLAEscalationsFunc(ctx, cutoff)
+	}
+	return 0, nil
+}
+func (m *mockRepo) GetActiveEscalations(ctx context.Context) ([]models.Escalation, error) {
+	if m.GetActiveEscalationsFunc != nil {
+		return m.GetActiveEscalationsFunc(ctx)
+	}
+	return nil, nil
+}
+func (m *mockRepo) AdvanceEscalation(ctx context.Context, prID, reason string, hop int, recipients []string) error {
+	if m.AdvanceEscalationFunc != nil {
+		return m.AdvanceEscalationFunc(ctx, prID, reason, hop, recipients)
+	}
+	return nil
+}
+func (m *mockRepo) GetUserStats(ctx context.Context, userID string, since time.Time) (models.UserStats, error) {
+	if m.GetUserStatsFunc != nil {
+		return m.GetUserStatsFunc(ctx, userID, since)
+	}
+	return models.UserStats{}, nil
+}
+func (m *mockRepo) SetUserSkills(ctx context.Context, userID string, skills []string) error {
+	if m.SetUserSkillsFunc != nil {
+		return m.SetUserSkillsFunc(ctx, userID, skills)
+	}
+	return nil
+}
+func (m *mockRepo) GetSkillMatches(ctx context.Context, skills []string) (map[string]int, error) {
+	if m.GetSkillMatchesFunc != nil {
+		return m.GetSkillMatchesFunc(ctx, skills)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetSecurityCoverage(ctx context.Context, teamName string, since time.Time) (models.SecurityCoverage, error) {
+	if m.GetSecurityCoverageFunc != nil {
+		return m.GetSecurityCoverageFunc(ctx, teamName, since)
+	}
+	return models.SecurityCoverage{}, nil
+}
+func (m *mockRepo) SetVacation(ctx context.Context, userID string, v *models.Vacation) error {
+	if m.SetVacationFunc != nil {
+		return m.SetVacationFunc(ctx, userID, v)
+	}
+	return nil
+}
+func (m *mockRepo) StartVacations(ctx context.Context, now time.Time) ([]string, error) {
+	if m.StartVacationsFunc != nil {
+		return m.StartVacationsFunc(ctx, now)
+	}
+	return nil, nil
+}
+func (m *mockRepo) EndVacations(ctx context.Context, now time.Time) ([]string, error) {
+	if m.EndVacationsFunc != nil {
+		return m.EndVacationsFunc(ctx, now)
+	}
+	return nil, nil
+}
+func (m *mockRepo) SetMentor(ctx context.Context, menteeID, mentorID string) error {
+	if m.SetMentorFunc != nil {
+		return m.SetMentorFunc(ctx, menteeID, mentorID)
+	}
+	return nil
+}
+func (m *mockRepo) GetMentors(ctx context.Context, userIDs []string) (map[string]string, error) {
+	if m.GetMentorsFunc != nil {
+		return m.GetMentorsFunc(ctx, userIDs)
+	}
+	return nil, nil
+}
+func (m *mockRepo) SetTimezone(ctx context.Context, userID, tz string) error {
+	if m.SetTimezoneFunc != nil {
+		return m.SetTimezoneFunc(ctx, userID, tz)
+	}
+	return nil
+}
+func (m *mockRepo) GetTimezones(ctx context.Context, userIDs []string) (map[string]string, error) {
+	if m.GetTimezonesFunc != nil {
+		return m.GetTimezonesFunc(ctx, userIDs)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetRampUpLoads(ctx context.Context, joinedAfter time.Time, maxAssigned int) (map[string]int, error) {
+	if m.GetRampUpLoadsFunc != nil {
+		return m.GetRampUpLoadsFunc(ctx, joinedAfter, maxAssigned)
+	}
+	return nil, nil
+}
+func (m *mockRepo) SetUserWeight(ctx context.Context, userID string, weight int) error {
+	if m.SetUserWeightFunc != nil {
+		return m.SetUserWeightFunc(ctx, userID, weight)
+	}
+	return nil
+}
+func (m *mockRepo) GetUserWeights(ctx context.Context, userIDs []string) (map[string]int, error) {
+	if m.GetUserWeightsFunc != nil {
+		return m.GetUserWeightsFunc(ctx, userIDs)
+	}
+	return nil, nil
+}
+func (m *mockRepo) AddSecondaryReviewer(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+	if m.AddSecondaryReviewerFunc != nil {
+		return m.AddSecondaryReviewerFunc(ctx, prID, userID)
+	}
+	return models.PullRequest{}, nil
+}
+func (m *mockRepo) GetStaleReviews(ctx context.Context, inactiveBefore time.Time) ([]models.PendingReview, error) {
+	if m.GetStaleReviewsFunc != nil {
+		return m.GetStaleReviewsFunc(ctx, inactiveBefore)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetUnderstaffedPRs(ctx context.Context, teamName, userID string) ([]string, error) {
+	if m.GetUnderstaffedPRsFunc != nil {
+		return m.GetUnderstaffedPRsFunc(ctx, teamName, userID)
+	}
+	return nil, nil
+}
+func (m *mockRepo) ReserveReviewers(ctx context.Context, authorID string, userIDs []string, until time.Time) error {
+	if m.ReserveReviewersFunc != nil {
+		return m.ReserveReviewersFunc(ctx, authorID, userIDs, until)
+	}
+	return nil
+}
+func (m *mockRepo) GetReservations(ctx context.Context, now time.Time) (map[string]string, error) {
+	if m.GetReservationsFunc != nil {
+		return m.GetReservationsFunc(ctx, now)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetAssignmentHistory(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+	if m.GetAssignmentHistoryFunc != nil {
+		return m.GetAssignmentHistoryFunc(ctx, prID)
+	}
+	return nil, nil
+}
+func (m *mockRepo) StartReviewRound(ctx context.Context, prID, requestedBy, freshReviewer string, dueAt *time.Time) (models.ReviewRound, error) {
+	if m.StartReviewRoundFunc != nil {
+		return m.StartReviewRoundFunc(ctx, prID, requestedBy, freshReviewer, dueAt)
+	}
+	return models.ReviewRound{}, nil
+}
+func (m *mockRepo) GetReviewRounds(ctx context.Context, prID string) ([]models.ReviewRound, error) {
+	if m.GetReviewRoundsFunc != nil {
+		return m.GetReviewRoundsFunc(ctx, prID)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetRecentReviewers(ctx context.Context, authorID string, since time.Time) ([]string, error) {
+	if m.GetRecentReviewersFunc != nil {
+		return m.GetRecentReviewersFunc(ctx, authorID, since)
+	}
+	return nil, nil
+}
+func (m *mockRepo) SaveRotation(ctx context.Context, teamName string, weeks []models.RotationWeek) error {
+	if m.SaveRotationFunc != nil {
+		return m.SaveRotationFunc(ctx, teamName, weeks)
+	}
+	return nil
+}
+func (m *mockRepo) GetRotation(ctx context.Context, teamName string, from time.Time) ([]models.RotationWeek, error) {
+	if m.GetRotationFunc != nil {
+		return m.GetRotationFunc(ctx, teamName, from)
+	}
+	return nil, nil
+}
+func (m *mockRepo) SetPRStatus(ctx context.Context, prID string, from, to models.PRStatus) error {
+	if m.SetPRStatusFunc != nil {
+		return m.SetPRStatusFunc(ctx, prID, from, to)
+	}
+	return nil
+}
+func (m *mockRepo) SetNotificationPrefs(ctx context.Context, userID string, prefs models.NotificationPrefs) error {
+	if m.SetNotificationPrefsFunc != nil {
+		return m.SetNotificationPrefsFunc(ctx, userID, prefs)
+	}
+	return nil
+}
+func (m *mockRepo) GetMergeSubscribers(ctx context.Context, userIDs []string) ([]string, error) {
+	if m.GetMergeSubscribersFunc != nil {
+		return m.GetMergeSubscribersFunc(ctx, userIDs)
+	}
+	return nil, nil
+}
+func (m *mockRepo) CreateUserToken(ctx context.Context, userID, tokenHash string) error {
+	if m.CreateUserTokenFunc != nil {
+		return m.CreateUserTokenFunc(ctx, userID, tokenHash)
+	}
+	return nil
+}
+func (m *mockRepo) GetUserByToken(ctx context.Context, tokenHash string) (string, error) {
+	if m.GetUserByTokenFunc != nil {
+		return m.GetUserByTokenFunc(ctx, tokenHash)
+	}
+	return "", nil
+}
+func (m *mockRepo) QueueMerge(ctx context.Context, prID, mergedBy string, mergeAt time.Time) error {
+	if m.QueueMergeFunc != nil {
+		return m.QueueMergeFunc(ctx, prID, mergedBy, mergeAt)
+	}
+	return nil
+}
+func (m *mockRepo) GetDueMerges(ctx context.Context, now time.Time) ([]models.QueuedMerge, error) {
+	if m.GetDueMergesFunc != nil {
+		return m.GetDueMergesFunc(ctx, now)
+	}
+	return nil, nil
+}
+func (m *mockRepo) DequeueMerge(ctx context.Context, prID string) error {
+	if m.DequeueMergeFunc != nil {
+		return m.DequeueMergeFunc(ctx, prID)
+	}
+	return nil
+}
+func (m *mockRepo) SetPRLabels(ctx context.Context, prID string, labels []string) error {
+	if m.SetPRLabelsFunc != nil {
+		return m.SetPRLabelsFunc(ctx, prID, labels)
+	}
+	return nil
+}
+func (m *mockRepo) GetReviewLoadBySize(ctx context.Context, teamName string) ([]models.ReviewerLoad, error) {
+	if m.GetReviewLoadBySizeFunc != nil {
+		return m.GetReviewLoadBySizeFunc(ctx, teamName)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetTableStats(ctx context.Context) ([]models.TableStats, error) {
+	if m.GetTableStatsFunc != nil {
+		return m.GetTableStatsFunc(ctx)
+	}
+	return nil, nil
+}
+func (m *mockRepo) GetArchivablePRs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	if m.GetArchivablePRsFunc != nil {
+		return m.GetArchivablePRsFunc(ctx, before, limit)
+	}
+	return nil, nil
+}
+func (m *mockRepo) ArchivePR(ctx context.Context, a models.ArchivedPR) error {
+	if m.ArchivePRFunc != nil {
+		return m.ArchivePRFunc(ctx, a)
+	}
+	return nil
+}
+func (m *mockRepo) GetArchivedPR(ctx context.Context, prID string) (models.ArchivedPR, error) {
+	if m.GetArchivedPRFunc != nil {
+		return m.GetArchivedPRFunc(ctx, prID)
+	}
+	return models.ArchivedPR{}, nil
+}
+func (m *mockRepo) ListArchivedPRs(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.ArchivedPRShort, error) {
+	if m.ListArchivedPRsFunc != nil {
+		return m.ListArchivedPRsFunc(ctx, filter, page)
+	}
+	return nil, nil
+}
+func (m *mockRepo) RecordReviewDecision(ctx context.Context, prID, userID string, decision models.ReviewStatus, comment string, t time.Time) error {
+	if m.RecordReviewDecisionFunc != nil {
+		return m.RecordReviewDecisionFunc(ctx, prID, userID, decision, comment, t)
+	}
+	return nil
+}
+func (m *mockRepo) ForgetUser(ctx context.Context, userID string) (models.User, error) {
+	if m.ForgetUserFunc != nil {
+		return m.ForgetUserFunc(ctx, userID)
+	}
+	return models.User{}, nil
+}
+func (m *mockRepo) GetTeamStats(ctx context.Context, teamName string) (models.TeamStats, error) {
+	if m.GetTeamStatsFunc != nil {
+		return m.GetTeamStatsFunc(ctx, teamName)
+	}
+	return models.TeamStats{}, nil
+}
+func (m *mockRepo) GetRepositorySettings(ctx context.Context, repository string) (models.RepositorySettings, error) {
+	if m.GetRepositorySettingsFunc != nil {
+		return m.GetRepositorySettingsFunc(ctx, repository)
+	}
+	return models.RepositorySettings{}, nil
+}
+func (m *mockRepo) SaveRepositorySettings(ctx context.Context, settings models.RepositorySettings) error {
+	if m.SaveRepositorySettingsFunc != nil {
+		return m.SaveRepositorySettingsFunc(ctx, settings)
+	}
+	return nil
+}
+func (m *mockRepo) GetTeamMemberships(ctx context.Context, teamName string) ([]models.Membership, error) {
+	if m.GetTeamMembershipsFunc != nil {
+		return m.GetTeamMembershipsFunc(ctx, teamName)
+	}
+	return nil, nil
+}
+func (m *mockRepo) FindPR(ctx context.Context, repository, id string) (string, error) {
+	if m.FindPRFunc != nil {
+		return m.FindPRFunc(ctx, repository, id)
+	}
+	if _, err := m.GetPR(ctx, id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
 
 func newTestService(mockR *mockRepo) *service.PRService {
 	mockL := &dummyLogger{}
@@ -196,33 +765,162 @@ func TestSetUserActive(t *testing.T) {
 	}
 }
 
-func TestCreatePR(t *testing.T) {
+func TestForgetUser(t *testing.T) {
 	mockR := &mockRepo{}
 	svc := newTestService(mockR)
 
-	pr := models.PullRequest{
-		PullRequestID:   "pr1",
-		PullRequestName: "Test PR",
-		AuthorID:        "u1",
+	mockR.ForgetUserFunc = func(ctx context.Context, uid string) (models.User, error) {
+		if uid == "u1" {
+			return models.User{UserID: uid, Username: models.ForgottenUsername, TeamName: "backend"}, nil
+		}
+		return models.User{}, errors.New("not found")
 	}
 
-	call := 0
-	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
-		if call == 0 {
-			call++
-			return models.PullRequest{}, errors.New("not found")
-		}
-		return pr, nil
+	u, err := svc.ForgetUser(context.Background(), "u1")
+	if err != nil || u.UserID != "u1" || u.Username != models.ForgottenUsername || u.IsActive {
+		t.Fatalf("expected u1 anonymized and inactive, got %+v, err=%v", u, err)
+	}
+	if _, err := svc.ForgetUser(context.Background(), "uX"); err != service.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
 	}
+}
 
-	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
-		return "teamA", nil
+func TestSetUserActive_BackfillsUnderstaffedPRs(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.UpdateUserActiveFunc = func(ctx context.Context, uid string, active bool) (models.User, error) {
+		return models.User{UserID: uid, TeamName: "teamA", IsActive: active}, nil
 	}
-	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
-		return []string{"u2"}, nil
+	var queried string
+	mockR.GetUnderstaffedPRsFunc = func(ctx context.Context, teamName, userID string) ([]string, error) {
+		queried = teamName + "/" + userID
+		return []string{"pr1", "pr2"}, nil
 	}
-	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
-		return models.User{UserID: userID, IsActive: true}, nil
+	var added []string
+	mockR.AddReviewerFunc = func(ctx context.Context, prID, userID string, want int) (models.PullRequest, error) {
+		added = append(added, prID+":"+userID)
+		return models.PullRequest{PullRequestID: prID}, nil
+	}
+
+	if _, err := svc.SetUserActive(context.Background(), "u1", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queried != "" {
+		t.Fatalf("deactivation should not backfill, queried %q", queried)
+	}
+
+	if _, err := svc.SetUserActive(context.Background(), "u1", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queried != "teamA/u1" {
+		t.Fatalf("expected understaffed PRs of teamA for u1, queried %q", queried)
+	}
+	if len(added) != 2 || added[0] != "pr1:u1" || added[1] != "pr2:u1" {
+		t.Fatalf("expected u1 added to pr1 and pr2, got %v", added)
+	}
+}
+
+func TestSetUserSkills(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserProfileFunc = func(ctx context.Context, userID string) (models.UserProfile, error) {
+		if userID != "u1" {
+			return models.UserProfile{}, errors.New("not found")
+		}
+		return models.UserProfile{User: models.User{UserID: userID, TeamName: "teamA"}}, nil
+	}
+	var got []string
+	mockR.SetUserSkillsFunc = func(ctx context.Context, userID string, skills []string) error {
+		got = skills
+		return nil
+	}
+
+	if _, err := svc.SetUserSkills(context.Background(), "u1", []string{" DB", "go", "db"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(got, ",") != "db,go" {
+		t.Fatalf("expected normalized skills, got %v", got)
+	}
+
+	if _, err := svc.SetUserSkills(context.Background(), "uX", []string{"go"}); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSetVacation(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserProfileFunc = func(ctx context.Context, userID string) (models.UserProfile, error) {
+		if userID != "u1" {
+			return models.UserProfile{}, errors.New("not found")
+		}
+		return models.UserProfile{User: models.User{UserID: userID, TeamName: "teamA"}}, nil
+	}
+	var got *models.Vacation
+	calls := 0
+	mockR.SetVacationFunc = func(ctx context.Context, userID string, v *models.Vacation) error {
+		got = v
+		calls++
+		return nil
+	}
+	started := false
+	mockR.StartVacationsFunc = func(ctx context.Context, now time.Time) ([]string, error) {
+		started = true
+		return []string{"u1"}, nil
+	}
+
+	until := time.Now().Add(48 * time.Hour)
+	if _, err := svc.SetVacation(context.Background(), "u1", time.Time{}, until); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got == nil || !got.Until.Equal(until.UTC()) || got.From.IsZero() || !started {
+		t.Fatalf("expected vacation from now applied at once, got %+v (started=%v)", got, started)
+	}
+
+	if _, err := svc.SetVacation(context.Background(), "u1", time.Time{}, time.Time{}); err != nil || got != nil {
+		t.Fatalf("expected vacation cancelled, got %+v, err=%v", got, err)
+	}
+
+	_, err := svc.SetVacation(context.Background(), "u1", until, until.Add(-time.Hour))
+	if !errors.Is(err, service.ErrInvalidVacation) || calls != 2 {
+		t.Fatalf("expected ErrInvalidVacation, got %v", err)
+	}
+
+	if _, err := svc.SetVacation(context.Background(), "uX", time.Time{}, until); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCreatePR(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID:   "pr1",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	}
+
+	call := 0
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		if call == 0 {
+			call++
+			return models.PullRequest{}, errors.New("not found")
+		}
+		return pr, nil
+	}
+
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u2"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
 	}
 	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error { return nil }
 
@@ -242,16 +940,95 @@ func TestMergePR(t *testing.T) {
 	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
 		return models.PullRequest{PullRequestID: prID, Status: "OPEN"}, nil
 	}
-	mockR.MergePRFunc = func(ctx context.Context, prID string, t time.Time) (models.PullRequest, error) {
+	mockR.MergePRFunc = func(ctx context.Context, prID, mergedBy string, t time.Time) (models.PullRequest, error) {
 		return models.PullRequest{PullRequestID: prID, Status: "MERGED"}, nil
 	}
 
-	pr, err := svc.MergePR(context.Background(), "pr1")
+	pr, err := svc.MergePR(context.Background(), "pr1", "", false)
 	if err != nil || pr.Status != "MERGED" {
 		t.Fatalf("expected merged PR, got %v, err=%v", pr, err)
 	}
 }
 
+func TestMergePR_MergedBy(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, Status: models.StatusOpen}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		if userID != "u1" {
+			return models.User{}, errors.New("not found")
+		}
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	var mergedBy string
+	mockR.MergePRFunc = func(ctx context.Context, prID, by string, t time.Time) (models.PullRequest, error) {
+		mergedBy = by
+		return models.PullRequest{PullRequestID: prID, Status: models.StatusMerged, MergedBy: by}, nil
+	}
+
+	if _, err := svc.MergePR(context.Background(), "pr1", "ghost", false); !errors.Is(err, service.ErrUnknownMerger) {
+		t.Fatalf("expected ErrUnknownMerger, got %v", err)
+	}
+	if mergedBy != "" {
+		t.Fatalf("expected no merge by an unknown user, got one by %q", mergedBy)
+	}
+	pr, err := svc.MergePR(context.Background(), "pr1", "u1", false)
+	if err != nil || mergedBy != "u1" || pr.MergedBy != "u1" {
+		t.Fatalf("expected the merge recorded as by u1, got %+v, err=%v", pr, err)
+	}
+}
+
+func TestMergePR_ApprovalQuorum(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		TeamName:      "alpha",
+		Status:        models.StatusOpen,
+		Assigned: []models.PRReviewer{
+			{UserID: "u1", Status: models.ReviewApproved},
+			{UserID: "u2", Status: models.ReviewPending},
+		},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, RequiredApprovals: 2}, nil
+	}
+	merges := 0
+	mockR.MergePRFunc = func(ctx context.Context, prID, mergedBy string, t time.Time) (models.PullRequest, error) {
+		merges++
+		return models.PullRequest{PullRequestID: prID, Status: models.StatusMerged}, nil
+	}
+
+	if _, err := svc.MergePR(context.Background(), "pr1", "", false); !errors.Is(err, service.ErrNotEnoughApprovals) {
+		t.Fatalf("expected ErrNotEnoughApprovals, got %v", err)
+	}
+	// Merging is admin-only, so team tokens can't override either.
+	job := service.NewJob(service.WithScope(context.Background(), service.Scope{TeamName: "alpha"}), "merge_pr",
+		map[string]interface{}{"pr_id": "pr1", "override": true})
+	svc.EnqueueJob(job)
+	if res := <-job.RespCh; !errors.Is(res.Error, service.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for team token override, got %v", res.Error)
+	}
+	if merges != 0 {
+		t.Fatalf("expected no merge, got %d", merges)
+	}
+	if _, err := svc.MergePR(context.Background(), "pr1", "", true); err != nil {
+		t.Fatalf("expected admin override to merge, got %v", err)
+	}
+
+	pr.Assigned[1].Status = models.ReviewApproved
+	if _, err := svc.MergePR(context.Background(), "pr1", "", false); err != nil || merges != 2 {
+		t.Fatalf("expected merge with quorum, got %v, merges=%d", err, merges)
+	}
+}
+
 func TestReassign(t *testing.T) {
 	mockR := &mockRepo{}
 	svc := newTestService(mockR)
@@ -285,6 +1062,313 @@ func TestReassign(t *testing.T) {
 	}
 }
 
+func TestAssignReviewer(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "author",
+		Status:        models.StatusOpen,
+		Assigned:      []models.PRReviewer{{UserID: "u1", IsActive: true}},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, uid string) (models.User, error) {
+		return models.User{UserID: uid, IsActive: uid != "idle"}, nil
+	}
+	var gotWant int
+	mockR.AddReviewerFunc = func(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+		gotWant = wantReviewers
+		out := pr
+		out.Assigned = append([]models.PRReviewer{}, pr.Assigned...)
+		out.Assigned = append(out.Assigned, models.PRReviewer{UserID: userID, IsActive: true})
+		return out, nil
+	}
+
+	updated, err := svc.AssignReviewer(context.Background(), "pr1", "u2")
+	if err != nil || len(updated.Assigned) != 2 || gotWant != 2 {
+		t.Fatalf("unexpected result %+v, want=%d, err=%v", updated, gotWant, err)
+	}
+
+	cases := map[string]error{
+		"author": service.ErrAuthorReviewer,
+		"u1":     service.ErrAlreadyAssigned,
+		"idle":   service.ErrUserInactive,
+	}
+	for uid, want := range cases {
+		if _, err := svc.AssignReviewer(context.Background(), "pr1", uid); err != want {
+			t.Errorf("%s: expected %v, got %v", uid, want, err)
+		}
+	}
+
+	pr.Assigned = append(pr.Assigned, models.PRReviewer{UserID: "u3", IsActive: true})
+	if _, err := svc.AssignReviewer(context.Background(), "pr1", "u2"); err != service.ErrReviewersFull {
+		t.Fatalf("expected ErrReviewersFull, got %v", err)
+	}
+	pr.Status = models.StatusMerged
+	if _, err := svc.AssignReviewer(context.Background(), "pr1", "u2"); err != service.ErrPRMerged {
+		t.Fatalf("expected ErrPRMerged, got %v", err)
+	}
+}
+
+func TestAssignSecondaryReviewer(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	// Both required slots are taken; the secondary does not need one.
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "author",
+		Status:        models.StatusOpen,
+		Assigned: []models.PRReviewer{
+			{UserID: "u1", IsActive: true, Role: models.ReviewerRequired},
+			{UserID: "u2", IsActive: true, Role: models.ReviewerRequired},
+		},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, uid string) (models.User, error) {
+		return models.User{UserID: uid, IsActive: true}, nil
+	}
+	mockR.AddSecondaryReviewerFunc = func(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+		if userID == "u4" {
+			return models.PullRequest{}, errors.New("conflict")
+		}
+		out := pr
+		out.Assigned = append(append([]models.PRReviewer{}, pr.Assigned...), models.PRReviewer{UserID: userID, IsActive: true, Role: models.ReviewerSecondary})
+		return out, nil
+	}
+
+	updated, err := svc.AssignSecondaryReviewer(context.Background(), "pr1", "u3")
+	if err != nil || len(updated.Assigned) != 3 {
+		t.Fatalf("unexpected result %+v, err=%v", updated, err)
+	}
+	if _, err := svc.AssignSecondaryReviewer(context.Background(), "pr1", "u4"); err != service.ErrSecondaryTaken {
+		t.Fatalf("expected ErrSecondaryTaken, got %v", err)
+	}
+	if _, err := svc.AssignSecondaryReviewer(context.Background(), "pr1", "author"); err != service.ErrAuthorReviewer {
+		t.Fatalf("expected ErrAuthorReviewer, got %v", err)
+	}
+
+	// A secondary does not take one of the required slots either.
+	pr.Assigned = []models.PRReviewer{
+		{UserID: "u1", IsActive: true, Role: models.ReviewerRequired},
+		{UserID: "u3", IsActive: true, Role: models.ReviewerSecondary},
+	}
+	mockR.AddReviewerFunc = func(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+		return pr, nil
+	}
+	if _, err := svc.AssignReviewer(context.Background(), "pr1", "u2"); err != nil {
+		t.Fatalf("expected a free required slot, got %v", err)
+	}
+}
+
+func TestFillReviewers(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID:     "pr1",
+		AuthorID:          "author",
+		TeamName:          "alpha",
+		Status:            models.StatusOpen,
+		NeedMoreReviewers: true,
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	members := []string{"author", "u1", "u2", "u3"}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		if team != "alpha" {
+			t.Fatalf("expected PR team alpha, got %q", team)
+		}
+		return members, nil
+	}
+	added := []string{}
+	mockR.AddReviewerFunc = func(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+		added = append(added, userID)
+		out := pr
+		out.Assigned = make([]models.PRReviewer, len(added))
+		for i, id := range added {
+			out.Assigned[i] = models.PRReviewer{UserID: id, IsActive: true}
+		}
+		out.NeedMoreReviewers = len(added) < wantReviewers
+		return out, nil
+	}
+
+	updated, err := svc.FillReviewers(context.Background(), "pr1")
+	if err != nil || len(updated.Assigned) != 2 || updated.NeedMoreReviewers {
+		t.Fatalf("unexpected result %+v, err=%v", updated, err)
+	}
+	for _, id := range added {
+		if id == "author" {
+			t.Fatal("author must not be assigned")
+		}
+	}
+
+	members = []string{"author"}
+	if _, err := svc.FillReviewers(context.Background(), "pr1"); err != service.ErrNoCandidate {
+		t.Fatalf("expected ErrNoCandidate, got %v", err)
+	}
+
+	pr.Status = models.StatusClosed
+	if _, err := svc.FillReviewers(context.Background(), "pr1"); err != service.ErrPRClosed {
+		t.Fatalf("expected ErrPRClosed, got %v", err)
+	}
+}
+
+func TestRequestChanges(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "author",
+		TeamName:      "alpha",
+		Status:        models.StatusOpen,
+		Assigned:      []models.PRReviewer{{UserID: "u1", IsActive: true}, {UserID: "u2", IsActive: true}},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"author", "u1", "u2", "u3"}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, ReviewSLA: &models.ReviewSLA{Hours: 24}}, nil
+	}
+	var fresh, cause string
+	mockR.AddSecondaryReviewerFunc = func(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+		fresh = userID
+		c, _ := repo.AssignmentCauseFromContext(ctx)
+		cause = c.Reason
+		return pr, nil
+	}
+	mockR.StartReviewRoundFunc = func(ctx context.Context, prID, requestedBy, freshReviewer string, dueAt *time.Time) (models.ReviewRound, error) {
+		return models.ReviewRound{PullRequestID: prID, Round: 2, RequestedBy: requestedBy, FreshReviewer: freshReviewer, StartedAt: time.Now(), DueAt: dueAt}, nil
+	}
+
+	res, err := svc.RequestChanges(context.Background(), "pr1", "u1", 0, true)
+	if err != nil || res.Round == nil || res.Round.RequestedBy != "u1" {
+		t.Fatalf("unexpected result %+v, err=%v", res, err)
+	}
+	if fresh != "u3" || res.Round.FreshReviewer != "u3" || cause != "round" {
+		t.Fatalf("expected u3 as the fresh reviewer for the round, got %q (%q)", fresh, cause)
+	}
+	// Without hours the round is due after the team's SLA.
+	if res.Round.DueAt == nil || time.Until(*res.Round.DueAt) < 23*time.Hour {
+		t.Fatalf("expected the round due in 24h, got %v", res.Round.DueAt)
+	}
+
+	if _, err := svc.RequestChanges(context.Background(), "pr1", "u3", 0, false); err != service.ErrNotAssigned {
+		t.Fatalf("expected ErrNotAssigned, got %v", err)
+	}
+	if _, err := svc.RequestChanges(context.Background(), "pr1", "u1", -1, false); err != service.ErrInvalidRound {
+		t.Fatalf("expected ErrInvalidRound, got %v", err)
+	}
+	pr.Status = models.StatusMerged
+	if _, err := svc.RequestChanges(context.Background(), "pr1", "u1", 0, false); err != service.ErrPRMerged {
+		t.Fatalf("expected ErrPRMerged, got %v", err)
+	}
+}
+
+func TestSubmitReview(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "author",
+		TeamName:      "alpha",
+		Status:        models.StatusOpen,
+		Assigned:      []models.PRReviewer{{UserID: "u1", IsActive: true}, {UserID: "u2", IsActive: true}},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{}, errors.New("not found")
+	}
+	type decision struct {
+		userID   string
+		decision models.ReviewStatus
+		comment  string
+	}
+	var recorded []decision
+	mockR.RecordReviewDecisionFunc = func(ctx context.Context, prID, userID string, d models.ReviewStatus, comment string, t time.Time) error {
+		recorded = append(recorded, decision{userID, d, comment})
+		return nil
+	}
+	approved := false
+	mockR.ApprovePRFunc = func(ctx context.Context, prID, userID string, t time.Time) (models.PullRequest, error) {
+		approved = true
+		return pr, nil
+	}
+	var round *models.ReviewRound
+	mockR.StartReviewRoundFunc = func(ctx context.Context, prID, requestedBy, freshReviewer string, dueAt *time.Time) (models.ReviewRound, error) {
+		round = &models.ReviewRound{PullRequestID: prID, Round: 2, RequestedBy: requestedBy, DueAt: dueAt}
+		return *round, nil
+	}
+
+	if _, err := svc.SubmitReview(context.Background(), "pr1", "u1", models.ReviewApproved, "lgtm"); err != nil || !approved {
+		t.Fatalf("expected the PR approved, err=%v", err)
+	}
+	res, err := svc.SubmitReview(context.Background(), "pr1", "u2", models.ReviewChangesRequested, "needs tests")
+	if err != nil || res.Round == nil || round == nil || round.RequestedBy != "u2" || round.DueAt != nil {
+		t.Fatalf("expected a new round without deadline, got %+v, err=%v", res, err)
+	}
+	want := []decision{{"u1", models.ReviewApproved, "lgtm"}, {"u2", models.ReviewChangesRequested, "needs tests"}}
+	if len(recorded) != len(want) || recorded[0] != want[0] || recorded[1] != want[1] {
+		t.Fatalf("expected decisions %+v, got %+v", want, recorded)
+	}
+
+	if _, err := svc.SubmitReview(context.Background(), "pr1", "u1", models.ReviewPending, ""); !errors.Is(err, service.ErrInvalidReview) {
+		t.Fatalf("expected ErrInvalidReview, got %v", err)
+	}
+	if _, err := svc.SubmitReview(context.Background(), "pr1", "u3", models.ReviewApproved, ""); err != service.ErrNotAssigned {
+		t.Fatalf("expected ErrNotAssigned, got %v", err)
+	}
+}
+
+func TestRemoveReviewer(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "author",
+		Status:        models.StatusOpen,
+		Assigned:      []models.PRReviewer{{UserID: "u1", IsActive: true}, {UserID: "u2", IsActive: true}},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.RemoveReviewerFunc = func(ctx context.Context, prID, userID string, wantReviewers int) (models.PullRequest, error) {
+		if userID == "u9" {
+			return models.PullRequest{}, errors.New("not assigned")
+		}
+		out := pr
+		out.Assigned = []models.PRReviewer{{UserID: "u2", IsActive: true}}
+		out.NeedMoreReviewers = true
+		return out, nil
+	}
+
+	updated, err := svc.RemoveReviewer(context.Background(), "pr1", "u1")
+	if err != nil || len(updated.Assigned) != 1 || !updated.NeedMoreReviewers {
+		t.Fatalf("unexpected result %+v, err=%v", updated, err)
+	}
+	if _, err := svc.RemoveReviewer(context.Background(), "pr1", "u9"); err != service.ErrNotAssigned {
+		t.Fatalf("expected ErrNotAssigned, got %v", err)
+	}
+	pr.Status = models.StatusMerged
+	if _, err := svc.RemoveReviewer(context.Background(), "pr1", "u1"); err != service.ErrPRMerged {
+		t.Fatalf("expected ErrPRMerged, got %v", err)
+	}
+}
+
 func TestGetStats(t *testing.T) {
 	mockR := &mockRepo{}
 	svc := newTestService(mockR)
@@ -356,11 +1440,39 @@ func TestEnqueueJob_Stopped(t *testing.T) {
 	}
 }
 
-func TestFullQueue(t *testing.T) {
+func TestEnqueueJob_ConcurrentStop(t *testing.T) {
 	mockR := &mockRepo{}
 	svc := newTestService(mockR)
 
-	block := make(chan struct{})
+	var wg sync.WaitGroup
+	results := make(chan service.JobResult, 400)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				svc.EnqueueJob(service.Job{Type: "get_team", Payload: map[string]interface{}{"team": "alpha"}, RespCh: results})
+			}
+		}()
+	}
+	svc.StopWorkers()
+	wg.Wait()
+
+	// Every job is answered, by a worker or canceled, and none panics.
+	for i := 0; i < 400; i++ {
+		select {
+		case <-results:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of 400 jobs answered", i)
+		}
+	}
+}
+
+func TestFullQueue(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	block := make(chan struct{})
 
 	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
 		<-block
@@ -397,3 +1509,3403 @@ DONE:
 		t.Fatalf("expected at least one task to be dropped with ErrJobQueueFull (tried %d enqueues)", maxAttempts)
 	}
 }
+
+func TestTeamToken(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	stored := map[string]string{}
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name}, nil
+	}
+	mockR.CreateTeamTokenFunc = func(ctx context.Context, teamName, tokenHash string) error {
+		stored[tokenHash] = teamName
+		return nil
+	}
+	mockR.GetTeamByTokenFunc = func(ctx context.Context, tokenHash string) (string, error) {
+		if team, ok := stored[tokenHash]; ok {
+			return team, nil
+		}
+		return "", errors.New("not found")
+	}
+	mockR.GetUserByTokenFunc = func(ctx context.Context, tokenHash string) (string, error) {
+		return "", errors.New("not found")
+	}
+
+	token, err := svc.IssueTeamToken(context.Background(), "alpha")
+	if err != nil || token == "" {
+		t.Fatalf("expected token, got %q, err=%v", token, err)
+	}
+	if _, ok := stored[token]; ok {
+		t.Fatalf("expected token to be stored hashed")
+	}
+
+	scope, err := svc.ResolveTokenScope(context.Background(), token)
+	if err != nil || scope.TeamName != "alpha" {
+		t.Fatalf("expected alpha scope, got %v, err=%v", scope, err)
+	}
+
+	_, err = svc.ResolveTokenScope(context.Background(), "bogus")
+	if err != service.ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+
+	scoped := service.WithScope(context.Background(), scope)
+	_, err = svc.IssueTeamToken(scoped, "alpha")
+	if err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for scoped issuer, got %v", err)
+	}
+}
+
+func TestUserToken(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	stored := map[string]string{}
+	mockR.CreateUserTokenFunc = func(ctx context.Context, userID, tokenHash string) error {
+		stored[tokenHash] = userID
+		return nil
+	}
+	mockR.GetTeamByTokenFunc = func(ctx context.Context, tokenHash string) (string, error) {
+		return "", errors.New("not found")
+	}
+	mockR.GetUserByTokenFunc = func(ctx context.Context, tokenHash string) (string, error) {
+		if uid, ok := stored[tokenHash]; ok {
+			return uid, nil
+		}
+		return "", errors.New("not found")
+	}
+	mockR.GetUserStatsFunc = func(ctx context.Context, userID string, since time.Time) (models.UserStats, error) {
+		return models.UserStats{User: models.User{UserID: userID, TeamName: "alpha"}}, nil
+	}
+	mockR.GetPRsByReviewerFunc = func(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+		return []models.PullRequestShort{{PullRequestID: "pr-" + userID, Status: models.StatusOpen}}, nil
+	}
+
+	token, err := svc.IssueUserToken(context.Background(), "u2")
+	if err != nil || token == "" {
+		t.Fatalf("expected token, got %q, err=%v", token, err)
+	}
+	scope, err := svc.ResolveTokenScope(context.Background(), token)
+	if err != nil || scope.UserID != "u2" || scope.TeamName != "" {
+		t.Fatalf("expected u2 scope, got %+v, err=%v", scope, err)
+	}
+	ctx := service.WithScope(context.Background(), scope)
+
+	stats, err := svc.MyStats(ctx, 4)
+	if err != nil || stats.UserID != "u2" {
+		t.Fatalf("expected own stats, got %+v, err=%v", stats, err)
+	}
+	prs, err := svc.MyReviews(ctx)
+	if err != nil || len(prs) != 1 || prs[0].PullRequestID != "pr-u2" {
+		t.Fatalf("expected own reviews, got %+v, err=%v", prs, err)
+	}
+	if _, err := svc.MyStats(context.Background(), 4); err != service.ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized without a user token, got %v", err)
+	}
+	teamCtx := service.WithScope(context.Background(), service.Scope{TeamName: "alpha"})
+	if _, err := svc.MyReviews(teamCtx); err != service.ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized for a team token, got %v", err)
+	}
+
+	job := service.Job{
+		Ctx:     ctx,
+		Type:    "get_user_stats",
+		Payload: map[string]interface{}{"uid": "u3", "weeks": 4},
+		RespCh:  make(chan service.JobResult, 1),
+	}
+	svc.EnqueueJob(job)
+	if res := <-job.RespCh; res.Error != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for another user's stats, got %v", res.Error)
+	}
+	if _, err := svc.IssueUserToken(ctx, "u3"); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for scoped issuer, got %v", err)
+	}
+}
+
+func TestTeamScope(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "alpha"})
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "beta", nil
+	}
+	_, err := svc.CreatePR(ctx, models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for foreign author, got %v", err)
+	}
+
+	mockR.GetReviewerStatsFunc = func(ctx context.Context) (map[string]int, error) {
+		return map[string]int{"u1": 3, "u9": 7}, nil
+	}
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name, Members: []models.TeamMember{{UserID: "u1"}}}, nil
+	}
+	stats, err := svc.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats) != 1 || stats["u1"] != 3 {
+		t.Fatalf("expected stats limited to team, got %v", stats)
+	}
+
+	job := service.Job{
+		Ctx:     ctx,
+		Type:    "get_team",
+		Payload: map[string]interface{}{"team": "alpha"},
+		RespCh:  make(chan service.JobResult, 1),
+	}
+	svc.EnqueueJob(job)
+	if res := <-job.RespCh; res.Error != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for out-of-scope job, got %v", res.Error)
+	}
+}
+
+func TestEnqueueJob_NeverBlocksWorker(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	abandoned := service.NewJob(ctx, "get_team", map[string]interface{}{"team": "alpha"})
+	svc.EnqueueJob(abandoned)
+
+	full := service.NewJob(context.Background(), "get_team", map[string]interface{}{"team": "beta"})
+	full.RespCh <- service.JobResult{Data: "stale"}
+	svc.EnqueueJob(full)
+
+	done := make(chan struct{})
+	go func() {
+		svc.StopWorkers()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("workers blocked on result delivery")
+	}
+
+	if res := <-abandoned.RespCh; res.Error != context.Canceled {
+		t.Fatalf("expected context.Canceled for abandoned job, got %v", res.Error)
+	}
+	if res := <-full.RespCh; res.Data != "stale" {
+		t.Fatalf("expected pre-filled result to be kept, got %v", res.Data)
+	}
+}
+
+func TestApprovePR(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		Status:        "OPEN",
+		Assigned:      []models.PRReviewer{{UserID: "u2", IsActive: true}},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.ApprovePRFunc = func(ctx context.Context, prID, userID string, at time.Time) (models.PullRequest, error) {
+		approved := pr
+		approved.Approvals = []models.PRApproval{{UserID: userID, ApprovedAt: at}}
+		return approved, nil
+	}
+
+	approved, err := svc.ApprovePR(context.Background(), "pr1", "u2")
+	if err != nil || len(approved.Approvals) != 1 || approved.Approvals[0].UserID != "u2" {
+		t.Fatalf("expected approval by u2, got %v, err=%v", approved.Approvals, err)
+	}
+
+	_, err = svc.ApprovePR(context.Background(), "pr1", "u3")
+	if err != service.ErrNotAssigned {
+		t.Fatalf("expected ErrNotAssigned, got %v", err)
+	}
+
+	pr.Status = "MERGED"
+	_, err = svc.ApprovePR(context.Background(), "pr1", "u2")
+	if err != service.ErrPRMerged {
+		t.Fatalf("expected ErrPRMerged, got %v", err)
+	}
+}
+
+func TestDeactivateTeam_LoadsEachEntityOnce(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name, Members: []models.TeamMember{{UserID: "u1"}, {UserID: "u2"}}}, nil
+	}
+	mockR.GetPRsByReviewerFunc = func(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+		return []models.PullRequestShort{{PullRequestID: "pr1"}}, nil
+	}
+
+	prLoads, userLoads := 0, 0
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		prLoads++
+		return models.PullRequest{
+			PullRequestID: prID,
+			Status:        "OPEN",
+			Assigned:      []models.PRReviewer{{UserID: "u1"}, {UserID: "u2"}},
+		}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		userLoads++
+		return models.User{UserID: userID, IsActive: false}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return nil, nil
+	}
+
+	if err := svc.DeactivateTeam(context.Background(), "alpha"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prLoads != 1 {
+		t.Fatalf("expected PR to be loaded once, got %d", prLoads)
+	}
+	if userLoads != 2 {
+		t.Fatalf("expected each reviewer to be loaded once, got %d loads", userLoads)
+	}
+}
+
+func TestPreviewDeactivation(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name, Members: []models.TeamMember{{UserID: "a1", IsActive: true}, {UserID: "a2", IsActive: true}}}, nil
+	}
+	mockR.GetPRsByReviewerFunc = func(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+		if userID == "a1" {
+			return []models.PullRequestShort{{PullRequestID: "pr1"}, {PullRequestID: "pr2"}, {PullRequestID: "pr3"}}, nil
+		}
+		return []models.PullRequestShort{{PullRequestID: "pr2"}}, nil
+	}
+	prs := map[string]models.PullRequest{
+		// beta still has b3 to take over from a1.
+		"pr1": {PullRequestID: "pr1", TeamName: "beta", AuthorID: "b2", Status: models.StatusInReview,
+			Assigned: []models.PRReviewer{{UserID: "a1"}, {UserID: "b1"}}},
+		// Nobody outside alpha is left to review alpha's own PR.
+		"pr2": {PullRequestID: "pr2", TeamName: "alpha", AuthorID: "a3", Status: models.StatusOpen,
+			Assigned: []models.PRReviewer{{UserID: "a1"}, {UserID: "a2"}}},
+		"pr3": {PullRequestID: "pr3", TeamName: "beta", Status: models.StatusMerged,
+			Assigned: []models.PRReviewer{{UserID: "a1"}}},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return prs[prID], nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		if team == "alpha" {
+			return []string{"a1", "a2", "a3"}, nil
+		}
+		return []string{"b1", "b2", "b3"}, nil
+	}
+	deactivated := false
+	mockR.SetTeamActiveFunc = func(ctx context.Context, teamName string, isActive bool) error {
+		deactivated = true
+		return nil
+	}
+
+	preview, err := svc.PreviewDeactivation(context.Background(), "alpha")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deactivated {
+		t.Fatal("expected preview to leave the team active")
+	}
+	if preview.Members != 2 || preview.PRs != 2 || preview.Reviews != 3 || preview.Reassignable != 1 || preview.Enough {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+	if len(preview.Stranded) != 1 || preview.Stranded[0].PullRequestID != "pr2" || preview.Stranded[0].Missing != 2 {
+		t.Fatalf("expected pr2 stranded without 2 reviewers, got %+v", preview.Stranded)
+	}
+	if preview.Candidates["beta"] != 3 || preview.Candidates["alpha"] != 1 {
+		t.Fatalf("unexpected candidates %v", preview.Candidates)
+	}
+
+	// a3 only reviews for alpha as an extra member and is demoted with it.
+	mockR.GetTeamMembershipsFunc = func(ctx context.Context, teamName string) ([]models.Membership, error) {
+		return []models.Membership{{TeamName: teamName, UserID: "a3", Role: models.RoleMember}}, nil
+	}
+	preview, err = svc.PreviewDeactivation(context.Background(), "alpha")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if preview.Members != 2 || preview.Candidates["alpha"] != 0 || preview.Candidates["beta"] != 3 {
+		t.Fatalf("expected extra member dropped from alpha candidates, got %+v", preview)
+	}
+}
+
+func TestClosePR(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	status := models.StatusOpen
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, Status: status, Assigned: []models.PRReviewer{{UserID: "u2", IsActive: true}}}, nil
+	}
+	mockR.ClosePRFunc = func(ctx context.Context, prID string, at time.Time) (models.PullRequest, error) {
+		status = models.StatusClosed
+		return models.PullRequest{PullRequestID: prID, Status: status, ClosedAt: &at}, nil
+	}
+
+	pr, err := svc.ClosePR(context.Background(), "pr1")
+	if err != nil || pr.Status != "CLOSED" || pr.ClosedAt == nil {
+		t.Fatalf("expected closed PR, got %v, err=%v", pr, err)
+	}
+
+	_, _, err = svc.Reassign(context.Background(), "pr1", "u2")
+	if err != service.ErrPRClosed {
+		t.Fatalf("expected ErrPRClosed on reassign, got %v", err)
+	}
+
+	_, err = svc.MergePR(context.Background(), "pr1", "", false)
+	if !errors.Is(err, service.ErrPRClosed) {
+		t.Fatalf("expected ErrPRClosed on merge, got %v", err)
+	}
+
+	status = "MERGED"
+	_, err = svc.ClosePR(context.Background(), "pr1")
+	if !errors.Is(err, service.ErrPRMerged) {
+		t.Fatalf("expected ErrPRMerged, got %v", err)
+	}
+}
+
+func TestMergeAndClose_LostRace(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	// The PR reads as open, then another request finishes it before the write.
+	var reads int
+	var final models.PRStatus
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		reads++
+		if reads == 1 {
+			return models.PullRequest{PullRequestID: prID, Status: models.StatusOpen}, nil
+		}
+		return models.PullRequest{PullRequestID: prID, Status: final}, nil
+	}
+	mockR.MergePRFunc = func(ctx context.Context, prID, mergedBy string, at time.Time) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("conflict: pr " + prID + " is " + string(final))
+	}
+	mockR.ClosePRFunc = func(ctx context.Context, prID string, at time.Time) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("conflict: pr " + prID + " is " + string(final))
+	}
+
+	reads, final = 0, models.StatusMerged
+	if _, err := svc.ClosePR(context.Background(), "pr1"); !errors.Is(err, service.ErrPRMerged) {
+		t.Fatalf("expected close racing a merge to fail with ErrPRMerged, got %v", err)
+	}
+	reads, final = 0, models.StatusClosed
+	if _, err := svc.MergePR(context.Background(), "pr1", "", false); !errors.Is(err, service.ErrPRClosed) {
+		t.Fatalf("expected merge racing a close to fail with ErrPRClosed, got %v", err)
+	}
+	reads, final = 0, models.StatusMerged
+	if pr, err := svc.MergePR(context.Background(), "pr1", "", false); err != nil || pr.Status != models.StatusMerged {
+		t.Fatalf("expected concurrent merge to return the merged PR, got %+v, err=%v", pr, err)
+	}
+}
+
+func TestCreatePR_StoresTeam(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if stored.TeamName != "teamA" {
+		t.Fatalf("expected PR team teamA, got %q", stored.TeamName)
+	}
+}
+
+func TestCreatePR_Repository(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	// keys maps "<repository> <id>" to the PR's key, as the repo layer does.
+	keys := map[string]string{" 42": "42"}
+	prs := map[string]models.PullRequest{"42": {PullRequestID: "42", ExternalID: "42"}}
+	mockR.FindPRFunc = func(ctx context.Context, repository, id string) (string, error) {
+		if key, ok := keys[repository+" "+id]; ok {
+			return key, nil
+		}
+		return "", errors.New("not found")
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		if pr, ok := prs[prID]; ok {
+			return pr, nil
+		}
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		pr.ExternalID = pr.PullRequestID
+		if _, taken := prs[pr.PullRequestID]; taken {
+			pr.PullRequestID = pr.Repository + "#" + pr.PullRequestID
+		}
+		keys[pr.Repository+" "+pr.ExternalID] = pr.PullRequestID
+		prs[pr.PullRequestID] = pr
+		return nil
+	}
+
+	pr, err := svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "web-42", Repository: "org/web", PullRequestName: "x", AuthorID: "u1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pr.PullRequestID != "web-42" || pr.Repository != "org/web" {
+		t.Fatalf("expected ID kept as sent, got %+v", pr)
+	}
+	// IDs are unique per repository only.
+	pr, err = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "42", Repository: "org/api", PullRequestName: "x", AuthorID: "u1"})
+	if err != nil {
+		t.Fatalf("expected the ID reusable in another repository, got %v", err)
+	}
+	if pr.ExternalID != "42" || pr.PullRequestID != "org/api#42" {
+		t.Fatalf("expected the PR keyed apart from the other 42, got %+v", pr)
+	}
+	if _, err := svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "42", Repository: "org/api", PullRequestName: "x", AuthorID: "u1"}); err != service.ErrPRExists {
+		t.Fatalf("expected ErrPRExists, got %v", err)
+	}
+	if _, err := svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "org/web#43", PullRequestName: "x", AuthorID: "u1"}); err != nil {
+		t.Fatalf("expected an ID with # accepted, got %v", err)
+	}
+}
+
+func TestPRJobs_RepositoryScopedID(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	keys := map[string]string{" 42": "42", "org/api 42": "org/api#42"}
+	mockR.FindPRFunc = func(ctx context.Context, repository, id string) (string, error) {
+		if key, ok := keys[repository+" "+id]; ok {
+			return key, nil
+		}
+		return "", errors.New("not found")
+	}
+	var gotKey string
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		gotKey = prID
+		external := prID
+		if i := strings.LastIndex(prID, "#"); i >= 0 {
+			external = prID[i+1:]
+		}
+		return models.PullRequest{PullRequestID: prID, ExternalID: external, AuthorID: "author", Status: models.StatusOpen}, nil
+	}
+
+	run := func(payload map[string]interface{}) (models.PullRequest, error) {
+		job := service.NewJob(service.WithBlindReviewBypass(context.Background()), "get_pr", payload)
+		svc.EnqueueJob(job)
+		res := <-job.RespCh
+		pr, _ := res.Data.(models.PullRequest)
+		return pr, res.Error
+	}
+
+	pr, err := run(map[string]interface{}{"pr_id": "42", "repository": "org/api"})
+	if err != nil || gotKey != "org/api#42" || pr.PullRequestID != "42" {
+		t.Fatalf("expected org/api's 42 shown as 42, got key %q pr %+v err %v", gotKey, pr, err)
+	}
+	if _, err := run(map[string]interface{}{"pr_id": "42"}); err != nil || gotKey != "42" {
+		t.Fatalf("expected the 42 without repository, got key %q err %v", gotKey, err)
+	}
+	if _, err := run(map[string]interface{}{"pr_id": "42", "repository": "org/web"}); err != service.ErrNotFound {
+		t.Fatalf("expected ErrNotFound in a repository without the ID, got %v", err)
+	}
+	// Keys stored by older releases still resolve without a repository.
+	if _, err := run(map[string]interface{}{"pr_id": "org/web#7"}); err != nil || gotKey != "org/web#7" {
+		t.Fatalf("expected the key looked up directly, got key %q err %v", gotKey, err)
+	}
+}
+
+func TestCreatePR_ArchivedID(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		return errors.New("conflict: pr " + pr.PullRequestID + " is archived")
+	}
+
+	_, err := svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if err != service.ErrPRExists {
+		t.Fatalf("expected ErrPRExists for an archived ID, got %v", err)
+	}
+}
+
+func TestCreatePR_DefaultReviewers(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, DefaultReviewers: []string{"owner", "u1"}}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"owner", "u2"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "owner" || stored.Assigned[1].UserID != "u2" {
+		t.Fatalf("expected owner plus one random pick, got %+v", stored.Assigned)
+	}
+}
+
+func TestCreatePR_RepositoryDefaultReviewers(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetRepositorySettingsFunc = func(ctx context.Context, repository string) (models.RepositorySettings, error) {
+		if repository != "org/platform" {
+			return models.RepositorySettings{}, errors.New("not found")
+		}
+		return models.RepositorySettings{Repository: repository, DefaultReviewers: []string{"platform-owner"}}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, DefaultReviewers: []string{"platform-owner", "team-owner"}}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u2"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", Repository: "org/platform", PullRequestName: "x", AuthorID: "u1"})
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "platform-owner" || stored.Assigned[1].UserID != "team-owner" {
+		t.Fatalf("expected the repository's then the team's defaults, got %+v", stored.Assigned)
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr2", Repository: "org/web", PullRequestName: "x", AuthorID: "platform-owner"})
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "team-owner" || stored.Assigned[1].UserID != "u2" {
+		t.Fatalf("expected the team's default plus one random pick, got %+v", stored.Assigned)
+	}
+}
+
+func TestUpdateRepositorySettings(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		if userID == "ghost" {
+			return models.User{}, errors.New("not found")
+		}
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	var saved models.RepositorySettings
+	mockR.SaveRepositorySettingsFunc = func(ctx context.Context, settings models.RepositorySettings) error {
+		saved = settings
+		return nil
+	}
+	mockR.GetRepositorySettingsFunc = func(ctx context.Context, repository string) (models.RepositorySettings, error) {
+		return saved, nil
+	}
+
+	got, err := svc.UpdateRepositorySettings(context.Background(), models.RepositorySettings{Repository: "org/api", DefaultReviewers: []string{"owner"}})
+	if err != nil || len(got.DefaultReviewers) != 1 || got.DefaultReviewers[0] != "owner" {
+		t.Fatalf("unexpected settings %+v, err=%v", got, err)
+	}
+	if _, err := svc.UpdateRepositorySettings(context.Background(), models.RepositorySettings{Repository: "org/api", DefaultReviewers: []string{"ghost"}}); !errors.Is(err, service.ErrInvalidSettings) {
+		t.Fatalf("expected ErrInvalidSettings for an unknown reviewer, got %v", err)
+	}
+	scoped := service.WithScope(context.Background(), service.Scope{TeamName: "alpha"})
+	if _, err := svc.UpdateRepositorySettings(scoped, models.RepositorySettings{Repository: "org/api"}); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for a team token, got %v", err)
+	}
+}
+
+func TestAssignmentHistory_BlindReview(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, AuthorID: "author", TeamName: "teamA", Status: models.StatusOpen}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, BlindReview: true}, nil
+	}
+	mockR.GetAssignmentHistoryFunc = func(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+		return []models.AssignmentEvent{{PullRequestID: prID, UserID: "u2", Action: models.EventAssigned, Reason: "random"}}, nil
+	}
+
+	got, err := svc.AssignmentHistory(service.WithCaller(context.Background(), "author"), "pr1")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("expected an empty history for the blind author, got %v, err=%v", got, err)
+	}
+	got, err = svc.AssignmentHistory(service.WithCaller(context.Background(), "u3"), "pr1")
+	if err != nil || len(got) != 1 {
+		t.Fatalf("expected the history for another user, got %v, err=%v", got, err)
+	}
+	got, err = svc.AssignmentHistory(context.Background(), "pr1")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("expected an empty history without a caller, got %v, err=%v", got, err)
+	}
+	got, err = svc.AssignmentHistory(service.WithBlindReviewBypass(context.Background()), "pr1")
+	if err != nil || len(got) != 1 {
+		t.Fatalf("expected the history with the admin bypass, got %v, err=%v", got, err)
+	}
+}
+
+func TestCreatePR_RecordsAssignmentReasons(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, DefaultReviewers: []string{"owner"}}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"owner", "u2"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	var cause repo.AssignmentCause
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		cause, _ = repo.AssignmentCauseFromContext(ctx)
+		return errors.New("stop")
+	}
+
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "teamA"})
+	_, _ = svc.CreatePR(ctx, models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if cause.TriggeredBy != "team:teamA" || cause.Reasons["owner"] != "default" {
+		t.Fatalf("expected owner as default by team:teamA, got %+v", cause)
+	}
+	if _, ok := cause.Reasons["u2"]; ok || cause.Reason != "random" {
+		t.Fatalf("expected u2 to fall back to random, got %+v", cause)
+	}
+}
+
+func TestCreatePR_CoAuthorsLast(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"pair", "busy", "helper"}, nil
+	}
+	mockR.GetOpenReviewLoadFunc = func(ctx context.Context, teamName string) (map[string]int, error) {
+		return map[string]int{"busy": 9, "helper": 1}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	var stored models.PullRequest
+	var cause repo.AssignmentCause
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		cause, _ = repo.AssignmentCauseFromContext(ctx)
+		return errors.New("stop")
+	}
+
+	// The idle co-author loses to the busy independent reviewer, but still
+	// fills the slot nobody independent is left for.
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1", CoAuthors: []string{"pair", "helper"}})
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "busy" || stored.Assigned[1].UserID != "pair" {
+		t.Fatalf("expected busy then the least loaded co-author, got %+v", stored.Assigned)
+	}
+	if _, ok := cause.Reasons["busy"]; ok || cause.Reasons["pair"] != "co_author" {
+		t.Fatalf("expected pair recorded as co_author, got %+v", cause.Reasons)
+	}
+}
+
+func TestCreatePR_StickyReviewers(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithStickyReviewers(14*24*time.Hour))
+	defer svc.StopWorkers()
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u2", "u3", "u4", "idle"}, nil
+	}
+	mockR.GetOpenReviewLoadFunc = func(ctx context.Context, teamName string) (map[string]int, error) {
+		return map[string]int{"u2": 5, "u3": 5, "u4": 5}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: userID != "u4"}, nil
+	}
+	// "gone" left the team and u4 is inactive; neither may be picked.
+	mockR.GetRecentReviewersFunc = func(ctx context.Context, authorID string, since time.Time) ([]string, error) {
+		if time.Since(since) < 13*24*time.Hour {
+			t.Errorf("expected a 14 day lookback, got %v", since)
+		}
+		return []string{"gone", "u4", "u3"}, nil
+	}
+	var stored models.PullRequest
+	var cause repo.AssignmentCause
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		cause, _ = repo.AssignmentCauseFromContext(ctx)
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "u3" || cause.Reasons["u3"] != "sticky" {
+		t.Fatalf("expected u3 kept as a sticky reviewer, got %+v (%+v)", stored.Assigned, cause)
+	}
+	// The other slot still goes to the least loaded candidate.
+	if stored.Assigned[1].UserID != "idle" {
+		t.Fatalf("expected idle in the other slot, got %+v", stored.Assigned)
+	}
+}
+
+func TestRegenerateRotation(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithRandSource(lastRand{}))
+	defer svc.StopWorkers()
+
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u1", "u2", "u3"}, nil
+	}
+	var saved []models.RotationWeek
+	mockR.SaveRotationFunc = func(ctx context.Context, teamName string, weeks []models.RotationWeek) error {
+		saved = weeks
+		return nil
+	}
+
+	rot, err := svc.RegenerateRotation(context.Background(), "alpha", 0)
+	if err != nil || len(rot.Weeks) != 4 || len(saved) != 4 {
+		t.Fatalf("expected four weeks, got %+v, err=%v", rot, err)
+	}
+	// Members are dealt out two a week, wrapping around.
+	want := [][]string{{"u1", "u2"}, {"u3", "u1"}, {"u2", "u3"}, {"u1", "u2"}}
+	for i, w := range rot.Weeks {
+		if strings.Join(w.Reviewers, ",") != strings.Join(want[i], ",") {
+			t.Fatalf("week %d: expected %v, got %v", i, want[i], w.Reviewers)
+		}
+		if w.WeekStart.Weekday() != time.Monday || (i > 0 && w.WeekStart.Sub(rot.Weeks[i-1].WeekStart) != 7*24*time.Hour) {
+			t.Fatalf("expected consecutive Mondays, got %v", rot.Weeks)
+		}
+	}
+
+	if _, err := svc.RegenerateRotation(context.Background(), "alpha", 27); err != service.ErrInvalidRotation {
+		t.Fatalf("expected ErrInvalidRotation, got %v", err)
+	}
+	scoped := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.RegenerateRotation(scoped, "alpha", 0); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestCreatePR_Rotation(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithRandSource(lastRand{}))
+	defer svc.StopWorkers()
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, ReviewRotation: true}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		out := []string{}
+		for _, id := range []string{"u1", "u2", "u3", "u4"} {
+			if id != exclude {
+				out = append(out, id)
+			}
+		}
+		return out, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	// Without a rotation for this week one is generated: u1 and u2 first.
+	var saved []models.RotationWeek
+	mockR.SaveRotationFunc = func(ctx context.Context, teamName string, weeks []models.RotationWeek) error {
+		saved = weeks
+		return nil
+	}
+	var stored models.PullRequest
+	var cause repo.AssignmentCause
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		cause, _ = repo.AssignmentCauseFromContext(ctx)
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if len(saved) == 0 {
+		t.Fatal("expected the rotation to be generated")
+	}
+	// The author is on duty, so only their partner comes from the rotation.
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "u2" || cause.Reasons["u2"] != "rotation" {
+		t.Fatalf("expected u2 from the rotation, got %+v (%+v)", stored.Assigned, cause)
+	}
+	if _, ok := cause.Reasons[stored.Assigned[1].UserID]; ok {
+		t.Fatalf("expected the other slot picked at random, got %+v", cause)
+	}
+}
+
+func TestCreatePR_PrefersLeastLoaded(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"busy", "u2", "u3", "idle"}, nil
+	}
+	mockR.GetOpenReviewLoadFunc = func(ctx context.Context, teamName string) (map[string]int, error) {
+		return map[string]int{"busy": 5, "u2": 1, "u3": 2}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	for i := 0; i < 20; i++ {
+		_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+		if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "idle" || stored.Assigned[1].UserID != "u2" {
+			t.Fatalf("expected idle and u2, got %+v", stored.Assigned)
+		}
+	}
+}
+
+// lastRand always draws the highest value.
+type lastRand struct{}
+
+func (lastRand) Intn(n int) (int, error) { return n - 1, nil }
+
+func TestCreatePR_RandSource(t *testing.T) {
+	mockR := &mockRepo{}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u2", "u3", "u4", "u5"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+	create := func(svc *service.PRService) []string {
+		_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+		ids := []string{}
+		for _, r := range stored.Assigned {
+			ids = append(ids, r.UserID)
+		}
+		return ids
+	}
+
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithRandSource(lastRand{}))
+	defer svc.StopWorkers()
+	if got := create(svc); len(got) != 2 || got[0] != "u5" || got[1] != "u4" {
+		t.Fatalf("expected u5 and u4, got %v", got)
+	}
+
+	a := service.NewService(mockR, &dummyLogger{}, service.WithRandSource(service.NewSeededRand(42)))
+	defer a.StopWorkers()
+	b := service.NewService(mockR, &dummyLogger{}, service.WithRandSource(service.NewSeededRand(42)))
+	defer b.StopWorkers()
+	for i := 0; i < 5; i++ {
+		if x, y := create(a), create(b); strings.Join(x, ",") != strings.Join(y, ",") {
+			t.Fatalf("same seed picked %v and %v", x, y)
+		}
+	}
+}
+
+func TestCreatePR_Reservations(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u2", "u3", "u4"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	// u2 and u3 are held for u1's upcoming PR.
+	mockR.GetReservationsFunc = func(ctx context.Context, now time.Time) (map[string]string, error) {
+		return map[string]string{"u2": "u1", "u3": "u1"}, nil
+	}
+	released := ""
+	mockR.ReserveReviewersFunc = func(ctx context.Context, authorID string, userIDs []string, until time.Time) error {
+		if len(userIDs) == 0 {
+			released = authorID
+		}
+		return nil
+	}
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return nil
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, err := svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u5"}); err == nil {
+			t.Fatal("expected the fake GetPR to fail after create")
+		}
+		if len(stored.Assigned) != 1 || stored.Assigned[0].UserID != "u4" {
+			t.Fatalf("expected only u4 for another author, got %+v", stored.Assigned)
+		}
+	}
+	if released != "" {
+		t.Fatalf("another author's PR released %q", released)
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr2", PullRequestName: "big", AuthorID: "u1"})
+	got := map[string]bool{}
+	for _, r := range stored.Assigned {
+		got[r.UserID] = true
+	}
+	if len(got) != 2 || !got["u2"] || !got["u3"] {
+		t.Fatalf("expected the reserved u2 and u3, got %+v", stored.Assigned)
+	}
+	if released != "u1" {
+		t.Fatalf("expected u1's reservation released, got %q", released)
+	}
+}
+
+func TestReserveReviewers_Validation(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: userID != "off"}, nil
+	}
+	mockR.ReserveReviewersFunc = func(ctx context.Context, authorID string, userIDs []string, until time.Time) error {
+		for _, id := range userIDs {
+			if id == "held" {
+				return errors.New("conflict")
+			}
+		}
+		return nil
+	}
+
+	soon := time.Now().Add(24 * time.Hour)
+	cases := []struct {
+		reviewers []string
+		until     time.Time
+		want      error
+	}{
+		{[]string{"u2"}, soon, nil},
+		{[]string{}, time.Time{}, nil},
+		{[]string{"u2"}, time.Now().Add(-time.Hour), service.ErrInvalidReservation},
+		{[]string{"u2"}, time.Now().Add(30 * 24 * time.Hour), service.ErrInvalidReservation},
+		{[]string{"u2", "u3", "u4"}, soon, service.ErrInvalidReservation},
+		{[]string{"u1"}, soon, service.ErrAuthorReviewer},
+		{[]string{"off"}, soon, service.ErrUserInactive},
+		{[]string{"held"}, soon, service.ErrReviewerHeld},
+	}
+	for _, c := range cases {
+		if _, err := svc.ReserveReviewers(context.Background(), "u1", c.reviewers, c.until); !errors.Is(err, c.want) {
+			t.Errorf("reviewers %v until %v: expected %v, got %v", c.reviewers, c.until, c.want, err)
+		}
+	}
+}
+
+func TestCreatePR_PrefersSkillMatch(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"busy", "u2", "idle"}, nil
+	}
+	mockR.GetOpenReviewLoadFunc = func(ctx context.Context, teamName string) (map[string]int, error) {
+		return map[string]int{"busy": 5, "u2": 1}, nil
+	}
+	var gotSkills []string
+	mockR.GetSkillMatchesFunc = func(ctx context.Context, skills []string) (map[string]int, error) {
+		gotSkills = skills
+		return map[string]int{"busy": 2, "u2": 1}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1", Labels: []string{" DB", "search", "db"}})
+	if strings.Join(gotSkills, ",") != "db,search" || strings.Join(stored.Labels, ",") != "db,search" {
+		t.Fatalf("expected normalized labels, got %v and %v", gotSkills, stored.Labels)
+	}
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "busy" || stored.Assigned[1].UserID != "u2" {
+		t.Fatalf("expected busy and u2, got %+v", stored.Assigned)
+	}
+}
+
+func TestCreatePR_PairsMentor(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"junior", "u2"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	mockR.GetMentorsFunc = func(ctx context.Context, userIDs []string) (map[string]string, error) {
+		return map[string]string{"junior": "senior"}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if len(stored.Assigned) != 3 || stored.NeedMoreReviewers {
+		t.Fatalf("expected two slots plus the mentor, got %+v (need_more=%v)", stored.Assigned, stored.NeedMoreReviewers)
+	}
+	if m := stored.Assigned[2]; m.UserID != "senior" || m.MentorOf != "junior" {
+		t.Fatalf("expected senior paired with junior, got %+v", m)
+	}
+}
+
+func TestCreatePR_PrefersWorkingHoursOverlap(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithTimezoneOverlap(service.WorkingHours{Start: 9, End: 18}))
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"honolulu", "paris", "unknown"}, nil
+	}
+	mockR.GetOpenReviewLoadFunc = func(ctx context.Context, teamName string) (map[string]int, error) {
+		return map[string]int{"paris": 5, "unknown": 3}, nil
+	}
+	mockR.GetTimezonesFunc = func(ctx context.Context, userIDs []string) (map[string]string, error) {
+		return map[string]string{"u1": "Europe/Berlin", "honolulu": "Pacific/Honolulu", "paris": "Europe/Paris"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "unknown" || stored.Assigned[1].UserID != "paris" {
+		t.Fatalf("expected the idle reviewer in Honolulu passed over, got %+v", stored.Assigned)
+	}
+}
+
+func TestSetTimezone(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserProfileFunc = func(ctx context.Context, userID string) (models.UserProfile, error) {
+		return models.UserProfile{User: models.User{UserID: userID, TeamName: "teamA"}}, nil
+	}
+	var got string
+	mockR.SetTimezoneFunc = func(ctx context.Context, userID, tz string) error {
+		got = tz
+		return nil
+	}
+
+	if _, err := svc.SetTimezone(context.Background(), "u1", "Asia/Tokyo"); err != nil || got != "Asia/Tokyo" {
+		t.Fatalf("expected timezone saved, got %q, err=%v", got, err)
+	}
+	for _, tz := range []string{"Mars/Olympus", "Local"} {
+		if _, err := svc.SetTimezone(context.Background(), "u1", tz); !errors.Is(err, service.ErrInvalidTimezone) {
+			t.Fatalf("expected ErrInvalidTimezone for %q, got %v", tz, err)
+		}
+	}
+}
+
+func TestCreatePR_RampUpCapsNewMembers(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithRampUp(service.RampUpConfig{Days: 14, Reviews: 5, MaxOpen: 1}))
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"newbie", "fresh", "u2", "u3"}, nil
+	}
+	mockR.GetOpenReviewLoadFunc = func(ctx context.Context, teamName string) (map[string]int, error) {
+		return map[string]int{"newbie": 1, "u2": 3, "u3": 4}, nil
+	}
+	var joinedAfter time.Time
+	var maxAssigned int
+	mockR.GetRampUpLoadsFunc = func(ctx context.Context, after time.Time, max int) (map[string]int, error) {
+		joinedAfter, maxAssigned = after, max
+		return map[string]int{"newbie": 1, "fresh": 0}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if maxAssigned != 5 || time.Since(joinedAfter) < 13*24*time.Hour {
+		t.Fatalf("expected ramp-up window of 14 days and 5 reviews, got %v and %d", joinedAfter, maxAssigned)
+	}
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "fresh" || stored.Assigned[1].UserID != "u2" {
+		t.Fatalf("expected the newbie at capacity skipped, got %+v", stored.Assigned)
+	}
+	if stored.Priority != models.PriorityNormal {
+		t.Fatalf("expected NORMAL priority by default, got %q", stored.Priority)
+	}
+
+	// An urgent PR may go past the cap.
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr2", PullRequestName: "x", AuthorID: "u1", Priority: models.PriorityUrgent})
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "fresh" || stored.Assigned[1].UserID != "newbie" {
+		t.Fatalf("expected the newbie picked for an urgent PR, got %+v", stored.Assigned)
+	}
+}
+
+func TestGetPRsByReviewer_UrgentFirst(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRsByReviewerFunc = func(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+		return []models.PullRequestShort{
+			{PullRequestID: "pr5", Priority: models.PriorityLow},
+			{PullRequestID: "pr4", Priority: models.PriorityNormal},
+			{PullRequestID: "pr3", Priority: models.PriorityUrgent},
+			{PullRequestID: "pr2", Priority: models.PriorityNormal},
+			{PullRequestID: "pr1", Priority: models.PriorityUrgent},
+		}, nil
+	}
+
+	prs, err := svc.GetPRsByReviewer(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, pr := range prs {
+		ids = append(ids, pr.PullRequestID)
+	}
+	if got := strings.Join(ids, ","); got != "pr3,pr1,pr4,pr2,pr5" {
+		t.Fatalf("expected urgent first, newest first within a priority, got %s", got)
+	}
+}
+
+func TestFillReviewers_FavorsJuniors(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, AuthorID: "u1", TeamName: "teamA", Status: models.StatusOpen,
+			Assigned: []models.PRReviewer{{UserID: "u2"}}}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u1", "u2", "senior", "junior"}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, WeightedSelection: models.FavorJuniors}, nil
+	}
+	mockR.GetUserWeightsFunc = func(ctx context.Context, userIDs []string) (map[string]int, error) {
+		return map[string]int{"senior": 10, "junior": 1}, nil
+	}
+	picked := map[string]int{}
+	mockR.AddReviewerFunc = func(ctx context.Context, prID, userID string, want int) (models.PullRequest, error) {
+		picked[userID]++
+		return models.PullRequest{PullRequestID: prID}, nil
+	}
+
+	for i := 0; i < 200; i++ {
+		if _, err := svc.FillReviewers(context.Background(), "pr1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if picked["junior"] <= picked["senior"]*3 {
+		t.Fatalf("expected juniors picked far more often, got %v", picked)
+	}
+}
+
+func TestUpdateTeamSettings_InvalidWeightedSelection(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, teamName string) (models.Team, error) {
+		return models.Team{TeamName: teamName}, nil
+	}
+
+	_, err := svc.UpdateTeamSettings(context.Background(), models.TeamSettings{TeamName: "teamA", WeightedSelection: "interns"})
+	if !errors.Is(err, service.ErrInvalidSettings) {
+		t.Fatalf("expected ErrInvalidSettings, got %v", err)
+	}
+}
+
+func TestSetMentor(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserProfileFunc = func(ctx context.Context, userID string) (models.UserProfile, error) {
+		return models.UserProfile{User: models.User{UserID: userID, TeamName: "teamA"}}, nil
+	}
+	mockR.GetMentorsFunc = func(ctx context.Context, userIDs []string) (map[string]string, error) {
+		return map[string]string{"u1": "u3"}, nil
+	}
+	var got string
+	mockR.SetMentorFunc = func(ctx context.Context, menteeID, mentorID string) error {
+		got = mentorID
+		return nil
+	}
+
+	if _, err := svc.SetMentor(context.Background(), "u2", "u1"); err != nil || got != "u1" {
+		t.Fatalf("expected mentor u1 saved, got %q, err=%v", got, err)
+	}
+	if _, err := svc.SetMentor(context.Background(), "u2", "u2"); !errors.Is(err, service.ErrInvalidMentor) {
+		t.Fatalf("expected ErrInvalidMentor for self-mentoring, got %v", err)
+	}
+	if _, err := svc.SetMentor(context.Background(), "u3", "u1"); !errors.Is(err, service.ErrInvalidMentor) {
+		t.Fatalf("expected ErrInvalidMentor for mutual mentoring, got %v", err)
+	}
+}
+
+func TestCreatePR_CrossTeamFallback(t *testing.T) {
+	mockR := &mockRepo{}
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "alpha", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u2"}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, FallbackTeams: []string{"platform"}}, nil
+	}
+	var pool []string
+	mockR.GetActiveMembersOfTeamsFunc = func(ctx context.Context, teamNames []string, exceptUser string) ([]string, error) {
+		pool = teamNames
+		return []string{"u2", "p1", "p2"}, nil
+	}
+	mockR.GetOpenReviewLoadFunc = func(ctx context.Context, teamName string) (map[string]int, error) {
+		if teamName == "platform" {
+			return map[string]int{"p1": 3}, nil
+		}
+		return nil, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+	newPR := models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"}
+
+	_, _ = newTestService(mockR).CreatePR(context.Background(), newPR)
+	if len(stored.Assigned) != 1 || !stored.NeedMoreReviewers || pool != nil {
+		t.Fatalf("expected no fallback when disabled, got %+v", stored)
+	}
+
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithCrossTeamFallback([]string{"global"}))
+	_, _ = svc.CreatePR(context.Background(), newPR)
+	if len(pool) != 1 || pool[0] != "platform" {
+		t.Fatalf("expected team fallback list to win over the global one, got %v", pool)
+	}
+	if len(stored.Assigned) != 2 || stored.Assigned[1].UserID != "p2" || stored.NeedMoreReviewers {
+		t.Fatalf("expected u2 plus least loaded p2, got %+v", stored)
+	}
+}
+
+func TestCreatePR_AssignmentMetrics(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	created := false
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		if !created {
+			return models.PullRequest{}, errors.New("not found")
+		}
+		return models.PullRequest{PullRequestID: prID}, nil
+	}
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		created = true
+		return nil
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "metrics-team", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"gone", "idle", "u2"}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		if userID == "gone" {
+			return models.User{}, errors.New("not found")
+		}
+		return models.User{UserID: userID, IsActive: userID != "idle"}, nil
+	}
+
+	if _, err := svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`assignment_candidates_filtered_total{reason="absent"}`,
+		`assignment_candidates_filtered_total{reason="inactive"}`,
+		`reviewer_assignments_total{strategy="random"}`,
+		`need_more_reviewers_total{team="metrics-team"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
+
+func TestTableStats_Metrics(t *testing.T) {
+	mockR := &mockRepo{}
+	collected := make(chan struct{}, 1)
+	mockR.GetTableStatsFunc = func(ctx context.Context) ([]models.TableStats, error) {
+		select {
+		case collected <- struct{}{}:
+		default:
+		}
+		return []models.TableStats{{
+			Table: "pr_reviewers", Rows: 1200, DeadRows: 30, TotalBytes: 204800,
+			Indexes: []models.IndexStats{{Name: "pr_reviewers_pkey", Bytes: 81920, BloatBytes: 16384}},
+		}}, nil
+	}
+	svc := service.NewService(mockR, &dummyLogger{})
+	defer svc.StopWorkers()
+
+	svc.StartTableStats(5 * time.Millisecond)
+	select {
+	case <-collected:
+	case <-time.After(time.Second):
+		t.Fatal("table stats were not collected")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`db_table_rows{table="pr_reviewers"} 1200`,
+		`db_table_dead_rows{table="pr_reviewers"} 30`,
+		`db_table_bytes{table="pr_reviewers"} 204800`,
+		`db_index_bloat_bytes{table="pr_reviewers",index="pr_reviewers_pkey"} 16384`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
+
+func TestReopenPR_ReplacesInactiveReviewers(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "u1",
+		TeamName:      "teamA",
+		Status:        "CLOSED",
+		Assigned: []models.PRReviewer{
+			{UserID: "u2", IsActive: false},
+			{UserID: "u3", IsActive: true},
+		},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.ReopenPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		pr.Status = "OPEN"
+		return pr, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, teamName, exceptUser string) ([]string, error) {
+		if teamName != "teamA" {
+			t.Fatalf("expected candidates from PR team, got %s", teamName)
+		}
+		return []string{"u1", "u3", "u4"}, nil
+	}
+	mockR.ReplaceReviewerFunc = func(ctx context.Context, prID, oldUID, newUID string) (models.PullRequest, error) {
+		if oldUID != "u2" || newUID != "u4" {
+			t.Fatalf("unexpected replacement %s -> %s", oldUID, newUID)
+		}
+		pr.Assigned = []models.PRReviewer{{UserID: "u3", IsActive: true}, {UserID: "u4", IsActive: true}}
+		return pr, nil
+	}
+
+	got, err := svc.ReopenPR(context.Background(), "pr1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != "OPEN" || got.Assigned[1].UserID != "u4" {
+		t.Fatalf("unexpected PR after reopen: %+v", got)
+	}
+
+	pr.Status = "MERGED"
+	if _, err := svc.ReopenPR(context.Background(), "pr1"); !errors.Is(err, service.ErrPRMerged) {
+		t.Fatalf("expected ErrPRMerged, got %v", err)
+	}
+
+	// Merged by another request between the read and the reopen.
+	reads := 0
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		reads++
+		if reads == 1 {
+			return models.PullRequest{PullRequestID: prID, Status: models.StatusClosed}, nil
+		}
+		return models.PullRequest{PullRequestID: prID, Status: models.StatusMerged}, nil
+	}
+	mockR.ReopenPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("conflict: pr pr1 is no longer CLOSED")
+	}
+	if _, err := svc.ReopenPR(context.Background(), "pr1"); !errors.Is(err, service.ErrPRMerged) {
+		t.Fatalf("expected ErrPRMerged after a concurrent merge, got %v", err)
+	}
+}
+
+func TestQueryStats_TruncatesOnBudget(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.QueryReviewerStatsFunc = func(ctx context.Context, q models.StatsQuery) (map[string]int, error) {
+		if q.TeamName != "alpha" {
+			t.Fatalf("expected team hint to reach repo, got %q", q.TeamName)
+		}
+		partial := map[string]int{"u1": 3}
+		<-ctx.Done()
+		return partial, ctx.Err()
+	}
+
+	report, err := svc.QueryStats(context.Background(), models.StatsQuery{TeamName: "alpha"}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Truncated || report.Stats["u1"] != 3 {
+		t.Fatalf("expected truncated partial stats, got %+v", report)
+	}
+
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.QueryStats(ctx, models.StatsQuery{TeamName: "alpha"}, 0); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for foreign team hint, got %v", err)
+	}
+}
+
+func TestListPRs(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	var gotFilter models.PRFilter
+	var gotPage models.Page
+	mockR.ListPRsFunc = func(ctx context.Context, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+		gotFilter, gotPage = filter, page
+		return []models.PullRequestShort{{PullRequestID: "pr1"}}, nil
+	}
+
+	prs, err := svc.ListPRs(context.Background(), models.PRFilter{Status: "OPEN"}, models.Page{Limit: 10000})
+	if err != nil || len(prs) != 1 {
+		t.Fatalf("unexpected result %v, err=%v", prs, err)
+	}
+	if gotPage.Limit != service.MaxPageLimit {
+		t.Fatalf("expected limit clamped to %d, got %d", service.MaxPageLimit, gotPage.Limit)
+	}
+
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "alpha"})
+	if _, err := svc.ListPRs(ctx, models.PRFilter{}, models.Page{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotFilter.TeamName != "alpha" || gotPage.Limit != service.DefaultPageLimit {
+		t.Fatalf("expected scoped filter and default page, got %+v %+v", gotFilter, gotPage)
+	}
+	if _, err := svc.ListPRs(ctx, models.PRFilter{TeamName: "beta"}, models.Page{}); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestSearchPRs(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	var gotQuery string
+	var gotFilter models.PRFilter
+	mockR.SearchPRsFunc = func(ctx context.Context, query string, filter models.PRFilter, page models.Page) ([]models.PullRequestShort, error) {
+		gotQuery, gotFilter = query, filter
+		return []models.PullRequestShort{{PullRequestID: "pr1"}}, nil
+	}
+
+	if _, err := svc.SearchPRs(context.Background(), "  ", models.PRFilter{}, models.Page{}); err == nil {
+		t.Fatal("expected error for empty query")
+	}
+
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "alpha"})
+	prs, err := svc.SearchPRs(ctx, " login ", models.PRFilter{TeamName: "beta", Label: " DB ", Repository: "org/app"}, models.Page{})
+	if err != nil || len(prs) != 1 {
+		t.Fatalf("unexpected result %v, err=%v", prs, err)
+	}
+	if gotQuery != "login" || gotFilter.TeamName != "alpha" || gotFilter.Label != "db" || gotFilter.Repository != "org/app" {
+		t.Fatalf("expected trimmed query scoped to alpha with label db in org/app, got %q %+v", gotQuery, gotFilter)
+	}
+}
+
+func TestSetPRLabels(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	status := models.StatusOpen
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		if prID != "pr1" {
+			return models.PullRequest{}, errors.New("not found")
+		}
+		return models.PullRequest{PullRequestID: prID, TeamName: "alpha", Status: status}, nil
+	}
+	var saved []string
+	mockR.SetPRLabelsFunc = func(ctx context.Context, prID string, labels []string) error {
+		saved = labels
+		return nil
+	}
+
+	pr, err := svc.SetPRLabels(context.Background(), "pr1", []string{"DB", " db", "Backend"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(saved, ",") != "db,backend" || strings.Join(pr.Labels, ",") != "db,backend" {
+		t.Fatalf("expected normalized labels db,backend, got saved %v, returned %v", saved, pr.Labels)
+	}
+
+	if _, err := svc.SetPRLabels(context.Background(), "pr2", nil); err != service.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	scoped := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.SetPRLabels(scoped, "pr1", nil); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for another team's token, got %v", err)
+	}
+	status = models.StatusMerged
+	if _, err := svc.SetPRLabels(context.Background(), "pr1", nil); err != service.ErrPRMerged {
+		t.Fatalf("expected ErrPRMerged, got %v", err)
+	}
+}
+
+func TestGetPR(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		if prID != "pr1" {
+			return models.PullRequest{}, errors.New("not found")
+		}
+		return models.PullRequest{PullRequestID: prID, TeamName: "alpha", Status: "OPEN"}, nil
+	}
+
+	pr, err := svc.GetPR(context.Background(), "pr1")
+	if err != nil || pr.PullRequestID != "pr1" {
+		t.Fatalf("unexpected result %v, err=%v", pr, err)
+	}
+	if _, err := svc.GetPR(context.Background(), "missing"); err != service.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.GetPR(ctx, "pr1"); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestSuggestReviewers(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "alpha", nil
+	}
+	mockR.GetCandidateSignalsFunc = func(ctx context.Context, teamName, authorID string) ([]models.CandidateSignals, error) {
+		return []models.CandidateSignals{
+			{UserID: "busy", IsActive: true, OpenReviews: 5},
+			{UserID: "expert", IsActive: true, OpenReviews: 1, AuthorReviews: 4},
+			{UserID: "idle", IsActive: true},
+			{UserID: "away", IsActive: false},
+		}, nil
+	}
+
+	got, err := svc.SuggestReviewers(context.Background(), "u1", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 suggestions, got %d", len(got))
+	}
+	if got[0].UserID != "expert" || got[1].UserID != "idle" || got[2].UserID != "busy" {
+		t.Fatalf("unexpected ranking: %+v", got)
+	}
+}
+
+func TestSuggestReviewers_TeamScoring(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "alpha", nil
+	}
+	recent := time.Now().UTC().Add(-time.Hour)
+	mockR.GetCandidateSignalsFunc = func(ctx context.Context, teamName, authorID string) ([]models.CandidateSignals, error) {
+		return []models.CandidateSignals{
+			{UserID: "fresh", IsActive: true, OpenReviews: 3, LastReviewAt: &recent},
+			{UserID: "rested", IsActive: true, OpenReviews: 3},
+			{UserID: "away", IsActive: false},
+		}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, Scoring: &models.ScoringConfig{
+			Filters: []string{"active"},
+			Weights: map[string]float64{"recency": 1},
+		}}, nil
+	}
+
+	got, err := svc.SuggestReviewers(context.Background(), "u1", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].UserID != "rested" || got[1].UserID != "fresh" {
+		t.Fatalf("unexpected ranking: %+v", got)
+	}
+}
+
+func TestCreatePR_SecurityReview(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "teamA", nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{
+			TeamName:         teamName,
+			DefaultReviewers: []string{"d1", "d2"},
+			SecurityReview:   &models.SecurityReviewRule{Team: "sec", Labels: []string{"security"}, Paths: []string{"internal/auth/"}},
+		}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		if team == "sec" {
+			return []string{"s1", "s2"}, nil
+		}
+		return []string{"d1", "d2", "u3"}, nil
+	}
+	mockR.GetOpenReviewLoadFunc = func(ctx context.Context, teamName string) (map[string]int, error) {
+		return map[string]int{"s2": 4}, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, IsActive: true}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1", Paths: []string{"/internal/auth/token.go"}})
+	if !stored.SecurityReview || stored.SecurityReviewer != "s1" {
+		t.Fatalf("expected security review by s1, got %v %q", stored.SecurityReview, stored.SecurityReviewer)
+	}
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "s1" || stored.Assigned[1].UserID != "d1" {
+		t.Fatalf("expected s1 before d1, got %+v", stored.Assigned)
+	}
+
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr2", PullRequestName: "x", AuthorID: "u1", Labels: []string{"docs"}, Paths: []string{"README.md"}})
+	if stored.SecurityReview || stored.Assigned[0].UserID != "d1" {
+		t.Fatalf("expected no security review, got %+v", stored)
+	}
+}
+
+func TestGetSecurityCoverage(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		if name != "alpha" {
+			return models.Team{}, errors.New("not found")
+		}
+		return models.Team{TeamName: name}, nil
+	}
+	mockR.GetSecurityCoverageFunc = func(ctx context.Context, teamName string, since time.Time) (models.SecurityCoverage, error) {
+		return models.SecurityCoverage{TeamName: teamName, Required: 4, Covered: 3, Approved: 2, Uncovered: []string{"pr9"}}, nil
+	}
+
+	cov, err := svc.GetSecurityCoverage(context.Background(), "alpha", 4)
+	if err != nil || cov.Coverage != 0.75 {
+		t.Fatalf("expected 0.75 coverage, got %+v, err=%v", cov, err)
+	}
+
+	if _, err := svc.GetSecurityCoverage(context.Background(), "ghost", 4); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetTeamStats(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamStatsFunc = func(ctx context.Context, teamName string) (models.TeamStats, error) {
+		if teamName != "alpha" {
+			return models.TeamStats{}, errors.New("not found")
+		}
+		return models.TeamStats{TeamName: teamName, CreatedPRs: 12, MergedPRs: 9, Reassignments: 3}, nil
+	}
+
+	stats, err := svc.GetTeamStats(context.Background(), "alpha")
+	if err != nil || stats.CreatedPRs != 12 || stats.MergedPRs != 9 || stats.Reassignments != 3 {
+		t.Fatalf("unexpected stats %+v, err=%v", stats, err)
+	}
+	if _, err := svc.GetTeamStats(context.Background(), "ghost"); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	scoped := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.GetTeamStats(scoped, "alpha"); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestGetReviewLoad(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name}, nil
+	}
+	mockR.GetReviewLoadBySizeFunc = func(ctx context.Context, teamName string) ([]models.ReviewerLoad, error) {
+		return []models.ReviewerLoad{
+			{UserID: "u1", Open: 2, Effort: 13, BySize: map[string]int{"L": 1, "XL": 1}},
+			{UserID: "u2", Open: 3, Effort: 7, BySize: map[string]int{"XS": 1, "S": 1, "": 1}},
+		}, nil
+	}
+
+	load, err := svc.GetReviewLoad(context.Background(), "alpha")
+	if err != nil || load.Effort != 20 || len(load.Reviewers) != 2 {
+		t.Fatalf("unexpected load %+v, err=%v", load, err)
+	}
+	if load.BySize["XL"] != 1 || load.BySize[""] != 1 || len(load.BySize) != 5 {
+		t.Fatalf("unexpected load by size %v", load.BySize)
+	}
+
+	scoped := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.GetReviewLoad(scoped, "alpha"); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestPRSize_Effort(t *testing.T) {
+	if models.SizeForLines(5) != models.SizeXS || models.SizeForLines(120) != models.SizeM || models.SizeForLines(5000) != models.SizeXL {
+		t.Fatalf("unexpected sizes for line counts")
+	}
+	if models.SizeXL.Effort() <= models.SizeL.Effort() || models.PRSize("").Effort() != models.SizeM.Effort() {
+		t.Fatalf("unexpected size efforts")
+	}
+}
+
+func TestUpdateTeamSettings_Validation(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name}, nil
+	}
+	saved := false
+	mockR.SaveTeamSettingsFunc = func(ctx context.Context, settings models.TeamSettings) error {
+		saved = true
+		return nil
+	}
+
+	_, err := svc.UpdateTeamSettings(context.Background(), models.TeamSettings{
+		TeamName: "alpha",
+		Scoring:  &models.ScoringConfig{Weights: map[string]float64{"karma": 1}},
+	})
+	if !errors.Is(err, service.ErrInvalidSettings) || saved {
+		t.Fatalf("expected ErrInvalidSettings without saving, got %v (saved=%v)", err, saved)
+	}
+
+	_, err = svc.UpdateTeamSettings(context.Background(), models.TeamSettings{
+		TeamName:         "alpha",
+		DefaultReviewers: []string{"u1", "u2", "u3"},
+	})
+	if !errors.Is(err, service.ErrInvalidSettings) || saved {
+		t.Fatalf("expected ErrInvalidSettings for too many default reviewers, got %v", err)
+	}
+
+	_, err = svc.UpdateTeamSettings(context.Background(), models.TeamSettings{
+		TeamName:      "alpha",
+		FallbackTeams: []string{"beta", "alpha"},
+	})
+	if !errors.Is(err, service.ErrInvalidSettings) || saved {
+		t.Fatalf("expected ErrInvalidSettings for own team as fallback, got %v", err)
+	}
+
+	_, err = svc.UpdateTeamSettings(context.Background(), models.TeamSettings{
+		TeamName:   "alpha",
+		Escalation: []models.EscalationHop{{To: "lead", DelayMinutes: 60}, {To: models.EscalationTarget, DelayMinutes: 30}},
+	})
+	if !errors.Is(err, service.ErrInvalidSettings) || saved {
+		t.Fatalf("expected ErrInvalidSettings for decreasing escalation delays, got %v", err)
+	}
+
+	_, err = svc.UpdateTeamSettings(context.Background(), models.TeamSettings{
+		TeamName:       "alpha",
+		SecurityReview: &models.SecurityReviewRule{Team: "sec"},
+	})
+	if !errors.Is(err, service.ErrInvalidSettings) || saved {
+		t.Fatalf("expected ErrInvalidSettings for security rule without conditions, got %v", err)
+	}
+
+	_, err = svc.UpdateTeamSettings(context.Background(), models.TeamSettings{
+		TeamName:         "alpha",
+		Scoring:          &models.ScoringConfig{Weights: map[string]float64{"load": 1}},
+		DefaultReviewers: []string{"u1"},
+		FallbackTeams:    []string{"beta"},
+	})
+	if err != nil || !saved {
+		t.Fatalf("expected settings saved, got %v", err)
+	}
+}
+
+func TestBlindReview_RedactsForCaller(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{
+			PullRequestID: prID,
+			AuthorID:      "author",
+			TeamName:      "alpha",
+			Status:        "OPEN",
+			Assigned:      []models.PRReviewer{{UserID: "rev", IsActive: true}},
+		}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, BlindReview: true}, nil
+	}
+
+	run := func(ctx context.Context) models.PullRequest {
+		job := service.NewJob(ctx, "get_pr", map[string]interface{}{"pr_id": "pr1"})
+		svc.EnqueueJob(job)
+		res := <-job.RespCh
+		if res.Error != nil {
+			t.Fatalf("unexpected error: %v", res.Error)
+		}
+		return res.Data.(models.PullRequest)
+	}
+
+	if pr := run(service.WithCaller(context.Background(), "author")); len(pr.Assigned) != 0 || pr.AuthorID != "author" {
+		t.Fatalf("author should not see reviewers: %+v", pr)
+	}
+	if pr := run(service.WithCaller(context.Background(), "rev")); pr.AuthorID != "" || len(pr.Assigned) != 1 {
+		t.Fatalf("reviewer should not see author: %+v", pr)
+	}
+	if pr := run(context.Background()); pr.AuthorID != "" || len(pr.Assigned) != 0 {
+		t.Fatalf("unidentified caller should see neither author nor reviewers: %+v", pr)
+	}
+	if pr := run(service.WithBlindReviewBypass(context.Background())); pr.AuthorID != "author" || len(pr.Assigned) != 1 {
+		t.Fatalf("admin bypass should see the full PR: %+v", pr)
+	}
+
+	// Failing to read the settings redacts, a team without settings doesn't.
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{}, errors.New("timeout")
+	}
+	if pr := run(service.WithCaller(context.Background(), "author")); len(pr.Assigned) != 0 {
+		t.Fatalf("author should not see reviewers when settings fail: %+v", pr)
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{}, errors.New("not found")
+	}
+	if pr := run(service.WithCaller(context.Background(), "author")); len(pr.Assigned) != 1 {
+		t.Fatalf("team without settings should not be blind: %+v", pr)
+	}
+}
+
+func TestBlindReview_HidesReplacementFromAuthor(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "author",
+		TeamName:      "alpha",
+		Status:        models.StatusOpen,
+		Assigned:      []models.PRReviewer{{UserID: "u1", IsActive: true}},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, uid string) (models.User, error) {
+		return models.User{UserID: uid, IsActive: true}, nil
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, uid string) (string, error) {
+		return "alpha", nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u2"}, nil
+	}
+	mockR.ReplaceReviewerFunc = func(ctx context.Context, prID, oldUser, newUser string) (models.PullRequest, error) {
+		out := pr
+		out.Assigned = []models.PRReviewer{{UserID: newUser, IsActive: true}}
+		return out, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, BlindReview: true}, nil
+	}
+
+	job := service.NewJob(service.WithCaller(context.Background(), "author"), "reassign_pr",
+		map[string]interface{}{"pr_id": "pr1", "old_user": "u1"})
+	svc.EnqueueJob(job)
+	res := <-job.RespCh
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %v", res.Error)
+	}
+	out := res.Data.(models.PRResult)
+	if out.ReplacedBy != "" || len(out.PR.Assigned) != 0 {
+		t.Fatalf("author should not see the replacement: %+v", out)
+	}
+}
+
+func TestUpdateTeam(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.UpdateTeamFunc = func(ctx context.Context, upd models.TeamUpdate) (models.Team, error) {
+		if upd.NewTeamName == "taken" {
+			return models.Team{}, errors.New("team exists")
+		}
+		return models.Team{TeamName: upd.NewTeamName}, nil
+	}
+
+	team, err := svc.UpdateTeam(context.Background(), models.TeamUpdate{TeamName: "alpha", NewTeamName: "beta", Remove: []string{"u2"}})
+	if err != nil || team.TeamName != "beta" {
+		t.Fatalf("unexpected result %v, err=%v", team, err)
+	}
+
+	if _, err := svc.UpdateTeam(context.Background(), models.TeamUpdate{TeamName: "alpha", NewTeamName: "taken"}); err != service.ErrTeamExists {
+		t.Fatalf("expected ErrTeamExists, got %v", err)
+	}
+
+	_, err = svc.UpdateTeam(context.Background(), models.TeamUpdate{
+		TeamName: "alpha",
+		Upsert:   []models.TeamMember{{UserID: "u2"}},
+		Remove:   []string{"u2"},
+	})
+	if err == nil {
+		t.Fatal("expected error for user both upserted and removed")
+	}
+}
+
+func TestDeleteTeam(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.DeleteTeamFunc = func(ctx context.Context, teamName string, force bool, wantReviewers int) ([]string, error) {
+		if wantReviewers != 2 {
+			t.Fatalf("expected reviewers target 2, got %d", wantReviewers)
+		}
+		if !force {
+			return []string{"pr1"}, errors.New("open reviews")
+		}
+		return []string{"pr1"}, nil
+	}
+
+	prs, err := svc.DeleteTeam(context.Background(), "alpha", false)
+	if err != service.ErrTeamInUse || len(prs) != 1 {
+		t.Fatalf("expected ErrTeamInUse with affected PRs, got %v, %v", prs, err)
+	}
+
+	prs, err = svc.DeleteTeam(context.Background(), "alpha", true)
+	if err != nil || len(prs) != 1 {
+		t.Fatalf("expected forced delete, got %v, %v", prs, err)
+	}
+}
+
+func TestGetUserActivity(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, TeamName: "alpha"}, nil
+	}
+	var gotPage models.Page
+	mockR.GetUserEventsFunc = func(ctx context.Context, userID string, page models.Page) ([]models.UserEvent, error) {
+		gotPage = page
+		return []models.UserEvent{{PullRequestID: "pr1", Kind: models.EventAssigned}}, nil
+	}
+
+	events, err := svc.GetUserActivity(context.Background(), "u1", models.Page{})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("unexpected result %v, err=%v", events, err)
+	}
+	if gotPage.Limit != service.DefaultPageLimit {
+		t.Fatalf("expected default limit, got %d", gotPage.Limit)
+	}
+
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.GetUserActivity(ctx, "u1", models.Page{}); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestGetUserStats(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	var gotSince time.Time
+	mockR.GetUserStatsFunc = func(ctx context.Context, userID string, since time.Time) (models.UserStats, error) {
+		if userID == "ghost" {
+			return models.UserStats{}, errors.New("not found")
+		}
+		gotSince = since
+		return models.UserStats{User: models.User{UserID: userID, TeamName: "alpha"}, Declines: 2}, nil
+	}
+
+	st, err := svc.GetUserStats(context.Background(), "u1", 0)
+	if err != nil || st.Declines != 2 {
+		t.Fatalf("unexpected result %+v, err=%v", st, err)
+	}
+	if weeks := time.Since(gotSince).Hours() / 24 / 7; weeks < service.DefaultStatsWeeks-1.01 || weeks > service.DefaultStatsWeeks-0.99 {
+		t.Fatalf("expected the default period, got since %v", gotSince)
+	}
+
+	if _, err := svc.GetUserStats(context.Background(), "ghost", 4); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.GetUserStats(ctx, "u1", 4); !errors.Is(err, service.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestGetUserProfile(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserProfileFunc = func(ctx context.Context, userID string) (models.UserProfile, error) {
+		if userID == "ghost" {
+			return models.UserProfile{}, errors.New("not found")
+		}
+		return models.UserProfile{User: models.User{UserID: userID, TeamName: "alpha"}, OpenReviews: 3}, nil
+	}
+
+	p, err := svc.GetUserProfile(context.Background(), "u1")
+	if err != nil || p.OpenReviews != 3 {
+		t.Fatalf("unexpected result %+v, err=%v", p, err)
+	}
+	if _, err := svc.GetUserProfile(context.Background(), "ghost"); err != service.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.GetUserProfile(ctx, "u1"); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	// An extra member of beta is within reach of beta's token.
+	mockR.GetTeamMembershipsFunc = func(ctx context.Context, teamName string) ([]models.Membership, error) {
+		return []models.Membership{{TeamName: teamName, UserID: "u1", Role: models.RoleMember}}, nil
+	}
+	if _, err := svc.GetUserProfile(ctx, "u1"); err != nil {
+		t.Fatalf("expected extra member to be in scope, got %v", err)
+	}
+}
+
+func TestAddMembership(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, TeamName: "alpha"}, nil
+	}
+	var got models.Membership
+	mockR.AddMembershipFunc = func(ctx context.Context, m models.Membership) error {
+		if m.TeamName == "ghost" {
+			return errors.New("not found")
+		}
+		got = m
+		return nil
+	}
+
+	if err := svc.AddMembership(context.Background(), models.Membership{TeamName: "beta", UserID: "u1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Role != models.RoleMember {
+		t.Fatalf("expected default member role, got %q", got.Role)
+	}
+	if err := svc.AddMembership(context.Background(), models.Membership{TeamName: "alpha", UserID: "u1"}); err != service.ErrHomeTeam {
+		t.Fatalf("expected ErrHomeTeam, got %v", err)
+	}
+	if err := svc.AddMembership(context.Background(), models.Membership{TeamName: "ghost", UserID: "u1"}); err != service.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := svc.AddMembership(context.Background(), models.Membership{TeamName: "beta", UserID: "u1", Role: "lead"}); err == nil {
+		t.Fatal("expected error for unknown role")
+	}
+}
+
+func TestReassign_UsesPRTeam(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{
+		PullRequestID: "pr1",
+		AuthorID:      "author",
+		TeamName:      "beta",
+		Status:        models.StatusOpen,
+		Assigned:      []models.PRReviewer{{UserID: "guest", IsActive: true}},
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, uid string) (models.User, error) {
+		return models.User{UserID: uid, TeamName: "alpha", IsActive: true}, nil
+	}
+	var gotTeam string
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		gotTeam = team
+		return []string{"u2"}, nil
+	}
+	mockR.ReplaceReviewerFunc = func(ctx context.Context, prID, oldUser, newUser string) (models.PullRequest, error) {
+		out := pr
+		out.Assigned = []models.PRReviewer{{UserID: newUser, IsActive: true}}
+		return out, nil
+	}
+
+	if _, newUID, err := svc.Reassign(context.Background(), "pr1", "guest"); err != nil || newUID != "u2" {
+		t.Fatalf("unexpected result newUID=%s, err=%v", newUID, err)
+	}
+	if gotTeam != "beta" {
+		t.Fatalf("expected candidates from PR team beta, got %q", gotTeam)
+	}
+}
+
+func TestAssignment_FollowsTeamScoring(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		if prID == "pr2" {
+			return models.PullRequest{PullRequestID: prID, AuthorID: "u1", TeamName: "alpha", Status: models.StatusOpen,
+				Assigned: []models.PRReviewer{{UserID: "u3", IsActive: true}}}, nil
+		}
+		return models.PullRequest{}, errors.New("not found")
+	}
+	mockR.GetUserTeamFunc = func(ctx context.Context, userID string) (string, error) {
+		return "alpha", nil
+	}
+	mockR.GetUserFunc = func(ctx context.Context, uid string) (models.User, error) {
+		return models.User{UserID: uid, TeamName: "alpha", IsActive: true}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u2", "u3", "u4", "u5"}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, Scoring: &models.ScoringConfig{
+			Filters: []string{"reviewed_author"},
+			Weights: map[string]float64{"expertise": 1},
+		}}, nil
+	}
+	mockR.GetCandidateSignalsFunc = func(ctx context.Context, teamName, authorID string) ([]models.CandidateSignals, error) {
+		return []models.CandidateSignals{
+			{UserID: "u2", IsActive: true, AuthorReviews: 1},
+			{UserID: "u3", IsActive: true, AuthorReviews: 4},
+			{UserID: "u4", IsActive: true, AuthorReviews: 2},
+			{UserID: "u5", IsActive: true},
+		}, nil
+	}
+
+	var stored models.PullRequest
+	mockR.CreatePRFunc = func(ctx context.Context, pr models.PullRequest) error {
+		stored = pr
+		return errors.New("stop")
+	}
+	_, _ = svc.CreatePR(context.Background(), models.PullRequest{PullRequestID: "pr1", PullRequestName: "x", AuthorID: "u1"})
+	if len(stored.Assigned) != 2 || stored.Assigned[0].UserID != "u3" || stored.Assigned[1].UserID != "u4" {
+		t.Fatalf("expected the two best scored candidates, got %+v", stored.Assigned)
+	}
+
+	mockR.ReplaceReviewerFunc = func(ctx context.Context, prID, oldUser, newUser string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, AuthorID: "u1", TeamName: "alpha", Status: models.StatusOpen,
+			Assigned: []models.PRReviewer{{UserID: newUser, IsActive: true}}}, nil
+	}
+	if _, newUID, err := svc.Reassign(context.Background(), "pr2", "u3"); err != nil || newUID != "u4" {
+		t.Fatalf("expected the best scored replacement u4, got %q, err=%v", newUID, err)
+	}
+}
+
+func TestAddTeamMember(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	var got models.TeamUpdate
+	mockR.UpdateTeamFunc = func(ctx context.Context, upd models.TeamUpdate) (models.Team, error) {
+		got = upd
+		return models.Team{TeamName: upd.TeamName, Members: upd.Upsert}, nil
+	}
+
+	team, err := svc.AddTeamMember(context.Background(), "alpha", models.TeamMember{UserID: "u4", Username: "Dave", IsActive: true})
+	if err != nil || len(team.Members) != 1 {
+		t.Fatalf("unexpected result %v, err=%v", team, err)
+	}
+	if got.TeamName != "alpha" || len(got.Upsert) != 1 || len(got.Remove) != 0 || got.NewTeamName != "" {
+		t.Fatalf("expected single-member upsert, got %+v", got)
+	}
+}
+
+func TestGetOrgSummary_Cached(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	calls := 0
+	mockR.GetOrgSummaryFunc = func(ctx context.Context, limit int) (models.OrgSummary, error) {
+		calls++
+		return models.OrgSummary{OpenPRs: 4, MergedPRs: 2, BusiestTeams: []models.TeamLoad{{TeamName: "alpha", OpenPRs: 3}}}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		sum, err := svc.GetOrgSummary(context.Background())
+		if err != nil || sum.OpenPRs != 4 || len(sum.BusiestTeams) != 1 {
+			t.Fatalf("unexpected summary %+v, err=%v", sum, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected one repo call, got %d", calls)
+	}
+}
+
+func TestApplyChange_DropsOrgSummary(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	calls := 0
+	mockR.GetOrgSummaryFunc = func(ctx context.Context, limit int) (models.OrgSummary, error) {
+		calls++
+		return models.OrgSummary{OpenPRs: calls}, nil
+	}
+
+	if sum, _ := svc.GetOrgSummary(context.Background()); sum.OpenPRs != 1 {
+		t.Fatalf("unexpected summary %+v", sum)
+	}
+	svc.ApplyChange("team_tokens")
+	if sum, _ := svc.GetOrgSummary(context.Background()); sum.OpenPRs != 1 {
+		t.Fatalf("expected cached summary after unrelated change, got %+v", sum)
+	}
+	svc.ApplyChange("pull_requests")
+	if sum, _ := svc.GetOrgSummary(context.Background()); sum.OpenPRs != 2 {
+		t.Fatalf("expected fresh summary after PR change, got %+v", sum)
+	}
+	svc.ApplyChange("")
+	if sum, _ := svc.GetOrgSummary(context.Background()); sum.OpenPRs != 3 {
+		t.Fatalf("expected fresh summary after reconnect, got %+v", sum)
+	}
+}
+
+func TestGetOrgSummary_ScopedForbidden(t *testing.T) {
+	svc := newTestService(&mockRepo{})
+	ctx := service.WithScope(context.Background(), service.Scope{TeamName: "alpha"})
+	if _, err := svc.GetOrgSummary(ctx); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	msgs []notify.Message
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.msgs = append(n.msgs, msg)
+	return nil
+}
+
+func (n *recordingNotifier) kinds() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]string, 0, len(n.msgs))
+	for _, m := range n.msgs {
+		out = append(out, m.Kind)
+	}
+	return out
+}
+
+func TestMergePR_NotifiesSubscribers(t *testing.T) {
+	mockR := &mockRepo{}
+	n := &recordingNotifier{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithNotifier(n))
+	defer svc.StopWorkers()
+
+	status := models.StatusApproved
+	pr := func() models.PullRequest {
+		return models.PullRequest{PullRequestID: "pr1", PullRequestName: "fix", AuthorID: "u1", Status: status,
+			Assigned: []models.PRReviewer{{UserID: "u2"}, {UserID: "u3"}}}
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr(), nil
+	}
+	mockR.MergePRFunc = func(ctx context.Context, prID, mergedBy string, at time.Time) (models.PullRequest, error) {
+		status = models.StatusMerged
+		return pr(), nil
+	}
+	var asked []string
+	mockR.GetMergeSubscribersFunc = func(ctx context.Context, userIDs []string) ([]string, error) {
+		asked = userIDs
+		return []string{"u1", "u3"}, nil
+	}
+
+	if _, err := svc.MergePR(context.Background(), "pr1", "", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(asked, ",") != "u1,u2,u3" {
+		t.Fatalf("expected author and reviewers to be checked, got %v", asked)
+	}
+	if len(n.msgs) != 1 || n.msgs[0].Kind != "pr.merged" || n.msgs[0].Fields["to"] != "u1,u3" {
+		t.Fatalf("expected one pr.merged message to u1,u3, got %+v", n.msgs)
+	}
+
+	// Merging again is a no-op and notifies nobody.
+	if _, err := svc.MergePR(context.Background(), "pr1", "", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(n.msgs) != 1 {
+		t.Fatalf("expected no second notification, got %d", len(n.msgs))
+	}
+}
+
+func TestMergeWindow(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	// A freeze around the current minute, so merges are closed for an hour.
+	now := time.Now().UTC()
+	window := &models.MergeWindow{Freezes: []models.MergeFreeze{{
+		From:  now.Add(-time.Hour).Format("Mon 15:04"),
+		Until: now.Add(time.Hour).Format("Mon 15:04"),
+	}}}
+	opensAt := now.Add(time.Hour).Truncate(time.Minute)
+
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, TeamName: "teamA", Status: models.StatusOpen}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, MergeWindow: window}, nil
+	}
+	merged := 0
+	var mergedBy string
+	mockR.MergePRFunc = func(ctx context.Context, prID, by string, at time.Time) (models.PullRequest, error) {
+		merged++
+		mergedBy = by
+		return models.PullRequest{PullRequestID: prID, Status: models.StatusMerged}, nil
+	}
+	var queuedAt time.Time
+	var queuedBy string
+	mockR.QueueMergeFunc = func(ctx context.Context, prID, by string, mergeAt time.Time) error {
+		queuedAt, queuedBy = mergeAt, by
+		return nil
+	}
+
+	_, err := svc.MergePR(context.Background(), "pr1", "", false)
+	var closed *service.MergeWindowError
+	if !errors.Is(err, service.ErrMergeWindowClosed) || !errors.As(err, &closed) {
+		t.Fatalf("expected ErrMergeWindowClosed, got %v", err)
+	}
+	if !closed.OpensAt.Equal(opensAt) {
+		t.Fatalf("expected window to open at %v, got %v", opensAt, closed.OpensAt)
+	}
+	if merged != 0 {
+		t.Fatalf("expected no merge while frozen")
+	}
+
+	job := service.NewJob(service.WithScope(context.Background(), service.Scope{TeamName: "teamA"}), "merge_pr",
+		map[string]interface{}{"pr_id": "pr1", "override": true})
+	svc.EnqueueJob(job)
+	if res := <-job.RespCh; !errors.Is(res.Error, service.ErrForbidden) {
+		t.Fatalf("expected team token override to be forbidden, got %v", res.Error)
+	}
+	if _, err := svc.MergePR(context.Background(), "pr1", "", true); err != nil || merged != 1 {
+		t.Fatalf("expected admin override to merge, got %v", err)
+	}
+
+	q, err := svc.QueueMerge(context.Background(), "pr1", "u2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !q.MergeAt.Equal(opensAt) || !queuedAt.Equal(opensAt) {
+		t.Fatalf("expected merge queued for %v, got %v", opensAt, q.MergeAt)
+	}
+	if q.MergedBy != "u2" || queuedBy != "u2" {
+		t.Fatalf("expected the merge queued by u2, got %q", queuedBy)
+	}
+
+	// Once the window opens the queue merges the PR and drops the entry.
+	window = nil
+	mockR.GetDueMergesFunc = func(ctx context.Context, now time.Time) ([]models.QueuedMerge, error) {
+		return []models.QueuedMerge{{PullRequestID: "pr1", MergeAt: opensAt, MergedBy: "u2"}}, nil
+	}
+	dequeued := make(chan string, 16)
+	mockR.DequeueMergeFunc = func(ctx context.Context, prID string) error {
+		dequeued <- prID
+		return nil
+	}
+	svc.StartMergeQueue(10 * time.Millisecond)
+	defer svc.StopWorkers()
+	select {
+	case id := <-dequeued:
+		if id != "pr1" {
+			t.Fatalf("expected pr1 dequeued, got %s", id)
+		}
+		if mergedBy != "u2" {
+			t.Fatalf("expected the queued merge recorded as by u2, got %q", mergedBy)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected queued merge to go through")
+	}
+}
+
+func TestUpdateTeamSettings_InvalidMergeWindow(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, teamName string) (models.Team, error) {
+		return models.Team{TeamName: teamName}, nil
+	}
+
+	tests := []struct {
+		name   string
+		window models.MergeWindow
+	}{
+		{"неизвестная таймзона", models.MergeWindow{Timezone: "Mars/Olympus", Freezes: []models.MergeFreeze{{From: "Fri 16:00", Until: "Mon 09:00"}}}},
+		{"неверное время", models.MergeWindow{Freezes: []models.MergeFreeze{{From: "Friday", Until: "Mon 09:00"}}}},
+		{"пустая заморозка", models.MergeWindow{Freezes: []models.MergeFreeze{{From: "Fri 16:00", Until: "fri 16:00"}}}},
+		{"вся неделя", models.MergeWindow{Freezes: []models.MergeFreeze{{From: "Mon 00:00", Until: "Thu 00:00"}, {From: "Wed 00:00", Until: "Mon 00:00"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.UpdateTeamSettings(context.Background(), models.TeamSettings{TeamName: "teamA", MergeWindow: &tt.window})
+			if !errors.Is(err, service.ErrInvalidSettings) {
+				t.Fatalf("expected ErrInvalidSettings, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAlerts_FireAndResolve(t *testing.T) {
+	mockR := &mockRepo{}
+	var mu sync.Mutex
+	sig := models.AlertSignals{OpenPRs: 10, WithoutReviewers: 5, OverSLA: 1}
+	mockR.GetAlertSignalsFunc = func(ctx context.Context, cutoff time.Time) (models.AlertSignals, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return sig, nil
+	}
+	n := &recordingNotifier{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithNotifier(n))
+	defer svc.StopWorkers()
+
+	svc.StartAlerts(service.AlertConfig{
+		Interval:               5 * time.Millisecond,
+		MaxPRsWithoutReviewers: 3,
+		MaxSLABreachPct:        50,
+		SLA:                    time.Hour,
+	})
+
+	waitFor := func(cond func([]models.Alert) bool) []models.Alert {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			alerts, err := svc.ActiveAlerts(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cond(alerts) {
+				return alerts
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatal("condition not reached")
+		return nil
+	}
+
+	alerts := waitFor(func(a []models.Alert) bool { return len(a) == 1 })
+	if alerts[0].Rule != service.AlertPRsWithoutReviewers || alerts[0].Value != 5 {
+		t.Fatalf("unexpected alert %+v", alerts[0])
+	}
+
+	mu.Lock()
+	sig = models.AlertSignals{OpenPRs: 10, WithoutReviewers: 0, OverSLA: 1}
+	mu.Unlock()
+	waitFor(func(a []models.Alert) bool { return len(a) == 0 && len(n.kinds()) == 2 })
+
+	kinds := n.kinds()
+	if len(kinds) != 2 || kinds[0] != "alert.firing" || kinds[1] != "alert.resolved" {
+		t.Fatalf("unexpected notifications %v", kinds)
+	}
+}
+
+func TestReminders_UnacknowledgedFirst(t *testing.T) {
+	mockR := &mockRepo{}
+	assigned := time.Now().Add(-2 * time.Hour)
+	acked := assigned.Add(time.Minute)
+	mockR.GetPendingReviewsFunc = func(ctx context.Context) ([]models.PendingReview, error) {
+		return []models.PendingReview{
+			{PullRequestID: "pr1", PullRequestName: "Fix login", UserID: "u2", AssignedAt: assigned},
+			{PullRequestID: "pr2", PullRequestName: "Add search", UserID: "u3", AssignedAt: assigned, AcknowledgedAt: &acked},
+		}, nil
+	}
+	n := &recordingNotifier{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithNotifier(n))
+	defer svc.StopWorkers()
+
+	svc.StartReminders(service.ReminderConfig{Interval: 5 * time.Millisecond, After: 24 * time.Hour, UnackedAfter: time.Hour})
+
+	deadline := time.Now().Add(time.Second)
+	for len(n.kinds()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.msgs) != 1 {
+		t.Fatalf("expected one reminder not repeated across ticks, got %+v", n.msgs)
+	}
+	if msg := n.msgs[0]; msg.Kind != "review.reminder" || msg.Fields["pull_request_id"] != "pr1" || msg.Fields["acknowledged"] != "false" {
+		t.Fatalf("unexpected reminder %+v", msg)
+	}
+}
+
+func TestSchedule_LeaderOnly(t *testing.T) {
+	mockR := &mockRepo{}
+	var runs atomic.Int32
+	mockR.EndVacationsFunc = func(ctx context.Context, now time.Time) ([]string, error) {
+		runs.Add(1)
+		return nil, nil
+	}
+	var leader atomic.Bool
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithLeader(leader.Load))
+	defer svc.StopWorkers()
+
+	svc.StartVacations(5 * time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if n := runs.Load(); n != 0 {
+		t.Fatalf("expected no runs on a follower, got %d", n)
+	}
+
+	leader.Store(true)
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if runs.Load() == 0 {
+		t.Fatal("expected the leader to run the task")
+	}
+}
+
+func TestReviewSLA(t *testing.T) {
+	mockR := &mockRepo{}
+	now := time.Now()
+	mockR.GetPendingReviewsFunc = func(ctx context.Context) ([]models.PendingReview, error) {
+		return []models.PendingReview{
+			{PullRequestID: "pr1", PullRequestName: "Fix login", TeamName: "alpha", UserID: "u2", AssignedAt: now.Add(-50 * time.Hour)},
+			{PullRequestID: "pr2", PullRequestName: "Add search", TeamName: "alpha", UserID: "u3", AssignedAt: now.Add(-time.Hour)},
+			{PullRequestID: "pr3", PullRequestName: "Bump deps", TeamName: "beta", UserID: "u4", AssignedAt: now.Add(-100 * time.Hour)},
+		}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		if teamName != "alpha" {
+			return models.TeamSettings{}, errors.New("not found")
+		}
+		return models.TeamSettings{TeamName: teamName, ReviewSLA: &models.ReviewSLA{Hours: 48}}, nil
+	}
+	n := &recordingNotifier{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithNotifier(n))
+	defer svc.StopWorkers()
+
+	overdue, err := svc.OverdueReviews(context.Background(), "")
+	if err != nil || len(overdue) != 1 || overdue[0].PullRequestID != "pr1" || !overdue[0].DueAt.Equal(overdue[0].AssignedAt.Add(48*time.Hour)) {
+		t.Fatalf("unexpected overdue reviews %+v, err=%v", overdue, err)
+	}
+	scoped := service.WithScope(context.Background(), service.Scope{TeamName: "beta"})
+	if _, err := svc.OverdueReviews(scoped, "alpha"); err != service.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	svc.StartReviewSLA(5 * time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for len(n.kinds()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.msgs) != 1 || n.msgs[0].Kind != "review.overdue" || n.msgs[0].Fields["pull_request_id"] != "pr1" {
+		t.Fatalf("expected one overdue notification for pr1, got %+v", n.msgs)
+	}
+}
+
+func TestReviewSLA_Rounds(t *testing.T) {
+	mockR := &mockRepo{}
+	now := time.Now()
+	roundStart, roundDue := now.Add(-10*time.Hour), now.Add(-time.Hour)
+	mockR.GetPendingReviewsFunc = func(ctx context.Context) ([]models.PendingReview, error) {
+		return []models.PendingReview{
+			// Past the round's own deadline though well within the SLA.
+			{PullRequestID: "pr1", TeamName: "alpha", UserID: "u2", AssignedAt: now.Add(-20 * time.Hour), RoundStartedAt: &roundStart, RoundDueAt: &roundDue},
+			// Long assigned, but the SLA counts from the new round.
+			{PullRequestID: "pr2", TeamName: "alpha", UserID: "u3", AssignedAt: now.Add(-100 * time.Hour), RoundStartedAt: &roundStart},
+			// A round deadline applies without a team SLA.
+			{PullRequestID: "pr3", TeamName: "beta", UserID: "u4", AssignedAt: now.Add(-20 * time.Hour), RoundStartedAt: &roundStart, RoundDueAt: &roundDue},
+		}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		if teamName != "alpha" {
+			return models.TeamSettings{}, errors.New("not found")
+		}
+		return models.TeamSettings{TeamName: teamName, ReviewSLA: &models.ReviewSLA{Hours: 48}}, nil
+	}
+	svc := newTestService(mockR)
+
+	overdue, err := svc.OverdueReviews(context.Background(), "")
+	if err != nil || len(overdue) != 2 || overdue[0].PullRequestID != "pr1" || overdue[1].PullRequestID != "pr3" {
+		t.Fatalf("unexpected overdue reviews %+v, err=%v", overdue, err)
+	}
+	if !overdue[0].DueAt.Equal(roundDue) {
+		t.Fatalf("expected the round deadline, got %v", overdue[0].DueAt)
+	}
+}
+
+func TestStaleReviews_Reassigned(t *testing.T) {
+	mockR := &mockRepo{}
+	var mu sync.Mutex
+	var cutoff time.Time
+	replaced := map[string]string{}
+	escalated := map[string]string{}
+	mockR.GetStaleReviewsFunc = func(ctx context.Context, inactiveBefore time.Time) ([]models.PendingReview, error) {
+		mu.Lock()
+		cutoff = inactiveBefore
+		mu.Unlock()
+		return []models.PendingReview{
+			{PullRequestID: "pr1", TeamName: "alpha", UserID: "u1"},
+			{PullRequestID: "pr2", TeamName: "beta", UserID: "u9"},
+		}, nil
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, AuthorID: "author", Status: models.StatusOpen}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		if team == "alpha" {
+			return []string{"u2"}, nil
+		}
+		return nil, nil
+	}
+	var cause repo.AssignmentCause
+	mockR.ReplaceReviewerFunc = func(ctx context.Context, prID, oldUser, newUser string) (models.PullRequest, error) {
+		mu.Lock()
+		replaced[prID] = oldUser + "->" + newUser
+		cause, _ = repo.AssignmentCauseFromContext(ctx)
+		mu.Unlock()
+		return models.PullRequest{PullRequestID: prID}, nil
+	}
+	mockR.StartEscalationFunc = func(ctx context.Context, prID, reason string) error {
+		mu.Lock()
+		escalated[prID] = reason
+		mu.Unlock()
+		return nil
+	}
+	svc := service.NewService(mockR, &dummyLogger{})
+	defer svc.StopWorkers()
+
+	svc.StartStaleReassign(service.StaleReviewConfig{Interval: 5 * time.Millisecond, Days: 3})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := len(replaced) > 0 && len(escalated) > 0
+		mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if replaced["pr1"] != "u1->u2" || len(replaced) != 1 {
+		t.Fatalf("expected pr1 handed from u1 to u2, got %v", replaced)
+	}
+	if cause.Reason != "stale" || cause.TriggeredBy != "scheduler:stale_reviews" {
+		t.Fatalf("expected the handoff recorded as stale by the scheduler, got %+v", cause)
+	}
+	if escalated["pr2"] != models.EscalationReassignFailed {
+		t.Fatalf("expected pr2 escalated, got %v", escalated)
+	}
+	if d := time.Since(cutoff); d < 72*time.Hour-time.Minute || d > 72*time.Hour+time.Minute {
+		t.Fatalf("expected a cutoff three days back, got %v", d)
+	}
+}
+
+func TestReviewTimeouts_Reassigned(t *testing.T) {
+	mockR := &mockRepo{}
+	now := time.Now()
+	acked := now.Add(-time.Hour)
+	mockR.GetPendingReviewsFunc = func(ctx context.Context) ([]models.PendingReview, error) {
+		return []models.PendingReview{
+			{PullRequestID: "pr1", PullRequestName: "Fix login", TeamName: "alpha", UserID: "u1", AssignedAt: now.Add(-50 * time.Hour)},
+			{PullRequestID: "pr2", PullRequestName: "Add search", TeamName: "alpha", UserID: "u1", AssignedAt: now.Add(-50 * time.Hour), AcknowledgedAt: &acked},
+			{PullRequestID: "pr3", PullRequestName: "Bump deps", TeamName: "alpha", UserID: "u1", AssignedAt: now.Add(-time.Hour)},
+		}, nil
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, AuthorID: "author", Status: models.StatusOpen,
+			Assigned: []models.PRReviewer{{UserID: "u1"}}}, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, team, exclude string) ([]string, error) {
+		return []string{"u1", "u2"}, nil
+	}
+	var mu sync.Mutex
+	replaced := map[string]string{}
+	var cause repo.AssignmentCause
+	mockR.ReplaceReviewerFunc = func(ctx context.Context, prID, oldUser, newUser string) (models.PullRequest, error) {
+		mu.Lock()
+		replaced[prID] = oldUser + "->" + newUser
+		cause, _ = repo.AssignmentCauseFromContext(ctx)
+		mu.Unlock()
+		return models.PullRequest{PullRequestID: prID}, nil
+	}
+	n := &recordingNotifier{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithNotifier(n))
+	defer svc.StopWorkers()
+
+	svc.StartReviewTimeouts(service.ReviewTimeoutConfig{Interval: 5 * time.Millisecond, After: 48 * time.Hour})
+	deadline := time.Now().Add(time.Second)
+	for len(n.kinds()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if replaced["pr1"] != "u1->u2" || len(replaced) != 1 {
+		t.Fatalf("expected only pr1 handed from u1 to u2, got %v", replaced)
+	}
+	if cause.Reason != "timeout" || cause.TriggeredBy != "scheduler:review_timeout" {
+		t.Fatalf("expected the handoff recorded as timeout by the scheduler, got %+v", cause)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.msgs) == 0 || n.msgs[0].Kind != "review.timeout" || n.msgs[0].Fields["to"] != "u1,u2" {
+		t.Fatalf("expected a timeout notification to u1 and u2, got %+v", n.msgs)
+	}
+}
+
+func TestArchiver_MovesFinishedPRs(t *testing.T) {
+	mockR := &mockRepo{}
+	merged := time.Now().Add(-100 * 24 * time.Hour)
+	var before time.Time
+	mockR.GetArchivablePRsFunc = func(ctx context.Context, b time.Time, limit int) ([]string, error) {
+		before = b
+		return []string{"pr1"}, nil
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, AuthorID: "author", TeamName: "alpha", Status: models.StatusMerged, MergedAt: &merged}, nil
+	}
+	mockR.GetAssignmentHistoryFunc = func(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+		return []models.AssignmentEvent{{PullRequestID: prID, UserID: "u1", Action: "assigned", Reason: "random"}}, nil
+	}
+	var mu sync.Mutex
+	var archived []models.ArchivedPR
+	mockR.ArchivePRFunc = func(ctx context.Context, a models.ArchivedPR) error {
+		mu.Lock()
+		defer mu.Unlock()
+		archived = append(archived, a)
+		return nil
+	}
+	svc := service.NewService(mockR, &dummyLogger{})
+	defer svc.StopWorkers()
+
+	svc.StartArchiver(service.ArchiveConfig{Interval: 5 * time.Millisecond, After: 90 * 24 * time.Hour})
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(archived)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(archived) == 0 {
+		t.Fatal("expected pr1 to be archived")
+	}
+	a := archived[0]
+	if a.PR.PullRequestID != "pr1" || len(a.History) != 1 || a.ArchivedAt.IsZero() {
+		t.Fatalf("expected pr1 archived with its history, got %+v", a)
+	}
+	if d := time.Since(before); d < 89*24*time.Hour || d > 91*24*time.Hour {
+		t.Fatalf("expected PRs finished 90 days ago to be archived, got cutoff %s ago", d)
+	}
+}
+
+func TestGetArchivedPR_Scope(t *testing.T) {
+	mockR := &mockRepo{}
+	mockR.GetArchivedPRFunc = func(ctx context.Context, prID string) (models.ArchivedPR, error) {
+		if prID != "pr1" {
+			return models.ArchivedPR{}, errors.New("not found")
+		}
+		return models.ArchivedPR{PR: models.PullRequest{PullRequestID: prID, TeamName: "alpha", Status: models.StatusMerged}}, nil
+	}
+	svc := newTestService(mockR)
+
+	if _, err := svc.GetArchivedPR(service.WithScope(context.Background(), service.Scope{TeamName: "alpha"}), "pr1"); err != nil {
+		t.Fatalf("expected alpha to read its archived PR, got %v", err)
+	}
+	if _, err := svc.GetArchivedPR(service.WithScope(context.Background(), service.Scope{TeamName: "beta"}), "pr1"); !errors.Is(err, service.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for another team, got %v", err)
+	}
+	if _, err := svc.GetArchivedPR(context.Background(), "pr2"); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestEscalations_WalkChain(t *testing.T) {
+	mockR := &mockRepo{}
+	var mu sync.Mutex
+	nextHop := 0
+	var recorded []string
+	started := time.Now().Add(-90 * time.Minute)
+	mockR.StartSLAEscalationsFunc = func(ctx context.Context, cutoff time.Time) (int, error) {
+		return 0, nil
+	}
+	mockR.GetActiveEscalationsFunc = func(ctx context.Context) ([]models.Escalation, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []models.Escalation{{
+			PullRequestID: "pr1", PullRequestName: "Fix login", TeamName: "alpha",
+			Reason: models.EscalationSLA, StartedAt: started, NextHop: nextHop, Reviewers: []string{"u2", "u3"},
+		}}, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, Escalation: []models.EscalationHop{
+			{To: models.EscalationTarget},
+			{To: "lead", DelayMinutes: 60, Title: "team lead: stuck PR"},
+			{To: "head", DelayMinutes: 240},
+		}}, nil
+	}
+	mockR.AdvanceEscalationFunc = func(ctx context.Context, prID, reason string, hop int, recipients []string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if hop != nextHop {
+			return errors.New("conflict")
+		}
+		nextHop++
+		recorded = append(recorded, strings.Join(recipients, ","))
+		return nil
+	}
+	n := &recordingNotifier{}
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithNotifier(n))
+	defer svc.StopWorkers()
+
+	svc.StartEscalations(service.EscalationConfig{Interval: 5 * time.Millisecond, SLA: time.Hour})
+
+	deadline := time.Now().Add(time.Second)
+	for len(n.kinds()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(n.msgs) != 2 || len(recorded) != 2 || recorded[0] != "u2,u3" || recorded[1] != "lead" {
+		t.Fatalf("expected the first two hops once each, got %+v (recorded %v)", n.msgs, recorded)
+	}
+	if msg := n.msgs[1]; msg.Kind != "review.escalation" || msg.Title != "team lead: stuck PR" || msg.Fields["hop"] != "2" {
+		t.Fatalf("unexpected escalation %+v", msg)
+	}
+}
+
+func TestAcknowledgeReview(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	status := models.StatusOpen
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, Status: status, Assigned: []models.PRReviewer{{UserID: "u2"}}}, nil
+	}
+	mockR.AcknowledgeReviewFunc = func(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+		if userID != "u2" {
+			return models.PullRequest{}, errors.New("not assigned")
+		}
+		now := time.Now()
+		return models.PullRequest{PullRequestID: prID, Assigned: []models.PRReviewer{{UserID: "u2", AcknowledgedAt: &now}}}, nil
+	}
+
+	pr, err := svc.AcknowledgeReview(context.Background(), "pr1", "u2")
+	if err != nil || pr.Assigned[0].AcknowledgedAt == nil {
+		t.Fatalf("expected acknowledged reviewer, got %+v err=%v", pr, err)
+	}
+	if _, err := svc.AcknowledgeReview(context.Background(), "pr1", "u9"); err != service.ErrNotAssigned {
+		t.Fatalf("expected ErrNotAssigned, got %v", err)
+	}
+	status = models.StatusMerged
+	if _, err := svc.AcknowledgeReview(context.Background(), "pr1", "u2"); err != service.ErrPRMerged {
+		t.Fatalf("expected ErrPRMerged, got %v", err)
+	}
+}
+
+func TestPRStatusLifecycle(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	pr := models.PullRequest{PullRequestID: "pr1", TeamName: "alpha", Status: models.StatusOpen,
+		Assigned: []models.PRReviewer{{UserID: "u2", IsActive: true}, {UserID: "u3", IsActive: true}}}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.GetTeamSettingsFunc = func(ctx context.Context, teamName string) (models.TeamSettings, error) {
+		return models.TeamSettings{TeamName: teamName, RequiredApprovals: 2}, nil
+	}
+	mockR.AcknowledgeReviewFunc = func(ctx context.Context, prID, userID string) (models.PullRequest, error) {
+		return pr, nil
+	}
+	mockR.ApprovePRFunc = func(ctx context.Context, prID, userID string, at time.Time) (models.PullRequest, error) {
+		for i := range pr.Assigned {
+			if pr.Assigned[i].UserID == userID {
+				pr.Assigned[i].Status = models.ReviewApproved
+			}
+		}
+		return pr, nil
+	}
+	var moves []string
+	mockR.SetPRStatusFunc = func(ctx context.Context, prID string, from, to models.PRStatus) error {
+		if from != pr.Status {
+			return errors.New("conflict")
+		}
+		moves = append(moves, string(from)+">"+string(to))
+		pr.Status = to
+		return nil
+	}
+
+	got, err := svc.AcknowledgeReview(context.Background(), "pr1", "u2")
+	if err != nil || got.Status != models.StatusInReview {
+		t.Fatalf("expected IN_REVIEW after ack, got %q err=%v", got.Status, err)
+	}
+	if got, err = svc.ApprovePR(context.Background(), "pr1", "u2"); err != nil || got.Status != models.StatusInReview {
+		t.Fatalf("expected IN_REVIEW below quorum, got %q err=%v", got.Status, err)
+	}
+	if got, err = svc.ApprovePR(context.Background(), "pr1", "u3"); err != nil || got.Status != models.StatusApproved {
+		t.Fatalf("expected APPROVED at quorum, got %q err=%v", got.Status, err)
+	}
+	if want := "OPEN>IN_REVIEW IN_REVIEW>APPROVED"; strings.Join(moves, " ") != want {
+		t.Fatalf("moves = %v, want %v", moves, want)
+	}
+
+	pr.Status = models.StatusMerged
+	_, err = svc.ReopenPR(context.Background(), "pr1")
+	var se *service.StatusError
+	if !errors.As(err, &se) || se.From != models.StatusMerged || se.To != models.StatusOpen {
+		t.Fatalf("expected StatusError MERGED>OPEN, got %v", err)
+	}
+	if !errors.Is(err, service.ErrBadTransition) || !errors.Is(err, service.ErrPRMerged) {
+		t.Fatalf("expected StatusError to match ErrBadTransition and ErrPRMerged, got %v", err)
+	}
+}
+
+func TestRemoveTeamMember_HandsOffReviews(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name, Members: []models.TeamMember{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}}}, nil
+	}
+	mockR.GetPRsByReviewerFunc = func(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+		return []models.PullRequestShort{
+			{PullRequestID: "pr1", Status: "OPEN"},
+			{PullRequestID: "pr2", Status: "OPEN"},
+			{PullRequestID: "pr3", Status: "MERGED"},
+		}, nil
+	}
+	var removed []string
+	mockR.UpdateTeamFunc = func(ctx context.Context, upd models.TeamUpdate) (models.Team, error) {
+		removed = upd.Remove
+		return models.Team{TeamName: upd.TeamName}, nil
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		pr := models.PullRequest{PullRequestID: prID, AuthorID: "u1", Status: "OPEN", TeamName: "alpha",
+			Assigned: []models.PRReviewer{{UserID: "u2"}}}
+		if prID == "pr2" {
+			pr.AuthorID = "u3"
+		}
+		return pr, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, teamName, exclude string) ([]string, error) {
+		return []string{"u1", "u3"}, nil
+	}
+	mockR.ReplaceReviewerFunc = func(ctx context.Context, prID, oldUser, newUser string) (models.PullRequest, error) {
+		return models.PullRequest{PullRequestID: prID, Assigned: []models.PRReviewer{{UserID: newUser}}}, nil
+	}
+	var cleaned []string
+	mockR.CleanupInactiveReviewersFunc = func(ctx context.Context, prID string) error {
+		cleaned = append(cleaned, prID)
+		return nil
+	}
+
+	h, err := svc.RemoveTeamMember(context.Background(), "alpha", "u2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "u2" {
+		t.Fatalf("expected u2 removed, got %v", removed)
+	}
+	if len(h.Reassigned) != 2 || h.Reassigned[0].PullRequestID != "pr1" || h.Reassigned[0].NewReviewerID != "u3" ||
+		h.Reassigned[1].NewReviewerID != "u1" {
+		t.Fatalf("unexpected handoff %+v", h)
+	}
+	if len(h.Unassigned) != 0 || len(cleaned) != 0 {
+		t.Fatalf("expected no unassigned PRs, got %v (cleaned %v)", h.Unassigned, cleaned)
+	}
+}
+
+func TestBulkReassign(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		switch userID {
+		case "u2", "u5":
+			return models.User{UserID: userID, IsActive: true}, nil
+		case "u6":
+			return models.User{UserID: userID}, nil
+		}
+		return models.User{}, errors.New("not found")
+	}
+	mockR.GetPRsByReviewerFunc = func(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+		return []models.PullRequestShort{
+			{PullRequestID: "pr1", Status: "OPEN"},
+			{PullRequestID: "pr2", Status: "OPEN"},
+			{PullRequestID: "pr3", Status: "MERGED"},
+		}, nil
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		pr := models.PullRequest{PullRequestID: prID, AuthorID: "u1", Status: "OPEN", TeamName: "alpha",
+			Assigned: []models.PRReviewer{{UserID: "u2"}}}
+		if prID == "pr2" {
+			pr.AuthorID = "u5"
+		}
+		return pr, nil
+	}
+	var replaced []string
+	mockR.ReplaceReviewerFunc = func(ctx context.Context, prID, oldUser, newUser string) (models.PullRequest, error) {
+		replaced = append(replaced, prID+":"+oldUser+"->"+newUser)
+		return models.PullRequest{PullRequestID: prID, Assigned: []models.PRReviewer{{UserID: newUser}}}, nil
+	}
+
+	report, err := svc.BulkReassign(context.Background(), "u2", "u5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Results) != 2 || report.Results[0].NewReviewerID != "u5" || report.Results[1].Error == "" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if len(replaced) != 1 || replaced[0] != "pr1:u2->u5" {
+		t.Fatalf("unexpected replacements %v", replaced)
+	}
+
+	if _, err := svc.BulkReassign(context.Background(), "u2", "u6"); err != service.ErrUserInactive {
+		t.Fatalf("expected ErrUserInactive, got %v", err)
+	}
+	if _, err := svc.BulkReassign(context.Background(), "u9", ""); err != service.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRemoveTeamMember_NotMember(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		return models.Team{TeamName: name, Members: []models.TeamMember{{UserID: "u1"}}}, nil
+	}
+	if _, err := svc.RemoveTeamMember(context.Background(), "alpha", "u9"); err != service.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMoveUserTeam_ReassignsOldTeamReviews(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, TeamName: "alpha", IsActive: true}, nil
+	}
+	mockR.GetPRsByReviewerFunc = func(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+		return []models.PullRequestShort{
+			{PullRequestID: "pr1", Status: "OPEN"},
+			{PullRequestID: "pr2", Status: "OPEN"},
+			{PullRequestID: "pr3", Status: "MERGED"},
+		}, nil
+	}
+	mockR.GetPRFunc = func(ctx context.Context, prID string) (models.PullRequest, error) {
+		pr := models.PullRequest{PullRequestID: prID, AuthorID: "u1", Status: "OPEN", TeamName: "alpha",
+			Assigned: []models.PRReviewer{{UserID: "u2"}}}
+		if prID == "pr2" {
+			pr.TeamName = "beta"
+		}
+		return pr, nil
+	}
+	mockR.GetActiveTeamMembersExceptFunc = func(ctx context.Context, teamName, exclude string) ([]string, error) {
+		return []string{"u1", "u2", "u3"}, nil
+	}
+	var gotHandoffs []models.ReviewHandoff
+	mockR.UpdateUserTeamFunc = func(ctx context.Context, userID, teamName string, handoffs []models.ReviewHandoff) (models.User, error) {
+		gotHandoffs = handoffs
+		return models.User{UserID: userID, TeamName: teamName, IsActive: true}, nil
+	}
+
+	move, err := svc.MoveUserTeam(context.Background(), "u2", "gamma", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if move.FromTeam != "alpha" || move.User.TeamName != "gamma" {
+		t.Fatalf("unexpected move %+v", move)
+	}
+	if len(gotHandoffs) != 1 || gotHandoffs[0].PullRequestID != "pr1" || gotHandoffs[0].NewReviewerID != "u3" {
+		t.Fatalf("unexpected handoffs %+v", gotHandoffs)
+	}
+	if len(move.Kept) != 1 || move.Kept[0] != "pr2" {
+		t.Fatalf("expected pr2 kept, got %v", move.Kept)
+	}
+}
+
+func TestMoveUserTeam_WithoutReassign(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.GetUserFunc = func(ctx context.Context, userID string) (models.User, error) {
+		return models.User{UserID: userID, TeamName: "alpha", IsActive: true}, nil
+	}
+	mockR.GetPRsByReviewerFunc = func(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+		return []models.PullRequestShort{{PullRequestID: "pr1", Status: "OPEN"}}, nil
+	}
+	mockR.UpdateUserTeamFunc = func(ctx context.Context, userID, teamName string, handoffs []models.ReviewHandoff) (models.User, error) {
+		if len(handoffs) != 0 {
+			t.Fatalf("expected no handoffs, got %v", handoffs)
+		}
+		return models.User{UserID: userID, TeamName: teamName}, nil
+	}
+
+	move, err := svc.MoveUserTeam(context.Background(), "u2", "gamma", false)
+	if err != nil || len(move.Kept) != 1 {
+		t.Fatalf("unexpected result %+v, err=%v", move, err)
+	}
+}
+
+func TestDiagnostics_TracksWorkers(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+	defer svc.StopWorkers()
+
+	release := make(chan struct{})
+	mockR.GetTeamFunc = func(ctx context.Context, name string) (models.Team, error) {
+		<-release
+		return models.Team{TeamName: name}, nil
+	}
+
+	job := service.NewJob(context.Background(), "get_team", map[string]interface{}{"team": "alpha"})
+	svc.EnqueueJob(job)
+
+	deadline := time.Now().Add(time.Second)
+	busy := false
+	for !busy && time.Now().Before(deadline) {
+		for _, w := range svc.Diagnostics().Workers {
+			if w.JobType == "get_team" {
+				busy = true
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	<-job.RespCh
+
+	if !busy {
+		t.Fatal("expected a worker to report get_team")
+	}
+	d := svc.Diagnostics()
+	if d.QueueCapacity == 0 || len(d.QueuedByType) != 0 {
+		t.Fatalf("unexpected diagnostics %+v", d)
+	}
+}
+
+func TestCheckConsistency_PassesLimit(t *testing.T) {
+	mockR := &mockRepo{}
+	svc := newTestService(mockR)
+
+	mockR.CheckConsistencyFunc = func(ctx context.Context, want int, repair bool) (models.ConsistencyReport, error) {
+		if want != 2 || !repair {
+			t.Fatalf("unexpected args want=%d repair=%v", want, repair)
+		}
+		return models.ConsistencyReport{Violations: []models.ConsistencyViolation{{Kind: models.ViolationTooManyReviewers}}, Repaired: true}, nil
+	}
+
+	report, err := svc.CheckConsistency(context.Background(), true)
+	if err != nil || len(report.Violations) != 1 || !report.Repaired {
+		t.Fatalf("unexpected report %+v, err=%v", report, err)
+	}
+}
+
+func TestExportAssignments(t *testing.T) {
+	mockR := &mockRepo{}
+	approved := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	mockR.GetAssignmentRecordsFunc = func(ctx context.Context, from, to time.Time) ([]models.AssignmentRecord, error) {
+		if !to.After(from) {
+			t.Errorf("unexpected window %v - %v", from, to)
+		}
+		return []models.AssignmentRecord{{
+			PullRequestID: "pr1", TeamName: "alpha", AuthorID: "u1", ReviewerID: "u2",
+			PRCreatedAt: approved.Add(-2 * time.Hour), AssignedAt: approved.Add(-time.Hour),
+			ApprovedAt: &approved, Status: "OPEN",
+		}}, nil
+	}
+
+	if _, err := newTestService(mockR).ExportAssignments(context.Background(), time.Time{}, time.Time{}); !errors.Is(err, service.ErrExportDisabled) {
+		t.Fatalf("expected ErrExportDisabled, got %v", err)
+	}
+
+	svc := service.NewService(mockR, &dummyLogger{}, service.WithAssignmentExport("salt"))
+	scoped := service.WithScope(context.Background(), service.Scope{TeamName: "alpha"})
+	if _, err := svc.ExportAssignments(scoped, time.Time{}, time.Time{}); !errors.Is(err, service.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	recs, err := svc.ExportAssignments(context.Background(), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recs) != 1 || recs[0].Reviewer == "u2" || recs[0].Reviewer == "" || recs[0].ApproveSec == nil || *recs[0].ApproveSec != 3600 {
+		t.Fatalf("unexpected records %+v", recs)
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// GetTeamSettings returns the team's stored settings with defaults filled in
+// for every section that was never configured.
+func (s *PRService) GetTeamSettings(ctx context.Context, teamName string) (models.TeamSettings, error) {
+	if err := validateTeamName(teamName); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return models.TeamSettings{}, err
+	}
+
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			s.log.Error("failed to get team settings", "team", teamName, "error", err)
+			return models.TeamSettings{}, err
+		}
+		settings = models.TeamSettings{TeamName: teamName}
+	}
+	if settings.Scoring == nil {
+		cfg := defaultScoringConfig()
+		settings.Scoring = &cfg
+	}
+	return settings, nil
+}
+
+// UpdateTeamSettings replaces the team's settings. Sections left nil reset
+// to defaults.
+func (s *PRService) UpdateTeamSettings(ctx context.Context, settings models.TeamSettings) (models.TeamSettings, error) {
+	if err := validateTeamName(settings.TeamName); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if _, ok := ScopeFromContext(ctx); ok {
+		return models.TeamSettings{}, ErrForbidden
+	}
+	if settings.Scoring != nil {
+		if err := validateScoringConfig(*settings.Scoring); err != nil {
+			return models.TeamSettings{}, err
+		}
+		if settings.Scoring.Filters == nil {
+			settings.Scoring.Filters = []string{}
+		}
+	}
+	if err := s.validateDefaultReviewers(ctx, settings.DefaultReviewers); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if _, err := s.GetTeam(ctx, settings.TeamName); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if err := s.validateFallbackTeams(ctx, settings.TeamName, settings.FallbackTeams); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if err := s.validateEscalation(ctx, settings.Escalation); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if err := s.validateSecurityReview(ctx, settings.SecurityReview); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if err := validateWeightedSelection(settings.WeightedSelection); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if err := validateRequiredApprovals(settings.RequiredApprovals); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if err := validateReviewSLA(settings.ReviewSLA); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if err := validateMergeWindow(settings.MergeWindow); err != nil {
+		return models.TeamSettings{}, err
+	}
+	if settings.SecurityReview != nil {
+		settings.SecurityReview.Labels = normalizeLabels(settings.SecurityReview.Labels)
+	}
+
+	if err := s.repo.SaveTeamSettings(ctx, settings); err != nil {
+		s.log.Error("failed to save team settings", "team", settings.TeamName, "error", err)
+		return models.TeamSettings{}, err
+	}
+	s.log.Success("team settings updated", "team", settings.TeamName)
+	return s.GetTeamSettings(ctx, settings.TeamName)
+}
+
+func (s *PRService) validateDefaultReviewers(ctx context.Context, ids []string) error {
+	if len(ids) > maxReviewers {
+		return fmt.Errorf("%w: at most %d default reviewers", ErrInvalidSettings, maxReviewers)
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			return fmt.Errorf("%w: default reviewers must be distinct user ids", ErrInvalidSettings)
+		}
+		seen[id] = true
+		if _, err := s.repo.GetUser(ctx, id); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return fmt.Errorf("%w: unknown default reviewer %q", ErrInvalidSettings, id)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRepositorySettings returns the repository's stored settings, empty for
+// a repository that was never configured.
+func (s *PRService) GetRepositorySettings(ctx context.Context, repository string) (models.RepositorySettings, error) {
+	if _, ok := ScopeFromContext(ctx); ok {
+		return models.RepositorySettings{}, ErrForbidden
+	}
+	settings, err := s.repo.GetRepositorySettings(ctx, repository)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			s.log.Error("failed to get repository settings", "repository", repository, "error", err)
+			return models.RepositorySettings{}, err
+		}
+		settings = models.RepositorySettings{Repository: repository}
+	}
+	if settings.DefaultReviewers == nil {
+		settings.DefaultReviewers = []string{}
+	}
+	return settings, nil
+}
+
+// UpdateRepositorySettings replaces the repository's settings. Repositories
+// span teams, so only an admin may change them.
+func (s *PRService) UpdateRepositorySettings(ctx context.Context, settings models.RepositorySettings) (models.RepositorySettings, error) {
+	if _, ok := ScopeFromContext(ctx); ok {
+		return models.RepositorySettings{}, ErrForbidden
+	}
+	if err := s.validateDefaultReviewers(ctx, settings.DefaultReviewers); err != nil {
+		return models.RepositorySettings{}, err
+	}
+
+	if err := s.repo.SaveRepositorySettings(ctx, settings); err != nil {
+		s.log.Error("failed to save repository settings", "repository", settings.Repository, "error", err)
+		return models.RepositorySettings{}, err
+	}
+	s.log.Success("repository settings updated", "repository", settings.Repository)
+	return s.GetRepositorySettings(ctx, settings.Repository)
+}
+
+// defaultReviewers returns the default reviewers who can take a PR by
+// authorID, the repository's before the team's: existing, active, not the
+// author and at most maxReviewers.
+func (s *PRService) defaultReviewers(ctx context.Context, teamName, repository, authorID string) []models.PRReviewer {
+	var ids []string
+	if repository != "" {
+		repoSettings, err := s.repo.GetRepositorySettings(ctx, repository)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			s.log.Warn("failed to load repository default reviewers", "repository", repository, "error", err)
+		}
+		ids = append(ids, repoSettings.DefaultReviewers...)
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		s.log.Warn("failed to load default reviewers", "team", teamName, "error", err)
+	}
+	ids = append(ids, settings.DefaultReviewers...)
+
+	out := []models.PRReviewer{}
+	for _, id := range ids {
+		if id == authorID || len(out) >= maxReviewers || hasReviewer(out, id) {
+			continue
+		}
+		u, err := s.repo.GetUser(ctx, id)
+		if err != nil || !u.IsActive {
+			continue
+		}
+		out = append(out, models.PRReviewer{UserID: u.UserID, Username: u.Username, IsActive: u.IsActive})
+	}
+	return out
+}
+
+func (s *PRService) validateFallbackTeams(ctx context.Context, teamName string, teams []string) error {
+	if len(teams) > maxFallbackTeams {
+		return fmt.Errorf("%w: at most %d fallback teams", ErrInvalidSettings, maxFallbackTeams)
+	}
+	seen := make(map[string]bool, len(teams))
+	for _, t := range teams {
+		if t == "" || t == teamName || seen[t] {
+			return fmt.Errorf("%w: fallback teams must be distinct other teams", ErrInvalidSettings)
+		}
+		seen[t] = true
+		if _, err := s.repo.GetTeam(ctx, t); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return fmt.Errorf("%w: unknown fallback team %q", ErrInvalidSettings, t)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// fallbackReviewers fills the rest of selected from the backup teams of
+// teamName, skill matches first, then least loaded. Lookup failures leave selected as is: the PR
+// is then created short of reviewers, as it would be without fallback.
+func (s *PRService) fallbackReviewers(ctx context.Context, teamName, authorID string, selected []models.PRReviewer, match map[string]int) ([]models.PRReviewer, error) {
+	teams := s.globalTeams
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	if err == nil && len(settings.FallbackTeams) > 0 {
+		teams = settings.FallbackTeams
+	}
+	pool := make([]string, 0, len(teams))
+	for _, t := range teams {
+		if t != teamName {
+			pool = append(pool, t)
+		}
+	}
+	if len(pool) == 0 {
+		return selected, nil
+	}
+
+	ids, err := s.repo.GetActiveMembersOfTeams(ctx, pool, authorID)
+	if err != nil {
+		s.log.Warn("failed to get fallback candidates", "team", teamName, "error", err)
+		return selected, nil
+	}
+	taken := make(map[string]bool, len(selected))
+	for _, r := range selected {
+		taken[r.UserID] = true
+	}
+	candidateIDs := ids[:0]
+	for _, id := range s.withoutReserved(ctx, authorID, s.withinRampUp(ctx, ids)) {
+		if !taken[id] {
+			candidateIDs = append(candidateIDs, id)
+		}
+	}
+
+	load := map[string]int{}
+	for _, t := range pool {
+		counts, err := s.repo.GetOpenReviewLoad(ctx, t)
+		if err != nil {
+			s.log.Warn("failed to get open review counts", "team", t, "error", err)
+			continue
+		}
+		for id, n := range counts {
+			load[id] = n
+		}
+	}
+
+	selected, _, err = s.pickReviewers(ctx, selected, candidateIDs, load, match, s.selectionWeights(ctx, teamName, candidateIDs))
+	if err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"PR-reviewer/internal/models"
+)
+
+// GetReviewLoad reports the team's open review load by PR size, the effort
+// load-balanced assignment compares reviewers by.
+func (s *PRService) GetReviewLoad(ctx context.Context, teamName string) (models.ReviewLoad, error) {
+	if err := validateTeamName(teamName); err != nil {
+		return models.ReviewLoad{}, err
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return models.ReviewLoad{}, err
+	}
+	if _, err := s.GetTeam(ctx, teamName); err != nil {
+		return models.ReviewLoad{}, err
+	}
+
+	reviewers, err := s.repo.GetReviewLoadBySize(ctx, teamName)
+	if err != nil {
+		s.log.Error("failed to get review load", "team", teamName, "error", err)
+		return models.ReviewLoad{}, err
+	}
+	load := models.ReviewLoad{TeamName: teamName, BySize: map[string]int{}, Reviewers: reviewers}
+	for _, r := range reviewers {
+		for size, n := range r.BySize {
+			load.BySize[size] += n
+		}
+		load.Effort += r.Effort
+	}
+	return load, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/notify"
+)
+
+// maxReviewSLAHours caps a team's review SLA at 30 days.
+const maxReviewSLAHours = 30 * 24
+
+// slaState remembers the deadline each assignment was last reported overdue
+// for, by "pr/user", so each is notified about once per review round.
+type slaState struct {
+	mu       sync.Mutex
+	notified map[string]time.Time
+}
+
+var slaBreaches = metrics.NewCounter("review_sla_breaches_total", "Reviews past their team's SLA, by the action taken: notify or reassign.", "action")
+
+// StartReviewSLA enforces the teams' review SLAs on the scheduler until
+// StopWorkers.
+func (s *PRService) StartReviewSLA(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.schedule("review_sla", interval, true, func(ctx context.Context) {
+		s.enforceReviewSLA(ctx, time.Now())
+	})
+}
+
+// OverdueReviews lists the open reviews past their team's SLA, oldest
+// first. An empty teamName lists every team's, or the token's team for a
+// team-scoped token.
+func (s *PRService) OverdueReviews(ctx context.Context, teamName string) ([]models.OverdueReview, error) {
+	if scope, ok := ScopeFromContext(ctx); ok && teamName == "" {
+		teamName = scope.TeamName
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return nil, err
+	}
+	overdue, _, err := s.overdueReviews(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	out := []models.OverdueReview{}
+	for _, o := range overdue {
+		if teamName == "" || o.TeamName == teamName {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+// overdueReviews returns the overdue reviews and the SLAs of their teams.
+// After changes were requested a review is due by its round's deadline, or
+// the SLA counted from the round's start; reviewers who joined after the
+// round began get the SLA from their assignment.
+func (s *PRService) overdueReviews(ctx context.Context, now time.Time) ([]models.OverdueReview, map[string]models.ReviewSLA, error) {
+	pending, err := s.repo.GetPendingReviews(ctx)
+	if err != nil {
+		s.log.Error("failed to load pending reviews", "error", err)
+		return nil, nil, err
+	}
+
+	slas := make(map[string]models.ReviewSLA)
+	loaded := make(map[string]bool)
+	out := []models.OverdueReview{}
+	for _, p := range pending {
+		if !loaded[p.TeamName] {
+			loaded[p.TeamName] = true
+			settings, err := s.repo.GetTeamSettings(ctx, p.TeamName)
+			if err != nil && !strings.Contains(err.Error(), "not found") {
+				s.log.Warn("failed to load review sla", "team", p.TeamName, "error", err)
+			}
+			if err == nil && settings.ReviewSLA != nil {
+				slas[p.TeamName] = *settings.ReviewSLA
+			}
+		}
+		sla, ok := slas[p.TeamName]
+		start := p.AssignedAt
+		var due time.Time
+		switch {
+		case p.RoundStartedAt != nil && p.RoundStartedAt.After(start) && p.RoundDueAt != nil:
+			due = *p.RoundDueAt
+		case !ok:
+			continue
+		default:
+			if p.RoundStartedAt != nil && p.RoundStartedAt.After(start) {
+				start = *p.RoundStartedAt
+			}
+			due = start.Add(time.Duration(sla.Hours) * time.Hour)
+		}
+		if now.Before(due) {
+			continue
+		}
+		out = append(out, models.OverdueReview{
+			PullRequestID:   p.PullRequestID,
+			PullRequestName: p.PullRequestName,
+			TeamName:        p.TeamName,
+			UserID:          p.UserID,
+			AssignedAt:      p.AssignedAt.UTC(),
+			DueAt:           due.UTC(),
+		})
+	}
+	return out, slas, nil
+}
+
+// enforceReviewSLA reassigns or reports each newly overdue review. A review
+// nobody could take over is reported instead.
+func (s *PRService) enforceReviewSLA(ctx context.Context, now time.Time) {
+	workerLog := s.log.WithWorker("scheduler-review_sla")
+
+	overdue, slas, err := s.overdueReviews(ctx, now)
+	if err != nil {
+		return
+	}
+
+	cache := newOpCache(s.repo)
+	var msgs []notify.Message
+	s.sla.mu.Lock()
+	if s.sla.notified == nil {
+		s.sla.notified = make(map[string]time.Time)
+	}
+	live := make(map[string]bool, len(overdue))
+	for _, o := range overdue {
+		key := o.PullRequestID + "/" + o.UserID
+		live[key] = true
+		if s.sla.notified[key].Equal(o.DueAt) {
+			continue
+		}
+
+		if slas[o.TeamName].Action == models.SLAReassign {
+			newUID, err := s.reassignReviewer(ctx, cache, o.PullRequestID, o.UserID, o.TeamName, "sla")
+			if err == nil {
+				slaBreaches.Inc(models.SLAReassign)
+				workerLog.Info("overdue review reassigned", "pr", o.PullRequestID, "old_user", o.UserID, "new_user", newUID)
+				continue
+			}
+			if !errors.Is(err, ErrNoCandidate) {
+				workerLog.Warn("failed to reassign overdue review", "pr", o.PullRequestID, "user", o.UserID, "error", err)
+				continue
+			}
+		}
+		s.sla.notified[key] = o.DueAt
+		msgs = append(msgs, overdueMessage(o, now))
+	}
+	for key := range s.sla.notified {
+		if !live[key] {
+			delete(s.sla.notified, key)
+		}
+	}
+	s.sla.mu.Unlock()
+
+	for _, msg := range msgs {
+		slaBreaches.Inc(models.SLANotify)
+		if err := s.notifier.Notify(ctx, msg); err != nil {
+			workerLog.Error("failed to send overdue review notification", "pr", msg.Fields["pull_request_id"], "user", msg.Fields["user_id"], "error", err)
+		}
+	}
+}
+
+func overdueMessage(o models.OverdueReview, now time.Time) notify.Message {
+	return notify.Message{
+		Kind:  "review.overdue",
+		Title: "review overdue: " + o.PullRequestName,
+		Text:  fmt.Sprintf("%s has been waiting for review by %s for %s, past the %s team SLA", o.PullRequestID, o.UserID, now.Sub(o.AssignedAt).Round(time.Minute), o.TeamName),
+		Fields: map[string]string{
+			"pull_request_id": o.PullRequestID,
+			"team_name":       o.TeamName,
+			"user_id":         o.UserID,
+			"due_at":          o.DueAt.Format(time.RFC3339),
+		},
+		At: now.UTC(),
+	}
+}
+
+func validateReviewSLA(sla *models.ReviewSLA) error {
+	if sla == nil {
+		return nil
+	}
+	if sla.Hours < 1 || sla.Hours > maxReviewSLAHours {
+		return fmt.Errorf("%w: review_sla.hours must be 1..%d", ErrInvalidSettings, maxReviewSLAHours)
+	}
+	switch sla.Action {
+	case "", models.SLANotify, models.SLAReassign:
+		return nil
+	}
+	return fmt.Errorf("%w: review_sla.action must be %q or %q", ErrInvalidSettings, models.SLANotify, models.SLAReassign)
+}
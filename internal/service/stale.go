@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// StaleReviewConfig controls the handoff of reviews held by users who have
+// been inactive for Days days. A zero Interval disables it.
+type StaleReviewConfig struct {
+	Interval time.Duration
+	Days     int
+}
+
+// StartStaleReassign hands off stale reviews on the scheduler until
+// StopWorkers.
+func (s *PRService) StartStaleReassign(cfg StaleReviewConfig) {
+	if cfg.Interval <= 0 || cfg.Days <= 0 {
+		return
+	}
+	s.schedule("stale_reviews", cfg.Interval, true, func(ctx context.Context) {
+		s.reassignStale(ctx, time.Now().UTC().AddDate(0, 0, -cfg.Days))
+	})
+}
+
+// reassignStale replaces every reviewer inactive since before cutoff with
+// another active member of the PR's team. A PR nobody can take over is
+// escalated.
+func (s *PRService) reassignStale(ctx context.Context, cutoff time.Time) {
+	workerLog := s.log.WithWorker("scheduler-stale_reviews")
+
+	stale, err := s.repo.GetStaleReviews(ctx, cutoff)
+	if err != nil {
+		workerLog.Warn("failed to load stale reviews", "error", err)
+		return
+	}
+
+	cache := newOpCache(s.repo)
+	reassigned := 0
+	for _, p := range stale {
+		newUID, err := s.reassignReviewer(ctx, cache, p.PullRequestID, p.UserID, p.TeamName, "stale")
+		if err != nil {
+			if errors.Is(err, ErrNoCandidate) {
+				workerLog.Warn("no replacement for stale review", "pr", p.PullRequestID, "user", p.UserID, "team", p.TeamName)
+				s.escalateReassignFailure(ctx, p.PullRequestID)
+				continue
+			}
+			workerLog.Warn("failed to reassign stale review", "pr", p.PullRequestID, "user", p.UserID, "error", err)
+			continue
+		}
+		reassigned++
+		workerLog.Info("stale review reassigned", "pr", p.PullRequestID, "old_user", p.UserID, "new_user", newUID)
+	}
+	if reassigned > 0 {
+		workerLog.Success("stale reviews reassigned", "count", reassigned)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+// prTransitions lists where a PR may move from each status. Review moves
+// forward from OPEN through IN_REVIEW to APPROVED, possibly skipping a step
+// when one approval is enough, and a change request sends an approved PR
+// back to review. Any active PR can be merged (the approval quorum is
+// checked on its own) or closed. A closed PR can only be reopened; a merged
+// one is final.
+var prTransitions = map[models.PRStatus][]models.PRStatus{
+	models.StatusOpen:     {models.StatusInReview, models.StatusApproved, models.StatusMerged, models.StatusClosed},
+	models.StatusInReview: {models.StatusApproved, models.StatusMerged, models.StatusClosed},
+	models.StatusApproved: {models.StatusInReview, models.StatusMerged, models.StatusClosed},
+	models.StatusClosed:   {models.StatusOpen},
+}
+
+// checkTransition returns a *StatusError unless a PR may move from from to
+// to.
+func checkTransition(from, to models.PRStatus) error {
+	for _, next := range prTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return &StatusError{From: from, To: to}
+}
+
+// checkActive returns ErrPRMerged or ErrPRClosed for a PR that can no longer
+// be worked on, for operations that leave its status alone.
+func checkActive(status models.PRStatus) error {
+	switch status {
+	case models.StatusMerged:
+		return ErrPRMerged
+	case models.StatusClosed:
+		return ErrPRClosed
+	}
+	return nil
+}
+
+// moveStatus moves pr to status to, or leaves it be when it's already there.
+// A PR another request moved in the meantime is left as that request left
+// it.
+func (s *PRService) moveStatus(ctx context.Context, pr models.PullRequest, to models.PRStatus) (models.PullRequest, error) {
+	if pr.Status == to {
+		return pr, nil
+	}
+	if err := checkTransition(pr.Status, to); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := s.repo.SetPRStatus(ctx, pr.PullRequestID, pr.Status, to); err != nil {
+		if strings.Contains(err.Error(), "conflict") {
+			s.log.Warn("pr status changed concurrently", "pr", pr.PullRequestID, "from", pr.Status, "to", to)
+			return s.repo.GetPR(ctx, pr.PullRequestID)
+		}
+		s.log.Error("failed to move pr status", "pr", pr.PullRequestID, "from", pr.Status, "to", to, "error", err)
+		return models.PullRequest{}, err
+	}
+	s.log.Info("pr status changed", "pr", pr.PullRequestID, "from", pr.Status, "to", to)
+	pr.Status = to
+	return pr, nil
+}
+
+// finalStatusConflict handles a merge or close that lost a race, err being
+// the repo's conflict: the PR is returned as is when it already reached to,
+// and the transition error from where it ended up otherwise.
+func (s *PRService) finalStatusConflict(ctx context.Context, prID string, to models.PRStatus, err error) (models.PullRequest, error) {
+	s.log.Warn("pr status changed concurrently", "pr", prID, "to", to)
+	current, getErr := s.repo.GetPR(ctx, prID)
+	if getErr != nil {
+		return models.PullRequest{}, getErr
+	}
+	if current.Status == to {
+		return current, nil
+	}
+	if tErr := checkTransition(current.Status, to); tErr != nil {
+		return models.PullRequest{}, tErr
+	}
+	return models.PullRequest{}, err
+}
+
+// reviewStatus is where approvals leave pr: APPROVED once as many assigned
+// reviewers have approved as its team requires (at least one), IN_REVIEW
+// before that.
+func (s *PRService) reviewStatus(ctx context.Context, pr models.PullRequest) models.PRStatus {
+	approved := 0
+	for _, r := range pr.Assigned {
+		if r.Status == models.ReviewApproved {
+			approved++
+		}
+	}
+	if approved == 0 {
+		return models.StatusInReview
+	}
+	if err := s.checkApprovals(ctx, pr); err != nil {
+		return models.StatusInReview
+	}
+	return models.StatusApproved
+}
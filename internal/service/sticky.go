@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+// WithStickyReviewers makes CreatePR prefer whoever reviewed the author's
+// PRs created within lookback, so follow-up work lands with reviewers who
+// already have the context. A zero lookback disables it.
+func WithStickyReviewers(lookback time.Duration) Option {
+	return func(s *PRService) { s.stickyLookback = lookback }
+}
+
+// withStickyReviewers fills the free slots of selected with the author's
+// recent reviewers, most frequent first. Only candidateIDs are considered,
+// so the usual team, ramp-up and reservation rules still apply. It also
+// returns how many were added.
+func (s *PRService) withStickyReviewers(ctx context.Context, authorID string, selected []models.PRReviewer, candidateIDs []string) ([]models.PRReviewer, int) {
+	if s.stickyLookback <= 0 || len(selected) >= maxReviewers || len(candidateIDs) == 0 {
+		return selected, 0
+	}
+	recent, err := s.repo.GetRecentReviewers(ctx, authorID, time.Now().UTC().Add(-s.stickyLookback))
+	if err != nil {
+		s.log.Warn("failed to get recent reviewers", "author", authorID, "error", err)
+		return selected, 0
+	}
+
+	eligible := make(map[string]bool, len(candidateIDs))
+	for _, id := range candidateIDs {
+		eligible[id] = true
+	}
+	added := 0
+	for _, id := range recent {
+		if len(selected) >= maxReviewers {
+			break
+		}
+		if !eligible[id] || hasReviewer(selected, id) {
+			continue
+		}
+		u, err := s.repo.GetUser(ctx, id)
+		if err != nil || !u.IsActive {
+			continue
+		}
+		selected = append(selected, models.PRReviewer{UserID: u.UserID, Username: u.Username, IsActive: u.IsActive})
+		added++
+	}
+	return selected, added
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+const (
+	defaultSuggestCount = maxReviewers
+	maxSuggestCount     = 20
+)
+
+// SuggestReviewers ranks the author's teammates as reviewer candidates
+// without assigning anyone, using the team's scoring pipeline. Inactive
+// members score zero. Automatic assignment follows the same pipeline once
+// the team configures one, see scoredCandidates.
+func (s *PRService) SuggestReviewers(ctx context.Context, authorID string, count int) ([]models.ReviewerSuggestion, error) {
+	if err := validateUserID(authorID); err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		count = defaultSuggestCount
+	}
+	if count > maxSuggestCount {
+		count = maxSuggestCount
+	}
+
+	teamName, err := s.repo.GetUserTeam(ctx, authorID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := checkTeamScope(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	signals, err := s.repo.GetCandidateSignals(ctx, teamName, authorID)
+	if err != nil {
+		s.log.Error("failed to get candidate signals", "author", authorID, "error", err)
+		return nil, err
+	}
+
+	settings, err := s.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := rankCandidates(signals, *settings.Scoring, time.Now().UTC())
+	if len(suggestions) > count {
+		suggestions = suggestions[:count]
+	}
+	return suggestions, nil
+}
+
+// scoredCandidates applies the scoring pipeline the team configured to the
+// automatic picks: ids its filters drop are removed, and the returned ranks,
+// 0 for the best score, take the place of open review load when picking.
+// Without a configured pipeline, or when signals can't be loaded, ids and
+// load come back unchanged. If the filters would drop everyone, ids are
+// kept so the PR still gets reviewers.
+func (s *PRService) scoredCandidates(ctx context.Context, teamName, authorID string, ids []string, load map[string]int) ([]string, map[string]int) {
+	if len(ids) == 0 {
+		return ids, load
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	if err != nil || settings.Scoring == nil {
+		return ids, load
+	}
+	signals, err := s.repo.GetCandidateSignals(ctx, teamName, authorID)
+	if err != nil {
+		s.log.Warn("failed to get candidate signals", "team", teamName, "error", err)
+		return ids, load
+	}
+
+	ranks := make(map[string]int, len(signals))
+	rank, prev := -1, 0.0
+	for _, sg := range rankCandidates(signals, *settings.Scoring, time.Now().UTC()) {
+		if rank < 0 || sg.Score != prev {
+			rank, prev = rank+1, sg.Score
+		}
+		ranks[sg.UserID] = rank
+	}
+	kept := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := ranks[id]; ok {
+			kept = append(kept, id)
+		}
+	}
+	if len(kept) == 0 {
+		return ids, load
+	}
+	return kept, ranks
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"PR-reviewer/internal/metrics"
+)
+
+var (
+	tableRows       = metrics.NewGauge("db_table_rows", "Estimated live rows per table.", "table")
+	tableDeadRows   = metrics.NewGauge("db_table_dead_rows", "Estimated dead rows per table, waiting for vacuum.", "table")
+	tableBytes      = metrics.NewGauge("db_table_bytes", "Size of each table with its indexes and TOAST.", "table")
+	indexBytes      = metrics.NewGauge("db_index_bytes", "Size of each btree index.", "table", "index")
+	indexBloatBytes = metrics.NewGauge("db_index_bloat_bytes", "Estimated bloat of each btree index: its size above what its rows need.", "table", "index")
+)
+
+// StartTableStats refreshes the table size metrics on the scheduler until
+// StopWorkers. Only the leader collects, so replicas don't repeat the same
+// series.
+func (s *PRService) StartTableStats(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.schedule("table_stats", interval, true, s.collectTableStats)
+}
+
+func (s *PRService) collectTableStats(ctx context.Context) {
+	stats, err := s.repo.GetTableStats(ctx)
+	if err != nil {
+		s.log.WithWorker("scheduler-table_stats").Warn("failed to collect table stats", "error", err)
+		return
+	}
+	for _, t := range stats {
+		tableRows.Set(float64(t.Rows), t.Table)
+		tableDeadRows.Set(float64(t.DeadRows), t.Table)
+		tableBytes.Set(float64(t.TotalBytes), t.Table)
+		for _, idx := range t.Indexes {
+			indexBytes.Set(float64(idx.Bytes), t.Table, idx.Name)
+			indexBloatBytes.Set(float64(idx.BloatBytes), t.Table, idx.Name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"PR-reviewer/internal/models"
+	"PR-reviewer/internal/notify"
+)
+
+// ReviewTimeoutConfig controls the replacement of reviewers who neither
+// acknowledged nor approved their assignment within After. A zero Interval
+// disables it.
+type ReviewTimeoutConfig struct {
+	Interval time.Duration
+	After    time.Duration
+}
+
+// StartReviewTimeouts replaces timed-out reviewers on the scheduler until
+// StopWorkers.
+func (s *PRService) StartReviewTimeouts(cfg ReviewTimeoutConfig) {
+	if cfg.Interval <= 0 || cfg.After <= 0 {
+		return
+	}
+	s.schedule("review_timeout", cfg.Interval, true, func(ctx context.Context) {
+		s.reassignTimedOut(ctx, cfg.After, time.Now())
+	})
+}
+
+// reassignTimedOut hands every review assigned at least after ago and
+// still neither acknowledged nor approved to another active member of the
+// PR's team, and tells both reviewers. A PR nobody can take over is
+// escalated.
+func (s *PRService) reassignTimedOut(ctx context.Context, after time.Duration, now time.Time) {
+	workerLog := s.log.WithWorker("scheduler-review_timeout")
+
+	pending, err := s.repo.GetPendingReviews(ctx)
+	if err != nil {
+		workerLog.Warn("failed to load pending reviews", "error", err)
+		return
+	}
+
+	cache := newOpCache(s.repo)
+	reassigned := 0
+	for _, p := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		if p.AcknowledgedAt != nil || now.Sub(p.AssignedAt) < after {
+			continue
+		}
+		newUID, err := s.reassignReviewer(ctx, cache, p.PullRequestID, p.UserID, p.TeamName, "timeout")
+		if err != nil {
+			if errors.Is(err, ErrNoCandidate) {
+				workerLog.Warn("no replacement for timed-out review", "pr", p.PullRequestID, "user", p.UserID, "team", p.TeamName)
+				s.escalateReassignFailure(ctx, p.PullRequestID)
+				continue
+			}
+			workerLog.Warn("failed to reassign timed-out review", "pr", p.PullRequestID, "user", p.UserID, "error", err)
+			continue
+		}
+		reassigned++
+		workerLog.Info("timed-out review reassigned", "pr", p.PullRequestID, "old_user", p.UserID, "new_user", newUID)
+		if err := s.notifier.Notify(ctx, timeoutMessage(p, newUID, now)); err != nil {
+			workerLog.Warn("failed to send review timeout notification", "pr", p.PullRequestID, "error", err)
+		}
+	}
+	if reassigned > 0 {
+		workerLog.Success("timed-out reviews reassigned", "count", reassigned)
+	}
+}
+
+func timeoutMessage(p models.PendingReview, newUID string, now time.Time) notify.Message {
+	return notify.Message{
+		Kind:  "review.timeout",
+		Title: "review reassigned: " + p.PullRequestName,
+		Text:  fmt.Sprintf("%s was handed from %s to %s after %s without acknowledgement or approval", p.PullRequestID, p.UserID, newUID, now.Sub(p.AssignedAt).Round(time.Minute)),
+		Fields: map[string]string{
+			"pull_request_id": p.PullRequestID,
+			"team_name":       p.TeamName,
+			"old_user_id":     p.UserID,
+			"new_user_id":     newUID,
+			"to":              p.UserID + "," + newUID,
+		},
+		At: now.UTC(),
+	}
+}
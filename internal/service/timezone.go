@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+// WorkingHours is the local working day, from Start to End o'clock, that
+// everyone is assumed to keep in their own timezone.
+type WorkingHours struct {
+	Start int
+	End   int
+}
+
+// WithTimezoneOverlap makes CreatePR prefer reviewers whose working hours
+// overlap the author's, so a review does not wait for the other side of the
+// globe to wake up.
+func WithTimezoneOverlap(hours WorkingHours) Option {
+	return func(s *PRService) { s.workingHours = &hours }
+}
+
+// SetTimezone stores the user's IANA timezone; an empty tz clears it.
+func (s *PRService) SetTimezone(ctx context.Context, userID, tz string) (models.UserProfile, error) {
+	if _, err := s.GetUserProfile(ctx, userID); err != nil {
+		return models.UserProfile{}, err
+	}
+	if tz != "" {
+		if _, err := loadTimezone(tz); err != nil {
+			return models.UserProfile{}, ErrInvalidTimezone
+		}
+	}
+
+	if err := s.repo.SetTimezone(ctx, userID, tz); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserProfile{}, ErrNotFound
+		}
+		s.log.Error("failed to set timezone", "user", userID, "error", err)
+		return models.UserProfile{}, err
+	}
+	s.log.Success("timezone updated", "user", userID, "timezone", tz)
+	return s.GetUserProfile(ctx, userID)
+}
+
+// loadTimezone is time.LoadLocation without "Local", which would be the
+// server's zone rather than the user's.
+func loadTimezone(tz string) (*time.Location, error) {
+	if tz == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	return time.LoadLocation(tz)
+}
+
+// splitByOverlap splits candidateIDs into those whose working hours overlap
+// the author's today and the rest. Candidates with no timezone are not held
+// against, and without the author's timezone nobody is.
+func (s *PRService) splitByOverlap(ctx context.Context, authorID string, candidateIDs []string, now time.Time) (near, far []string) {
+	zones, err := s.repo.GetTimezones(ctx, append([]string{authorID}, candidateIDs...))
+	if err != nil {
+		s.log.Warn("failed to get timezones", "author", authorID, "error", err)
+		return candidateIDs, nil
+	}
+	author, err := loadTimezone(zones[authorID])
+	if zones[authorID] == "" || err != nil {
+		return candidateIDs, nil
+	}
+
+	near = make([]string, 0, len(candidateIDs))
+	for _, id := range candidateIDs {
+		loc, err := loadTimezone(zones[id])
+		if zones[id] == "" || err != nil || workingOverlap(author, loc, *s.workingHours, now) > 0 {
+			near = append(near, id)
+		} else {
+			far = append(far, id)
+		}
+	}
+	return near, far
+}
+
+// workingOverlap returns how much of a's working day at now is also b's,
+// counting b's days on either side for zones across the date line.
+func workingOverlap(a, b *time.Location, hours WorkingHours, now time.Time) time.Duration {
+	aStart, aEnd := workingDay(now.In(a), hours)
+	var total time.Duration
+	for _, shift := range []int{-1, 0, 1} {
+		bStart, bEnd := workingDay(now.In(b).AddDate(0, 0, shift), hours)
+		start, end := aStart, aEnd
+		if bStart.After(start) {
+			start = bStart
+		}
+		if bEnd.Before(end) {
+			end = bEnd
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+func workingDay(t time.Time, hours WorkingHours) (time.Time, time.Time) {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, hours.Start, 0, 0, 0, t.Location()), time.Date(y, m, d, hours.End, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"PR-reviewer/internal/models"
+)
+
+// SetVacation schedules the user's out-of-office period, replacing any
+// previous one; a zero from means now and a zero until cancels it. The user
+// is deactivated while the vacation lasts and reactivated when it ends.
+func (s *PRService) SetVacation(ctx context.Context, userID string, from, until time.Time) (models.UserProfile, error) {
+	if _, err := s.GetUserProfile(ctx, userID); err != nil {
+		return models.UserProfile{}, err
+	}
+
+	now := time.Now().UTC()
+	var v *models.Vacation
+	if !until.IsZero() {
+		if from.IsZero() {
+			from = now
+		}
+		if !until.After(from) || !until.After(now) {
+			return models.UserProfile{}, ErrInvalidVacation
+		}
+		v = &models.Vacation{From: from.UTC(), Until: until.UTC()}
+	}
+
+	if err := s.repo.SetVacation(ctx, userID, v); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserProfile{}, ErrNotFound
+		}
+		s.log.Error("failed to set vacation", "user", userID, "error", err)
+		return models.UserProfile{}, err
+	}
+	s.log.Success("vacation updated", "user", userID, "cancelled", v == nil)
+	// Apply it now rather than on the next tick, so a vacation starting
+	// today keeps the user out of assignments right away.
+	s.applyVacations(ctx, now)
+	return s.GetUserProfile(ctx, userID)
+}
+
+// StartVacations starts and ends vacations on the scheduler until
+// StopWorkers.
+func (s *PRService) StartVacations(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.schedule("vacations", interval, true, func(ctx context.Context) {
+		s.applyVacations(ctx, time.Now().UTC())
+	})
+}
+
+func (s *PRService) applyVacations(ctx context.Context, now time.Time) {
+	workerLog := s.log.WithWorker("scheduler-vacations")
+
+	if ended, err := s.repo.EndVacations(ctx, now); err != nil {
+		workerLog.Warn("failed to end vacations", "error", err)
+	} else if len(ended) > 0 {
+		workerLog.Info("users back from vacation", "users", strings.Join(ended, ","))
+		for _, id := range ended {
+			if u, err := s.repo.GetUser(ctx, id); err == nil {
+				s.backfillReviewer(ctx, u)
+			}
+		}
+	}
+	if started, err := s.repo.StartVacations(ctx, now); err != nil {
+		workerLog.Warn("failed to start vacations", "error", err)
+	} else if len(started) > 0 {
+		workerLog.Info("users left on vacation", "users", strings.Join(started, ","))
+	}
+}
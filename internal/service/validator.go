@@ -3,6 +3,7 @@ package service
 import (
 	"PR-reviewer/internal/models"
 	"errors"
+	"strings"
 )
 
 var (
@@ -27,6 +28,13 @@ func validatePullRequest(pr models.PullRequest) error {
 	return nil
 }
 
+func validatePRID(prID string) error {
+	if prID == "" {
+		return errMissingPRID
+	}
+	return nil
+}
+
 func validateUserID(userID string) error {
 	if userID == "" {
 		return errMissingUserID
@@ -48,9 +56,48 @@ func validateTeam(team models.Team) error {
 	return nil
 }
 
+func validateTeamUpdate(upd models.TeamUpdate) error {
+	if upd.TeamName == "" {
+		return errMissingTeamName
+	}
+	userIDs := make(map[string]bool)
+	for _, member := range upd.Upsert {
+		if member.UserID == "" {
+			return errMissingUserID
+		}
+		if userIDs[member.UserID] {
+			return errDuplicates
+		}
+		userIDs[member.UserID] = true
+	}
+	for _, uid := range upd.Remove {
+		if userIDs[uid] {
+			return errDuplicates
+		}
+		userIDs[uid] = true
+	}
+	return nil
+}
+
 func validateTeamName(name string) error {
 	if name == "" {
 		return errMissingTeamName
 	}
 	return nil
 }
+
+// normalizeLabels lowercases and trims labels and skills so "DB" and " db"
+// route the same, dropping empties and duplicates.
+func normalizeLabels(labels []string) []string {
+	out := make([]string, 0, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l == "" || seen[l] {
+			continue
+		}
+		seen[l] = true
+		out = append(out, l)
+	}
+	return out
+}
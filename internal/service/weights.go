@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"PR-reviewer/internal/models"
+)
+
+const maxUserWeight = 10
+
+// SetUserWeight sets the user's seniority weight, from 1 to maxUserWeight.
+func (s *PRService) SetUserWeight(ctx context.Context, userID string, weight int) (models.UserProfile, error) {
+	if weight < 1 || weight > maxUserWeight {
+		return models.UserProfile{}, ErrInvalidWeight
+	}
+	if _, err := s.GetUserProfile(ctx, userID); err != nil {
+		return models.UserProfile{}, err
+	}
+
+	if err := s.repo.SetUserWeight(ctx, userID, weight); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return models.UserProfile{}, ErrNotFound
+		}
+		s.log.Error("failed to set user weight", "user", userID, "error", err)
+		return models.UserProfile{}, err
+	}
+	s.log.Success("user weight updated", "user", userID, "weight", weight)
+	return s.GetUserProfile(ctx, userID)
+}
+
+// selectionWeights returns how likely each of ids is to win a random pick
+// for the team's PRs: in proportion to their weight when the team favors
+// seniors, inversely when it favors juniors. It returns nil, an even draw,
+// when the team weighs nobody or the weights cannot be loaded.
+func (s *PRService) selectionWeights(ctx context.Context, teamName string, ids []string) map[string]float64 {
+	if len(ids) < 2 {
+		return nil
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, teamName)
+	if err != nil || settings.WeightedSelection == "" {
+		return nil
+	}
+	stored, err := s.repo.GetUserWeights(ctx, ids)
+	if err != nil {
+		s.log.Warn("failed to get user weights", "team", teamName, "error", err)
+		return nil
+	}
+
+	weights := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		w, ok := stored[id]
+		if !ok || w < 1 {
+			w = 1
+		}
+		if settings.WeightedSelection == models.FavorJuniors {
+			weights[id] = 1 / float64(w)
+		} else {
+			weights[id] = float64(w)
+		}
+	}
+	return weights
+}
+
+// weightedRandInt returns a random index into ids, drawn in proportion to
+// weights. Nil weights give every id the same chance.
+func weightedRandInt(rng RandSource, ids []string, weights map[string]float64) (int, error) {
+	if weights == nil {
+		return rng.Intn(len(ids))
+	}
+	total := 0.0
+	for _, id := range ids {
+		total += weights[id]
+	}
+	if total <= 0 {
+		return rng.Intn(len(ids))
+	}
+
+	const precision = math.MaxInt32
+	r, err := rng.Intn(precision)
+	if err != nil {
+		return 0, err
+	}
+	x := float64(r) / precision * total
+	for i, id := range ids {
+		x -= weights[id]
+		if x < 0 {
+			return i, nil
+		}
+	}
+	return len(ids) - 1, nil
+}
+
+func validateWeightedSelection(v string) error {
+	switch v {
+	case "", models.FavorSeniors, models.FavorJuniors:
+		return nil
+	}
+	return fmt.Errorf("%w: weighted_selection must be %q or %q", ErrInvalidSettings, models.FavorSeniors, models.FavorJuniors)
+}
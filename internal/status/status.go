@@ -0,0 +1,94 @@
+// Package status serves GET /status, a public summary of the service's
+// health that is safe to embed in a team wiki. Details stay behind the
+// admin diagnostics.
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"PR-reviewer/internal/selftest"
+	"PR-reviewer/internal/service"
+)
+
+// A queue fuller than this fraction of its capacity is reported unhealthy:
+// requests are close to being rejected.
+const queueHealthyRatio = 0.8
+
+type Source interface {
+	Diagnostics() service.Diagnostics
+}
+
+type Summary struct {
+	// Status is "ok", "degraded" when the job queue is backed up, or "down"
+	// when the database is unreachable.
+	Status       string `json:"status"`
+	ServiceUp    bool   `json:"service_up"`
+	QueueHealthy bool   `json:"queue_healthy"`
+	DBReachable  bool   `json:"db_reachable"`
+	// LastSchedulerRun is the latest run of any background task that
+	// finished in time; absent until one has.
+	LastSchedulerRun *time.Time `json:"last_scheduler_run,omitempty"`
+	CheckedAt        time.Time  `json:"checked_at"`
+}
+
+// Get builds the summary, running the database check.
+func Get(ctx context.Context, src Source, db selftest.Check, timeout time.Duration) Summary {
+	d := src.Diagnostics()
+	rep := selftest.Run(ctx, timeout, []selftest.Check{db})
+
+	sum := Summary{
+		Status:       "ok",
+		ServiceUp:    true,
+		QueueHealthy: float64(d.QueueDepth) <= queueHealthyRatio*float64(d.QueueCapacity),
+		DBReachable:  rep.Passed(),
+		CheckedAt:    time.Now().UTC(),
+	}
+	for _, st := range d.Schedulers {
+		if st.LastRun.IsZero() {
+			continue
+		}
+		if sum.LastSchedulerRun == nil || st.LastRun.After(*sum.LastSchedulerRun) {
+			last := st.LastRun
+			sum.LastSchedulerRun = &last
+		}
+	}
+	switch {
+	case !sum.DBReachable:
+		sum.Status = "down"
+	case !sum.QueueHealthy:
+		sum.Status = "degraded"
+	}
+	return sum
+}
+
+// Handler serves GET /status. The summary is reused for ttl so a popular
+// wiki page does not turn into load on the database. Like /readyz it
+// answers 503 when the service is down.
+func Handler(src Source, db selftest.Check, timeout, ttl time.Duration) http.Handler {
+	var (
+		mu   sync.Mutex
+		last Summary
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if last.CheckedAt.IsZero() || time.Since(last.CheckedAt) >= ttl {
+			last = Get(r.Context(), src, db, timeout)
+		}
+		sum := last
+		mu.Unlock()
+
+		code := http.StatusOK
+		if sum.Status == "down" {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(ttl/time.Second)))
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(sum)
+	})
+}
@@ -0,0 +1,68 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"PR-reviewer/internal/selftest"
+	"PR-reviewer/internal/service"
+)
+
+type fakeSource struct{ d service.Diagnostics }
+
+func (f fakeSource) Diagnostics() service.Diagnostics { return f.d }
+
+func TestHandler(t *testing.T) {
+	last := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	src := fakeSource{d: service.Diagnostics{
+		QueueDepth:    10,
+		QueueCapacity: 200,
+		Schedulers: []service.SchedulerState{
+			{Name: "alerts", LastRun: last.Add(-time.Minute)},
+			{Name: "vacations", LastRun: last},
+			{Name: "reminders"},
+		},
+	}}
+	calls := 0
+	db := selftest.Check{Name: "database", Run: func(ctx context.Context) error {
+		calls++
+		return nil
+	}}
+
+	h := Handler(src, db, time.Second, time.Minute)
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		var got Summary
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("invalid response %q: %v", rr.Body.String(), err)
+		}
+		if got.Status != "ok" || !got.QueueHealthy || !got.DBReachable || got.LastSchedulerRun == nil || !got.LastSchedulerRun.Equal(last) {
+			t.Fatalf("unexpected summary %+v", got)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected the database checked once within ttl, got %d", calls)
+	}
+}
+
+func TestGet(t *testing.T) {
+	up := selftest.Check{Name: "database", Run: func(ctx context.Context) error { return nil }}
+	down := selftest.Check{Name: "database", Run: func(ctx context.Context) error { return errors.New("connection refused") }}
+	full := fakeSource{d: service.Diagnostics{QueueDepth: 190, QueueCapacity: 200}}
+
+	if got := Get(context.Background(), full, up, time.Second); got.Status != "degraded" || got.QueueHealthy {
+		t.Fatalf("expected degraded on a backed up queue, got %+v", got)
+	}
+	if got := Get(context.Background(), full, down, time.Second); got.Status != "down" || got.DBReachable || got.LastSchedulerRun != nil {
+		t.Fatalf("expected down without the database, got %+v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestS3Put(t *testing.T) {
+	var gotPath, gotAuth, gotHash, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotAuth, gotHash, gotBody = r.URL.EscapedPath(), r.Header.Get("Authorization"), r.Header.Get("X-Amz-Content-Sha256"), string(body)
+	}))
+	defer srv.Close()
+
+	s := NewS3(Config{Endpoint: srv.URL + "/", Bucket: "dumps", Prefix: "exports/", AccessKey: "AK", SecretKey: "SK"}, time.Second)
+	s.now = func() time.Time { return time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC) }
+
+	if err := s.Put(context.Background(), "a b.ndjson", []byte("{}\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPath != "/dumps/exports/a%20b.ndjson" {
+		t.Errorf("unexpected path %q", gotPath)
+	}
+	if gotBody != "{}\n" || gotHash != sha256Hex([]byte("{}\n")) {
+		t.Errorf("unexpected body %q or hash %q", gotBody, gotHash)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AK/20251101/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("unexpected authorization %q", gotAuth)
+	}
+}
+
+func TestS3PutError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+	}))
+	defer srv.Close()
+
+	s := NewS3(Config{Endpoint: srv.URL, Bucket: "dumps"}, time.Second)
+	err := s.Put(context.Background(), "x", nil)
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("expected AccessDenied error, got %v", err)
+	}
+	if err := s.Ping(context.Background()); err == nil {
+		t.Fatal("expected ping to fail")
+	}
+}
@@ -24,3 +24,370 @@ CREATE TABLE IF NOT EXISTS pr_reviewers (
     user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
     PRIMARY KEY (pull_request_id, user_id)
 );
+
+CREATE TABLE IF NOT EXISTS team_tokens (
+    token_hash TEXT PRIMARY KEY,
+    team_name TEXT NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS pr_approvals (
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    approved_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pull_request_id, user_id)
+);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP NULL;
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS team_name TEXT NULL REFERENCES teams(team_name) ON DELETE SET NULL;
+UPDATE pull_requests pr SET team_name = u.team_name
+FROM users u
+WHERE pr.author_id = u.user_id AND pr.team_name IS NULL;
+CREATE INDEX IF NOT EXISTS idx_pull_requests_team_name ON pull_requests(team_name);
+
+CREATE TABLE IF NOT EXISTS reviewer_stats (
+    user_id TEXT PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    assigned_count INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO reviewer_stats(user_id, assigned_count)
+SELECT user_id, COUNT(*) FROM pr_reviewers GROUP BY user_id
+ON CONFLICT (user_id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_pull_requests_status_created ON pull_requests(status, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS team_settings (
+    team_name TEXT PRIMARY KEY REFERENCES teams(team_name) ON DELETE CASCADE,
+    settings JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
+
+CREATE TABLE IF NOT EXISTS pr_events (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_pr_events_user ON pr_events(user_id, created_at DESC, id DESC);
+INSERT INTO pr_events(pull_request_id, user_id, kind, created_at)
+SELECT rr.pull_request_id, rr.user_id, 'assigned', pr.created_at
+FROM pr_reviewers rr
+JOIN pull_requests pr ON pr.pull_request_id = rr.pull_request_id
+WHERE NOT EXISTS (SELECT 1 FROM pr_events);
+INSERT INTO pr_events(pull_request_id, user_id, kind, created_at)
+SELECT a.pull_request_id, a.user_id, 'approved', a.approved_at
+FROM pr_approvals a
+WHERE NOT EXISTS (SELECT 1 FROM pr_events WHERE kind = 'approved');
+INSERT INTO pr_events(pull_request_id, user_id, kind, created_at)
+SELECT pull_request_id, author_id, 'merged', merged_at
+FROM pull_requests
+WHERE status = 'MERGED' AND merged_at IS NOT NULL
+AND NOT EXISTS (SELECT 1 FROM pr_events WHERE kind = 'merged');
+
+DO $$
+BEGIN
+    ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check
+        CHECK (status IN ('OPEN', 'MERGED', 'CLOSED'));
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE TABLE IF NOT EXISTS team_memberships (
+    team_name TEXT NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'observer')),
+    PRIMARY KEY (team_name, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_team_memberships_user ON team_memberships(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_pr_events_pr_user ON pr_events(pull_request_id, user_id, kind, created_at);
+
+CREATE TABLE IF NOT EXISTS pr_escalations (
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('sla', 'reassign_failed')),
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    next_hop INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (pull_request_id, reason)
+);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+CREATE TABLE IF NOT EXISTS user_skills (
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    skill TEXT NOT NULL,
+    PRIMARY KEY (user_id, skill)
+);
+CREATE INDEX IF NOT EXISTS idx_user_skills_skill ON user_skills(skill);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS security_review BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS security_reviewer TEXT NULL REFERENCES users(user_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_pull_requests_security ON pull_requests(team_name, created_at) WHERE security_review;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS vacation_from TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS vacation_until TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS vacation_active BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS mentorships (
+    mentee_id TEXT PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    mentor_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (mentee_id <> mentor_id)
+);
+CREATE INDEX IF NOT EXISTS idx_mentorships_mentor ON mentorships(mentor_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '';
+
+-- Users present before ramp-up existed keep a NULL joined_at and never ramp up.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS joined_at TIMESTAMP NULL;
+ALTER TABLE users ALTER COLUMN joined_at SET DEFAULT NOW();
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS weight INT NOT NULL DEFAULT 1 CHECK (weight BETWEEN 1 AND 10);
+
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'required' CHECK (role IN ('required', 'secondary'));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pr_reviewers_secondary ON pr_reviewers(pull_request_id) WHERE role = 'secondary';
+
+-- Change data capture: every committed write to the assignment tables sends
+-- the table name on the pr_changes channel, so each replica can drop what it
+-- has cached from it.
+CREATE OR REPLACE FUNCTION notify_pr_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('pr_changes', TG_TABLE_NAME);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER teams_notify_change AFTER INSERT OR UPDATE OR DELETE ON teams
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_pr_change();
+CREATE OR REPLACE TRIGGER users_notify_change AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_pr_change();
+CREATE OR REPLACE TRIGGER pull_requests_notify_change AFTER INSERT OR UPDATE OR DELETE ON pull_requests
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_pr_change();
+CREATE OR REPLACE TRIGGER pr_reviewers_notify_change AFTER INSERT OR UPDATE OR DELETE ON pr_reviewers
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_pr_change();
+
+CREATE TABLE IF NOT EXISTS cluster_leader (
+    id INT PRIMARY KEY CHECK (id = 1),
+    instance TEXT NOT NULL,
+    acquired_at TIMESTAMP NOT NULL,
+    renewed_at TIMESTAMP NOT NULL
+);
+
+-- Reviewers get their own assignment time, backfilled from the assignment
+-- events, so review SLAs do not need to search the event log.
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP NULL;
+UPDATE pr_reviewers rr SET assigned_at = COALESCE(
+    (SELECT MAX(e.created_at) FROM pr_events e
+     WHERE e.pull_request_id = rr.pull_request_id AND e.user_id = rr.user_id AND e.kind = 'assigned'),
+    (SELECT pr.created_at FROM pull_requests pr WHERE pr.pull_request_id = rr.pull_request_id))
+WHERE rr.assigned_at IS NULL;
+ALTER TABLE pr_reviewers ALTER COLUMN assigned_at SET DEFAULT NOW();
+ALTER TABLE pr_reviewers ALTER COLUMN assigned_at SET NOT NULL;
+
+-- inactive_since records when a user stopped being active, whichever code
+-- path deactivated them, so their open reviews can be handed off once they
+-- have been gone for long enough.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS inactive_since TIMESTAMP NULL;
+UPDATE users SET inactive_since = NOW() WHERE NOT is_active AND inactive_since IS NULL;
+
+CREATE OR REPLACE FUNCTION track_inactive_since() RETURNS trigger AS $$
+BEGIN
+    IF NEW.is_active THEN
+        NEW.inactive_since := NULL;
+    ELSIF TG_OP = 'INSERT' OR OLD.is_active THEN
+        NEW.inactive_since := NOW();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER users_track_inactive_since BEFORE INSERT OR UPDATE OF is_active ON users
+    FOR EACH ROW EXECUTE FUNCTION track_inactive_since();
+
+CREATE TABLE IF NOT EXISTS instances (
+    instance TEXT PRIMARY KEY,
+    version TEXT NOT NULL,
+    commit TEXT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    heartbeat_at TIMESTAMP NOT NULL,
+    workers INT NOT NULL,
+    busy_workers INT NOT NULL,
+    queue_depth INT NOT NULL,
+    longest_job_seconds DOUBLE PRECISION NOT NULL
+);
+
+-- Reviewers held for an author's upcoming large PR. A reviewer is held for
+-- one author at a time; expired rows are ignored and cleaned up lazily.
+CREATE TABLE IF NOT EXISTS review_reservations (
+    user_id TEXT PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    author_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_review_reservations_author ON review_reservations(author_id);
+
+-- Why each reviewer was assigned or taken off, and who asked. Written in the
+-- same transaction as the pr_events row for the change.
+CREATE TABLE IF NOT EXISTS assignment_events (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('assigned', 'unassigned', 'reassigned_away')),
+    reason TEXT NOT NULL,
+    triggered_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_assignment_events_pr ON assignment_events(pull_request_id, created_at, id);
+INSERT INTO assignment_events(pull_request_id, user_id, action, reason, triggered_by, created_at)
+SELECT pull_request_id, user_id, kind, 'unknown', 'system', created_at
+FROM pr_events
+WHERE kind IN ('assigned', 'unassigned', 'reassigned_away')
+AND NOT EXISTS (SELECT 1 FROM assignment_events);
+
+-- Review rounds after the first, each started by a reviewer requesting
+-- changes. due_at is the round's own deadline for the pending reviewers.
+CREATE TABLE IF NOT EXISTS review_rounds (
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    round INT NOT NULL CHECK (round > 1),
+    requested_by TEXT NOT NULL,
+    fresh_reviewer TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    due_at TIMESTAMP,
+    PRIMARY KEY (pull_request_id, round)
+);
+
+-- Weekly reviewer pairs of teams that run a review rotation.
+CREATE TABLE IF NOT EXISTS review_rotations (
+    team_name TEXT NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    week_start DATE NOT NULL,
+    reviewers TEXT[] NOT NULL,
+    generated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (team_name, week_start)
+);
+
+-- PRs go through review before merge: IN_REVIEW once a reviewer has started,
+-- APPROVED once the team's approvals are in.
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check
+    CHECK (status IN ('OPEN', 'IN_REVIEW', 'APPROVED', 'MERGED', 'CLOSED'));
+
+-- Whether the user is notified when a PR they wrote or review is merged.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notify_merged BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Tokens bound to a single user, for the /me endpoints.
+CREATE TABLE IF NOT EXISTS user_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Merges waiting for their team's merge window to open.
+CREATE TABLE IF NOT EXISTS merge_queue (
+    pull_request_id TEXT PRIMARY KEY REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    merge_at TIMESTAMP NOT NULL,
+    queued_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Lists and searches filter PRs by label.
+CREATE INDEX IF NOT EXISTS idx_pull_requests_labels ON pull_requests USING GIN (labels);
+
+-- How urgently a PR needs review; URGENT PRs skip reviewers' open review caps.
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'NORMAL'
+    CHECK (priority IN ('LOW', 'NORMAL', 'URGENT'));
+
+-- Review effort estimate; load-balanced assignment weighs open reviews by it.
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS size TEXT
+    CHECK (size IN ('XS', 'S', 'M', 'L', 'XL'));
+
+-- Git repository of a PR, for services covering several. A PR's ID only has
+-- to be unique within its repository; see external_id below.
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS repository TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_pull_requests_repository ON pull_requests(repository, created_at DESC);
+
+-- Target branch and description of a PR, for integrations mirroring SCM PRs.
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS target_branch TEXT NOT NULL DEFAULT '';
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+-- Merged and closed PRs moved out of the hot tables by the archiver. The PR
+-- and its assignment history are kept as JSON snapshots; the columns beside
+-- them only serve the archive listing's filters.
+CREATE TABLE IF NOT EXISTS archived_pull_requests (
+    pull_request_id TEXT PRIMARY KEY,
+    pull_request_name TEXT NOT NULL,
+    author_id TEXT NOT NULL,
+    team_name TEXT NOT NULL DEFAULT '',
+    repository TEXT NOT NULL DEFAULT '',
+    target_branch TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    priority TEXT NOT NULL DEFAULT 'NORMAL',
+    labels TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NULL,
+    archived_at TIMESTAMP NOT NULL,
+    pr JSONB NOT NULL,
+    history JSONB NOT NULL DEFAULT '[]'
+);
+CREATE INDEX IF NOT EXISTS idx_archived_pull_requests_team ON archived_pull_requests(team_name, archived_at DESC);
+CREATE INDEX IF NOT EXISTS idx_archived_pull_requests_author ON archived_pull_requests(author_id, archived_at DESC);
+
+-- Who merged a PR, for stats and audits. Merges from before this column or
+-- from the merge queue leave it empty.
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS merged_by TEXT NULL REFERENCES users(user_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_pull_requests_merged_by ON pull_requests(merged_by, merged_at) WHERE merged_by IS NOT NULL;
+
+-- Each reviewer's latest decision on a PR and the comment left with it.
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS decision TEXT NULL CHECK (decision IN ('APPROVED', 'CHANGES_REQUESTED'));
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS decision_comment TEXT NOT NULL DEFAULT '';
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS decided_at TIMESTAMP NULL;
+
+-- Who queued a merge, recorded as the PR's merged_by once the queue merges it.
+ALTER TABLE merge_queue ADD COLUMN IF NOT EXISTS merged_by TEXT NULL REFERENCES users(user_id) ON DELETE SET NULL;
+
+-- Per-team PR counters kept in the same transaction as the change they count,
+-- so team stats read one row. They are running totals and survive archiving.
+CREATE TABLE IF NOT EXISTS team_stats (
+    team_name TEXT PRIMARY KEY REFERENCES teams(team_name) ON DELETE CASCADE,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    merged_count INTEGER NOT NULL DEFAULT 0,
+    reassigned_count INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO team_stats(team_name, created_count, merged_count, reassigned_count)
+SELECT t.team_name,
+    (SELECT COUNT(*) FROM pull_requests pr WHERE pr.team_name = t.team_name)
+        + (SELECT COUNT(*) FROM archived_pull_requests a WHERE a.team_name = t.team_name),
+    (SELECT COUNT(*) FROM pull_requests pr WHERE pr.team_name = t.team_name AND pr.status = 'MERGED')
+        + (SELECT COUNT(*) FROM archived_pull_requests a WHERE a.team_name = t.team_name AND a.status = 'MERGED'),
+    (SELECT COUNT(*) FROM pr_events e JOIN pull_requests pr ON pr.pull_request_id = e.pull_request_id
+        WHERE pr.team_name = t.team_name AND e.kind = 'reassigned_away')
+FROM teams t
+ON CONFLICT (team_name) DO NOTHING;
+
+-- Per-repository defaults, e.g. platform owners reviewing every PR of a
+-- repository whichever team opens it.
+CREATE TABLE IF NOT EXISTS repository_settings (
+    repository TEXT PRIMARY KEY,
+    default_reviewers TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The ID a PR was created with, unique per repository. pull_request_id stays
+-- the row key other tables refer to and equals external_id unless another
+-- repository's PR already held that key. PRs stored as "<repository>#<id>"
+-- by earlier releases get their plain ID back.
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS external_id TEXT;
+UPDATE pull_requests SET external_id = CASE
+    WHEN repository <> '' AND left(pull_request_id, length(repository) + 1) = repository || '#'
+        THEN substr(pull_request_id, length(repository) + 2)
+    ELSE pull_request_id END
+WHERE external_id IS NULL;
+ALTER TABLE pull_requests ALTER COLUMN external_id SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pull_requests_external_id ON pull_requests(repository, external_id);
+
+ALTER TABLE archived_pull_requests ADD COLUMN IF NOT EXISTS external_id TEXT;
+UPDATE archived_pull_requests SET external_id = CASE
+    WHEN repository <> '' AND left(pull_request_id, length(repository) + 1) = repository || '#'
+        THEN substr(pull_request_id, length(repository) + 2)
+    ELSE pull_request_id END
+WHERE external_id IS NULL;
+ALTER TABLE archived_pull_requests ALTER COLUMN external_id SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_archived_pull_requests_external_id ON archived_pull_requests(repository, external_id);